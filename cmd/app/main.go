@@ -1,60 +1,34 @@
 package main
 
 import (
+	"context"
 	"log/slog"
-	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/AtIasShrugged/antisocial/internal/config"
 	server "github.com/AtIasShrugged/antisocial/internal/http"
-	"github.com/AtIasShrugged/antisocial/libs/logger/handlers/slogpretty"
-)
-
-const (
-	envLocal = "local"
-	envDev   = "dev"
-	envProd  = "prod"
+	"github.com/AtIasShrugged/antisocial/internal/tracing"
+	"github.com/AtIasShrugged/antisocial/libs/logger"
 )
 
 func main() {
 	cfg := config.MustLoad()
-	log := setupLogger(cfg.Env)
+	log, levels, closeLog := mustSetupLogger(cfg)
+	defer closeLog()
 
-	server.Run(log, cfg)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
 
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
-
-	<-stop
+	server.Run(ctx, log, levels, cfg)
 
 	log.Info("Gracefully stopped")
 }
 
-func setupLogger(env string) *slog.Logger {
-	var log *slog.Logger
-
-	switch env {
-	case envLocal:
-		log = setupPrettySlog()
-	case envDev:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-		)
-	case envProd:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-		)
-	}
-	return log
-}
-
-func setupPrettySlog() *slog.Logger {
-	opts := slogpretty.PrettyHandlerOptions{
-		SlogOpts: &slog.HandlerOptions{
-			Level: slog.LevelDebug,
-		},
+func mustSetupLogger(cfg *config.Config) (*slog.Logger, *logger.Levels, func() error) {
+	handler, closeLog, err := logger.NewHandler(cfg.Env, cfg.Log)
+	if err != nil {
+		panic("cannot set up logger: " + err.Error())
 	}
-	handler := opts.NewPrettyHandler(os.Stdout)
-	return slog.New(handler)
+	return slog.New(tracing.NewLogHandler(handler)), handler.Levels(), closeLog
 }
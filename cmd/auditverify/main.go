@@ -0,0 +1,71 @@
+// Command auditverify walks the audit trail and checks that no event was
+// modified, removed or reordered. It prints the head of the chain, which can
+// be passed back with -checkpoint on later runs to also catch events cut off
+// the end or a chain rewritten from scratch.
+//
+//	auditverify -config config/local.yml -checkpoint 1234:9f86d0...
+//
+// It exits with 1 when the chain is broken and 2 when it can't be checked.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/AtIasShrugged/antisocial/internal/audit"
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	audit_repo "github.com/AtIasShrugged/antisocial/internal/repository/audit"
+	"github.com/AtIasShrugged/antisocial/libs/logger/handlers/slogdiscard"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func main() {
+	var checkpoints []audit.Checkpoint
+	flag.Func("checkpoint", "`id:hash` of an event the chain must still contain; repeatable", func(s string) error {
+		c, err := audit.ParseCheckpoint(s)
+		if err != nil {
+			return err
+		}
+		checkpoints = append(checkpoints, c)
+		return nil
+	})
+	cfg := config.MustLoad()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	os.Exit(run(ctx, cfg, checkpoints))
+}
+
+func run(ctx context.Context, cfg *config.Config, checkpoints []audit.Checkpoint) int {
+	pool, err := pgxpool.New(ctx, cfg.DB.DSN())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "can't connect to database:", err)
+		return 2
+	}
+	defer pool.Close()
+
+	log := slogdiscard.NewDiscardLogger()
+	trail := audit.New(audit_repo.New(pool, log), log)
+
+	result, err := trail.Verify(ctx, checkpoints...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verified %d events before failing: %s\n", result.Events, err)
+		if errors.Is(err, audit.ErrChainBroken) {
+			return 1
+		}
+		return 2
+	}
+
+	if result.Events == 0 {
+		fmt.Println("ok: the audit trail is empty")
+		return 0
+	}
+	fmt.Printf("ok: %d events verified\nhead: %s\n", result.Events, result.Head)
+	return 0
+}
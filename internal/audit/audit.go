@@ -0,0 +1,130 @@
+// Package audit keeps a tamper-evident trail of privileged actions. Events
+// are chained: each one's hash covers the hash of the event before it, so
+// editing, removing or reordering stored events breaks the chain from that
+// point on.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	audit_repo "github.com/AtIasShrugged/antisocial/internal/repository/audit"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+	verifyBatchSize  = 1000
+)
+
+var ErrInvalidFilter = errors.New("invalid audit filter")
+
+type Trail struct {
+	repo audit_repo.AuditRepository
+	log  *slog.Logger
+	now  func() time.Time
+}
+
+func New(repo audit_repo.AuditRepository, log *slog.Logger) *Trail {
+	return &Trail{
+		repo: repo,
+		log:  log,
+		now:  time.Now,
+	}
+}
+
+// InTx runs fn in a transaction. Record and the repositories called with the
+// context of fn join it, so that an action is stored along with its event or
+// not at all.
+func (t *Trail) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.repo.InTx(ctx, fn)
+}
+
+// Record appends event on behalf of the user of ctx, along with the client
+// the request came from. Actions must not stand without their event, so the
+// caller fails the action when Record does.
+func (t *Trail) Record(ctx context.Context, event models.AuditEvent) error {
+	const op = "Trail.Record"
+
+	event.ActorID, _ = auth.UserID(ctx)
+	client := clientFromContext(ctx)
+	event.IP, event.UserAgent = client.ip, client.userAgent
+	event.CreatedAt = t.now().UTC().Truncate(time.Microsecond)
+
+	var err error
+	if event.Before, err = marshal(event.Before); err == nil {
+		event.After, err = marshal(event.After)
+	}
+	if err == nil {
+		_, err = t.repo.Append(ctx, event, Hash)
+	}
+	if err != nil {
+		metrics.AuditFailures.Inc()
+		sl.FromContext(ctx, t.log).ErrorContext(ctx, "can't record audit event", sl.Op(op),
+			slog.String("action", string(event.Action)),
+			slog.String("target_type", string(event.TargetType)),
+			slog.String("target_id", event.TargetID),
+			sl.Err(err))
+		return fmt.Errorf("can't record audit event: %w", err)
+	}
+	metrics.AuditEvents.WithLabelValues(string(event.Action)).Inc()
+	return nil
+}
+
+// marshal turns v into the JSON that gets stored and hashed. nil stays nil.
+func marshal(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("can't encode audit state: %w", err)
+	}
+	return json.RawMessage(b), nil
+}
+
+// List returns a page of events, newest first.
+func (t *Trail) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	if err := auth.Authorize(ctx, auth.AuditRead, nil); err != nil {
+		return nil, err
+	}
+	if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		return nil, fmt.Errorf("%w: since must be before until", ErrInvalidFilter)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	filter.Limit = min(filter.Limit, maxListLimit)
+
+	return t.repo.List(ctx, filter)
+}
+
+// Verify walks the whole chain and checks every link and every checkpoint.
+// It is meant for operators and checks no permission.
+func (t *Trail) Verify(ctx context.Context, checkpoints ...Checkpoint) (Verification, error) {
+	v := newVerifier(checkpoints)
+	after := int64(0)
+	for {
+		events, err := t.repo.Scan(ctx, after, verifyBatchSize)
+		if err != nil {
+			return v.result, err
+		}
+		for _, event := range events {
+			if err := v.add(event); err != nil {
+				return v.result, err
+			}
+		}
+		if len(events) < verifyBatchSize {
+			return v.done()
+		}
+		after = events[len(events)-1].ID
+	}
+}
@@ -0,0 +1,223 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	audit_repo "github.com/AtIasShrugged/antisocial/internal/repository/audit"
+	"github.com/AtIasShrugged/antisocial/libs/logger/handlers/slogdiscard"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepo keeps events the way the database does: JSON re-encoded by
+// the driver and times read back in the server's zone.
+type memoryRepo struct {
+	events []models.AuditEvent
+	err    error
+}
+
+// InTx drops the events appended by fn when it fails, as a rollback would.
+func (m *memoryRepo) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	n := len(m.events)
+	if err := fn(ctx); err != nil {
+		m.events = m.events[:n]
+		return err
+	}
+	return nil
+}
+
+func (m *memoryRepo) Append(_ context.Context, event models.AuditEvent, seal audit_repo.Sealer) (models.AuditEvent, error) {
+	if m.err != nil {
+		return models.AuditEvent{}, m.err
+	}
+	event.PrevHash = []byte{}
+	if len(m.events) > 0 {
+		event.PrevHash = m.events[len(m.events)-1].Hash
+	}
+	event.ID = int64(len(m.events) + 1)
+
+	var err error
+	if event.Hash, err = seal(event.PrevHash, event); err != nil {
+		return models.AuditEvent{}, err
+	}
+
+	stored := event
+	stored.CreatedAt = event.CreatedAt.In(time.FixedZone("MSK", 3*60*60))
+	for _, v := range []*any{&stored.Before, &stored.After} {
+		if *v != nil {
+			b, err := json.Marshal(*v)
+			if err != nil {
+				return models.AuditEvent{}, err
+			}
+			*v = json.RawMessage(b)
+		}
+	}
+	m.events = append(m.events, stored)
+	return event, nil
+}
+
+func (m *memoryRepo) List(_ context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	return m.events, nil
+}
+
+func (m *memoryRepo) Scan(_ context.Context, afterID int64, limit int) ([]models.AuditEvent, error) {
+	var page []models.AuditEvent
+	for _, e := range m.events {
+		if e.ID > afterID && len(page) < limit {
+			page = append(page, e)
+		}
+	}
+	return page, nil
+}
+
+func newTrail(t *testing.T, n int) (*Trail, *memoryRepo) {
+	t.Helper()
+
+	repo := &memoryRepo{}
+	trail := New(repo, slogdiscard.NewDiscardLogger())
+	now := time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC)
+	trail.now = func() time.Time { return now }
+
+	ctx := WithClient(auth.WithPrincipal(context.Background(), auth.Principal{UserID: 1, Role: models.RoleAdmin}), "10.0.0.1", "curl/8.0")
+	for i := 0; i < n; i++ {
+		err := trail.Record(ctx, models.AuditEvent{
+			Action:     models.AuditRoleChange,
+			TargetType: models.AuditTargetUser,
+			TargetID:   "7",
+			Before:     map[string]string{"role": "user"},
+			After:      map[string]string{"role": "<moderator>"},
+		})
+		require.NoError(t, err)
+		now = now.Add(time.Second)
+	}
+	require.Len(t, repo.events, n)
+	return trail, repo
+}
+
+func TestRecord(t *testing.T) {
+	_, repo := newTrail(t, 1)
+
+	event := repo.events[0]
+	assert.Equal(t, 1, event.ActorID)
+	assert.Equal(t, "10.0.0.1", event.IP)
+	assert.Equal(t, "curl/8.0", event.UserAgent)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.UTC), event.CreatedAt.UTC())
+	assert.Equal(t, json.RawMessage(`{"role":"user"}`), event.Before)
+	assert.Empty(t, event.PrevHash)
+	assert.Len(t, event.Hash, 32)
+}
+
+func TestRecordReturnsFailures(t *testing.T) {
+	repo := &memoryRepo{err: errors.New("db is down")}
+	trail := New(repo, slogdiscard.NewDiscardLogger())
+
+	err := trail.Record(context.Background(), models.AuditEvent{Action: models.AuditRoleChange})
+	require.Error(t, err)
+	assert.Empty(t, repo.events)
+}
+
+func TestVerify(t *testing.T) {
+	trail, repo := newTrail(t, verifyBatchSize+5)
+
+	result, err := trail.Verify(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, verifyBatchSize+5, result.Events)
+	assert.Equal(t, int64(verifyBatchSize+5), result.Head.ID)
+
+	head := result.Head
+	_, err = trail.Verify(context.Background(), head)
+	require.NoError(t, err)
+
+	// Cutting off the newest event leaves a valid chain, which only the
+	// checkpoint notices.
+	repo.events = repo.events[:len(repo.events)-1]
+	_, err = trail.Verify(context.Background())
+	require.NoError(t, err)
+	_, err = trail.Verify(context.Background(), head)
+	require.ErrorIs(t, err, ErrChainBroken)
+	assert.ErrorContains(t, err, "checkpoint event 1005 is missing")
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	for name, tamper := range map[string]func(events []models.AuditEvent) []models.AuditEvent{
+		"modified": func(events []models.AuditEvent) []models.AuditEvent {
+			events[2].After = json.RawMessage(`{"role":"admin"}`)
+			return events
+		},
+		"actor changed": func(events []models.AuditEvent) []models.AuditEvent {
+			events[2].ActorID = 2
+			return events
+		},
+		"removed": func(events []models.AuditEvent) []models.AuditEvent {
+			return append(events[:2], events[3:]...)
+		},
+		"reordered": func(events []models.AuditEvent) []models.AuditEvent {
+			events[1], events[2] = events[2], events[1]
+			return events
+		},
+		"rehashed": func(events []models.AuditEvent) []models.AuditEvent {
+			// Recomputing the hash of an edited event doesn't help: the next
+			// event still points at the old one.
+			events[2].TargetID = "8"
+			events[2].Hash, _ = Hash(events[2].PrevHash, events[2])
+			return events
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			trail, repo := newTrail(t, 5)
+			repo.events = tamper(repo.events)
+
+			_, err := trail.Verify(context.Background())
+			require.ErrorIs(t, err, ErrChainBroken)
+		})
+	}
+}
+
+func TestParseCheckpoint(t *testing.T) {
+	c, err := ParseCheckpoint("12:" + "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08")
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), c.ID)
+	assert.Equal(t, "12:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", c.String())
+
+	for _, s := range []string{"", "12", "x:9f86", "0:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", "12:9f86"} {
+		_, err := ParseCheckpoint(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestListRequiresPermission(t *testing.T) {
+	trail, _ := newTrail(t, 1)
+
+	_, err := trail.List(auth.WithPrincipal(context.Background(), auth.Principal{UserID: 2, Role: models.RoleModerator}), models.AuditFilter{})
+	require.ErrorIs(t, err, auth.ErrForbidden)
+
+	since := time.Now()
+	_, err = trail.List(auth.WithPrincipal(context.Background(), auth.Principal{UserID: 1, Role: models.RoleAdmin}),
+		models.AuditFilter{Since: &since, Until: &since})
+	require.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+func TestMiddleware(t *testing.T) {
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/", func(c echo.Context) error {
+		client := clientFromContext(c.Request().Context())
+		return c.String(http.StatusOK, client.ip+" "+client.userAgent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("User-Agent", "test-agent")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "192.0.2.1 test-agent", rec.Body.String())
+}
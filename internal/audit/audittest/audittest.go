@@ -0,0 +1,35 @@
+// Package audittest provides an auditor for tests of code that records
+// audit events.
+package audittest
+
+import (
+	"context"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+)
+
+// Recorder keeps the events it is given, in order, instead of chaining and
+// storing them. Record fails with Err when it is set.
+type Recorder struct {
+	Events []models.AuditEvent
+	Err    error
+}
+
+// InTx runs fn and forgets the events it recorded if it fails, as a
+// rollback would.
+func (r *Recorder) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	n := len(r.Events)
+	if err := fn(ctx); err != nil {
+		r.Events = r.Events[:n]
+		return err
+	}
+	return nil
+}
+
+func (r *Recorder) Record(_ context.Context, event models.AuditEvent) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	r.Events = append(r.Events, event)
+	return nil
+}
@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+)
+
+var ErrChainBroken = errors.New("audit chain is broken")
+
+// sealed is what an event's hash covers, in a fixed field order. Times are
+// in UTC at the microsecond precision the database keeps.
+type sealed struct {
+	ID         int64              `json:"id"`
+	ActorID    int                `json:"actor_id"`
+	Action     models.AuditAction `json:"action"`
+	TargetType models.AuditTarget `json:"target_type"`
+	TargetID   string             `json:"target_id"`
+	IP         string             `json:"ip"`
+	UserAgent  string             `json:"user_agent"`
+	Before     any                `json:"before"`
+	After      any                `json:"after"`
+	CreatedAt  string             `json:"created_at"`
+}
+
+// Hash returns SHA-256 over prev followed by the JSON encoding of event,
+// leaving out the event's own hashes.
+func Hash(prev []byte, event models.AuditEvent) ([]byte, error) {
+	body, err := json.Marshal(sealed{
+		ID:         event.ID,
+		ActorID:    event.ActorID,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		IP:         event.IP,
+		UserAgent:  event.UserAgent,
+		Before:     event.Before,
+		After:      event.After,
+		CreatedAt:  event.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't encode audit event: %w", err)
+	}
+
+	h := sha256.New()
+	h.Write(prev)
+	h.Write(body)
+	return h.Sum(nil), nil
+}
+
+// Checkpoint is an event hash noted down outside the database. The chain
+// alone can't tell when its newest events were cut off or the whole of it
+// rewritten; a checkpoint that no longer matches can.
+type Checkpoint struct {
+	ID   int64
+	Hash []byte
+}
+
+// ParseCheckpoint reads a checkpoint written as "<id>:<hex hash>".
+func ParseCheckpoint(s string) (Checkpoint, error) {
+	id, hash, ok := strings.Cut(s, ":")
+	if !ok {
+		return Checkpoint{}, fmt.Errorf("checkpoint %q is not <id>:<hash>", s)
+	}
+	var (
+		c   Checkpoint
+		err error
+	)
+	if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil || c.ID <= 0 {
+		return Checkpoint{}, fmt.Errorf("checkpoint %q has a bad id", s)
+	}
+	if c.Hash, err = hex.DecodeString(hash); err != nil || len(c.Hash) != sha256.Size {
+		return Checkpoint{}, fmt.Errorf("checkpoint %q has a bad hash", s)
+	}
+	return c, nil
+}
+
+func (c Checkpoint) String() string {
+	return strconv.FormatInt(c.ID, 10) + ":" + hex.EncodeToString(c.Hash)
+}
+
+// Verification sums up a verified chain. Head is its newest event, to be
+// kept as a checkpoint for the next run.
+type Verification struct {
+	Events int
+	Head   Checkpoint
+}
+
+// verifier checks events one at a time, in chain order.
+type verifier struct {
+	checkpoints map[int64][]byte
+	result      Verification
+}
+
+func newVerifier(checkpoints []Checkpoint) *verifier {
+	v := &verifier{checkpoints: make(map[int64][]byte, len(checkpoints))}
+	for _, c := range checkpoints {
+		v.checkpoints[c.ID] = c.Hash
+	}
+	return v
+}
+
+func (v *verifier) add(event models.AuditEvent) error {
+	if !bytes.Equal(event.PrevHash, v.result.Head.Hash) {
+		return fmt.Errorf("%w: event %d doesn't follow event %d; events were removed or reordered",
+			ErrChainBroken, event.ID, v.result.Head.ID)
+	}
+	hash, err := Hash(event.PrevHash, event)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(hash, event.Hash) {
+		return fmt.Errorf("%w: event %d was modified", ErrChainBroken, event.ID)
+	}
+	if want, ok := v.checkpoints[event.ID]; ok {
+		if !bytes.Equal(want, event.Hash) {
+			return fmt.Errorf("%w: event %d doesn't match its checkpoint", ErrChainBroken, event.ID)
+		}
+		delete(v.checkpoints, event.ID)
+	}
+
+	v.result.Events++
+	v.result.Head = Checkpoint{ID: event.ID, Hash: event.Hash}
+	return nil
+}
+
+// done checks that every checkpoint was passed.
+func (v *verifier) done() (Verification, error) {
+	if len(v.checkpoints) == 0 {
+		return v.result, nil
+	}
+	missing := int64(0)
+	for id := range v.checkpoints {
+		if missing == 0 || id < missing {
+			missing = id
+		}
+	}
+	return v.result, fmt.Errorf("%w: checkpoint event %d is missing", ErrChainBroken, missing)
+}
@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxUserAgentLen bounds what a client can make every event carry.
+const maxUserAgentLen = 512
+
+type ctxKey struct{}
+
+type client struct {
+	ip, userAgent string
+}
+
+// WithClient returns a copy of ctx whose events are recorded as coming from
+// ip with userAgent.
+func WithClient(ctx context.Context, ip, userAgent string) context.Context {
+	if len(userAgent) > maxUserAgentLen {
+		userAgent = userAgent[:maxUserAgentLen]
+	}
+	return context.WithValue(ctx, ctxKey{}, client{ip: ip, userAgent: userAgent})
+}
+
+func clientFromContext(ctx context.Context) client {
+	c, _ := ctx.Value(ctxKey{}).(client)
+	return c
+}
+
+// Middleware notes the client of every request for the events it records.
+// The IP comes from the Echo IP extractor, so it is only as trustworthy as
+// the proxy configuration.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			c.SetRequest(req.WithContext(WithClient(req.Context(), c.RealIP(), req.UserAgent())))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Package auth authenticates requests and decides what their users may do.
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+)
+
+// Throttle slows down clients that keep failing to authenticate. AuthFailed
+// counts a failure from ip and returns how long ip must wait before trying
+// again, or zero while it may go on.
+type Throttle interface {
+	AuthFailed(ctx context.Context, ip string) time.Duration
+}
+
+// errUnavailable is returned when the role or state of a user can't be
+// loaded, which is no fault of the request.
+var errUnavailable = errors.New("can't authenticate right now")
+
+// Principal is the authenticated user of a request. Only a suspended State
+// changes what it may do.
+type Principal struct {
+	UserID int
+	Role   models.Role
+	State  models.AccountState
+}
+
+type ctxKey struct{}
+
+// WithPrincipal returns a copy of ctx that belongs to p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext returns the authenticated user of ctx, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
+
+// UserID returns the id of the authenticated user of ctx, if any.
+func UserID(ctx context.Context) (int, bool) {
+	p, ok := FromContext(ctx)
+	return p.UserID, ok
+}
+
+// authenticate returns a copy of ctx that belongs to the user of the
+// credentials raw, given as "Bearer <token>". It is shared by the HTTP
+// middleware and the gRPC interceptor, which answer its errors each in
+// their own way.
+func authenticate(ctx context.Context, raw string, tokens *Tokens, roles RoleSource, states StateSource, log *slog.Logger) (context.Context, error) {
+	const op = "auth.authenticate"
+
+	token, ok := strings.CutPrefix(raw, "Bearer ")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	userID, err := tokens.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = sl.With(ctx, log, sl.UserID(userID))
+	role, err := roles.Role(ctx, userID)
+	if err != nil {
+		sl.FromContext(ctx, log).ErrorContext(ctx, "can't load role", sl.Op(op), sl.Err(err))
+		return nil, errUnavailable
+	}
+
+	state, err := states.State(ctx, userID)
+	if err != nil {
+		sl.FromContext(ctx, log).ErrorContext(ctx, "can't load account state", sl.Op(op), sl.Err(err))
+		return nil, errUnavailable
+	}
+	if state == models.AccountDeactivated {
+		return nil, ErrDeactivated
+	}
+
+	return WithPrincipal(ctx, Principal{UserID: userID, Role: role, State: state}), nil
+}
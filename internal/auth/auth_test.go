@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/libs/logger/handlers/slogdiscard"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func as(id int, role models.Role) context.Context {
+	return WithPrincipal(context.Background(), Principal{UserID: id, Role: role})
+}
+
+func TestAuthorize(t *testing.T) {
+	user, moderator := as(1, models.RoleUser), as(2, models.RoleModerator)
+
+	require.NoError(t, Authorize(user, PostsUpdate, Owner(1)))
+	require.ErrorIs(t, Authorize(user, PostsUpdate, Owner(2)), ErrForbidden)
+	require.ErrorIs(t, Authorize(user, PostsUpdate, nil), ErrForbidden)
+	require.ErrorIs(t, Authorize(user, ReportsRead, nil), ErrForbidden)
+
+	// "posts:delete:any" reaches every post; "posts:update:own" only the
+	// moderator's own.
+	require.NoError(t, Authorize(moderator, PostsDelete, Owner(1)))
+	require.NoError(t, Authorize(moderator, ReportsRead, nil))
+	require.ErrorIs(t, Authorize(moderator, PostsUpdate, Owner(1)), ErrForbidden)
+	require.ErrorIs(t, Authorize(moderator, RolesManage, nil), ErrForbidden)
+
+	require.NoError(t, Authorize(as(3, models.RoleAdmin), RolesManage, nil))
+	require.ErrorIs(t, Authorize(context.Background(), PostsCreate, Owner(1)), ErrUnauthenticated)
+	require.ErrorIs(t, Authorize(as(1, "guest"), PostsCreate, Owner(1)), ErrForbidden)
+}
+
+func TestAuthorizeSuspended(t *testing.T) {
+	for _, role := range models.Roles {
+		ctx := WithPrincipal(context.Background(), Principal{UserID: 1, Role: role, State: models.AccountSuspended})
+
+		require.ErrorIs(t, Authorize(ctx, PostsCreate, Owner(1)), ErrForbidden, role)
+		require.ErrorIs(t, Authorize(ctx, ReportsRead, nil), ErrForbidden, role)
+		require.NoError(t, Authorize(ctx, AppealsCreate, Owner(1)), role)
+		require.ErrorIs(t, Authorize(ctx, AppealsCreate, Owner(2)), ErrForbidden, role)
+	}
+
+	limited := WithPrincipal(context.Background(), Principal{UserID: 1, Role: models.RoleUser, State: models.AccountLimited})
+	require.NoError(t, Authorize(limited, PostsCreate, Owner(1)))
+}
+
+func TestRolesBuildOnEachOther(t *testing.T) {
+	for i := 1; i < len(models.Roles); i++ {
+		lower, higher := Grants(models.Roles[i-1]), Grants(models.Roles[i])
+		assert.Subset(t, higher, lower, "%s lacks grants of %s", models.Roles[i], models.Roles[i-1])
+	}
+	for _, role := range models.Roles {
+		assert.False(t, Can(role, Public), role)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tokens := NewTokens("s3cret")
+	tokens.now = func() time.Time { return now }
+
+	token, err := tokens.Issue(42, time.Hour)
+	require.NoError(t, err)
+	id, err := tokens.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, 42, id)
+
+	_, err = NewTokens("other").Verify(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	head, rest, _ := strings.Cut(token, ".")
+	_, sig, _ := strings.Cut(rest, ".")
+	forged := head + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1","exp":9999999999}`)) + "." + sig
+	_, err = tokens.Verify(forged)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + strings.SplitN(token, ".", 3)[1] + "."
+	_, err = tokens.Verify(unsigned)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	now = now.Add(time.Hour)
+	_, err = tokens.Verify(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = NewTokens("").Issue(42, time.Hour)
+	require.ErrorIs(t, err, ErrNoSecret)
+	_, err = NewTokens("").Verify(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+type roleSource map[int]models.Role
+
+func (r roleSource) Role(_ context.Context, userID int) (models.Role, error) {
+	if userID == 99 {
+		return "", errors.New("db is down")
+	}
+	if role, ok := r[userID]; ok {
+		return role, nil
+	}
+	return models.RoleUser, nil
+}
+
+type stateSource map[int]models.AccountState
+
+func (s stateSource) State(_ context.Context, userID int) (models.AccountState, error) {
+	if userID == 98 {
+		return "", errors.New("db is down")
+	}
+	if state, ok := s[userID]; ok {
+		return state, nil
+	}
+	return models.AccountActive, nil
+}
+
+// throttle lets left failures through, then asks for a 30 second wait.
+type throttle struct {
+	left int
+	ips  []string
+}
+
+func (th *throttle) AuthFailed(_ context.Context, ip string) time.Duration {
+	th.ips = append(th.ips, ip)
+	if th.left > 0 {
+		th.left--
+		return 0
+	}
+	return 30 * time.Second
+}
+
+func TestMiddleware(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	tokens := NewTokens("s3cret")
+
+	e := echo.New()
+	e.Use(Middleware(tokens, roleSource{2: models.RoleModerator, 3: models.RoleModerator},
+		stateSource{3: models.AccountSuspended, 4: models.AccountDeactivated}, &throttle{left: 100}, log))
+	e.Use(Require(map[string]Permission{
+		"GET /public":  Public,
+		"GET /reports": ReportsRead,
+	}, log))
+	whoami := func(c echo.Context) error {
+		p, _ := FromContext(c.Request().Context())
+		return c.JSON(http.StatusOK, p)
+	}
+	e.GET("/public", whoami)
+	e.GET("/reports", whoami)
+
+	serve := func(path, header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if header != "" {
+			req.Header.Set(echo.HeaderAuthorization, header)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	bearer := func(id int) string {
+		token, err := tokens.Issue(id, time.Hour)
+		require.NoError(t, err)
+		return "Bearer " + token
+	}
+
+	rec := serve("/public", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"UserID":0,"Role":"","State":""}`, rec.Body.String())
+
+	rec = serve("/public", "Bearer nope")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, rec.Header().Get(echo.HeaderWWWAuthenticate))
+
+	rec = serve("/reports", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+
+	assert.Equal(t, http.StatusForbidden, serve("/reports", bearer(1)).Code)
+
+	rec = serve("/reports", bearer(2))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"UserID":2,"Role":"moderator","State":"active"}`, rec.Body.String())
+
+	// Suspended moderators sign in, but lose what their role grants.
+	rec = serve("/public", bearer(3))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"UserID":3,"Role":"moderator","State":"suspended"}`, rec.Body.String())
+	assert.Equal(t, http.StatusForbidden, serve("/reports", bearer(3)).Code)
+
+	rec = serve("/public", bearer(4))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), ErrDeactivated.Error())
+
+	assert.Equal(t, http.StatusServiceUnavailable, serve("/public", bearer(99)).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serve("/public", bearer(98)).Code)
+	assert.Equal(t, http.StatusNotFound, serve("/missing", "").Code)
+}
+
+func TestInterceptors(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	tokens := NewTokens("s3cret")
+
+	authenticate := UnaryInterceptor(tokens, roleSource{2: models.RoleModerator}, stateSource{4: models.AccountDeactivated}, &throttle{left: 100}, log)
+	requirePerm := RequireUnary(map[string]Permission{
+		"/test.Service/Public":  Public,
+		"/test.Service/Reports": ReportsRead,
+	}, log)
+	whoami := func(ctx context.Context, _ any) (any, error) {
+		p, _ := FromContext(ctx)
+		return p, nil
+	}
+
+	call := func(method string, header string) (any, codes.Code) {
+		ctx := context.Background()
+		if header != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", header))
+		}
+		info := &grpc.UnaryServerInfo{FullMethod: method}
+		resp, err := authenticate(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+			return requirePerm(ctx, req, info, whoami)
+		})
+		return resp, status.Code(err)
+	}
+	bearer := func(id int) string {
+		token, err := tokens.Issue(id, time.Hour)
+		assert.NoError(t, err)
+		return "Bearer " + token
+	}
+
+	resp, code := call("/test.Service/Public", "")
+	assert.Equal(t, codes.OK, code)
+	assert.Equal(t, Principal{}, resp)
+
+	_, code = call("/test.Service/Public", "Bearer nope")
+	assert.Equal(t, codes.Unauthenticated, code)
+	_, code = call("/test.Service/Reports", "")
+	assert.Equal(t, codes.Unauthenticated, code)
+	_, code = call("/test.Service/Reports", bearer(1))
+	assert.Equal(t, codes.PermissionDenied, code)
+
+	resp, code = call("/test.Service/Reports", bearer(2))
+	assert.Equal(t, codes.OK, code)
+	assert.Equal(t, Principal{UserID: 2, Role: models.RoleModerator, State: models.AccountActive}, resp)
+
+	_, code = call("/test.Service/Public", bearer(4))
+	assert.Equal(t, codes.PermissionDenied, code)
+	_, code = call("/test.Service/Public", bearer(99))
+	assert.Equal(t, codes.Unavailable, code)
+	_, code = call("/test.Service/Missing", bearer(2))
+	assert.Equal(t, codes.PermissionDenied, code)
+}
+
+// Every failed authentication counts against the client's address, and
+// clients out of attempts are told to wait.
+func TestFailuresAreThrottled(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	tokens := NewTokens("s3cret")
+	valid, err := tokens.Issue(1, time.Hour)
+	require.NoError(t, err)
+
+	th := &throttle{left: 1}
+	e := echo.New()
+	e.Use(Middleware(tokens, roleSource{}, stateSource{}, th, log))
+	e.GET("/public", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	serve := func(header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/public", nil)
+		req.Header.Set(echo.HeaderAuthorization, header)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve("Bearer "+valid).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer nope").Code)
+	rec := serve("Bearer nope")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get(echo.HeaderRetryAfter))
+	assert.Equal(t, []string{"192.0.2.1", "192.0.2.1"}, th.ips)
+
+	th = &throttle{left: 1}
+	authenticate := UnaryInterceptor(tokens, roleSource{}, stateSource{}, th, log)
+	call := func(header string) codes.Code {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.9"), Port: 4321}})
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", header))
+		_, err := authenticate(ctx, nil, &grpc.UnaryServerInfo{}, func(context.Context, any) (any, error) { return nil, nil })
+		return status.Code(err)
+	}
+
+	assert.Equal(t, codes.OK, call("Bearer "+valid))
+	assert.Equal(t, codes.Unauthenticated, call("Bearer nope"))
+	assert.Equal(t, codes.ResourceExhausted, call("Bearer nope"))
+	assert.Equal(t, []string{"10.0.0.9", "10.0.0.9"}, th.ips)
+}
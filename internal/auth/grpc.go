@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryInterceptor is Middleware for gRPC: it authenticates calls whose
+// "authorization" metadata carries a bearer token, and lets the others go on
+// anonymously. Failed authentications are throttled as by Middleware.
+func UnaryInterceptor(tokens *Tokens, roles RoleSource, states StateSource, throttle Throttle, log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		raw := md.Get("authorization")
+		if len(raw) == 0 {
+			return next(ctx, req)
+		}
+		authed, err := authenticate(ctx, raw[0], tokens, roles, states, log)
+		switch {
+		case errors.Is(err, errUnavailable):
+			return nil, status.Error(codes.Unavailable, err.Error())
+		case errors.Is(err, ErrDeactivated):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		case err != nil:
+			if wait := throttle.AuthFailed(ctx, PeerIP(ctx)); wait > 0 {
+				_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(wait.Seconds())))))
+				return nil, status.Error(codes.ResourceExhausted, ErrTooManyFailures.Error())
+			}
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return next(authed, req)
+	}
+}
+
+// PeerIP is the address a call came from, the RealIP of a direct
+// connection.
+func PeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// RequireUnary is Require for gRPC, with methods keyed by their full name,
+// e.g. "/antisocial.v1.PostService/GetPost". Methods missing from methods
+// are closed to everyone.
+func RequireUnary(methods map[string]Permission, log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		if err := requireMethod(ctx, methods, info.FullMethod, log); err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+// RequireStream is RequireUnary for streaming methods. Streams are not
+// authenticated, so only public ones get through.
+func RequireStream(methods map[string]Permission, log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+		if err := requireMethod(ss.Context(), methods, info.FullMethod, log); err != nil {
+			return err
+		}
+		return next(srv, ss)
+	}
+}
+
+func requireMethod(ctx context.Context, methods map[string]Permission, method string, log *slog.Logger) error {
+	const op = "auth.requireMethod"
+
+	perm, ok := methods[method]
+	if !ok {
+		sl.FromContext(ctx, log).ErrorContext(ctx, "method has no permission", sl.Op(op), slog.String("method", method))
+		return status.Error(codes.PermissionDenied, ErrForbidden.Error())
+	}
+	if perm == Public {
+		return nil
+	}
+
+	p, ok := FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, ErrUnauthenticated.Error())
+	}
+	if !holds(p.grants(), perm) {
+		return status.Error(codes.PermissionDenied, ErrForbidden.Error())
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+)
+
+type RoleSource interface {
+	Role(ctx context.Context, userID int) (models.Role, error)
+}
+
+type StateSource interface {
+	State(ctx context.Context, userID int) (models.AccountState, error)
+}
+
+// Middleware authenticates requests carrying "Authorization: Bearer
+// <token>". Requests without the header go on anonymously; whether that is
+// enough is up to Require. Deactivated accounts are turned away however
+// valid their token. Failed authentications are counted per client IP by
+// throttle, and clients out of attempts get 429 instead of 401.
+func Middleware(tokens *Tokens, roles RoleSource, states StateSource, throttle Throttle, log *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			raw := req.Header.Get(echo.HeaderAuthorization)
+			if raw == "" {
+				return next(c)
+			}
+			ctx, err := authenticate(req.Context(), raw, tokens, roles, states, log)
+			switch {
+			case errors.Is(err, errUnavailable):
+				return c.JSON(http.StatusServiceUnavailable, err.Error())
+			case errors.Is(err, ErrDeactivated):
+				return c.JSON(http.StatusForbidden, err.Error())
+			case err != nil:
+				if wait := throttle.AuthFailed(req.Context(), c.RealIP()); wait > 0 {
+					c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+					return c.JSON(http.StatusTooManyRequests, ErrTooManyFailures.Error())
+				}
+				return unauthorized(c, err)
+			}
+
+			c.SetRequest(req.WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// Require enforces the permission routes maps each route to, keyed by
+// method and path as registered, e.g. "GET /posts/:id". Routes missing from
+// routes are closed to everyone, so that a new route stays shut until it is
+// given a permission. Ownership is checked later, by Authorize.
+func Require(routes map[string]Permission, log *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			const op = "auth.Require"
+			ctx := c.Request().Context()
+
+			// Unmatched requests end in 404 or 405 and have nothing to guard.
+			if c.Path() == "" {
+				return next(c)
+			}
+
+			route := c.Request().Method + " " + c.Path()
+			perm, ok := routes[route]
+			if !ok {
+				sl.FromContext(ctx, log).ErrorContext(ctx, "route has no permission", sl.Op(op), slog.String("route", route))
+				return c.JSON(http.StatusForbidden, ErrForbidden.Error())
+			}
+			if perm == Public {
+				return next(c)
+			}
+
+			p, ok := FromContext(ctx)
+			if !ok {
+				return unauthorized(c, ErrUnauthenticated)
+			}
+			if !holds(p.grants(), perm) {
+				return c.JSON(http.StatusForbidden, ErrForbidden.Error())
+			}
+			return next(c)
+		}
+	}
+}
+
+// HTTPStatus maps authorization failures to their status code.
+func HTTPStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		return http.StatusUnauthorized, true
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden, true
+	}
+	return 0, false
+}
+
+func unauthorized(c echo.Context, err error) error {
+	if errors.Is(err, ErrInvalidToken) {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+	} else {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+	}
+	return c.JSON(http.StatusUnauthorized, err.Error())
+}
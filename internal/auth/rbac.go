@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("permission denied")
+	ErrDeactivated     = errors.New("account is deactivated")
+	ErrTooManyFailures = errors.New("too many failed authentications, try again later")
+)
+
+// Permission names an action, such as "posts:update". Roles hold
+// permissions outright or scoped with ":own" or ":any": "posts:delete:any"
+// lets moderators remove anyone's post, while "posts:update:own" only lets
+// users edit their own.
+type Permission string
+
+const (
+	// Public marks routes open to anonymous requests. No role holds it.
+	Public Permission = "public"
+
+	PostsCreate       Permission = "posts:create"
+	PostsReadDrafts   Permission = "posts:read_drafts"
+	PostsUpdate       Permission = "posts:update"
+	PostsDelete       Permission = "posts:delete"
+	AttachmentsCreate Permission = "attachments:create"
+	PollsVote         Permission = "polls:vote"
+	NotificationsRead Permission = "notifications:read"
+	ReportsCreate     Permission = "reports:create"
+	FollowsManage     Permission = "follows:manage"
+	AccountsRead      Permission = "accounts:read"
+	AccountsManage    Permission = "accounts:manage"
+	AppealsCreate     Permission = "appeals:create"
+	AppealsReview     Permission = "appeals:review"
+	ReportsRead       Permission = "reports:read"
+	ReportsAssign     Permission = "reports:assign"
+	ReportsResolve    Permission = "reports:resolve"
+	UsersSuspend      Permission = "users:suspend"
+	RolesRead         Permission = "roles:read"
+	RolesManage       Permission = "roles:manage"
+	LogsManage        Permission = "logs:manage"
+	AuditRead         Permission = "audit:read"
+	BlocklistManage   Permission = "blocklist:manage"
+)
+
+const (
+	scopeOwn = ":own"
+	scopeAny = ":any"
+)
+
+var (
+	userGrants = []string{
+		"posts:create:own",
+		"posts:read_drafts:own",
+		"posts:update:own",
+		"attachments:create:own",
+		"polls:vote:own",
+		"notifications:read:own",
+		"reports:create:own",
+		"follows:manage:own",
+		"accounts:read:own",
+		"appeals:create:own",
+	}
+	moderatorGrants = slices.Concat(userGrants, []string{
+		"posts:delete:any",
+		"reports:read",
+		"reports:assign",
+		"reports:resolve",
+		"users:suspend",
+		"roles:read",
+		"accounts:read",
+		"accounts:manage",
+		"appeals:review",
+	})
+	// suspendedGrants replace the grants of any role while its holder is
+	// suspended: enough to find out why and to appeal.
+	suspendedGrants = []string{
+		"notifications:read:own",
+		"accounts:read:own",
+		"appeals:create:own",
+	}
+	adminGrants = slices.Concat(moderatorGrants, []string{
+		"roles:manage",
+		"logs:manage",
+		"audit:read",
+		"blocklist:manage",
+	})
+)
+
+var grants = map[models.Role]map[string]bool{
+	models.RoleUser:      set(userGrants),
+	models.RoleModerator: set(moderatorGrants),
+	models.RoleAdmin:     set(adminGrants),
+}
+
+var suspended = set(suspendedGrants)
+
+// grants returns what p holds, which depends on its state as well as its
+// role.
+func (p Principal) grants() map[string]bool {
+	if p.State == models.AccountSuspended {
+		return suspended
+	}
+	return grants[p.Role]
+}
+
+func set(grants []string) map[string]bool {
+	m := make(map[string]bool, len(grants))
+	for _, g := range grants {
+		m[g] = true
+	}
+	return m
+}
+
+// Resource is something a permission may be scoped to.
+type Resource interface {
+	OwnerID() int
+}
+
+// Owner is a resource known only by the user it belongs to.
+type Owner int
+
+func (o Owner) OwnerID() int { return int(o) }
+
+// Can reports whether role holds perm in any scope. It is the coarse check
+// done before the resource is known.
+func Can(role models.Role, perm Permission) bool {
+	return holds(grants[role], perm)
+}
+
+func holds(g map[string]bool, perm Permission) bool {
+	return g[string(perm)] || g[string(perm)+scopeAny] || g[string(perm)+scopeOwn]
+}
+
+// Grants returns what role holds, scopes included.
+func Grants(role models.Role) []string {
+	switch role {
+	case models.RoleUser:
+		return slices.Clone(userGrants)
+	case models.RoleModerator:
+		return slices.Clone(moderatorGrants)
+	case models.RoleAdmin:
+		return slices.Clone(adminGrants)
+	}
+	return nil
+}
+
+// Authorize checks that the user of ctx may perform perm on resource. A nil
+// resource only passes with the permission held outright or for any
+// resource.
+func Authorize(ctx context.Context, perm Permission, resource Resource) error {
+	p, ok := FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	g := p.grants()
+	if g[string(perm)] || g[string(perm)+scopeAny] {
+		return nil
+	}
+	if resource != nil && resource.OwnerID() == p.UserID && g[string(perm)+scopeOwn] {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrForbidden, perm)
+}
+
+// AuthorizeOver checks that the user of ctx holds a role above target, the
+// role of the user they act on. Moderators can't restrict each other or the
+// admins above them, and admins can't restrict each other.
+func AuthorizeOver(ctx context.Context, target models.Role) error {
+	p, ok := FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if slices.Index(models.Roles, p.Role) <= slices.Index(models.Roles, target) {
+		return fmt.Errorf("%w: can't act on a %s", ErrForbidden, target)
+	}
+	return nil
+}
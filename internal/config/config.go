@@ -2,29 +2,205 @@ package config
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
+	"github.com/AtIasShrugged/antisocial/libs/logger"
+	"github.com/AtIasShrugged/antisocial/libs/logger/redact"
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Env    string         `yaml:"env" env-default:"local"`
-	Server ServerConfig   `yaml:"server"`
-	DB     DatabaseConfig `yaml:"database"`
+	Env         string            `yaml:"env" env-default:"local"`
+	Server      ServerConfig      `yaml:"server"`
+	DB          DatabaseConfig    `yaml:"database"`
+	Media       MediaConfig       `yaml:"media"`
+	Storage     StorageConfig     `yaml:"storage"`
+	LinkPreview LinkPreviewConfig `yaml:"link_preview"`
+	Polls       PollsConfig       `yaml:"polls"`
+	Posts       PostsConfig       `yaml:"posts"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Health      HealthConfig      `yaml:"health"`
+	Log         logger.Config     `yaml:"log"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Admin       AdminConfig       `yaml:"admin"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Spam        SpamConfig        `yaml:"spam"`
+	Blocklist   BlocklistConfig   `yaml:"blocklist"`
+	API         APIConfig         `yaml:"api"`
+	GraphQL     GraphQLConfig     `yaml:"graphql"`
+	GRPC        GRPCConfig        `yaml:"grpc"`
 }
 
 type ServerConfig struct {
 	Host string `yaml:"host" env-default:"localhost"`
 	Port string `yaml:"port" env-default:"3002"`
+	// DrainDelay is how long readiness reports failure before the server
+	// stops accepting connections, giving load balancers time to notice.
+	DrainDelay      time.Duration `yaml:"drain_delay" env-default:"5s"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"15s"`
+	// TrustProxyHeaders takes client IPs from X-Forwarded-For and X-Real-IP.
+	// Enable it only behind a proxy that sets them, or clients can pick
+	// their own IP and dodge rate limits.
+	TrustProxyHeaders bool `yaml:"trust_proxy_headers" env-default:"false"`
 }
 
 type DatabaseConfig struct {
-	Driver string `yaml:"driver"`
-	Host   string `yaml:"host" env-default:"localhost"`
-	Port   string `yaml:"port" env-default:"5432"`
-	User   string `yaml:"user" env-default:"postgres"`
-	Pass   string `yaml:"pass" env-default:"postgres"`
-	Name   string `yaml:"name" env-default:"antisocial"`
+	Driver string                `yaml:"driver"`
+	Host   string                `yaml:"host" env-default:"localhost"`
+	Port   string                `yaml:"port" env-default:"5432"`
+	User   string                `yaml:"user" env-default:"postgres"`
+	Pass   redact.Secret[string] `yaml:"pass" env-default:"postgres"`
+	Name   string                `yaml:"name" env-default:"antisocial"`
+}
+
+// DSN returns the connection string of the database.
+func (d DatabaseConfig) DSN() string {
+	return fmt.Sprintf("%s://%s:%s@%s:%s/%s?sslmode=disable", d.Driver, d.User, d.Pass.Reveal(), d.Host, d.Port, d.Name)
+}
+
+type MediaConfig struct {
+	MaxImageSize      int64         `yaml:"max_image_size" env-default:"10485760"`
+	MaxVideoSize      int64         `yaml:"max_video_size" env-default:"52428800"`
+	OrphanTTL         time.Duration `yaml:"orphan_ttl" env-default:"24h"`
+	GCInterval        time.Duration `yaml:"gc_interval" env-default:"1h"`
+	ProcessInterval   time.Duration `yaml:"process_interval" env-default:"5s"`
+	ProcessingTimeout time.Duration `yaml:"processing_timeout" env-default:"10m"`
+}
+
+type LinkPreviewConfig struct {
+	FetchTimeout   time.Duration `yaml:"fetch_timeout" env-default:"5s"`
+	MaxBodySize    int64         `yaml:"max_body_size" env-default:"1048576"`
+	MaxRedirects   int           `yaml:"max_redirects" env-default:"5"`
+	CacheTTL       time.Duration `yaml:"cache_ttl" env-default:"24h"`
+	WorkerInterval time.Duration `yaml:"worker_interval" env-default:"10s"`
+	UserAgent      string        `yaml:"user_agent" env-default:"antisocial-unfurl/1.0"`
+}
+
+type PollsConfig struct {
+	CloseInterval time.Duration `yaml:"close_interval" env-default:"30s"`
+}
+
+type PostsConfig struct {
+	ScheduleInterval time.Duration `yaml:"schedule_interval" env-default:"10s"`
+	ReapInterval     time.Duration `yaml:"reap_interval" env-default:"1m"`
+}
+
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled" env-default:"false"`
+	Endpoint    string  `yaml:"endpoint" env-default:"localhost:4318"`
+	Insecure    bool    `yaml:"insecure" env-default:"false"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+	ServiceName string  `yaml:"service_name" env-default:"antisocial"`
+}
+
+type HealthConfig struct {
+	Timeout  time.Duration `yaml:"timeout" env-default:"2s"`
+	CacheTTL time.Duration `yaml:"cache_ttl" env-default:"2s"`
+}
+
+// AuthConfig sets up bearer token authentication. Requests can't
+// authenticate while TokenSecret is empty.
+type AuthConfig struct {
+	TokenSecret redact.Secret[string] `yaml:"token_secret" env:"AUTH_TOKEN_SECRET"`
+	// Admins always hold the admin role, so that someone can grant roles to
+	// everyone else.
+	Admins []int `yaml:"admins" env:"AUTH_ADMINS" env-separator:","`
+}
+
+type AdminConfig struct {
+	// LogLevelTTL is how long a runtime log level change lasts when the
+	// request doesn't say; MaxLogLevelTTL caps what it may ask for.
+	LogLevelTTL    time.Duration `yaml:"log_level_ttl" env-default:"15m"`
+	MaxLogLevelTTL time.Duration `yaml:"max_log_level_ttl" env-default:"24h"`
+}
+
+// RateLimitConfig sets the token buckets as "<count>/<period>", e.g. "30/1m".
+// Anonymous applies per IP to requests without a user and Auth per IP to
+// failed authentications; Read and Write apply per user.
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" env-default:"true"`
+	// Store is "memory" for a single instance or "postgres" to share buckets
+	// between instances.
+	Store         string        `yaml:"store" env-default:"memory"`
+	Anonymous     string        `yaml:"anonymous" env-default:"60/1m"`
+	Read          string        `yaml:"read" env-default:"300/1m"`
+	Write         string        `yaml:"write" env-default:"30/1m"`
+	Auth          string        `yaml:"auth" env-default:"10/15m"`
+	SweepInterval time.Duration `yaml:"sweep_interval" env-default:"5m"`
+}
+
+// SpamConfig tunes the spam filter of new posts. Posts scoring at least
+// HoldThreshold are held for review, those scoring at least LimitThreshold
+// are shadow-limited outright.
+type SpamConfig struct {
+	Enabled        bool    `yaml:"enabled" env-default:"true"`
+	HoldThreshold  float64 `yaml:"hold_threshold" env-default:"0.7"`
+	LimitThreshold float64 `yaml:"limit_threshold" env-default:"0.9"`
+	// DuplicateWindow is how far back identical posts by other accounts
+	// count.
+	DuplicateWindow time.Duration `yaml:"duplicate_window" env-default:"24h"`
+	// VelocityWindow and VelocityLimit set the posting rate that scores as
+	// spam on its own; half of it scores nothing.
+	VelocityWindow time.Duration `yaml:"velocity_window" env-default:"1h"`
+	VelocityLimit  int           `yaml:"velocity_limit" env-default:"20"`
+	// NewAccountAge is how long after their first post accounts count as
+	// new.
+	NewAccountAge time.Duration `yaml:"new_account_age" env-default:"24h"`
+	// MinTrainingPosts is how many spam and ham posts each the naive Bayes
+	// model must have learned from before its opinion counts.
+	MinTrainingPosts int `yaml:"min_training_posts" env-default:"20"`
+}
+
+// BlocklistConfig sets how often the blocklist is reloaded from the
+// database, which is how changes made through other instances get here.
+type BlocklistConfig struct {
+	ReloadInterval time.Duration `yaml:"reload_interval" env-default:"30s"`
+}
+
+// APIConfig schedules the retirement of the unversioned routes the API was
+// first served at, which now alias their /v1 successors.
+type APIConfig struct {
+	LegacyDeprecatedAt time.Time `yaml:"legacy_deprecated_at" env-default:"2026-10-19T00:00:00Z"`
+	LegacySunset       time.Time `yaml:"legacy_sunset" env-default:"2027-04-19T00:00:00Z"`
+}
+
+// GraphQLConfig bounds what a single GraphQL query may ask for. Depth counts
+// nested fields; complexity counts every field, those under lists as many
+// times as the list is expected to be long.
+type GraphQLConfig struct {
+	MaxDepth      int `yaml:"max_depth" env-default:"10"`
+	MaxComplexity int `yaml:"max_complexity" env-default:"1000"`
+	// PersistedQueries is how many persisted queries are remembered, least
+	// recently used going first.
+	PersistedQueries int `yaml:"persisted_queries" env-default:"1000"`
+}
+
+// GRPCConfig is where the gRPC API is served, alongside the HTTP one.
+type GRPCConfig struct {
+	Port string `yaml:"port" env-default:"3003"`
+	// Reflection lets tools such as grpcurl list the services without the
+	// proto files.
+	Reflection bool `yaml:"reflection" env-default:"true"`
+}
+
+type StorageConfig struct {
+	Driver string             `yaml:"driver" env-default:"local"`
+	Local  LocalStorageConfig `yaml:"local"`
+	S3     S3StorageConfig    `yaml:"s3"`
+}
+
+type LocalStorageConfig struct {
+	Root string `yaml:"root" env-default:"./data/blobs"`
+}
+
+type S3StorageConfig struct {
+	Endpoint  string                `yaml:"endpoint"`
+	Region    string                `yaml:"region" env-default:"us-east-1"`
+	Bucket    string                `yaml:"bucket"`
+	AccessKey string                `yaml:"access_key"`
+	SecretKey redact.Secret[string] `yaml:"secret_key"`
 }
 
 func MustLoad() *Config {
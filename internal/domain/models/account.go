@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+type AccountState string
+
+const (
+	// AccountActive is the state of every account nothing was done to.
+	AccountActive AccountState = "active"
+	// AccountLimited accounts keep posting, but only their followers see it.
+	AccountLimited AccountState = "limited"
+	// AccountSuspended accounts can sign in to appeal and nothing else, and
+	// their posts are hidden.
+	AccountSuspended AccountState = "suspended"
+	// AccountDeactivated accounts can't sign in and their posts are hidden.
+	AccountDeactivated AccountState = "deactivated"
+)
+
+// AccountStates lists every state an account may be put in.
+var AccountStates = []AccountState{AccountActive, AccountLimited, AccountSuspended, AccountDeactivated}
+
+// AccountStatus is the state of an account and why it is in it. Every state
+// but AccountActive lapses back to it at ExpiresAt.
+type AccountStatus struct {
+	UserID    int          `json:"user_id"`
+	State     AccountState `json:"state"`
+	Reason    string       `json:"reason,omitempty"`
+	ExpiresAt *time.Time   `json:"expires_at,omitempty"`
+	ChangedBy int          `json:"changed_by,omitempty"`
+	// ActionID is the moderation action that suspended the account, if a
+	// report did.
+	ActionID  *int       `json:"action_id,omitempty"`
+	ChangedAt *time.Time `json:"changed_at,omitempty"`
+}
+
+type AppealStatus string
+
+const (
+	AppealOpen    AppealStatus = "open"
+	AppealGranted AppealStatus = "granted"
+	AppealDenied  AppealStatus = "denied"
+)
+
+// Appeal asks moderators to lift the suspension of UserID.
+type Appeal struct {
+	ID         int          `json:"id"`
+	UserID     int          `json:"user_id"`
+	Body       string       `json:"body"`
+	Status     AppealStatus `json:"status"`
+	ReviewerID *int         `json:"reviewer_id,omitempty"`
+	Decision   string       `json:"decision,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	DecidedAt  *time.Time   `json:"decided_at,omitempty"`
+}
+
+// AppealFilter narrows the appeal queue. Appeals come oldest first,
+// starting after the appeal with id After.
+type AppealFilter struct {
+	Status AppealStatus
+	UserID int
+	After  int
+	Limit  int
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+type AttachmentStatus string
+
+const (
+	AttachmentProcessing AttachmentStatus = "processing"
+	AttachmentReady      AttachmentStatus = "ready"
+	AttachmentFailed     AttachmentStatus = "failed"
+)
+
+type Attachment struct {
+	ID          int                 `json:"id"`
+	OwnerID     int                 `json:"owner_id"`
+	BlobKey     string              `json:"-"`
+	ContentType string              `json:"content_type"`
+	Size        int64               `json:"size"`
+	Status      AttachmentStatus    `json:"status"`
+	Width       int                 `json:"width,omitempty"`
+	Height      int                 `json:"height,omitempty"`
+	Blurhash    string              `json:"blurhash,omitempty"`
+	Variants    []AttachmentVariant `json:"variants,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+type AttachmentVariant struct {
+	Name        string `json:"name"`
+	BlobKey     string `json:"-"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+}
+
+// PostAttachment is an attachment as referenced by a post. Clients only send
+// AttachmentID and AltText; the rest is filled in when the post is read.
+type PostAttachment struct {
+	AttachmentID int                 `json:"attachment_id" validate:"required"`
+	AltText      string              `json:"alt_text"`
+	ContentType  string              `json:"content_type,omitempty"`
+	Status       AttachmentStatus    `json:"status,omitempty"`
+	Width        int                 `json:"width,omitempty"`
+	Height       int                 `json:"height,omitempty"`
+	Blurhash     string              `json:"blurhash,omitempty"`
+	Variants     []AttachmentVariant `json:"variants,omitempty"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditReportAssign       AuditAction = "report.assign"
+	AuditReportResolve      AuditAction = "report.resolve"
+	AuditRoleChange         AuditAction = "role.change"
+	AuditLogLevelChange     AuditAction = "log_level.change"
+	AuditLogLevelRestore    AuditAction = "log_level.restore"
+	AuditAccountStateChange AuditAction = "account.state_change"
+	AuditAppealDecide       AuditAction = "appeal.decide"
+	AuditBlocklistAdd       AuditAction = "blocklist.add"
+	AuditBlocklistRemove    AuditAction = "blocklist.remove"
+)
+
+type AuditTarget string
+
+const (
+	AuditTargetReport    AuditTarget = "report"
+	AuditTargetUser      AuditTarget = "user"
+	AuditTargetLogLevel  AuditTarget = "log_level"
+	AuditTargetAppeal    AuditTarget = "appeal"
+	AuditTargetBlocklist AuditTarget = "blocklist_entry"
+)
+
+// AuditEvent records a privileged action. Before and After hold the state of
+// the target around the action as JSON; events read back from the database
+// carry them as json.RawMessage. Hash covers the event and PrevHash, which
+// is the hash of the event before it.
+type AuditEvent struct {
+	ID         int64       `json:"id"`
+	ActorID    int         `json:"actor_id"`
+	Action     AuditAction `json:"action"`
+	TargetType AuditTarget `json:"target_type"`
+	TargetID   string      `json:"target_id"`
+	IP         string      `json:"ip"`
+	UserAgent  string      `json:"user_agent"`
+	Before     any         `json:"before"`
+	After      any         `json:"after"`
+	CreatedAt  time.Time   `json:"created_at"`
+	PrevHash   []byte      `json:"prev_hash"`
+	Hash       []byte      `json:"hash"`
+}
+
+// AuditFilter narrows a listing of audit events, newest first. Zero values
+// match everything; Before is the ID to continue below.
+type AuditFilter struct {
+	ActorID    int
+	Action     AuditAction
+	TargetType AuditTarget
+	TargetID   string
+	Since      *time.Time
+	Until      *time.Time
+	Before     int64
+	Limit      int
+}
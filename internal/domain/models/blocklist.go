@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+type BlocklistKind string
+
+const (
+	// BlockTerm matches words of the body, or a regular expression.
+	BlockTerm BlocklistKind = "term"
+	// BlockDomain matches links to a domain or any of its subdomains.
+	BlockDomain BlocklistKind = "domain"
+	// BlockURL matches links by host and path, with "*" standing for
+	// anything.
+	BlockURL BlocklistKind = "url"
+)
+
+type BlocklistSeverity string
+
+const (
+	BlockReject   BlocklistSeverity = "reject"
+	BlockReplace  BlocklistSeverity = "replace"
+	BlockModerate BlocklistSeverity = "moderate"
+)
+
+// BlocklistEntry is something posts may not contain, and what happens to
+// those that do.
+type BlocklistEntry struct {
+	ID       int               `json:"id"`
+	Kind     BlocklistKind     `json:"kind"`
+	Pattern  string            `json:"pattern"`
+	Regex    bool              `json:"regex,omitempty"`
+	Severity BlocklistSeverity `json:"severity"`
+	// Replacement stands in for what BlockReplace entries match; empty
+	// masks it with asterisks.
+	Replacement string    `json:"replacement,omitempty"`
+	Comment     string    `json:"comment,omitempty"`
+	CreatedBy   int       `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// BlocklistMatch is where an entry matched, as byte offsets into the body.
+type BlocklistMatch struct {
+	EntryID  int
+	Severity BlocklistSeverity
+	Start    int
+	End      int
+}
+
+// Screening is the outcome of running a body past the blocklist. Body has
+// the matches of BlockReplace entries replaced. Severity is the strictest
+// of the matches, empty when nothing matched.
+type Screening struct {
+	Body     string
+	Severity BlocklistSeverity
+	Matches  []BlocklistMatch
+}
+
+// ModerationHold keeps a post from everyone but its author until a
+// moderator settles the report it files.
+type ModerationHold struct {
+	Reason  ReportReason
+	Comment string
+}
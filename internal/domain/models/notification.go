@@ -0,0 +1,23 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type NotificationKind string
+
+const (
+	NotificationPollClosed       NotificationKind = "poll_closed"
+	NotificationModerationAction NotificationKind = "moderation_action"
+	NotificationAppealDecided    NotificationKind = "appeal_decided"
+)
+
+type Notification struct {
+	ID        int              `json:"id"`
+	UserID    int              `json:"user_id"`
+	Kind      NotificationKind `json:"kind"`
+	Payload   json.RawMessage  `json:"payload"`
+	CreatedAt time.Time        `json:"created_at"`
+	ReadAt    *time.Time       `json:"read_at,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type Poll struct {
+	ID        int          `json:"id"`
+	PostID    int          `json:"post_id,omitempty"`
+	Multiple  bool         `json:"multiple"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	Options   []PollOption `json:"options"`
+	// Closed, Voted and the tallies are only filled in for reads.
+	Closed     bool `json:"closed"`
+	Voted      bool `json:"voted,omitempty"`
+	TotalVotes *int `json:"total_votes,omitempty"`
+}
+
+type PollOption struct {
+	ID    int    `json:"id"`
+	Text  string `json:"text"`
+	Votes *int   `json:"votes,omitempty"`
+}
+
+type PollVote struct {
+	UserID    int   `json:"user_id" validate:"required"`
+	OptionIDs []int `json:"option_ids" validate:"required"`
+}
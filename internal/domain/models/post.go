@@ -1,7 +1,33 @@
 package models
 
+import "time"
+
+type PostStatus string
+
+const (
+	PostDraft     PostStatus = "draft"
+	PostScheduled PostStatus = "scheduled"
+	PostPublished PostStatus = "published"
+)
+
 type Post struct {
-	ID       int    `json:"id"`
-	AuthorID int    `json:"author_id" validate:"required"`
-	Body     string `json:"body" validate:"required"`
+	ID           int              `json:"id"`
+	AuthorID     int              `json:"author_id" validate:"required"`
+	Body         string           `json:"body" validate:"required"`
+	Status       PostStatus       `json:"status,omitempty"`
+	PublishAt    *time.Time       `json:"publish_at,omitempty"`
+	TTLSeconds   int              `json:"ttl_seconds,omitempty"`
+	ExpiresAt    *time.Time       `json:"expires_at,omitempty"`
+	Attachments  []PostAttachment `json:"attachments,omitempty"`
+	LinkPreviews []LinkPreview    `json:"link_previews,omitempty"`
+	Poll         *Poll            `json:"poll,omitempty"`
+	// Spam is set by the spam filter on creation, and when reading a post
+	// it didn't allow. It is never shown to clients, not even the author.
+	Spam *SpamClassification `json:"-"`
+	// Hold sends a new post or edited draft to the moderation queue, hidden
+	// like a post the spam filter held.
+	Hold *ModerationHold `json:"-"`
+	// AuthorState is the state of the author when reading a post, empty
+	// while the author is active. Like Spam, it is for the caller to act on.
+	AuthorState AccountState `json:"-"`
 }
@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+type ReportTarget string
+
+const (
+	ReportPost    ReportTarget = "post"
+	ReportAccount ReportTarget = "account"
+)
+
+type ReportReason string
+
+const (
+	ReasonSpam           ReportReason = "spam"
+	ReasonHarassment     ReportReason = "harassment"
+	ReasonHate           ReportReason = "hate"
+	ReasonViolence       ReportReason = "violence"
+	ReasonSexual         ReportReason = "sexual"
+	ReasonMisinformation ReportReason = "misinformation"
+	ReasonImpersonation  ReportReason = "impersonation"
+	ReasonOther          ReportReason = "other"
+)
+
+// ReportReasons lists the categories a report may be filed under.
+var ReportReasons = []ReportReason{
+	ReasonSpam, ReasonHarassment, ReasonHate, ReasonViolence,
+	ReasonSexual, ReasonMisinformation, ReasonImpersonation, ReasonOther,
+}
+
+type ReportStatus string
+
+const (
+	ReportOpen     ReportStatus = "open"
+	ReportResolved ReportStatus = "resolved"
+)
+
+// Report flags a post, or an account when PostID is unset. For post reports
+// AccountID is filled in with the post's author.
+type Report struct {
+	ID         int                `json:"id"`
+	ReporterID int                `json:"reporter_id" validate:"required"`
+	Target     ReportTarget       `json:"target"`
+	PostID     *int               `json:"post_id,omitempty"`
+	AccountID  int                `json:"account_id"`
+	Reason     ReportReason       `json:"reason" validate:"required"`
+	Comment    string             `json:"comment"`
+	Status     ReportStatus       `json:"status"`
+	AssigneeID *int               `json:"assignee_id,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+	ResolvedAt *time.Time         `json:"resolved_at,omitempty"`
+	Actions    []ModerationAction `json:"actions,omitempty" db:"-"`
+}
+
+// ReportFilter narrows the moderation queue. Zero fields match everything.
+// Reports come oldest first, starting after the report with id After.
+type ReportFilter struct {
+	Status     ReportStatus
+	Reason     ReportReason
+	Target     ReportTarget
+	AccountID  int
+	AssigneeID int
+	Unassigned bool
+	After      int
+	Limit      int
+}
+
+type ModerationActionKind string
+
+const (
+	ActionDismiss    ModerationActionKind = "dismiss"
+	ActionRemovePost ModerationActionKind = "remove_post"
+	ActionWarn       ModerationActionKind = "warn"
+	ActionSuspend    ModerationActionKind = "suspend"
+)
+
+// ModerationAction resolves a report. Every action records who took it and
+// why.
+type ModerationAction struct {
+	ID          int                  `json:"id"`
+	ReportID    int                  `json:"report_id"`
+	ModeratorID int                  `json:"moderator_id"`
+	Action      ModerationActionKind `json:"action" validate:"required"`
+	Rationale   string               `json:"rationale" validate:"required"`
+	// ExpiresAt is when a suspension lapses. Suspensions need one; other
+	// actions can't have one.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Roles lists every role, least privileged first.
+var Roles = []Role{RoleUser, RoleModerator, RoleAdmin}
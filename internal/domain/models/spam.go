@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+type SpamVerdict string
+
+const (
+	SpamAllow SpamVerdict = "allow"
+	// SpamHold hides a post from everyone but its author until a moderator
+	// reviews it.
+	SpamHold SpamVerdict = "hold"
+	// SpamLimit hides a post from everyone but its author, without telling
+	// them or asking for review.
+	SpamLimit SpamVerdict = "limit"
+)
+
+// SpamClassification is what the spam filter made of a post: a score in
+// [0, 1], the signals that contributed to it and the resulting verdict.
+type SpamClassification struct {
+	Verdict SpamVerdict `json:"verdict"`
+	Score   float64     `json:"score"`
+	Reasons []string    `json:"reasons,omitempty"`
+}
+
+// SpamSignals describe the author of a post and the posts like it.
+type SpamSignals struct {
+	// DuplicateAuthors is how many other accounts recently posted the same
+	// body.
+	DuplicateAuthors int
+	// RecentPosts is how many posts the author recently wrote.
+	RecentPosts int
+	// FirstPostAt stands in for the account's age; nil if the author never
+	// posted.
+	FirstPostAt *time.Time
+}
+
+// SpamTokenCounts is how many spam and ham posts of the training set
+// contained a token.
+type SpamTokenCounts struct {
+	Spam int
+	Ham  int
+}
+
+// SpamModel is the part of the naive Bayes model needed to classify a post:
+// the size of the training set and the counts of the post's tokens.
+type SpamModel struct {
+	SpamPosts int
+	HamPosts  int
+	Tokens    map[string]SpamTokenCounts
+}
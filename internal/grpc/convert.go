@@ -0,0 +1,132 @@
+package grpc_server
+
+import (
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/grpc/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var postStatuses = map[models.PostStatus]pb.PostStatus{
+	models.PostDraft:     pb.PostStatus_POST_STATUS_DRAFT,
+	models.PostScheduled: pb.PostStatus_POST_STATUS_SCHEDULED,
+	models.PostPublished: pb.PostStatus_POST_STATUS_PUBLISHED,
+}
+
+var accountStates = map[models.AccountState]pb.AccountState{
+	models.AccountActive:      pb.AccountState_ACCOUNT_STATE_ACTIVE,
+	models.AccountLimited:     pb.AccountState_ACCOUNT_STATE_LIMITED,
+	models.AccountSuspended:   pb.AccountState_ACCOUNT_STATE_SUSPENDED,
+	models.AccountDeactivated: pb.AccountState_ACCOUNT_STATE_DEACTIVATED,
+}
+
+func postToPB(p models.Post) *pb.Post {
+	post := &pb.Post{
+		Id:         int64(p.ID),
+		AuthorId:   int64(p.AuthorID),
+		Body:       p.Body,
+		Status:     postStatuses[p.Status],
+		PublishAt:  timestamp(p.PublishAt),
+		TtlSeconds: int64(p.TTLSeconds),
+		ExpiresAt:  timestamp(p.ExpiresAt),
+	}
+	for _, a := range p.Attachments {
+		attachment := &pb.Attachment{
+			AttachmentId: int64(a.AttachmentID),
+			AltText:      a.AltText,
+			ContentType:  a.ContentType,
+			Status:       string(a.Status),
+			Width:        int64(a.Width),
+			Height:       int64(a.Height),
+			Blurhash:     a.Blurhash,
+		}
+		for _, v := range a.Variants {
+			attachment.Variants = append(attachment.Variants, &pb.AttachmentVariant{
+				Name:        v.Name,
+				ContentType: v.ContentType,
+				Width:       int64(v.Width),
+				Height:      int64(v.Height),
+				Size:        v.Size,
+			})
+		}
+		post.Attachments = append(post.Attachments, attachment)
+	}
+	for _, l := range p.LinkPreviews {
+		post.LinkPreviews = append(post.LinkPreviews, &pb.LinkPreview{
+			Url:         l.URL,
+			Title:       l.Title,
+			Description: l.Description,
+			ImageUrl:    l.ImageURL,
+			SiteName:    l.SiteName,
+		})
+	}
+	if p.Poll != nil {
+		post.Poll = &pb.Poll{
+			Id:        int64(p.Poll.ID),
+			Multiple:  p.Poll.Multiple,
+			ExpiresAt: timestamppb.New(p.Poll.ExpiresAt),
+			Closed:    p.Poll.Closed,
+		}
+		for _, o := range p.Poll.Options {
+			post.Poll.Options = append(post.Poll.Options, &pb.PollOption{Id: int64(o.ID), Text: o.Text})
+		}
+	}
+	return post
+}
+
+func postsToPB(posts []models.Post) []*pb.Post {
+	out := make([]*pb.Post, len(posts))
+	for i, p := range posts {
+		out[i] = postToPB(p)
+	}
+	return out
+}
+
+// postFromPB is the post req asks to create, as the HTTP API binds it from
+// JSON.
+func postFromPB(req *pb.CreatePostRequest) models.Post {
+	post := models.Post{
+		AuthorID:   int(req.GetAuthorId()),
+		Body:       req.GetBody(),
+		PublishAt:  timeOf(req.GetPublishAt()),
+		TTLSeconds: int(req.GetTtlSeconds()),
+	}
+	for _, a := range req.GetAttachments() {
+		post.Attachments = append(post.Attachments, models.PostAttachment{
+			AttachmentID: int(a.GetAttachmentId()),
+			AltText:      a.GetAltText(),
+		})
+	}
+	if poll := req.GetPoll(); poll != nil {
+		post.Poll = &models.Poll{Multiple: poll.GetMultiple(), ExpiresAt: poll.GetExpiresAt().AsTime()}
+		for _, text := range poll.GetOptions() {
+			post.Poll.Options = append(post.Poll.Options, models.PollOption{Text: text})
+		}
+	}
+	return post
+}
+
+func accountStatusToPB(s models.AccountStatus) *pb.AccountStatus {
+	return &pb.AccountStatus{
+		UserId:    int64(s.UserID),
+		State:     accountStates[s.State],
+		Reason:    s.Reason,
+		ExpiresAt: timestamp(s.ExpiresAt),
+	}
+}
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func timeOf(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
@@ -0,0 +1,43 @@
+package grpc_server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// codeOf maps the statuses the HTTP API answers errors with to the codes
+// that say the same over gRPC, so that both APIs agree on what each error
+// means. Gone posts are as not found as missing ones.
+var codeOf = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusGone:                codes.NotFound,
+	http.StatusConflict:            codes.FailedPrecondition,
+	http.StatusUnprocessableEntity: codes.InvalidArgument,
+}
+
+// statusFunc maps the errors of a service to HTTP statuses, as the Status
+// functions of the HTTP handlers do, and reports whether it knew the error.
+type statusFunc func(err error) (int, bool)
+
+// fail turns err into a gRPC status. Errors statusOf doesn't know are
+// logged and answered with Unknown, where the HTTP API falls back to 400.
+func fail(ctx context.Context, log *slog.Logger, op string, err error, statusOf statusFunc) error {
+	st, ok := statusOf(err)
+	if !ok {
+		sl.FromContext(ctx, log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return status.Error(codes.Unknown, err.Error())
+	}
+	code, ok := codeOf[st]
+	if !ok {
+		code = codes.Unknown
+	}
+	return status.Error(code, err.Error())
+}
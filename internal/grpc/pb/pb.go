@@ -0,0 +1,4 @@
+// Package pb is the code generated from the protobuf definitions in proto/.
+package pb
+
+//go:generate protoc -I ../../../proto --go_out=../../.. --go_opt=module=github.com/AtIasShrugged/antisocial --go-grpc_out=../../.. --go-grpc_opt=module=github.com/AtIasShrugged/antisocial antisocial/v1/posts.proto antisocial/v1/users.proto
@@ -0,0 +1,188 @@
+package grpc_server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/grpc/pb"
+	post_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/post"
+	"github.com/AtIasShrugged/antisocial/internal/tracing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// maxBatch bounds the posts a single BatchGetPosts may ask for.
+const maxBatch = 100
+
+type PostService interface {
+	GetByID(ctx context.Context, id int) (models.Post, error)
+	GetMany(ctx context.Context, ids []int) (map[int]models.Post, error)
+	Create(ctx context.Context, post models.Post) (int, error)
+	ListDrafts(ctx context.Context, authorID int) ([]models.Post, error)
+	GetDraft(ctx context.Context, id int, authorID int) (models.Post, error)
+	UpdateDraft(ctx context.Context, id int, authorID int, body string) error
+	Reschedule(ctx context.Context, id int, authorID int, publishAt time.Time) error
+	Cancel(ctx context.Context, id int, authorID int) error
+	Publish(ctx context.Context, id int, authorID int) error
+}
+
+// PostServer serves pb.PostService over the PostService of the HTTP API,
+// answering its errors with the codes of the statuses post_handler.Status
+// gives them.
+type PostServer struct {
+	pb.UnimplementedPostServiceServer
+	service PostService
+	log     *slog.Logger
+}
+
+func NewPostServer(service PostService, log *slog.Logger) *PostServer {
+	return &PostServer{
+		service: service,
+		log:     log,
+	}
+}
+
+func (s *PostServer) GetPost(ctx context.Context, req *pb.GetPostRequest) (*pb.Post, error) {
+	const op = "PostServer.GetPost"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	post, err := s.service.GetByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, fail(ctx, s.log, op, err, post_handler.Status)
+	}
+
+	return postToPB(post), nil
+}
+
+func (s *PostServer) BatchGetPosts(ctx context.Context, req *pb.BatchGetPostsRequest) (*pb.BatchGetPostsResponse, error) {
+	const op = "PostServer.BatchGetPosts"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if len(req.GetIds()) > maxBatch {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("can't get more than %d posts at once", maxBatch))
+	}
+	ids := make([]int, len(req.GetIds()))
+	for i, id := range req.GetIds() {
+		ids[i] = int(id)
+	}
+
+	posts, err := s.service.GetMany(ctx, ids)
+	if err != nil {
+		return nil, fail(ctx, s.log, op, err, post_handler.Status)
+	}
+
+	resp := &pb.BatchGetPostsResponse{}
+	for _, id := range ids {
+		if post, ok := posts[id]; ok {
+			resp.Posts = append(resp.Posts, postToPB(post))
+		}
+	}
+	return resp, nil
+}
+
+func (s *PostServer) CreatePost(ctx context.Context, req *pb.CreatePostRequest) (*pb.CreatePostResponse, error) {
+	const op = "PostServer.CreatePost"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	post := postFromPB(req)
+
+	id, err := s.service.Create(ctx, post)
+	if err != nil {
+		return nil, fail(ctx, s.log, op, err, post_handler.Status)
+	}
+
+	return &pb.CreatePostResponse{Id: int64(id)}, nil
+}
+
+func (s *PostServer) ListDrafts(ctx context.Context, req *pb.ListDraftsRequest) (*pb.ListDraftsResponse, error) {
+	const op = "PostServer.ListDrafts"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	authorID := int(req.GetAuthorId())
+
+	posts, err := s.service.ListDrafts(ctx, authorID)
+	if err != nil {
+		return nil, fail(ctx, s.log, op, err, post_handler.Status)
+	}
+
+	return &pb.ListDraftsResponse{Posts: postsToPB(posts)}, nil
+}
+
+func (s *PostServer) GetDraft(ctx context.Context, req *pb.GetDraftRequest) (*pb.Post, error) {
+	const op = "PostServer.GetDraft"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	authorID := int(req.GetAuthorId())
+
+	post, err := s.service.GetDraft(ctx, int(req.GetId()), authorID)
+	if err != nil {
+		return nil, fail(ctx, s.log, op, err, post_handler.Status)
+	}
+
+	return postToPB(post), nil
+}
+
+func (s *PostServer) UpdateDraft(ctx context.Context, req *pb.UpdateDraftRequest) (*emptypb.Empty, error) {
+	const op = "PostServer.UpdateDraft"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	authorID := int(req.GetAuthorId())
+
+	if err := s.service.UpdateDraft(ctx, int(req.GetId()), authorID, req.GetBody()); err != nil {
+		return nil, fail(ctx, s.log, op, err, post_handler.Status)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *PostServer) ReschedulePost(ctx context.Context, req *pb.ReschedulePostRequest) (*emptypb.Empty, error) {
+	const op = "PostServer.ReschedulePost"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	authorID := int(req.GetAuthorId())
+
+	if err := s.service.Reschedule(ctx, int(req.GetId()), authorID, req.GetPublishAt().AsTime()); err != nil {
+		return nil, fail(ctx, s.log, op, err, post_handler.Status)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *PostServer) CancelSchedule(ctx context.Context, req *pb.CancelScheduleRequest) (*emptypb.Empty, error) {
+	const op = "PostServer.CancelSchedule"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	authorID := int(req.GetAuthorId())
+
+	if err := s.service.Cancel(ctx, int(req.GetId()), authorID); err != nil {
+		return nil, fail(ctx, s.log, op, err, post_handler.Status)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *PostServer) PublishPost(ctx context.Context, req *pb.PublishPostRequest) (*emptypb.Empty, error) {
+	const op = "PostServer.PublishPost"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	authorID := int(req.GetAuthorId())
+
+	if err := s.service.Publish(ctx, int(req.GetId()), authorID); err != nil {
+		return nil, fail(ctx, s.log, op, err, post_handler.Status)
+	}
+
+	return &emptypb.Empty{}, nil
+}
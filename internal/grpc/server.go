@@ -0,0 +1,156 @@
+// Package grpc_server serves posts and users over gRPC, for backend services
+// that want typed calls rather than JSON. It runs in the same binary as the
+// HTTP API, on its own port, over the same services, with the same tokens,
+// permissions and error mapping.
+package grpc_server
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/grpc/pb"
+	"github.com/AtIasShrugged/antisocial/internal/http/requestid"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/internal/ratelimit"
+	"github.com/AtIasShrugged/antisocial/internal/service/health"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// methods is what each method requires, as permissions in the HTTP router
+// is for routes. A method missing here answers PermissionDenied to everyone.
+var methods = map[string]auth.Permission{
+	pb.PostService_GetPost_FullMethodName:        auth.Public,
+	pb.PostService_BatchGetPosts_FullMethodName:  auth.Public,
+	pb.PostService_CreatePost_FullMethodName:     auth.PostsCreate,
+	pb.PostService_ListDrafts_FullMethodName:     auth.PostsReadDrafts,
+	pb.PostService_GetDraft_FullMethodName:       auth.PostsReadDrafts,
+	pb.PostService_UpdateDraft_FullMethodName:    auth.PostsUpdate,
+	pb.PostService_ReschedulePost_FullMethodName: auth.PostsUpdate,
+	pb.PostService_CancelSchedule_FullMethodName: auth.PostsUpdate,
+	pb.PostService_PublishPost_FullMethodName:    auth.PostsUpdate,
+
+	pb.UserService_GetAccountStatus_FullMethodName: auth.AccountsRead,
+	pb.UserService_Follow_FullMethodName:           auth.FollowsManage,
+	pb.UserService_Unfollow_FullMethodName:         auth.FollowsManage,
+
+	grpc_health_v1.Health_Check_FullMethodName: auth.Public,
+	grpc_health_v1.Health_Watch_FullMethodName: auth.Public,
+
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":      auth.Public,
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": auth.Public,
+}
+
+// classes is the rate limit class of each method, matching the routes
+// serving the same calls over HTTP. Health checks and reflection are not
+// limited.
+var classes = map[string]ratelimit.Class{
+	pb.PostService_GetPost_FullMethodName:        ratelimit.Read,
+	pb.PostService_BatchGetPosts_FullMethodName:  ratelimit.Read,
+	pb.PostService_CreatePost_FullMethodName:     ratelimit.Write,
+	pb.PostService_ListDrafts_FullMethodName:     ratelimit.Read,
+	pb.PostService_GetDraft_FullMethodName:       ratelimit.Read,
+	pb.PostService_UpdateDraft_FullMethodName:    ratelimit.Write,
+	pb.PostService_ReschedulePost_FullMethodName: ratelimit.Write,
+	pb.PostService_CancelSchedule_FullMethodName: ratelimit.Write,
+	pb.PostService_PublishPost_FullMethodName:    ratelimit.Write,
+
+	pb.UserService_GetAccountStatus_FullMethodName: ratelimit.Read,
+	pb.UserService_Follow_FullMethodName:           ratelimit.Write,
+	pb.UserService_Unfollow_FullMethodName:         ratelimit.Write,
+}
+
+type Readiness interface {
+	Ready(ctx context.Context) health.Report
+}
+
+// New returns a server of the post and user services. authenticate is
+// auth.UnaryInterceptor, given what the HTTP API authenticates with, and
+// calls take from the buckets of limiter like HTTP requests do.
+func New(
+	cfg config.GRPCConfig,
+	authenticate grpc.UnaryServerInterceptor,
+	limiter *ratelimit.Limiter,
+	readiness Readiness,
+	posts PostService,
+	accounts AccountService,
+	follows FollowService,
+	log *slog.Logger,
+) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			requestid.UnaryInterceptor(log),
+			accessLog(log),
+			metrics.UnaryInterceptor(),
+			recoverer(log),
+			authenticate,
+			auth.RequireUnary(methods, log),
+			limiter.UnaryInterceptor(classes),
+		),
+		grpc.ChainStreamInterceptor(auth.RequireStream(methods, log)),
+	)
+	pb.RegisterPostServiceServer(s, NewPostServer(posts, log))
+	pb.RegisterUserServiceServer(s, NewUserServer(accounts, follows, log))
+	grpc_health_v1.RegisterHealthServer(s, &healthServer{readiness: readiness, services: map[string]bool{
+		pb.PostService_ServiceDesc.ServiceName: true,
+		pb.UserService_ServiceDesc.ServiceName: true,
+	}})
+	if cfg.Reflection {
+		reflection.Register(s)
+	}
+	return s
+}
+
+// recoverer answers panics with Internal instead of taking the HTTP API
+// down with the gRPC one, as middleware.Recover does for Echo.
+func recoverer(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				sl.FromContext(ctx, log).ErrorContext(ctx, "panic serving gRPC",
+					slog.String("method", info.FullMethod), slog.Any("panic", r), slog.String("stack", string(debug.Stack())))
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return next(ctx, req)
+	}
+}
+
+// accessLog logs every call once it's answered, as middleware.Logger does
+// for HTTP requests.
+func accessLog(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := next(ctx, req)
+		sl.FromContext(ctx, log).InfoContext(ctx, "served gRPC call",
+			slog.String("code", status.Code(err).String()), slog.Duration("latency", time.Since(start)))
+		return resp, err
+	}
+}
+
+// healthServer answers the gRPC health protocol with the readiness that
+// /readyz reports, draining included. Every service is as healthy as the
+// server. Watch is left unimplemented, as the protocol allows.
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	readiness Readiness
+	services  map[string]bool
+}
+
+func (h *healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if req.GetService() != "" && !h.services[req.GetService()] {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	if !h.readiness.Ready(ctx).OK() {
+		return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
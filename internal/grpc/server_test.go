@@ -0,0 +1,248 @@
+package grpc_server
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/grpc/pb"
+	"github.com/AtIasShrugged/antisocial/internal/ratelimit"
+	post_repo "github.com/AtIasShrugged/antisocial/internal/repository/post"
+	"github.com/AtIasShrugged/antisocial/internal/service/health"
+	post_service "github.com/AtIasShrugged/antisocial/internal/service/post"
+	"github.com/AtIasShrugged/antisocial/libs/logger/handlers/slogdiscard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakePosts has post 1, knows post 2 expired, and blocks posts saying
+// "blocked". Its other methods panic.
+type fakePosts struct{ PostService }
+
+func (fakePosts) GetByID(_ context.Context, id int) (models.Post, error) {
+	switch id {
+	case 1:
+		return models.Post{ID: 1, AuthorID: 1, Body: "hi", Status: models.PostPublished,
+			Poll: &models.Poll{ID: 1, Options: []models.PollOption{{ID: 1, Text: "yes"}}}}, nil
+	case 2:
+		return models.Post{}, post_repo.ErrPostExpired
+	}
+	return models.Post{}, post_repo.ErrPostNotFound
+}
+
+func (f fakePosts) GetMany(ctx context.Context, ids []int) (map[int]models.Post, error) {
+	posts := make(map[int]models.Post)
+	for _, id := range ids {
+		if post, err := f.GetByID(ctx, id); err == nil {
+			posts[id] = post
+		}
+	}
+	return posts, nil
+}
+
+func (fakePosts) Create(ctx context.Context, post models.Post) (int, error) {
+	if err := auth.Authorize(ctx, auth.PostsCreate, auth.Owner(post.AuthorID)); err != nil {
+		return 0, err
+	}
+	if post.Body == "blocked" {
+		return 0, post_service.ErrBlocked
+	}
+	return 7, nil
+}
+
+type fakeAccounts struct{ AccountService }
+
+type fakeFollows struct{ FollowService }
+
+type roles struct{}
+
+func (roles) Role(context.Context, int) (models.Role, error) { return models.RoleUser, nil }
+
+type states struct{}
+
+func (states) State(context.Context, int) (models.AccountState, error) {
+	return models.AccountActive, nil
+}
+
+type readiness struct{ ok bool }
+
+func (r *readiness) Ready(context.Context) health.Report {
+	if r.ok {
+		return health.Report{Status: health.StatusOK}
+	}
+	return health.Report{Status: health.StatusFailing}
+}
+
+// unlimited leaves rate limits off.
+var unlimited = config.RateLimitConfig{Anonymous: "1/1s", Read: "1/1s", Write: "1/1s", Auth: "1/1s"}
+
+func serve(t *testing.T, ready *readiness, limits config.RateLimitConfig) (*grpc.Server, *grpc.ClientConn, *auth.Tokens) {
+	t.Helper()
+	log := slogdiscard.NewDiscardLogger()
+	tokens := auth.NewTokens("s3cret")
+	limiter, err := ratelimit.New(ratelimit.NewMemoryStore(), limits, log)
+	require.NoError(t, err)
+
+	s := New(config.GRPCConfig{Reflection: true}, auth.UnaryInterceptor(tokens, roles{}, states{}, limiter, log), limiter,
+		ready, fakePosts{}, fakeAccounts{}, fakeFollows{}, log)
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return s, conn, tokens
+}
+
+func TestEveryMethodHasItsPermission(t *testing.T) {
+	s, _, _ := serve(t, &readiness{ok: true}, unlimited)
+
+	registered := make(map[string]bool)
+	for name, info := range s.GetServiceInfo() {
+		for _, m := range info.Methods {
+			registered["/"+name+"/"+m.Name] = true
+		}
+	}
+	for method := range registered {
+		assert.Contains(t, methods, method)
+	}
+	for method := range methods {
+		assert.True(t, registered[method], "%s has a permission but isn't served", method)
+	}
+	for method := range registered {
+		if strings.HasPrefix(method, "/antisocial.") {
+			assert.Contains(t, classes, method, "%s has no rate limit class", method)
+		}
+	}
+}
+
+func TestPostService(t *testing.T) {
+	_, conn, tokens := serve(t, &readiness{ok: true}, unlimited)
+	client := pb.NewPostServiceClient(conn)
+	ctx := context.Background()
+
+	post, err := client.GetPost(ctx, &pb.GetPostRequest{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "hi", post.GetBody())
+	assert.Equal(t, pb.PostStatus_POST_STATUS_PUBLISHED, post.GetStatus())
+	assert.Equal(t, "yes", post.GetPoll().GetOptions()[0].GetText())
+
+	_, err = client.GetPost(ctx, &pb.GetPostRequest{Id: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.GetPost(ctx, &pb.GetPostRequest{Id: 3})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	batch, err := client.BatchGetPosts(ctx, &pb.BatchGetPostsRequest{Ids: []int64{3, 1, 2}})
+	require.NoError(t, err)
+	require.Len(t, batch.GetPosts(), 1)
+	assert.Equal(t, int64(1), batch.GetPosts()[0].GetId())
+
+	_, err = client.CreatePost(ctx, &pb.CreatePostRequest{AuthorId: 1, Body: "hi"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	token, err := tokens.Issue(1, time.Hour)
+	require.NoError(t, err)
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+
+	created, err := client.CreatePost(authed, &pb.CreatePostRequest{AuthorId: 1, Body: "hi"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), created.GetId())
+
+	_, err = client.CreatePost(authed, &pb.CreatePostRequest{AuthorId: 2, Body: "hi"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.CreatePost(authed, &pb.CreatePostRequest{AuthorId: 1, Body: "blocked"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRateLimit(t *testing.T) {
+	_, conn, tokens := serve(t, &readiness{ok: true}, config.RateLimitConfig{
+		Enabled: true, Anonymous: "2/1m", Read: "3/1m", Write: "1/1m", Auth: "1/1m",
+	})
+	client := pb.NewPostServiceClient(conn)
+	ctx := context.Background()
+
+	for range 2 {
+		_, err := client.GetPost(ctx, &pb.GetPostRequest{Id: 1})
+		require.NoError(t, err)
+	}
+	var header metadata.MD
+	_, err := client.GetPost(ctx, &pb.GetPostRequest{Id: 1}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"30"}, header.Get("retry-after"))
+
+	// Signed-in callers have their own budget per class.
+	token, err := tokens.Issue(1, time.Hour)
+	require.NoError(t, err)
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	_, err = client.GetPost(authed, &pb.GetPostRequest{Id: 1}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3"}, header.Get("ratelimit-limit"))
+	assert.Equal(t, []string{"2"}, header.Get("ratelimit-remaining"))
+
+	_, err = client.CreatePost(authed, &pb.CreatePostRequest{AuthorId: 1, Body: "hi"})
+	require.NoError(t, err)
+	_, err = client.CreatePost(authed, &pb.CreatePostRequest{AuthorId: 1, Body: "hi"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Health checks are never limited.
+	health := grpc_health_v1.NewHealthClient(conn)
+	for range 3 {
+		_, err := health.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		require.NoError(t, err)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	_, conn, _ := serve(t, &readiness{ok: true}, unlimited)
+	client := pb.NewPostServiceClient(conn)
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "abc-123")
+	_, err := client.GetPost(ctx, &pb.GetPostRequest{Id: 1}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc-123"}, header.Get("x-request-id"))
+
+	_, err = client.GetPost(context.Background(), &pb.GetPostRequest{Id: 1}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Len(t, header.Get("x-request-id"), 1)
+	assert.Len(t, header.Get("x-request-id")[0], 32)
+}
+
+func TestHealth(t *testing.T) {
+	ready := &readiness{ok: true}
+	_, conn, _ := serve(t, ready, unlimited)
+	client := grpc_health_v1.NewHealthClient(conn)
+	ctx := context.Background()
+
+	resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	resp, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: pb.PostService_ServiceDesc.ServiceName})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	_, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "nope"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	ready.ok = false
+	resp, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+}
@@ -0,0 +1,80 @@
+package grpc_server
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/grpc/pb"
+	account_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/account"
+	follow_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/follow"
+	"github.com/AtIasShrugged/antisocial/internal/tracing"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type AccountService interface {
+	Get(ctx context.Context, userID int) (models.AccountStatus, error)
+}
+
+type FollowService interface {
+	Follow(ctx context.Context, followerID, followeeID int) error
+	Unfollow(ctx context.Context, followerID, followeeID int) error
+}
+
+// UserServer serves pb.UserService over the account and follow services
+// of the HTTP API.
+type UserServer struct {
+	pb.UnimplementedUserServiceServer
+	accounts AccountService
+	follows  FollowService
+	log      *slog.Logger
+}
+
+func NewUserServer(accounts AccountService, follows FollowService, log *slog.Logger) *UserServer {
+	return &UserServer{
+		accounts: accounts,
+		follows:  follows,
+		log:      log,
+	}
+}
+
+func (s *UserServer) GetAccountStatus(ctx context.Context, req *pb.GetAccountStatusRequest) (*pb.AccountStatus, error) {
+	const op = "UserServer.GetAccountStatus"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	status, err := s.accounts.Get(ctx, int(req.GetUserId()))
+	if err != nil {
+		return nil, fail(ctx, s.log, op, err, account_handler.Status)
+	}
+
+	return accountStatusToPB(status), nil
+}
+
+func (s *UserServer) Follow(ctx context.Context, req *pb.FollowRequest) (*emptypb.Empty, error) {
+	const op = "UserServer.Follow"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	followerID := int(req.GetFollowerId())
+
+	if err := s.follows.Follow(ctx, followerID, int(req.GetFolloweeId())); err != nil {
+		return nil, fail(ctx, s.log, op, err, follow_handler.Status)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) Unfollow(ctx context.Context, req *pb.FollowRequest) (*emptypb.Empty, error) {
+	const op = "UserServer.Unfollow"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	followerID := int(req.GetFollowerId())
+
+	if err := s.follows.Unfollow(ctx, followerID, int(req.GetFolloweeId())); err != nil {
+		return nil, fail(ctx, s.log, op, err, follow_handler.Status)
+	}
+
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,125 @@
+// Package apiversion tells the versions of the API apart. Routes are
+// tagged with the version they belong to, and responses are shaped for it
+// on their way out, so that a later version can change a model without
+// breaking clients of an earlier one.
+package apiversion
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Version string
+
+const V1 Version = "v1"
+
+type ctxKey struct{}
+
+// Middleware tags requests with the version of the route they matched.
+func Middleware(v Version) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			c.SetRequest(req.WithContext(context.WithValue(req.Context(), ctxKey{}, v)))
+			return next(c)
+		}
+	}
+}
+
+// FromContext returns the version of the route ctx's request matched, and
+// false outside versioned routes.
+func FromContext(ctx context.Context) (Version, bool) {
+	v, ok := ctx.Value(ctxKey{}).(Version)
+	return v, ok
+}
+
+// Deprecated marks the responses of a route that is going away in favor of
+// successor, the route template it aliases, e.g. "/v1/posts/:id". Clients
+// are told when the route was deprecated (RFC 9745), when it stops being
+// served (RFC 8594), and where to go instead.
+func Deprecated(successor string, since, sunset time.Time) echo.MiddlewareFunc {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	sunsetAt := sunset.UTC().Format(http.TimeFormat)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			h := c.Response().Header()
+			h.Set("Deprecation", deprecation)
+			h.Set("Sunset", sunsetAt)
+			h.Add("Link", "<"+fill(successor, c)+`>; rel="successor-version"`)
+			return next(c)
+		}
+	}
+}
+
+// fill puts the path parameters of c's request into route.
+func fill(route string, c echo.Context) string {
+	segments := strings.Split(route, "/")
+	for i, s := range segments {
+		if name, ok := strings.CutPrefix(s, ":"); ok {
+			segments[i] = c.Param(name)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Serializer encodes responses as Echo's default serializer does, after
+// shaping them for the version of the route. A version that a model has
+// moved on from registers a shape with Shape turning the model back into
+// what its clients expect.
+type Serializer struct {
+	echo.DefaultJSONSerializer
+	shapes map[Version]map[reflect.Type]func(any) any
+}
+
+func NewSerializer() *Serializer {
+	return &Serializer{shapes: make(map[Version]map[reflect.Type]func(any) any)}
+}
+
+// Shape has responses of type T, and slices of them, sent to clients of v
+// as what shape returns.
+func Shape[T any](s *Serializer, v Version, shape func(T) any) {
+	if s.shapes[v] == nil {
+		s.shapes[v] = make(map[reflect.Type]func(any) any)
+	}
+	s.shapes[v][reflect.TypeOf((*T)(nil)).Elem()] = func(x any) any { return shape(x.(T)) }
+}
+
+func (s *Serializer) Serialize(c echo.Context, i any, indent string) error {
+	if v, ok := FromContext(c.Request().Context()); ok {
+		i = s.shape(v, i)
+	}
+	return s.DefaultJSONSerializer.Serialize(c, i, indent)
+}
+
+func (s *Serializer) shape(v Version, i any) any {
+	shapes := s.shapes[v]
+	if len(shapes) == 0 || i == nil {
+		return i
+	}
+	t := reflect.TypeOf(i)
+	if shape, ok := shapes[t]; ok {
+		return shape(i)
+	}
+	if t.Kind() != reflect.Slice {
+		return i
+	}
+	shape, ok := shapes[t.Elem()]
+	if !ok {
+		return i
+	}
+	list := reflect.ValueOf(i)
+	if list.IsNil() {
+		return i
+	}
+	shaped := make([]any, list.Len())
+	for j := range shaped {
+		shaped[j] = shape(list.Index(j).Interface())
+	}
+	return shaped
+}
@@ -0,0 +1,60 @@
+package apiversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestSerializerShapesForTheVersion(t *testing.T) {
+	s := NewSerializer()
+	// As if v1 clients knew the title as "name".
+	Shape(s, V1, func(i item) any {
+		return map[string]any{"id": i.ID, "name": i.Title}
+	})
+
+	e := echo.New()
+	e.JSONSerializer = s
+	e.GET("/v1/item", func(c echo.Context) error { return c.JSON(http.StatusOK, item{1, "a"}) }, Middleware(V1))
+	e.GET("/v1/items", func(c echo.Context) error { return c.JSON(http.StatusOK, []item{{1, "a"}, {2, "b"}}) }, Middleware(V1))
+	e.GET("/v1/none", func(c echo.Context) error { return c.JSON(http.StatusOK, []item(nil)) }, Middleware(V1))
+	e.GET("/v1/count", func(c echo.Context) error { return c.JSON(http.StatusOK, 2) }, Middleware(V1))
+	e.GET("/item", func(c echo.Context) error { return c.JSON(http.StatusOK, item{1, "a"}) })
+
+	get := func(path string) string {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+	assert.JSONEq(t, `{"id": 1, "name": "a"}`, get("/v1/item"))
+	assert.JSONEq(t, `[{"id": 1, "name": "a"}, {"id": 2, "name": "b"}]`, get("/v1/items"))
+	assert.JSONEq(t, `null`, get("/v1/none"))
+	assert.JSONEq(t, `2`, get("/v1/count"))
+	assert.JSONEq(t, `{"id": 1, "title": "a"}`, get("/item"))
+}
+
+func TestDeprecated(t *testing.T) {
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	e := echo.New()
+	e.GET("/posts/:id/replies/:reply", func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+		Deprecated("/v1/posts/:id/replies/:reply", since, sunset))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts/4/replies/9", nil))
+	assert.Equal(t, "@1790812800", rec.Header().Get("Deprecation"))
+	assert.Equal(t, "Thu, 01 Apr 2027 00:00:00 GMT", rec.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/posts/4/replies/9>; rel="successor-version"`, rec.Header().Get("Link"))
+}
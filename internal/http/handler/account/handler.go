@@ -0,0 +1,228 @@
+package account_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	repo "github.com/AtIasShrugged/antisocial/internal/repository/account"
+	"github.com/AtIasShrugged/antisocial/internal/service/account"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+)
+
+type AccountService interface {
+	Get(ctx context.Context, userID int) (models.AccountStatus, error)
+	Set(ctx context.Context, status models.AccountStatus) (models.AccountStatus, error)
+	Appeal(ctx context.Context, appeal models.Appeal) (models.Appeal, error)
+	GetAppeal(ctx context.Context, id int) (models.Appeal, error)
+	ListAppeals(ctx context.Context, filter models.AppealFilter) ([]models.Appeal, error)
+	Decide(ctx context.Context, decision models.Appeal) (models.Appeal, error)
+}
+
+type AccountHandler struct {
+	service AccountService
+	log     *slog.Logger
+}
+
+func New(service AccountService, log *slog.Logger) *AccountHandler {
+	return &AccountHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+type stateRequest struct {
+	State     models.AccountState `json:"state"`
+	Reason    string              `json:"reason"`
+	ExpiresAt *time.Time          `json:"expires_at"`
+}
+
+type appealRequest struct {
+	Body string `json:"body"`
+}
+
+type decisionRequest struct {
+	Status   models.AppealStatus `json:"status"`
+	Decision string              `json:"decision"`
+}
+
+// GetState returns the state of the account in the path and why it is in
+// it.
+func (h *AccountHandler) GetState(c echo.Context) error {
+	const op = "AccountHandler.GetState"
+	ctx := c.Request().Context()
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	status, err := h.service.Get(ctx, userID)
+	if err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// SetState puts the account in the path in the state of the body until it
+// expires.
+func (h *AccountHandler) SetState(c echo.Context) error {
+	const op = "AccountHandler.SetState"
+	ctx := c.Request().Context()
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	var req stateRequest
+	if err := c.Bind(&req); err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad json: %w", err).Error())
+	}
+
+	status, err := h.service.Set(ctx, models.AccountStatus{
+		UserID:    userID,
+		State:     req.State,
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+	sl.FromContext(ctx, h.log).WarnContext(ctx, "account state changed",
+		slog.Int("target_user_id", userID), slog.String("state", string(status.State)))
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// Appeal files an appeal against the suspension of the account in the path.
+func (h *AccountHandler) Appeal(c echo.Context) error {
+	const op = "AccountHandler.Appeal"
+	ctx := c.Request().Context()
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	var req appealRequest
+	if err := c.Bind(&req); err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad json: %w", err).Error())
+	}
+
+	appeal, err := h.service.Appeal(ctx, models.Appeal{UserID: userID, Body: req.Body})
+	if err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.JSON(http.StatusOK, appeal)
+}
+
+// ListAppeals returns the appeal queue, filtered by the optional "status"
+// and "user_id" query parameters and paged with "after" and "limit".
+func (h *AccountHandler) ListAppeals(c echo.Context) error {
+	const op = "AccountHandler.ListAppeals"
+	ctx := c.Request().Context()
+
+	filter := models.AppealFilter{Status: models.AppealStatus(c.QueryParam("status"))}
+	err := echo.QueryParamsBinder(c).
+		Int("user_id", &filter.UserID).
+		Int("after", &filter.After).
+		Int("limit", &filter.Limit).
+		BindError()
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	appeals, err := h.service.ListAppeals(ctx, filter)
+	if err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.JSON(http.StatusOK, appeals)
+}
+
+func (h *AccountHandler) GetAppeal(c echo.Context) error {
+	const op = "AccountHandler.GetAppeal"
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	appeal, err := h.service.GetAppeal(ctx, id)
+	if err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.JSON(http.StatusOK, appeal)
+}
+
+// Decide grants or denies the appeal in the path.
+func (h *AccountHandler) Decide(c echo.Context) error {
+	const op = "AccountHandler.Decide"
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	var req decisionRequest
+	if err := c.Bind(&req); err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad json: %w", err).Error())
+	}
+
+	appeal, err := h.service.Decide(ctx, models.Appeal{ID: id, Status: req.Status, Decision: req.Decision})
+	if err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.JSON(http.StatusOK, appeal)
+}
+
+// Status is the status errors of AccountService are answered with, and
+// whether the error is one it knows.
+func Status(err error) (int, bool) {
+	if status, ok := auth.HTTPStatus(err); ok {
+		return status, true
+	}
+	switch {
+	case errors.Is(err, repo.ErrAppealNotFound):
+		return http.StatusNotFound, true
+	case errors.Is(err, repo.ErrAppealOpen), errors.Is(err, repo.ErrAppealDecided),
+		errors.Is(err, account.ErrNotSuspended), errors.Is(err, account.ErrOwnAccount),
+		errors.Is(err, account.ErrOwnAppeal):
+		return http.StatusConflict, true
+	case errors.Is(err, account.ErrInvalidState), errors.Is(err, account.ErrInvalidAppeal),
+		errors.Is(err, account.ErrInvalidFilter):
+		return http.StatusBadRequest, true
+	}
+	return http.StatusBadRequest, false
+}
+
+func (h *AccountHandler) fail(ctx context.Context, c echo.Context, op string, err error) error {
+	status, ok := Status(err)
+	if !ok {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+	}
+	return c.JSON(status, err.Error())
+}
@@ -0,0 +1,79 @@
+package account_handler
+
+import (
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+)
+
+// Operations describes the routes of AccountHandler.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/users/:id/state", ID: "getAccountState", Tags: []string{"accounts"},
+		Summary: "Get the state of an account and why it is in it",
+		Params:  []openapi.Param{openapi.PathParam("id", "User ID.")},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The state of the account.", models.AccountStatus{}),
+			http.StatusBadRequest: openapi.Error("The ID is not a number."),
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/admin/users/:id/state", ID: "setAccountState", Tags: []string{"accounts"},
+		Summary: "Put an account in a state until it expires",
+		Params:  []openapi.Param{openapi.PathParam("id", "User ID.")},
+		Body:    openapi.JSONBody(stateRequest{}),
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The new state of the account.", models.AccountStatus{}),
+			http.StatusBadRequest: openapi.Error("The state is invalid."),
+			http.StatusConflict:   openapi.Error("Moderators can't change their own account."),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/users/:id/appeals", ID: "createAppeal", Tags: []string{"appeals"},
+		Summary: "Appeal against the suspension of an account",
+		Params:  []openapi.Param{openapi.PathParam("id", "User ID.")},
+		Body:    openapi.JSONBody(appealRequest{}),
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The appeal.", models.Appeal{}),
+			http.StatusBadRequest: openapi.Error("The appeal is invalid."),
+			http.StatusConflict:   openapi.Error("The account isn't suspended, or an appeal is already open."),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/appeals", ID: "listAppeals", Tags: []string{"appeals"},
+		Summary: "List the appeal queue",
+		Params: []openapi.Param{
+			openapi.Query("status", "Only appeals in this status.", models.AppealStatus("")),
+			openapi.Query("user_id", "Only appeals of this user.", 0),
+			openapi.Query("after", "Only appeals after this ID.", 0),
+			openapi.Query("limit", "How many appeals to return.", 0),
+		},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The appeals, oldest first.", []models.Appeal{}),
+			http.StatusBadRequest: openapi.Error("The filter is invalid."),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/appeals/:id", ID: "getAppeal", Tags: []string{"appeals"},
+		Summary: "Get an appeal",
+		Params:  []openapi.Param{openapi.PathParam("id", "Appeal ID.")},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The appeal.", models.Appeal{}),
+			http.StatusBadRequest: openapi.Error("The ID is not a number."),
+			http.StatusNotFound:   openapi.Error("There is no such appeal."),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/appeals/:id/decision", ID: "decideAppeal", Tags: []string{"appeals"},
+		Summary: "Grant or deny an appeal",
+		Params:  []openapi.Param{openapi.PathParam("id", "Appeal ID.")},
+		Body:    openapi.JSONBody(decisionRequest{}),
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The decided appeal.", models.Appeal{}),
+			http.StatusBadRequest: openapi.Error("The decision is invalid."),
+			http.StatusNotFound:   openapi.Error("There is no such appeal."),
+			http.StatusConflict:   openapi.Error("The appeal is decided already, or is the caller's own."),
+		},
+	},
+}
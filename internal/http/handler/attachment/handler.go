@@ -0,0 +1,108 @@
+package attachment_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	repo "github.com/AtIasShrugged/antisocial/internal/repository/attachment"
+	"github.com/AtIasShrugged/antisocial/internal/service/attachment"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+)
+
+type AttachmentService interface {
+	Upload(ctx context.Context, ownerID int, r io.Reader, size int64) (models.Attachment, error)
+	Open(ctx context.Context, id int, variant string) (string, int64, io.ReadCloser, error)
+}
+
+type AttachmentHandler struct {
+	service AttachmentService
+	log     *slog.Logger
+}
+
+func New(service AttachmentService, log *slog.Logger) *AttachmentHandler {
+	return &AttachmentHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// Upload expects a multipart form with an "owner_id" field and a "file" part.
+func (h *AttachmentHandler) Upload(c echo.Context) error {
+	const op = "AttachmentHandler.Upload"
+	ctx := c.Request().Context()
+
+	ownerID, err := strconv.Atoi(c.FormValue("owner_id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad file: %w", err).Error())
+	}
+	file, err := fh.Open()
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad file: %w", err).Error())
+	}
+	defer file.Close()
+
+	a, err := h.service.Upload(ctx, ownerID, file, fh.Size)
+	if err != nil {
+		if status, ok := auth.HTTPStatus(err); ok {
+			return c.JSON(status, err.Error())
+		}
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		switch {
+		case errors.Is(err, attachment.ErrTooLarge):
+			return c.JSON(http.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, attachment.ErrUnsupportedMediaType):
+			return c.JSON(http.StatusUnsupportedMediaType, err.Error())
+		default:
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	return c.JSON(http.StatusOK, a)
+}
+
+// GetByID streams the attachment content. The optional "variant" query
+// parameter selects a processed rendition such as "thumbnail".
+func (h *AttachmentHandler) GetByID(c echo.Context) error {
+	const op = "AttachmentHandler.GetByID"
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	contentType, size, rc, err := h.service.Open(ctx, id, c.QueryParam("variant"))
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrAttachmentNotFound), errors.Is(err, attachment.ErrVariantNotFound):
+			return c.String(http.StatusNotFound, err.Error())
+		case errors.Is(err, attachment.ErrProcessing):
+			return c.String(http.StatusConflict, err.Error())
+		case errors.Is(err, attachment.ErrProcessingFailed):
+			return c.String(http.StatusUnprocessableEntity, err.Error())
+		}
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	defer rc.Close()
+
+	c.Response().Header().Set(echo.HeaderContentLength, strconv.FormatInt(size, 10))
+	return c.Stream(http.StatusOK, contentType, rc)
+}
@@ -0,0 +1,45 @@
+package attachment_handler
+
+import (
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+)
+
+// Operations describes the routes of AttachmentHandler.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodPost, Path: "/attachments", ID: "uploadAttachment", Tags: []string{"attachments"},
+		Summary: "Upload an image or a video",
+		Body: &openapi.Body{ContentType: "multipart/form-data", Schema: &openapi.Schema{
+			Type: "object",
+			Properties: map[string]*openapi.Schema{
+				"owner_id": {Type: "integer"},
+				"file":     {Type: "string", ContentEncoding: "binary"},
+			},
+			Required: []string{"owner_id", "file"},
+		}},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:                    openapi.JSON("The attachment, which may still be processing.", models.Attachment{}),
+			http.StatusBadRequest:            openapi.Error("The form is invalid."),
+			http.StatusRequestEntityTooLarge: openapi.Error("The file is too large."),
+			http.StatusUnsupportedMediaType:  openapi.Error("The file is not a supported image or video."),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/attachments/:id", ID: "getAttachment", Tags: []string{"attachments"},
+		Summary: "Download an attachment",
+		Params: []openapi.Param{
+			openapi.PathParam("id", "Attachment ID."),
+			openapi.Query("variant", "A processed rendition, such as \"thumbnail\".", ""),
+		},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:                  openapi.Binary("The content of the attachment.", "*/*"),
+			http.StatusBadRequest:          openapi.Error("The ID is not a number."),
+			http.StatusNotFound:            openapi.Error("There is no such attachment or variant, or no post you can see carries it."),
+			http.StatusConflict:            openapi.Error("The attachment is still processing."),
+			http.StatusUnprocessableEntity: openapi.Error("Processing the attachment failed."),
+		},
+	},
+}
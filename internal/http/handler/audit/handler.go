@@ -0,0 +1,78 @@
+package audit_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/audit"
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+)
+
+type AuditTrail interface {
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+}
+
+type AuditHandler struct {
+	trail AuditTrail
+	log   *slog.Logger
+}
+
+func New(trail AuditTrail, log *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		trail: trail,
+		log:   log,
+	}
+}
+
+// List returns audit events newest first, filtered by the optional
+// "actor_id", "action", "target_type", "target_id", "since" and "until"
+// (RFC 3339) query parameters and paged with "before" and "limit".
+func (h *AuditHandler) List(c echo.Context) error {
+	const op = "AuditHandler.List"
+	ctx := c.Request().Context()
+
+	filter := models.AuditFilter{
+		Action:     models.AuditAction(c.QueryParam("action")),
+		TargetType: models.AuditTarget(c.QueryParam("target_type")),
+		TargetID:   c.QueryParam("target_id"),
+	}
+	var since, until time.Time
+	err := echo.QueryParamsBinder(c).
+		Int("actor_id", &filter.ActorID).
+		Time("since", &since, time.RFC3339).
+		Time("until", &until, time.RFC3339).
+		Int64("before", &filter.Before).
+		Int("limit", &filter.Limit).
+		BindError()
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+	if !since.IsZero() {
+		filter.Since = &since
+	}
+	if !until.IsZero() {
+		filter.Until = &until
+	}
+
+	events, err := h.trail.List(ctx, filter)
+	if err != nil {
+		if status, ok := auth.HTTPStatus(err); ok {
+			return c.JSON(status, err.Error())
+		}
+		if errors.Is(err, audit.ErrInvalidFilter) {
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, events)
+}
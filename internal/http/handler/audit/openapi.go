@@ -0,0 +1,31 @@
+package audit_handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+)
+
+// Operations describes the routes of AuditHandler.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/admin/audit-events", ID: "listAuditEvents", Tags: []string{"audit"},
+		Summary: "List audit events, newest first",
+		Params: []openapi.Param{
+			openapi.Query("actor_id", "Only events caused by this user.", 0),
+			openapi.Query("action", "Only events of this action.", models.AuditAction("")),
+			openapi.Query("target_type", "Only events on this kind of target.", models.AuditTarget("")),
+			openapi.Query("target_id", "Only events on this target.", ""),
+			openapi.Query("since", "Only events since this time, in RFC 3339.", time.Time{}),
+			openapi.Query("until", "Only events before this time, in RFC 3339.", time.Time{}),
+			openapi.Query("before", "Only events before this ID.", 0),
+			openapi.Query("limit", "How many events to return.", 0),
+		},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The events.", []models.AuditEvent{}),
+			http.StatusBadRequest: openapi.Error("The filter is invalid."),
+		},
+	},
+}
@@ -0,0 +1,118 @@
+package blocklist_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	repo "github.com/AtIasShrugged/antisocial/internal/repository/blocklist"
+	"github.com/AtIasShrugged/antisocial/internal/service/blocklist"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+)
+
+type BlocklistService interface {
+	List(ctx context.Context) ([]models.BlocklistEntry, error)
+	Create(ctx context.Context, entry models.BlocklistEntry) (int, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type BlocklistHandler struct {
+	service BlocklistService
+	log     *slog.Logger
+}
+
+func New(service BlocklistService, log *slog.Logger) *BlocklistHandler {
+	return &BlocklistHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+type entryRequest struct {
+	Kind        models.BlocklistKind     `json:"kind"`
+	Pattern     string                   `json:"pattern"`
+	Regex       bool                     `json:"regex"`
+	Severity    models.BlocklistSeverity `json:"severity"`
+	Replacement string                   `json:"replacement"`
+	Comment     string                   `json:"comment"`
+}
+
+func (h *BlocklistHandler) List(c echo.Context) error {
+	const op = "BlocklistHandler.List"
+	ctx := c.Request().Context()
+
+	entries, err := h.service.List(ctx)
+	if err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
+
+// Create adds the entry in the body and returns its ID.
+func (h *BlocklistHandler) Create(c echo.Context) error {
+	const op = "BlocklistHandler.Create"
+	ctx := c.Request().Context()
+
+	var req entryRequest
+	if err := c.Bind(&req); err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad json: %w", err).Error())
+	}
+
+	id, err := h.service.Create(ctx, models.BlocklistEntry{
+		Kind:        req.Kind,
+		Pattern:     req.Pattern,
+		Regex:       req.Regex,
+		Severity:    req.Severity,
+		Replacement: req.Replacement,
+		Comment:     req.Comment,
+	})
+	if err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+	sl.FromContext(ctx, h.log).WarnContext(ctx, "blocklist entry added",
+		slog.Int("entry_id", id), slog.String("kind", string(req.Kind)), slog.String("severity", string(req.Severity)))
+
+	return c.JSON(http.StatusOK, id)
+}
+
+func (h *BlocklistHandler) Delete(c echo.Context) error {
+	const op = "BlocklistHandler.Delete"
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	if err := h.service.Delete(ctx, id); err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+	sl.FromContext(ctx, h.log).WarnContext(ctx, "blocklist entry removed", slog.Int("entry_id", id))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *BlocklistHandler) fail(ctx context.Context, c echo.Context, op string, err error) error {
+	if status, ok := auth.HTTPStatus(err); ok {
+		return c.JSON(status, err.Error())
+	}
+	switch {
+	case errors.Is(err, repo.ErrEntryNotFound):
+		return c.String(http.StatusNotFound, err.Error())
+	case errors.Is(err, repo.ErrEntryExists):
+		return c.JSON(http.StatusConflict, err.Error())
+	case errors.Is(err, blocklist.ErrInvalidEntry):
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+	return c.JSON(http.StatusBadRequest, err.Error())
+}
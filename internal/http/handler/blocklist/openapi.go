@@ -0,0 +1,39 @@
+package blocklist_handler
+
+import (
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+)
+
+// Operations describes the routes of BlocklistHandler.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/admin/blocklist", ID: "listBlocklist", Tags: []string{"blocklist"},
+		Summary: "List the blocklist",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: openapi.JSON("The entries, oldest first.", []models.BlocklistEntry{}),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/blocklist", ID: "createBlocklistEntry", Tags: []string{"blocklist"},
+		Summary: "Add a term, domain or URL pattern to the blocklist",
+		Body:    openapi.JSONBody(entryRequest{}),
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The ID of the new entry.", 0),
+			http.StatusBadRequest: openapi.Error("The entry is invalid."),
+			http.StatusConflict:   openapi.Error("The pattern is listed already."),
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/admin/blocklist/:id", ID: "deleteBlocklistEntry", Tags: []string{"blocklist"},
+		Summary: "Remove an entry from the blocklist",
+		Params:  []openapi.Param{openapi.PathParam("id", "Entry ID.")},
+		Responses: map[int]openapi.Response{
+			http.StatusNoContent:  openapi.NoContent("The entry is removed."),
+			http.StatusBadRequest: openapi.Error("The ID is not a number."),
+			http.StatusNotFound:   openapi.Error("There is no such entry."),
+		},
+	},
+}
@@ -0,0 +1,39 @@
+package docs_handler
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+	"github.com/labstack/echo/v4"
+)
+
+// page renders the document it fetches from /openapi.json. It is self
+// contained so that the docs work without reaching out to a CDN.
+//
+//go:embed docs.html
+var page []byte
+
+// DocsHandler serves the OpenAPI document of the API and a page to read it
+// with.
+type DocsHandler struct {
+	spec []byte
+}
+
+func New(doc *openapi.Document) (*DocsHandler, error) {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("can't encode API description: %w", err)
+	}
+	return &DocsHandler{spec: spec}, nil
+}
+
+func (h *DocsHandler) Spec(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, h.spec)
+}
+
+func (h *DocsHandler) UI(c echo.Context) error {
+	return c.HTMLBlob(http.StatusOK, page)
+}
@@ -0,0 +1,25 @@
+package docs_handler
+
+import (
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+)
+
+// Operations describes the routes of DocsHandler.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/openapi.json", ID: "getOpenAPI", Tags: []string{"meta"},
+		Summary: "Get this document",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: openapi.JSON("The OpenAPI document of the API.", nil),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/docs", ID: "getDocs", Tags: []string{"meta"},
+		Summary: "Read this document in a browser",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Description: "The docs page.", ContentType: "text/html", Schema: &openapi.Schema{Type: "string"}},
+		},
+	},
+}
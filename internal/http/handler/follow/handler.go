@@ -0,0 +1,97 @@
+package follow_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/service/follow"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+)
+
+type FollowService interface {
+	Follow(ctx context.Context, followerID, followeeID int) error
+	Unfollow(ctx context.Context, followerID, followeeID int) error
+}
+
+type FollowHandler struct {
+	service FollowService
+	log     *slog.Logger
+}
+
+func New(service FollowService, log *slog.Logger) *FollowHandler {
+	return &FollowHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// Follow makes the user in the path follow the one after "following".
+func (h *FollowHandler) Follow(c echo.Context) error {
+	const op = "FollowHandler.Follow"
+	ctx := c.Request().Context()
+
+	followerID, followeeID, err := params(c)
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	if err := h.service.Follow(ctx, followerID, followeeID); err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *FollowHandler) Unfollow(c echo.Context) error {
+	const op = "FollowHandler.Unfollow"
+	ctx := c.Request().Context()
+
+	followerID, followeeID, err := params(c)
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	if err := h.service.Unfollow(ctx, followerID, followeeID); err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func params(c echo.Context) (followerID, followeeID int, err error) {
+	if followerID, err = strconv.Atoi(c.Param("id")); err != nil {
+		return 0, 0, err
+	}
+	if followeeID, err = strconv.Atoi(c.Param("followee")); err != nil {
+		return 0, 0, err
+	}
+	return followerID, followeeID, nil
+}
+
+// Status maps errors of FollowService as post_handler.Status does those of
+// PostService.
+func Status(err error) (int, bool) {
+	if status, ok := auth.HTTPStatus(err); ok {
+		return status, true
+	}
+	if errors.Is(err, follow.ErrSelfFollow) {
+		return http.StatusBadRequest, true
+	}
+	return http.StatusBadRequest, false
+}
+
+func (h *FollowHandler) fail(ctx context.Context, c echo.Context, op string, err error) error {
+	status, ok := Status(err)
+	if !ok {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+	}
+	return c.JSON(status, err.Error())
+}
@@ -0,0 +1,34 @@
+package follow_handler
+
+import (
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+)
+
+var followParams = []openapi.Param{
+	openapi.PathParam("id", "The follower."),
+	openapi.PathParam("followee", "The user followed."),
+}
+
+// Operations describes the routes of FollowHandler.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodPut, Path: "/users/:id/following/:followee", ID: "follow", Tags: []string{"follows"},
+		Summary: "Follow a user",
+		Params:  followParams,
+		Responses: map[int]openapi.Response{
+			http.StatusNoContent:  openapi.NoContent("The user is followed."),
+			http.StatusBadRequest: openapi.Error("An ID is not a number, or users tried to follow themselves."),
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/users/:id/following/:followee", ID: "unfollow", Tags: []string{"follows"},
+		Summary: "Stop following a user",
+		Params:  followParams,
+		Responses: map[int]openapi.Response{
+			http.StatusNoContent:  openapi.NoContent("The user is no longer followed."),
+			http.StatusBadRequest: openapi.Error("An ID is not a number."),
+		},
+	},
+}
@@ -0,0 +1,65 @@
+package graphql_handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	post_repo "github.com/AtIasShrugged/antisocial/internal/repository/post"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/location"
+)
+
+// codedError is an error with a code in its extensions, which clients
+// branch on rather than on the message.
+type codedError struct {
+	err  error
+	code string
+}
+
+func (e codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e codedError) Unwrap() error {
+	return e.err
+}
+
+func (e codedError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
+
+// fieldError gives err the code of the status the REST handlers answer it
+// with.
+func fieldError(err error) error {
+	if err == nil {
+		return nil
+	}
+	code := "BAD_REQUEST"
+	if status, ok := auth.HTTPStatus(err); ok {
+		code = "FORBIDDEN"
+		if status == http.StatusUnauthorized {
+			code = "UNAUTHENTICATED"
+		}
+	} else if errors.Is(err, post_repo.ErrPostNotFound) {
+		code = "NOT_FOUND"
+	}
+	return codedError{err: err, code: code}
+}
+
+// requestError is an error of the request as a whole, reported before
+// anything runs.
+func requestError(err error) gqlerrors.FormattedError {
+	code := "BAD_REQUEST"
+	switch {
+	case errors.Is(err, ErrPersistedQueryNotFound):
+		code = "PERSISTED_QUERY_NOT_FOUND"
+	case errors.Is(err, ErrTooDeep), errors.Is(err, ErrTooComplex):
+		code = "QUERY_TOO_COMPLEX"
+	}
+	return gqlerrors.FormattedError{
+		Message:    err.Error(),
+		Locations:  []location.SourceLocation{},
+		Extensions: map[string]any{"code": code},
+	}
+}
@@ -0,0 +1,147 @@
+// Package graphql_handler serves a GraphQL API over the same services as
+// the REST routes, so that clients can fetch a post along with its author,
+// attachments and poll in one round-trip. The schema covers what the
+// services offer: published posts, and users with their account state and
+// drafts. Replies, reactions and feeds have no service yet and join the
+// schema when they get one.
+//
+// Lookups of posts are batched per request, queries are bounded in depth
+// and complexity, and clients may send queries by hash once the server has
+// seen them.
+package graphql_handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/tracing"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/labstack/echo/v4"
+)
+
+type PostService interface {
+	GetMany(ctx context.Context, ids []int) (map[int]models.Post, error)
+	ListDrafts(ctx context.Context, authorID int) ([]models.Post, error)
+}
+
+type AccountService interface {
+	Get(ctx context.Context, userID int) (models.AccountStatus, error)
+}
+
+type GraphQLHandler struct {
+	schema    graphql.Schema
+	posts     PostService
+	limits    Limits
+	persisted *persistedQueries
+	log       *slog.Logger
+}
+
+func New(posts PostService, accounts AccountService, cfg config.GraphQLConfig, log *slog.Logger) (*GraphQLHandler, error) {
+	schema, err := newSchema(posts, accounts)
+	if err != nil {
+		return nil, fmt.Errorf("can't build GraphQL schema: %w", err)
+	}
+	return &GraphQLHandler{
+		schema:    schema,
+		posts:     posts,
+		limits:    Limits{MaxDepth: cfg.MaxDepth, MaxComplexity: cfg.MaxComplexity},
+		persisted: newPersistedQueries(cfg.PersistedQueries),
+		log:       log,
+	}, nil
+}
+
+// graphQLRequest is a GraphQL request, sent as a JSON body or, for GET, as
+// query parameters with variables and extensions in JSON.
+type graphQLRequest struct {
+	Query         string             `json:"query"`
+	OperationName string             `json:"operationName,omitempty"`
+	Variables     map[string]any     `json:"variables,omitempty"`
+	Extensions    *requestExtensions `json:"extensions,omitempty"`
+}
+
+type requestExtensions struct {
+	PersistedQuery *persistedQuery `json:"persistedQuery,omitempty"`
+}
+
+// Serve runs a query. Errors of the query come back with status 200 in the
+// errors of the result, as GraphQL clients expect; only requests that
+// can't be read get a 400.
+func (h *GraphQLHandler) Serve(c echo.Context) error {
+	const op = "GraphQLHandler.Serve"
+	ctx, span := tracing.Start(c.Request().Context(), op)
+	defer span.End()
+
+	req, err := h.read(c)
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad request: %w", err).Error())
+	}
+
+	return c.JSON(http.StatusOK, h.run(ctx, req))
+}
+
+func (h *GraphQLHandler) read(c echo.Context) (graphQLRequest, error) {
+	var req graphQLRequest
+	if c.Request().Method != http.MethodGet {
+		err := c.Bind(&req)
+		return req, err
+	}
+
+	req.Query = c.QueryParam("query")
+	req.OperationName = c.QueryParam("operationName")
+	if v := c.QueryParam("variables"); v != "" {
+		if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+			return req, fmt.Errorf("bad variables: %w", err)
+		}
+	}
+	if v := c.QueryParam("extensions"); v != "" {
+		if err := json.Unmarshal([]byte(v), &req.Extensions); err != nil {
+			return req, fmt.Errorf("bad extensions: %w", err)
+		}
+	}
+	return req, nil
+}
+
+func (h *GraphQLHandler) run(ctx context.Context, req graphQLRequest) *graphql.Result {
+	var persisted *persistedQuery
+	if req.Extensions != nil {
+		persisted = req.Extensions.PersistedQuery
+	}
+	query, err := h.persisted.resolve(req.Query, persisted)
+	if err != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{requestError(err)}}
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+	if v := graphql.ValidateDocument(&h.schema, doc, nil); !v.IsValid {
+		return &graphql.Result{Errors: v.Errors}
+	}
+	if err := h.limits.check(&h.schema, doc, req.OperationName, req.Variables); err != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{requestError(err)}}
+	}
+	if persisted != nil {
+		h.persisted.add(persisted.SHA256Hash, query)
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(ctx, h.posts),
+	})
+}
@@ -0,0 +1,170 @@
+package graphql_handler_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	graphql_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/graphql"
+	"github.com/AtIasShrugged/antisocial/libs/logger/handlers/slogdiscard"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePosts has posts 1 to 3, and counts the batches asked for.
+type fakePosts struct {
+	batches [][]int
+}
+
+func (f *fakePosts) GetMany(_ context.Context, ids []int) (map[int]models.Post, error) {
+	f.batches = append(f.batches, ids)
+	posts := make(map[int]models.Post)
+	for _, id := range ids {
+		if id <= 3 {
+			posts[id] = models.Post{ID: id, AuthorID: 10 + id, Body: "hi"}
+		}
+	}
+	return posts, nil
+}
+
+func (f *fakePosts) ListDrafts(context.Context, int) ([]models.Post, error) {
+	return nil, nil
+}
+
+type fakeAccounts struct{}
+
+func (fakeAccounts) Get(_ context.Context, userID int) (models.AccountStatus, error) {
+	if userID == 11 {
+		return models.AccountStatus{}, auth.ErrForbidden
+	}
+	return models.AccountStatus{UserID: userID, State: models.AccountActive}, nil
+}
+
+type response struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func serve(t *testing.T, h *graphql_handler.GraphQLHandler, body any) response {
+	t.Helper()
+	b, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(b)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	require.NoError(t, h.Serve(echo.New().NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp
+}
+
+func newHandler(t *testing.T, posts *fakePosts, cfg config.GraphQLConfig) *graphql_handler.GraphQLHandler {
+	t.Helper()
+	h, err := graphql_handler.New(posts, fakeAccounts{}, cfg, slogdiscard.NewDiscardLogger())
+	require.NoError(t, err)
+	return h
+}
+
+var cfg = config.GraphQLConfig{MaxDepth: 10, MaxComplexity: 1000, PersistedQueries: 10}
+
+func TestPostsAreFetchedInOneBatch(t *testing.T) {
+	posts := &fakePosts{}
+	h := newHandler(t, posts, cfg)
+
+	resp := serve(t, h, map[string]any{
+		"query":     `query($ids: [Int!]!) { a: post(id: 1) { id } b: post(id: 4) { id } posts(ids: $ids) { id body } }`,
+		"variables": map[string]any{"ids": []int{2, 3}},
+	})
+
+	require.Empty(t, resp.Errors)
+	assert.Equal(t, map[string]any{
+		"a":     map[string]any{"id": float64(1)},
+		"b":     nil,
+		"posts": []any{map[string]any{"id": float64(2), "body": "hi"}, map[string]any{"id": float64(3), "body": "hi"}},
+	}, resp.Data)
+	require.Len(t, posts.batches, 1)
+	assert.ElementsMatch(t, []int{1, 4, 2, 3}, posts.batches[0])
+}
+
+func TestErrorsCarryCodes(t *testing.T) {
+	h := newHandler(t, &fakePosts{}, cfg)
+
+	resp := serve(t, h, map[string]any{"query": `{ posts(ids: [1, 2]) { author { id state { state } } } }`})
+
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "FORBIDDEN", resp.Errors[0].Extensions["code"])
+	posts := resp.Data["posts"].([]any)
+	assert.Equal(t, map[string]any{"id": float64(12), "state": map[string]any{"state": "active"}}, posts[1].(map[string]any)["author"])
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   config.GraphQLConfig
+		query string
+	}{
+		{
+			name:  "too deep",
+			cfg:   config.GraphQLConfig{MaxDepth: 3},
+			query: `{ post(id: 1) { author { drafts { id } } } }`,
+		},
+		{
+			name:  "too deep through a fragment",
+			cfg:   config.GraphQLConfig{MaxDepth: 3},
+			query: `{ post(id: 1) { ...p } } fragment p on Post { author { drafts { id } } }`,
+		},
+		{
+			name:  "too complex",
+			cfg:   config.GraphQLConfig{MaxComplexity: 50},
+			query: `{ posts(ids: [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]) { id body author { id } attachments { id } } }`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts := &fakePosts{}
+			resp := serve(t, newHandler(t, posts, tt.cfg), map[string]any{"query": tt.query})
+
+			require.Len(t, resp.Errors, 1)
+			assert.Equal(t, "QUERY_TOO_COMPLEX", resp.Errors[0].Extensions["code"])
+			assert.Nil(t, resp.Data)
+			assert.Empty(t, posts.batches, "the query ran")
+		})
+	}
+}
+
+func TestPersistedQueries(t *testing.T) {
+	h := newHandler(t, &fakePosts{}, cfg)
+	query := `{ post(id: 1) { id } }`
+	sum := sha256.Sum256([]byte(query))
+	ext := map[string]any{"persistedQuery": map[string]any{"version": 1, "sha256Hash": hex.EncodeToString(sum[:])}}
+
+	resp := serve(t, h, map[string]any{"extensions": ext})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "PERSISTED_QUERY_NOT_FOUND", resp.Errors[0].Extensions["code"])
+
+	resp = serve(t, h, map[string]any{"query": query, "extensions": ext})
+	require.Empty(t, resp.Errors)
+
+	resp = serve(t, h, map[string]any{"extensions": ext})
+	require.Empty(t, resp.Errors)
+	assert.Equal(t, map[string]any{"post": map[string]any{"id": float64(1)}}, resp.Data)
+
+	resp = serve(t, h, map[string]any{"query": `{ post(id: 2) { id } }`, "extensions": ext})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "BAD_REQUEST", resp.Errors[0].Extensions["code"])
+}
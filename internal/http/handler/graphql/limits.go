@@ -0,0 +1,154 @@
+package graphql_handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+var (
+	ErrTooDeep    = errors.New("query is too deep")
+	ErrTooComplex = errors.New("query is too complex")
+)
+
+// defaultListSize is how long a list is taken to be when the query doesn't
+// say, as it does with the ids of posts.
+const defaultListSize = 10
+
+// Limits bounds the queries the handler runs.
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// cost is what running a selection set takes.
+type cost struct {
+	depth      int
+	complexity int
+}
+
+// check measures the operation of doc that will run and fails if it
+// exceeds l. doc must have passed validation, which rules out unknown
+// fields and fragment cycles. Introspection is not counted, so that tools
+// can read the schema whatever the limits.
+func (l Limits) check(schema *graphql.Schema, doc *ast.Document, operationName string, variables map[string]any) error {
+	m := measure{
+		schema:    schema,
+		variables: variables,
+		fragments: make(map[string]*ast.FragmentDefinition),
+	}
+	var op *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			m.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || def.Name != nil && def.Name.Value == operationName {
+				op = def
+			}
+		}
+	}
+	if op == nil {
+		return nil
+	}
+
+	root := schema.QueryType()
+	if op.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	c := m.selections(op.SelectionSet, root)
+	if l.MaxDepth > 0 && c.depth > l.MaxDepth {
+		return fmt.Errorf("%w: depth %d, at most %d", ErrTooDeep, c.depth, l.MaxDepth)
+	}
+	if l.MaxComplexity > 0 && c.complexity > l.MaxComplexity {
+		return fmt.Errorf("%w: complexity %d, at most %d", ErrTooComplex, c.complexity, l.MaxComplexity)
+	}
+	return nil
+}
+
+type measure struct {
+	schema    *graphql.Schema
+	variables map[string]any
+	fragments map[string]*ast.FragmentDefinition
+}
+
+func (m measure) selections(set *ast.SelectionSet, parent graphql.Type) cost {
+	var total cost
+	if set == nil {
+		return total
+	}
+	for _, sel := range set.Selections {
+		var c cost
+		switch sel := sel.(type) {
+		case *ast.Field:
+			c = m.field(sel, parent)
+		case *ast.InlineFragment:
+			on := parent
+			if sel.TypeCondition != nil {
+				on = m.schema.Type(sel.TypeCondition.Name.Value)
+			}
+			c = m.selections(sel.SelectionSet, on)
+		case *ast.FragmentSpread:
+			if f, ok := m.fragments[sel.Name.Value]; ok {
+				c = m.selections(f.SelectionSet, m.schema.Type(f.TypeCondition.Name.Value))
+			}
+		}
+		total.depth = max(total.depth, c.depth)
+		total.complexity += c.complexity
+	}
+	return total
+}
+
+func (m measure) field(f *ast.Field, parent graphql.Type) cost {
+	name := f.Name.Value
+	if strings.HasPrefix(name, "__") {
+		return cost{}
+	}
+	var fields graphql.FieldDefinitionMap
+	switch p := parent.(type) {
+	case *graphql.Object:
+		fields = p.Fields()
+	case *graphql.Interface:
+		fields = p.Fields()
+	}
+	def, ok := fields[name]
+	if !ok {
+		return cost{depth: 1, complexity: 1}
+	}
+
+	typ, size := def.Type, 1
+	if nn, ok := typ.(*graphql.NonNull); ok {
+		typ = nn.OfType
+	}
+	if list, ok := typ.(*graphql.List); ok {
+		typ, size = list.OfType, m.listSize(f)
+		if nn, ok := typ.(*graphql.NonNull); ok {
+			typ = nn.OfType
+		}
+	}
+
+	children := m.selections(f.SelectionSet, typ)
+	return cost{depth: 1 + children.depth, complexity: 1 + size*children.complexity}
+}
+
+// listSize is how many items the list field f is expected to hold: the
+// number of ids it asks for, or defaultListSize.
+func (m measure) listSize(f *ast.Field) int {
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != "ids" {
+			continue
+		}
+		switch v := arg.Value.(type) {
+		case *ast.ListValue:
+			return len(v.Values)
+		case *ast.Variable:
+			if ids, ok := m.variables[v.Name.Value].([]any); ok {
+				return len(ids)
+			}
+		}
+	}
+	return defaultListSize
+}
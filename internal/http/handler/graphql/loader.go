@@ -0,0 +1,68 @@
+package graphql_handler
+
+import (
+	"context"
+	"sync"
+)
+
+// loader batches the lookups resolvers make while a query runs. Resolvers
+// queue keys with load and get a thunk back; the executor only calls thunks
+// once the fields around them have been resolved, so the first thunk called
+// fetches every key queued by then in one go. Results are kept for the rest
+// of the request, so a key asked for twice is fetched once.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	results map[K]*result[V]
+}
+
+type result[V any] struct {
+	fetched bool
+	value   V
+	found   bool
+	err     error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, results: make(map[K]*result[V])}
+}
+
+// load queues key and returns a thunk yielding its value, and false for keys
+// fetch didn't find.
+func (l *loader[K, V]) load(ctx context.Context, key K) func() (V, bool, error) {
+	l.mu.Lock()
+	r, ok := l.results[key]
+	if !ok {
+		r = &result[V]{}
+		l.results[key] = r
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, bool, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !r.fetched {
+			l.dispatch(ctx)
+		}
+		return r.value, r.found, r.err
+	}
+}
+
+// dispatch fetches the pending keys. l.mu is held.
+func (l *loader[K, V]) dispatch(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+	values, err := l.fetch(ctx, keys)
+	for _, key := range keys {
+		r := l.results[key]
+		r.fetched = true
+		if err != nil {
+			r.err = err
+			continue
+		}
+		r.value, r.found = values[key]
+	}
+}
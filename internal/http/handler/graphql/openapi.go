@@ -0,0 +1,44 @@
+package graphql_handler
+
+import (
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+	"github.com/graphql-go/graphql"
+)
+
+const description = "Runs a GraphQL query over posts and users. Errors of the query, including those of " +
+	"the depth and complexity limits, come back with status 200 in errors, each with a code in its " +
+	"extensions. A query may be sent by its SHA-256 alone in extensions.persistedQuery once it has " +
+	"been sent with it; an unknown hash answers PERSISTED_QUERY_NOT_FOUND."
+
+// Operations describes the routes of GraphQLHandler. They are served at the
+// root rather than under a version, as GraphQL schemas evolve by adding
+// fields.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/graphql", ID: "getGraphQL", Tags: []string{"graphql"},
+		Summary:     "Run a GraphQL query",
+		Description: description,
+		Params: []openapi.Param{
+			openapi.Query("query", "The query.", ""),
+			openapi.Query("operationName", "The operation to run, if the query has several.", ""),
+			openapi.Query("variables", "The variables, in JSON.", ""),
+			openapi.Query("extensions", "The extensions, in JSON.", ""),
+		},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The result.", graphql.Result{}),
+			http.StatusBadRequest: openapi.Error("The variables or extensions are not JSON."),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/graphql", ID: "postGraphQL", Tags: []string{"graphql"},
+		Summary:     "Run a GraphQL query",
+		Description: description,
+		Body:        openapi.JSONBody(graphQLRequest{}),
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The result.", graphql.Result{}),
+			http.StatusBadRequest: openapi.Error("The body is not a GraphQL request."),
+		},
+	},
+}
@@ -0,0 +1,89 @@
+package graphql_handler
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrPersistedQueryNotFound = errors.New("PersistedQueryNotFound")
+	ErrPersistedQueryMismatch = errors.New("provided sha does not match query")
+)
+
+// persistedQuery is the extension clients use to send a query by its hash,
+// as in Apollo's automatic persisted queries: a client sends the hash
+// alone, and the query with it only if the server answers that it doesn't
+// know the hash.
+type persistedQuery struct {
+	Version    int    `json:"version"`
+	SHA256Hash string `json:"sha256Hash"`
+}
+
+// persistedQueries remembers the queries sent with their hash, forgetting
+// the least recently used past its size.
+type persistedQueries struct {
+	size int
+
+	mu      sync.Mutex
+	order   *list.List
+	queries map[string]*list.Element
+}
+
+type persisted struct {
+	hash, query string
+}
+
+func newPersistedQueries(size int) *persistedQueries {
+	return &persistedQueries{
+		size:    size,
+		order:   list.New(),
+		queries: make(map[string]*list.Element),
+	}
+}
+
+// resolve returns the query the request stands for: the one it carries,
+// once checked against its hash, or the one known by the hash.
+func (p *persistedQueries) resolve(query string, ext *persistedQuery) (string, error) {
+	if ext == nil {
+		return query, nil
+	}
+	if query == "" {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		e, ok := p.queries[ext.SHA256Hash]
+		if !ok {
+			return "", ErrPersistedQueryNotFound
+		}
+		p.order.MoveToFront(e)
+		return e.Value.(persisted).query, nil
+	}
+	sum := sha256.Sum256([]byte(query))
+	if hex.EncodeToString(sum[:]) != ext.SHA256Hash {
+		return "", ErrPersistedQueryMismatch
+	}
+	return query, nil
+}
+
+// add remembers query by its hash. The handler only adds queries that
+// passed validation and the limits, so that the cache holds nothing that
+// wouldn't run.
+func (p *persistedQueries) add(hash, query string) {
+	if p.size <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := p.queries[hash]; ok {
+		p.order.MoveToFront(e)
+		return
+	}
+	p.queries[hash] = p.order.PushFront(persisted{hash: hash, query: query})
+	for p.order.Len() > p.size {
+		oldest := p.order.Back()
+		p.order.Remove(oldest)
+		delete(p.queries, oldest.Value.(persisted).hash)
+	}
+}
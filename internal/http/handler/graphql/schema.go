@@ -0,0 +1,268 @@
+package graphql_handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/graphql-go/graphql"
+)
+
+// maxIDs bounds the posts a single posts field may ask for.
+const maxIDs = 100
+
+// user is a user as the schema knows them: by ID, with the rest resolved
+// on demand.
+type user struct {
+	ID int
+}
+
+// loadersKey holds the loaders of a request in its context.
+type loadersKey struct{}
+
+type loaders struct {
+	posts *loader[int, models.Post]
+}
+
+func withLoaders(ctx context.Context, posts PostService) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		posts: newLoader(posts.GetMany),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// loadPost resolves to the post id, or null for posts that don't exist or
+// that the user may not see.
+func loadPost(ctx context.Context, id int) func() (any, error) {
+	thunk := loadersFrom(ctx).posts.load(ctx, id)
+	return func() (any, error) {
+		post, ok, err := thunk()
+		if err != nil || !ok {
+			return nil, fieldError(err)
+		}
+		return post, nil
+	}
+}
+
+func newSchema(posts PostService, accounts AccountService) (graphql.Schema, error) {
+	variant := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AttachmentVariant",
+		Fields: graphql.Fields{
+			"name":        {Type: graphql.NewNonNull(graphql.String)},
+			"contentType": {Type: graphql.NewNonNull(graphql.String)},
+			"width":       {Type: graphql.NewNonNull(graphql.Int)},
+			"height":      {Type: graphql.NewNonNull(graphql.Int)},
+			"size":        {Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	attachment := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Attachment",
+		Fields: graphql.Fields{
+			"id": {
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(models.PostAttachment).AttachmentID, nil
+				},
+			},
+			"altText":     {Type: graphql.NewNonNull(graphql.String)},
+			"contentType": {Type: graphql.String},
+			"status":      {Type: graphql.String},
+			"width":       {Type: graphql.Int},
+			"height":      {Type: graphql.Int},
+			"blurhash":    {Type: graphql.String},
+			"variants": {
+				Type: listOf(variant),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return orEmpty(p.Source.(models.PostAttachment).Variants), nil
+				},
+			},
+		},
+	})
+
+	linkPreview := graphql.NewObject(graphql.ObjectConfig{
+		Name: "LinkPreview",
+		Fields: graphql.Fields{
+			"url":         {Type: graphql.NewNonNull(graphql.String)},
+			"title":       {Type: graphql.String},
+			"description": {Type: graphql.String},
+			"imageUrl":    {Type: graphql.String},
+			"siteName":    {Type: graphql.String},
+		},
+	})
+
+	pollOption := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PollOption",
+		Fields: graphql.Fields{
+			"id":   {Type: graphql.NewNonNull(graphql.Int)},
+			"text": {Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	poll := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Poll",
+		Description: "A poll attached to a post. Tallies are served by the poll endpoints.",
+		Fields: graphql.Fields{
+			"id":        {Type: graphql.NewNonNull(graphql.Int)},
+			"multiple":  {Type: graphql.NewNonNull(graphql.Boolean)},
+			"expiresAt": {Type: graphql.NewNonNull(graphql.DateTime)},
+			"closed":    {Type: graphql.NewNonNull(graphql.Boolean)},
+			"options": {
+				Type: listOf(pollOption),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return orEmpty(p.Source.(*models.Poll).Options), nil
+				},
+			},
+		},
+	})
+
+	accountStatus := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AccountStatus",
+		Fields: graphql.Fields{
+			"state":     {Type: graphql.NewNonNull(graphql.String)},
+			"reason":    {Type: graphql.String},
+			"expiresAt": {Type: graphql.DateTime},
+		},
+	})
+
+	// Post and User refer to each other, so their fields are added once
+	// both exist.
+	post := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Post",
+		Fields: graphql.Fields{},
+	})
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "User",
+		Fields: graphql.Fields{},
+	})
+
+	post.AddFieldConfig("id", &graphql.Field{Type: graphql.NewNonNull(graphql.Int)})
+	post.AddFieldConfig("body", &graphql.Field{Type: graphql.NewNonNull(graphql.String)})
+	post.AddFieldConfig("expiresAt", &graphql.Field{Type: graphql.DateTime})
+	post.AddFieldConfig("author", &graphql.Field{
+		Type: graphql.NewNonNull(userType),
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return user{ID: p.Source.(models.Post).AuthorID}, nil
+		},
+	})
+	post.AddFieldConfig("attachments", &graphql.Field{
+		Type: listOf(attachment),
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return orEmpty(p.Source.(models.Post).Attachments), nil
+		},
+	})
+	post.AddFieldConfig("linkPreviews", &graphql.Field{
+		Type: listOf(linkPreview),
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return orEmpty(p.Source.(models.Post).LinkPreviews), nil
+		},
+	})
+	post.AddFieldConfig("poll", &graphql.Field{Type: poll})
+
+	userType.AddFieldConfig("id", &graphql.Field{Type: graphql.NewNonNull(graphql.Int)})
+	userType.AddFieldConfig("state", &graphql.Field{
+		Type:        accountStatus,
+		Description: "The state of the account, for its owner and moderators.",
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			status, err := accounts.Get(p.Context, p.Source.(user).ID)
+			if err != nil {
+				return nil, fieldError(err)
+			}
+			return status, nil
+		},
+	})
+	userType.AddFieldConfig("drafts", &graphql.Field{
+		Type:        listOf(post),
+		Description: "The drafts and scheduled posts of the user, for the user.",
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			drafts, err := posts.ListDrafts(p.Context, p.Source.(user).ID)
+			if err != nil {
+				return nil, fieldError(err)
+			}
+			return orEmpty(drafts), nil
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"post": {
+				Type:        post,
+				Description: "A published post, or null if there is none the caller may see.",
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return loadPost(p.Context, p.Args["id"].(int)), nil
+				},
+			},
+			"posts": {
+				Type:        graphql.NewNonNull(graphql.NewList(post)),
+				Description: fmt.Sprintf("Published posts by ID, in order, with null for those the caller may not see. At most %d.", maxIDs),
+				Args: graphql.FieldConfigArgument{
+					"ids": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.Int)))},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					ids := p.Args["ids"].([]any)
+					if len(ids) > maxIDs {
+						return nil, fmt.Errorf("can't get more than %d posts at once", maxIDs)
+					}
+					thunks := make([]func() (any, error), len(ids))
+					for i, id := range ids {
+						thunks[i] = loadPost(p.Context, id.(int))
+					}
+					return func() (any, error) {
+						list := make([]any, len(thunks))
+						for i, thunk := range thunks {
+							post, err := thunk()
+							if err != nil {
+								return nil, err
+							}
+							list[i] = post
+						}
+						return list, nil
+					}, nil
+				},
+			},
+			"user": {
+				Type: graphql.NewNonNull(userType),
+				Args: graphql.FieldConfigArgument{
+					"id": {Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return user{ID: p.Args["id"].(int)}, nil
+				},
+			},
+			"viewer": {
+				Type:        userType,
+				Description: "The user the request is authenticated as, or null.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					id, ok := auth.UserID(p.Context)
+					if !ok {
+						return nil, nil
+					}
+					return user{ID: id}, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// listOf is a non-null list of non-null t.
+func listOf(t graphql.Type) graphql.Output {
+	return graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(t)))
+}
+
+// orEmpty turns nil slices into empty ones, which non-null lists need.
+func orEmpty[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
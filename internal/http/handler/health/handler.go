@@ -0,0 +1,39 @@
+package health_handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/service/health"
+	"github.com/labstack/echo/v4"
+)
+
+type HealthService interface {
+	Ready(ctx context.Context) health.Report
+}
+
+type HealthHandler struct {
+	service HealthService
+}
+
+func New(service HealthService) *HealthHandler {
+	return &HealthHandler{
+		service: service,
+	}
+}
+
+// Live reports that the process is up and serving requests. It checks no
+// dependencies: a failing database must not get the process restarted.
+func (h *HealthHandler) Live(c echo.Context) error {
+	return c.JSON(http.StatusOK, health.Report{Status: health.StatusOK})
+}
+
+// Ready reports whether the instance should receive traffic, with the
+// outcome of every check.
+func (h *HealthHandler) Ready(c echo.Context) error {
+	report := h.service.Ready(c.Request().Context())
+	if !report.OK() {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,43 @@
+package health_handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	health_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/health"
+	"github.com/AtIasShrugged/antisocial/internal/service/health"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadyFlipsOnDrain(t *testing.T) {
+	e := echo.New()
+	service := health.New(config.HealthConfig{Timeout: time.Second})
+	service.Add("database", func(context.Context) error { return nil })
+	handler := health_handler.New(service)
+
+	rec := httptest.NewRecorder()
+	if assert.NoError(t, handler.Ready(e.NewContext(httptest.NewRequest(http.MethodGet, "/readyz", nil), rec))) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"status":"ok","checks":{"database":{"status":"ok"},"shutdown":{"status":"ok"}}}`, rec.Body.String())
+	}
+
+	service.Drain()
+
+	rec = httptest.NewRecorder()
+	if assert.NoError(t, handler.Ready(e.NewContext(httptest.NewRequest(http.MethodGet, "/readyz", nil), rec))) {
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.JSONEq(t, `{"status":"failing","checks":{"database":{"status":"ok"},"shutdown":{"status":"failing","error":"shutting down"}}}`, rec.Body.String())
+	}
+
+	// Liveness is unaffected: the process is still fine.
+	rec = httptest.NewRecorder()
+	if assert.NoError(t, handler.Live(e.NewContext(httptest.NewRequest(http.MethodGet, "/healthz", nil), rec))) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+	}
+}
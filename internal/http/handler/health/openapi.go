@@ -0,0 +1,27 @@
+package health_handler
+
+import (
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+	"github.com/AtIasShrugged/antisocial/internal/service/health"
+)
+
+// Operations describes the routes of HealthHandler.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/healthz", ID: "live", Tags: []string{"health"},
+		Summary: "Report that the process is up",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: openapi.JSON("The process is up.", health.Report{}),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/readyz", ID: "ready", Tags: []string{"health"},
+		Summary: "Report whether the instance should receive traffic",
+		Responses: map[int]openapi.Response{
+			http.StatusOK:                 openapi.JSON("Every check passed.", health.Report{}),
+			http.StatusServiceUnavailable: openapi.JSON("A check failed, or the instance is shutting down.", health.Report{}),
+		},
+	},
+}
@@ -0,0 +1,202 @@
+package loglevel_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/libs/logger"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+)
+
+var ErrInvalidTTL = errors.New("ttl must be positive and within the allowed maximum")
+
+type Levels interface {
+	Snapshot() logger.LevelsSnapshot
+	SetGlobal(level slog.Level, ttl time.Duration)
+	SetOverride(component string, level slog.Level, ttl time.Duration)
+	RemoveOverride(component string) bool
+}
+
+type Auditor interface {
+	Record(ctx context.Context, event models.AuditEvent) error
+}
+
+type LogLevelHandler struct {
+	levels  Levels
+	cfg     config.AdminConfig
+	auditor Auditor
+	log     *slog.Logger
+}
+
+func New(levels Levels, cfg config.AdminConfig, auditor Auditor, log *slog.Logger) *LogLevelHandler {
+	return &LogLevelHandler{
+		levels:  levels,
+		cfg:     cfg,
+		auditor: auditor,
+		log:     log,
+	}
+}
+
+// globalTarget is the audit target ID of the global level; overrides use
+// their component.
+const globalTarget = "global"
+
+type levelRequest struct {
+	Level string `json:"level"`
+	// TTL is a duration such as "10m"; empty means the configured default.
+	TTL string `json:"ttl"`
+}
+
+// Get returns the global level and the per-component overrides along with
+// when each of them reverts.
+func (h *LogLevelHandler) Get(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.levels.Snapshot())
+}
+
+// SetGlobal changes the level of every logger without an override until the
+// TTL runs out.
+func (h *LogLevelHandler) SetGlobal(c echo.Context) error {
+	const op = "LogLevelHandler.SetGlobal"
+	ctx := c.Request().Context()
+
+	level, ttl, err := h.bind(c)
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	before := h.levels.Snapshot().LevelState
+	h.levels.SetGlobal(level, ttl)
+	sl.FromContext(ctx, h.log).WarnContext(ctx, "global log level changed",
+		slog.String("level", level.String()), slog.Duration("ttl", ttl))
+
+	after := h.levels.Snapshot()
+	if err := h.record(ctx, models.AuditLogLevelChange, globalTarget, &before, &after.LevelState); err != nil {
+		h.levels.SetGlobal(before.Level, remaining(&before))
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, after)
+}
+
+// SetOverride changes the level of the component in the path, e.g.
+// "PostRepository", until the TTL runs out.
+func (h *LogLevelHandler) SetOverride(c echo.Context) error {
+	const op = "LogLevelHandler.SetOverride"
+	ctx := c.Request().Context()
+
+	component := c.Param("component")
+	level, ttl, err := h.bind(c)
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	before := override(h.levels.Snapshot(), component)
+	h.levels.SetOverride(component, level, ttl)
+	sl.FromContext(ctx, h.log).WarnContext(ctx, "log level override set",
+		slog.String("component", component), slog.String("level", level.String()), slog.Duration("ttl", ttl))
+
+	after := h.levels.Snapshot()
+	if err := h.record(ctx, models.AuditLogLevelChange, component, before, override(after, component)); err != nil {
+		h.restore(component, before)
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, after)
+}
+
+func (h *LogLevelHandler) RemoveOverride(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	component := c.Param("component")
+	before := override(h.levels.Snapshot(), component)
+	if !h.levels.RemoveOverride(component) {
+		return c.String(http.StatusNotFound, "override not found")
+	}
+	sl.FromContext(ctx, h.log).WarnContext(ctx, "log level override removed", slog.String("component", component))
+	if err := h.record(ctx, models.AuditLogLevelRestore, component, before, nil); err != nil {
+		h.restore(component, before)
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// record audits a change that has already been applied. A change that can't
+// be audited must not stay, so callers undo it when record fails.
+func (h *LogLevelHandler) record(ctx context.Context, action models.AuditAction, target string, before, after *logger.LevelState) error {
+	event := models.AuditEvent{
+		Action:     action,
+		TargetType: models.AuditTargetLogLevel,
+		TargetID:   target,
+	}
+	// Typed nil pointers would be recorded as JSON nulls; leave them unset.
+	if before != nil {
+		event.Before = before
+	}
+	if after != nil {
+		event.After = after
+	}
+	return h.auditor.Record(ctx, event)
+}
+
+// restore puts the override of component back to state, or drops it when
+// state is nil.
+func (h *LogLevelHandler) restore(component string, state *logger.LevelState) {
+	if state == nil {
+		h.levels.RemoveOverride(component)
+		return
+	}
+	h.levels.SetOverride(component, state.Level, remaining(state))
+}
+
+// remaining is the TTL state had left, zero if it never expires. A state
+// that expired meanwhile gets the shortest TTL rather than none.
+func remaining(state *logger.LevelState) time.Duration {
+	if state.ExpiresAt == nil {
+		return 0
+	}
+	return max(time.Until(*state.ExpiresAt), time.Nanosecond)
+}
+
+// override returns the override of component, or nil if it has none.
+func override(snapshot logger.LevelsSnapshot, component string) *logger.LevelState {
+	state, ok := snapshot.Overrides[component]
+	if !ok {
+		return nil
+	}
+	return &state
+}
+
+func (h *LogLevelHandler) bind(c echo.Context) (slog.Level, time.Duration, error) {
+	var req levelRequest
+	if err := c.Bind(&req); err != nil {
+		return 0, 0, fmt.Errorf("bad json: %w", err)
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+		return 0, 0, fmt.Errorf("bad level: %w", err)
+	}
+
+	ttl := h.cfg.LogLevelTTL
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil {
+			return 0, 0, fmt.Errorf("bad ttl: %w", err)
+		}
+	}
+	if ttl <= 0 || ttl > h.cfg.MaxLogLevelTTL {
+		return 0, 0, ErrInvalidTTL
+	}
+
+	return level, ttl, nil
+}
@@ -0,0 +1,198 @@
+package loglevel_handler_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/audit/audittest"
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	loglevel_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/loglevel"
+	"github.com/AtIasShrugged/antisocial/libs/logger"
+	"github.com/AtIasShrugged/antisocial/libs/logger/handlers/slogdiscard"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tokens = auth.NewTokens("s3cret")
+
+// roles makes user 1 an admin and everyone else a plain user.
+type roles struct{}
+
+func (roles) Role(_ context.Context, userID int) (models.Role, error) {
+	if userID == 1 {
+		return models.RoleAdmin, nil
+	}
+	return models.RoleUser, nil
+}
+
+// active leaves every account active.
+type active struct{}
+
+func (active) State(context.Context, int) (models.AccountState, error) {
+	return models.AccountActive, nil
+}
+
+// unthrottled lets clients fail to authenticate as often as they like.
+type unthrottled struct{}
+
+func (unthrottled) AuthFailed(context.Context, string) time.Duration { return 0 }
+
+func issue(t *testing.T, userID int) string {
+	t.Helper()
+	token, err := tokens.Issue(userID, time.Hour)
+	require.NoError(t, err)
+	return token
+}
+
+func newServer(t *testing.T, tokens *auth.Tokens) (*echo.Echo, *logger.Levels, string) {
+	e, levels, _, token := newAuditedServer(t, tokens)
+	return e, levels, token
+}
+
+func newAuditedServer(t *testing.T, tokens *auth.Tokens) (*echo.Echo, *logger.Levels, *audittest.Recorder, string) {
+	t.Helper()
+
+	levels := logger.NewLevels(slog.LevelInfo)
+	log := slogdiscard.NewDiscardLogger()
+	auditor := &audittest.Recorder{}
+	handler := loglevel_handler.New(levels, config.AdminConfig{
+		LogLevelTTL:    15 * time.Minute,
+		MaxLogLevelTTL: time.Hour,
+	}, auditor, log)
+
+	e := echo.New()
+	e.Use(auth.Middleware(tokens, roles{}, active{}, unthrottled{}, log))
+	e.Use(auth.Require(map[string]auth.Permission{
+		"GET /admin/log-level":               auth.LogsManage,
+		"PUT /admin/log-level":               auth.LogsManage,
+		"PUT /admin/log-level/:component":    auth.LogsManage,
+		"DELETE /admin/log-level/:component": auth.LogsManage,
+	}, log))
+	admin := e.Group("/admin")
+	admin.GET("/log-level", handler.Get)
+	admin.PUT("/log-level", handler.SetGlobal)
+	admin.PUT("/log-level/:component", handler.SetOverride)
+	admin.DELETE("/log-level/:component", handler.RemoveOverride)
+	return e, levels, auditor, issue(t, 1)
+}
+
+func do(e *echo.Echo, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequiresAdmin(t *testing.T) {
+	e, levels, _ := newServer(t, tokens)
+
+	for _, token := range []string{"", "wrong"} {
+		rec := do(e, http.MethodPut, "/admin/log-level", token, `{"level":"debug"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+	rec := do(e, http.MethodPut, "/admin/log-level", issue(t, 2), `{"level":"debug"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, slog.LevelInfo, levels.Level())
+}
+
+func TestClosedWithoutSecret(t *testing.T) {
+	e, _, _ := newServer(t, auth.NewTokens(""))
+
+	rec := do(e, http.MethodGet, "/admin/log-level", issue(t, 1), "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestSetGlobalAndOverride(t *testing.T) {
+	e, levels, auditor, token := newAuditedServer(t, tokens)
+
+	rec := do(e, http.MethodPut, "/admin/log-level", token, `{"level":"warn","ttl":"5m"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, slog.LevelWarn, levels.Snapshot().Level)
+
+	rec = do(e, http.MethodPut, "/admin/log-level/PostRepository", token, `{"level":"debug"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"PostRepository":{"level":"DEBUG","expires_at":`)
+
+	override := levels.Snapshot().Overrides["PostRepository"]
+	require.NotNil(t, override.ExpiresAt)
+	// The configured default TTL applies when the request has none.
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), *override.ExpiresAt, time.Minute)
+
+	rec = do(e, http.MethodGet, "/admin/log-level", token, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"level":"WARN"`)
+
+	rec = do(e, http.MethodDelete, "/admin/log-level/PostRepository", token, "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, levels.Snapshot().Overrides)
+
+	rec = do(e, http.MethodDelete, "/admin/log-level/PostRepository", token, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.Len(t, auditor.Events, 3)
+	assert.Equal(t, models.AuditLogLevelChange, auditor.Events[0].Action)
+	assert.Equal(t, "global", auditor.Events[0].TargetID)
+	assert.Equal(t, &logger.LevelState{Level: slog.LevelInfo}, auditor.Events[0].Before)
+	assert.Equal(t, "PostRepository", auditor.Events[1].TargetID)
+	assert.Nil(t, auditor.Events[1].Before)
+	assert.Equal(t, models.AuditLogLevelRestore, auditor.Events[2].Action)
+	assert.Nil(t, auditor.Events[2].After)
+}
+
+// A change that can't be audited is undone.
+func TestUndoesUnauditedChanges(t *testing.T) {
+	e, levels, auditor, token := newAuditedServer(t, tokens)
+
+	rec := do(e, http.MethodPut, "/admin/log-level/PostRepository", token, `{"level":"warn","ttl":"5m"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	want := levels.Snapshot()
+
+	auditor.Err = errors.New("db is down")
+	for _, req := range []struct{ method, path, body string }{
+		{http.MethodPut, "/admin/log-level", `{"level":"debug"}`},
+		{http.MethodPut, "/admin/log-level/PostRepository", `{"level":"debug"}`},
+		{http.MethodPut, "/admin/log-level/PostService", `{"level":"debug"}`},
+		{http.MethodDelete, "/admin/log-level/PostRepository", ""},
+	} {
+		rec := do(e, req.method, req.path, token, req.body)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code, req.path)
+
+		got := levels.Snapshot()
+		assert.Equal(t, want.Level, got.Level)
+		require.Len(t, got.Overrides, 1)
+		override := got.Overrides["PostRepository"]
+		assert.Equal(t, slog.LevelWarn, override.Level)
+		require.NotNil(t, override.ExpiresAt)
+		assert.WithinDuration(t, *want.Overrides["PostRepository"].ExpiresAt, *override.ExpiresAt, time.Second)
+	}
+	assert.Equal(t, slog.LevelInfo, levels.Level())
+	require.Len(t, auditor.Events, 1)
+}
+
+func TestRejectsBadRequests(t *testing.T) {
+	e, levels, token := newServer(t, tokens)
+
+	for _, body := range []string{
+		`{"level":"loud"}`,
+		`{"level":"debug","ttl":"forever"}`,
+		`{"level":"debug","ttl":"-1m"}`,
+		`{"level":"debug","ttl":"2h"}`,
+	} {
+		rec := do(e, http.MethodPut, "/admin/log-level", token, body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+	assert.Equal(t, slog.LevelInfo, levels.Level())
+}
@@ -0,0 +1,52 @@
+package loglevel_handler
+
+import (
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+	"github.com/AtIasShrugged/antisocial/libs/logger"
+)
+
+var componentParam = openapi.Param{Name: "component", In: "path", Description: "The logger, e.g. \"PostRepository\".", Required: true, Type: ""}
+
+// Operations describes the routes of LogLevelHandler.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/admin/log-level", ID: "getLogLevels", Tags: []string{"log-level"},
+		Summary: "Get the global log level and the per-component overrides",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: openapi.JSON("The levels and when they revert.", logger.LevelsSnapshot{}),
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/admin/log-level", ID: "setGlobalLogLevel", Tags: []string{"log-level"},
+		Summary: "Change the global log level for a while",
+		Body:    openapi.JSONBody(levelRequest{}),
+		Responses: map[int]openapi.Response{
+			http.StatusOK:                  openapi.JSON("The levels.", logger.LevelsSnapshot{}),
+			http.StatusBadRequest:          openapi.Error("The level or TTL is invalid."),
+			http.StatusInternalServerError: openapi.Error("The change can't be audited and is undone."),
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/admin/log-level/:component", ID: "setLogLevelOverride", Tags: []string{"log-level"},
+		Summary: "Change the log level of a component for a while",
+		Params:  []openapi.Param{componentParam},
+		Body:    openapi.JSONBody(levelRequest{}),
+		Responses: map[int]openapi.Response{
+			http.StatusOK:                  openapi.JSON("The levels.", logger.LevelsSnapshot{}),
+			http.StatusBadRequest:          openapi.Error("The level or TTL is invalid."),
+			http.StatusInternalServerError: openapi.Error("The change can't be audited and is undone."),
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/admin/log-level/:component", ID: "removeLogLevelOverride", Tags: []string{"log-level"},
+		Summary: "Revert a component to the global log level",
+		Params:  []openapi.Param{componentParam},
+		Responses: map[int]openapi.Response{
+			http.StatusNoContent:           openapi.NoContent("The override is removed."),
+			http.StatusNotFound:            openapi.Error("The component has no override."),
+			http.StatusInternalServerError: openapi.Error("The removal can't be audited and is undone."),
+		},
+	},
+}
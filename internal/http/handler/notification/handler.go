@@ -0,0 +1,52 @@
+package notification_handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationService interface {
+	List(ctx context.Context, userID int) ([]models.Notification, error)
+}
+
+type NotificationHandler struct {
+	service NotificationService
+	log     *slog.Logger
+}
+
+func New(service NotificationService, log *slog.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+func (h *NotificationHandler) List(c echo.Context) error {
+	const op = "NotificationHandler.List"
+	ctx := c.Request().Context()
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	notifications, err := h.service.List(ctx, userID)
+	if err != nil {
+		if status, ok := auth.HTTPStatus(err); ok {
+			return c.JSON(status, err.Error())
+		}
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, notifications)
+}
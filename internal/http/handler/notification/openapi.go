@@ -0,0 +1,21 @@
+package notification_handler
+
+import (
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+)
+
+// Operations describes the routes of NotificationHandler.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/users/:id/notifications", ID: "listNotifications", Tags: []string{"notifications"},
+		Summary: "List the notifications of a user",
+		Params:  []openapi.Param{openapi.PathParam("id", "User ID.")},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The notifications.", []models.Notification{}),
+			http.StatusBadRequest: openapi.Error("The ID is not a number."),
+		},
+	},
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/http/apiversion"
+	account_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/account"
+	attachment_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/attachment"
+	audit_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/audit"
+	blocklist_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/blocklist"
+	docs_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/docs"
+	follow_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/follow"
+	graphql_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/graphql"
+	health_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/health"
+	loglevel_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/loglevel"
+	notification_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/notification"
+	poll_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/poll"
+	post_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/post"
+	report_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/report"
+	role_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/role"
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+)
+
+var metricsOperations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/metrics", ID: "getMetrics", Tags: []string{"meta"},
+		Summary: "Prometheus metrics",
+		Responses: map[int]openapi.Response{
+			http.StatusOK: openapi.Text("Metrics in the Prometheus text format."),
+		},
+	},
+}
+
+// enums are the types of the models whose values are a closed set.
+var enums = []openapi.Enum{
+	openapi.EnumOf(models.PostDraft, models.PostScheduled, models.PostPublished),
+	openapi.EnumOf(models.AttachmentProcessing, models.AttachmentReady, models.AttachmentFailed),
+	openapi.EnumOf(models.AccountStates...),
+	openapi.EnumOf(models.AppealOpen, models.AppealGranted, models.AppealDenied),
+	openapi.EnumOf(models.ReportPost, models.ReportAccount),
+	openapi.EnumOf(models.ReportReasons...),
+	openapi.EnumOf(models.ReportOpen, models.ReportResolved),
+	openapi.EnumOf(models.ActionDismiss, models.ActionRemovePost, models.ActionWarn, models.ActionSuspend),
+	openapi.EnumOf(models.Roles...),
+	openapi.EnumOf(models.BlockTerm, models.BlockDomain, models.BlockURL),
+	openapi.EnumOf(models.BlockReject, models.BlockReplace, models.BlockModerate),
+}
+
+// Spec describes every route mount serves. Whether a route needs a token
+// follows from permissions, so the two can't disagree.
+func Spec() (*openapi.Document, error) {
+	api := slices.Concat(
+		post_handler.Operations,
+		attachment_handler.Operations,
+		poll_handler.Operations,
+		notification_handler.Operations,
+		report_handler.Operations,
+		follow_handler.Operations,
+		account_handler.Operations,
+		loglevel_handler.Operations,
+		role_handler.Operations,
+		audit_handler.Operations,
+		blocklist_handler.Operations,
+	)
+	ops := make([]openapi.Operation, 0, len(api)+len(legacyAliases))
+	for _, op := range api {
+		op.Path = apiPrefix + op.Path
+		ops = append(ops, op)
+	}
+	for _, op := range ops[:len(api)] {
+		alias, ok := legacyAliases[op.Route()]
+		if !ok {
+			continue
+		}
+		legacy := op
+		legacy.Path = alias
+		legacy.ID = op.ID + "Legacy"
+		legacy.Deprecated = true
+		legacy.Description = fmt.Sprintf("Deprecated alias of %s, answering with Deprecation, Sunset and Link "+
+			"headers that point at it.", op.Route())
+		ops = append(ops, legacy)
+	}
+	ops = slices.Concat(ops,
+		graphql_handler.Operations,
+		// Last, so that models.Report keeps the name Report and
+		// health.Report is the one prefixed.
+		health_handler.Operations,
+		metricsOperations,
+		docs_handler.Operations,
+	)
+
+	for i := range ops {
+		ops[i].Authenticated = permissions[ops[i].Route()] != auth.Public
+	}
+	return openapi.Build(openapi.Info{
+		Title:   "antisocial",
+		Version: string(apiversion.V1),
+		Description: "Posts, polls, attachments and the moderation around them. Errors are a bare string " +
+			"saying what went wrong.",
+	}, ops, enums...)
+}
@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	account_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/account"
+	audit_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/audit"
+	blocklist_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/blocklist"
+	docs_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/docs"
+	graphql_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/graphql"
+	health_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/health"
+	loglevel_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/loglevel"
+	notification_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/notification"
+	poll_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/poll"
+	post_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/post"
+	report_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/report"
+	role_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/role"
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+	blocklist_repo "github.com/AtIasShrugged/antisocial/internal/repository/blocklist"
+	post_repo "github.com/AtIasShrugged/antisocial/internal/repository/post"
+	"github.com/AtIasShrugged/antisocial/internal/service/health"
+	post_service "github.com/AtIasShrugged/antisocial/internal/service/post"
+	"github.com/AtIasShrugged/antisocial/libs/logger"
+	"github.com/AtIasShrugged/antisocial/libs/logger/handlers/slogdiscard"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func spec(t *testing.T) *openapi.Document {
+	t.Helper()
+	doc, err := Spec()
+	require.NoError(t, err)
+	return doc
+}
+
+func TestSpecCoversEveryRoute(t *testing.T) {
+	doc := spec(t)
+
+	described := make(map[string]bool)
+	for path, methods := range doc.Paths {
+		for method := range methods {
+			described[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+	for _, r := range mounted(t).Routes() {
+		if r.Method == echo.RouteNotFound {
+			continue
+		}
+		route := r.Method + " " + openapi.Path(r.Path)
+		assert.True(t, described[route], "%s is not described", route)
+		delete(described, route)
+	}
+	assert.Empty(t, described, "described routes that aren't mounted")
+}
+
+// The fakes below answer with a fixed value, or an error for the IDs that
+// the cases of TestSpecMatchesResponses use to reach error responses.
+
+type fakePosts struct{ post_handler.PostService }
+
+func (fakePosts) GetByID(_ context.Context, id int) (models.Post, error) {
+	switch id {
+	case 2:
+		return models.Post{}, post_repo.ErrPostNotFound
+	case 3:
+		return models.Post{}, post_repo.ErrPostExpired
+	}
+	at := time.Now()
+	return models.Post{
+		ID: id, AuthorID: 1, Body: "hi", Status: models.PostPublished, PublishAt: &at,
+		Poll: &models.Poll{ID: 1, Options: []models.PollOption{{Text: "yes"}}},
+	}, nil
+}
+
+func (f fakePosts) GetMany(ctx context.Context, ids []int) (map[int]models.Post, error) {
+	posts := make(map[int]models.Post)
+	for _, id := range ids {
+		if post, err := f.GetByID(ctx, id); err == nil {
+			posts[id] = post
+		}
+	}
+	return posts, nil
+}
+
+func (fakePosts) Create(_ context.Context, post models.Post) (int, error) {
+	if post.Body == "blocked" {
+		return 0, post_service.ErrBlocked
+	}
+	return 7, nil
+}
+
+func (fakePosts) ListDrafts(context.Context, int) ([]models.Post, error) {
+	return nil, nil
+}
+
+type fakePolls struct{ poll_handler.PollService }
+
+func (fakePolls) Get(_ context.Context, id int) (models.Poll, error) {
+	total := 3
+	return models.Poll{ID: id, Options: []models.PollOption{{Text: "a"}, {Text: "b"}}, TotalVotes: &total}, nil
+}
+
+type fakeNotifications struct{}
+
+func (fakeNotifications) List(_ context.Context, userID int) ([]models.Notification, error) {
+	return []models.Notification{{ID: 1, UserID: userID, Kind: models.NotificationPollClosed, Payload: json.RawMessage(`{"poll_id":1}`)}}, nil
+}
+
+type fakeModeration struct {
+	report_handler.ModerationService
+}
+
+func (fakeModeration) List(context.Context, models.ReportFilter) ([]models.Report, error) {
+	post := 4
+	return []models.Report{{
+		ID: 1, ReporterID: 2, Target: models.ReportPost, PostID: &post, Reason: models.ReportReasons[0],
+		Status: models.ReportResolved, Actions: []models.ModerationAction{{Action: models.ActionDismiss}},
+	}}, nil
+}
+
+type fakeAccounts struct{ account_handler.AccountService }
+
+func (fakeAccounts) Get(_ context.Context, userID int) (models.AccountStatus, error) {
+	return models.AccountStatus{UserID: userID, State: models.AccountSuspended, Reason: "spam"}, nil
+}
+
+func (fakeAccounts) ListAppeals(context.Context, models.AppealFilter) ([]models.Appeal, error) {
+	return []models.Appeal{{ID: 1, UserID: 2, Body: "sorry", Status: models.AppealOpen}}, nil
+}
+
+type fakeRoles struct{ role_handler.RoleService }
+
+func (fakeRoles) Get(context.Context, int) (models.Role, error) {
+	return models.RoleModerator, nil
+}
+
+type fakeBlocklist struct {
+	blocklist_handler.BlocklistService
+}
+
+func (fakeBlocklist) List(context.Context) ([]models.BlocklistEntry, error) {
+	return []models.BlocklistEntry{{ID: 1, Kind: models.BlockDomain, Pattern: "spam.example", Severity: models.BlockReject}}, nil
+}
+
+func (fakeBlocklist) Create(context.Context, models.BlocklistEntry) (int, error) {
+	return 0, blocklist_repo.ErrEntryExists
+}
+
+type fakeAudit struct{}
+
+func (fakeAudit) List(context.Context, models.AuditFilter) ([]models.AuditEvent, error) {
+	return []models.AuditEvent{{ID: 1, ActorID: 1, Action: models.AuditRoleChange, TargetType: models.AuditTargetUser, Hash: []byte{1}}}, nil
+}
+
+type fakeHealth struct{}
+
+func (fakeHealth) Ready(context.Context) health.Report {
+	return health.Report{Status: health.StatusFailing, Checks: map[string]health.CheckResult{
+		"database": {Status: health.StatusFailing, Error: "down"},
+	}}
+}
+
+type discardAuditor struct{}
+
+func (discardAuditor) Record(context.Context, models.AuditEvent) error { return nil }
+
+// adminRoles makes user 1 an admin and everyone else a plain user.
+type adminRoles struct{}
+
+func (adminRoles) Role(_ context.Context, userID int) (models.Role, error) {
+	if userID == 1 {
+		return models.RoleAdmin, nil
+	}
+	return models.RoleUser, nil
+}
+
+type activeAccounts struct{}
+
+func (activeAccounts) State(context.Context, int) (models.AccountState, error) {
+	return models.AccountActive, nil
+}
+
+type unthrottled struct{}
+
+func (unthrottled) AuthFailed(context.Context, string) time.Duration { return 0 }
+
+func TestSpecMatchesResponses(t *testing.T) {
+	doc := spec(t)
+	docs, err := docs_handler.New(doc)
+	require.NoError(t, err)
+
+	log := slogdiscard.NewDiscardLogger()
+	graphql, err := graphql_handler.New(fakePosts{}, fakeAccounts{}, config.GraphQLConfig{MaxDepth: 10, MaxComplexity: 1000, PersistedQueries: 10}, log)
+	require.NoError(t, err)
+	tokens := auth.NewTokens("s3cret")
+	token, err := tokens.Issue(1, time.Hour)
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(auth.Middleware(tokens, adminRoles{}, activeAccounts{}, unthrottled{}, log))
+	e.Use(auth.Require(permissions, log))
+	pass := func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	mount(e, handlers{
+		health:       health_handler.New(fakeHealth{}),
+		post:         post_handler.New(fakePosts{}, log),
+		poll:         poll_handler.New(fakePolls{}, log),
+		notification: notification_handler.New(fakeNotifications{}, log),
+		report:       report_handler.New(fakeModeration{}, log),
+		role:         role_handler.New(fakeRoles{}, log),
+		logLevel:     loglevel_handler.New(logger.NewLevels(slog.LevelInfo), config.AdminConfig{}, discardAuditor{}, log),
+		audit:        audit_handler.New(fakeAudit{}, log),
+		account:      account_handler.New(fakeAccounts{}, log),
+		blocklist:    blocklist_handler.New(fakeBlocklist{}, log),
+		docs:         docs,
+		graphql:      graphql,
+	}, routeLimits{
+		read: pass, write: pass, uploadLimit: 1 << 20,
+		deprecated: deprecation(time.Now(), time.Now().AddDate(0, 6, 0)),
+	})
+
+	tests := []struct {
+		method, route, path, body string
+		anonymous, deprecated     bool
+		want                      int
+	}{
+		{method: http.MethodGet, route: "/healthz", path: "/healthz", want: http.StatusOK},
+		{method: http.MethodGet, route: "/readyz", path: "/readyz", want: http.StatusServiceUnavailable},
+		{method: http.MethodGet, route: "/metrics", path: "/metrics", want: http.StatusOK},
+		{method: http.MethodGet, route: "/openapi.json", path: "/openapi.json", want: http.StatusOK},
+		{method: http.MethodGet, route: "/docs", path: "/docs", want: http.StatusOK},
+
+		{
+			method: http.MethodPost, route: "/graphql", path: "/graphql",
+			body: `{"query": "{ posts(ids: [1, 2]) { id author { id state { state } } poll { options { text } } } }"}`, want: http.StatusOK,
+		},
+		{method: http.MethodPost, route: "/graphql", path: "/graphql", body: `{"query": "{ nope }"}`, want: http.StatusOK},
+		{method: http.MethodPost, route: "/graphql", path: "/graphql", body: `{`, want: http.StatusBadRequest},
+		{method: http.MethodGet, route: "/graphql", path: "/graphql?query=%7Bviewer%7Bid%7D%7D", anonymous: true, want: http.StatusOK},
+		{method: http.MethodGet, route: "/graphql", path: "/graphql?query=%7Bviewer%7Bid%7D%7D&variables=x", want: http.StatusBadRequest},
+
+		{method: http.MethodGet, route: "/v1/posts/:id", path: "/v1/posts/1", want: http.StatusOK},
+		{method: http.MethodGet, route: "/v1/posts/:id", path: "/v1/posts/2", want: http.StatusNotFound},
+		{method: http.MethodGet, route: "/v1/posts/:id", path: "/v1/posts/3", want: http.StatusGone},
+		{method: http.MethodGet, route: "/v1/posts/:id", path: "/v1/posts/x", want: http.StatusBadRequest},
+		{method: http.MethodPost, route: "/v1/posts", path: "/v1/posts", body: `{"author_id": 1, "body": "hi"}`, want: http.StatusOK},
+		{method: http.MethodPost, route: "/v1/posts", path: "/v1/posts", body: `{"author_id": 1, "body": "blocked"}`, want: http.StatusUnprocessableEntity},
+		{method: http.MethodPost, route: "/v1/posts", path: "/v1/posts", body: `{`, want: http.StatusBadRequest},
+		{method: http.MethodPost, route: "/v1/posts", path: "/v1/posts", body: `{}`, anonymous: true, want: http.StatusUnauthorized},
+		{method: http.MethodGet, route: "/v1/users/:id/drafts", path: "/v1/users/1/drafts", want: http.StatusOK},
+
+		{method: http.MethodGet, route: "/v1/polls/:id", path: "/v1/polls/1", want: http.StatusOK},
+		{method: http.MethodGet, route: "/v1/users/:id/notifications", path: "/v1/users/1/notifications", want: http.StatusOK},
+		{method: http.MethodGet, route: "/v1/users/:id/state", path: "/v1/users/1/state", want: http.StatusOK},
+
+		{method: http.MethodGet, route: "/v1/admin/reports", path: "/v1/admin/reports", want: http.StatusOK},
+		{method: http.MethodGet, route: "/v1/admin/appeals", path: "/v1/admin/appeals", want: http.StatusOK},
+		{method: http.MethodGet, route: "/v1/admin/users/:id/role", path: "/v1/admin/users/2/role", want: http.StatusOK},
+		{method: http.MethodGet, route: "/v1/admin/log-level", path: "/v1/admin/log-level", want: http.StatusOK},
+		{method: http.MethodGet, route: "/v1/admin/audit-events", path: "/v1/admin/audit-events", want: http.StatusOK},
+		{method: http.MethodGet, route: "/v1/admin/blocklist", path: "/v1/admin/blocklist", want: http.StatusOK},
+		{
+			method: http.MethodPost, route: "/v1/admin/blocklist", path: "/v1/admin/blocklist",
+			body: `{"kind": "domain", "pattern": "spam.example", "severity": "reject"}`, want: http.StatusConflict,
+		},
+
+		{method: http.MethodGet, route: "/posts/:id", path: "/posts/1", want: http.StatusOK, deprecated: true},
+		{method: http.MethodPost, route: "/posts/create", path: "/posts/create", body: `{"author_id": 1, "body": "hi"}`, want: http.StatusOK, deprecated: true},
+		{method: http.MethodGet, route: "/admin/blocklist", path: "/admin/blocklist", want: http.StatusOK, deprecated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req := httptest.NewRequest(tt.method, tt.path, body)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if !tt.anonymous {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.want, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.deprecated, rec.Header().Get("Deprecation") != "", "Deprecation header")
+			assert.NoError(t, doc.CheckResponse(tt.method, tt.route, rec.Code, rec.Header().Get(echo.HeaderContentType), rec.Body.Bytes()))
+		})
+	}
+}
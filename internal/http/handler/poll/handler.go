@@ -0,0 +1,91 @@
+package poll_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	repo "github.com/AtIasShrugged/antisocial/internal/repository/poll"
+	"github.com/AtIasShrugged/antisocial/internal/service/poll"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+)
+
+type PollService interface {
+	Get(ctx context.Context, id int) (models.Poll, error)
+	Vote(ctx context.Context, id int, vote models.PollVote) (models.Poll, error)
+}
+
+type PollHandler struct {
+	service PollService
+	log     *slog.Logger
+}
+
+func New(service PollService, log *slog.Logger) *PollHandler {
+	return &PollHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// GetByID returns a poll; results are included once the authenticated
+// viewer has voted or the poll has closed.
+func (h *PollHandler) GetByID(c echo.Context) error {
+	const op = "PollHandler.GetByID"
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+	p, err := h.service.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, repo.ErrPollNotFound) {
+			return c.String(http.StatusNotFound, err.Error())
+		}
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, p)
+}
+
+func (h *PollHandler) Vote(c echo.Context) error {
+	const op = "PollHandler.Vote"
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	var vote models.PollVote
+	if err := c.Bind(&vote); err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad json: %w", err).Error())
+	}
+
+	p, err := h.service.Vote(ctx, id, vote)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrPollNotFound):
+			return c.String(http.StatusNotFound, err.Error())
+		case errors.Is(err, repo.ErrAlreadyVoted), errors.Is(err, poll.ErrPollClosed):
+			return c.JSON(http.StatusConflict, err.Error())
+		}
+		if status, ok := auth.HTTPStatus(err); ok {
+			return c.JSON(status, err.Error())
+		}
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, p)
+}
@@ -0,0 +1,68 @@
+package poll_handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	poll_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/poll"
+	repoMock "github.com/AtIasShrugged/antisocial/internal/repository/poll/mocks"
+	"github.com/AtIasShrugged/antisocial/internal/service/poll"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// The viewer is whoever the request is authenticated as. A user_id in the
+// query must not reveal the tallies of a poll that user voted in.
+func TestGetByIDIgnoresClaimedViewer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := repoMock.NewMockPollRepository(ctrl)
+	handler := poll_handler.New(poll.New(repo, nil, log), log)
+
+	votes := 3
+	voted := models.Poll{
+		ID: 1, ExpiresAt: time.Now().Add(time.Hour), Voted: true, TotalVotes: &votes,
+		Options: []models.PollOption{{ID: 11, Text: "yes", Votes: &votes}},
+	}
+	unvoted := voted
+	unvoted.Voted = false
+
+	// Only user 6 voted; the anonymous request claims to be them.
+	repo.EXPECT().GetByID(gomock.Any(), 1, 0).Return(unvoted, nil).Times(1)
+	repo.EXPECT().GetByID(gomock.Any(), 1, 6).Return(voted, nil).Times(1)
+
+	get := func(ctx context.Context) models.Poll {
+		req := httptest.NewRequest(http.MethodGet, "/v1/polls/1?user_id=6", nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetPath("/v1/polls/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		require.NoError(t, handler.GetByID(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var p models.Poll
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+		return p
+	}
+
+	p := get(context.Background())
+	assert.Nil(t, p.TotalVotes)
+	assert.Nil(t, p.Options[0].Votes)
+
+	p = get(auth.WithPrincipal(context.Background(), auth.Principal{UserID: 6, Role: models.RoleUser}))
+	require.NotNil(t, p.TotalVotes)
+	assert.Equal(t, 3, *p.TotalVotes)
+}
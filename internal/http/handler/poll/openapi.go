@@ -0,0 +1,35 @@
+package poll_handler
+
+import (
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+)
+
+// Operations describes the routes of PollHandler.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/polls/:id", ID: "getPoll", Tags: []string{"polls"},
+		Summary:     "Get a poll",
+		Description: "Results are included once the authenticated viewer has voted or the poll has closed.",
+		Params:      []openapi.Param{openapi.PathParam("id", "Poll ID.")},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The poll.", models.Poll{}),
+			http.StatusBadRequest: openapi.Error("The ID is not a number."),
+			http.StatusNotFound:   openapi.Error("There is no such poll."),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/polls/:id/votes", ID: "votePoll", Tags: []string{"polls"},
+		Summary: "Vote in a poll",
+		Params:  []openapi.Param{openapi.PathParam("id", "Poll ID.")},
+		Body:    openapi.JSONBody(models.PollVote{}),
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The poll with its results.", models.Poll{}),
+			http.StatusBadRequest: openapi.Error("The vote is invalid."),
+			http.StatusNotFound:   openapi.Error("There is no such poll."),
+			http.StatusConflict:   openapi.Error("The voter voted already, or the poll is closed."),
+		},
+	},
+}
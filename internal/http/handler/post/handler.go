@@ -7,15 +7,26 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/AtIasShrugged/antisocial/internal/auth"
 	"github.com/AtIasShrugged/antisocial/internal/domain/models"
 	repo "github.com/AtIasShrugged/antisocial/internal/repository/post"
+	post_service "github.com/AtIasShrugged/antisocial/internal/service/post"
+	"github.com/AtIasShrugged/antisocial/internal/tracing"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
 	"github.com/labstack/echo/v4"
 )
 
 type PostService interface {
 	GetByID(ctx context.Context, id int) (models.Post, error)
 	Create(ctx context.Context, post models.Post) (int, error)
+	ListDrafts(ctx context.Context, authorID int) ([]models.Post, error)
+	GetDraft(ctx context.Context, id int, authorID int) (models.Post, error)
+	UpdateDraft(ctx context.Context, id int, authorID int, body string) error
+	Reschedule(ctx context.Context, id int, authorID int, publishAt time.Time) error
+	Cancel(ctx context.Context, id int, authorID int) error
+	Publish(ctx context.Context, id int, authorID int) error
 }
 
 type PostHandler struct {
@@ -32,21 +43,18 @@ func New(service PostService, log *slog.Logger) *PostHandler {
 
 func (p *PostHandler) GetByID(c echo.Context) error {
 	const op = "PostHandler.GetByID"
+	ctx, span := tracing.Start(c.Request().Context(), op)
+	defer span.End()
 
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		p.log.Error(op + ":" + err.Error())
+		sl.FromContext(ctx, p.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
 		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
 	}
 
-	post, err := p.service.GetByID(c.Request().Context(), id)
+	post, err := p.service.GetByID(ctx, id)
 	if err != nil {
-		if errors.Is(err, repo.ErrPostNotFound) {
-			p.log.Error(op + ":" + err.Error())
-			return c.String(http.StatusNotFound, err.Error())
-		}
-		p.log.Error(op + ":" + err.Error())
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return p.fail(ctx, c, op, err)
 	}
 
 	return c.JSON(http.StatusOK, post)
@@ -54,18 +62,195 @@ func (p *PostHandler) GetByID(c echo.Context) error {
 
 func (p *PostHandler) Create(c echo.Context) error {
 	const op = "PostHandler.Create"
+	ctx, span := tracing.Start(c.Request().Context(), op)
+	defer span.End()
 
 	var post models.Post
 	if err := c.Bind(&post); err != nil {
-		p.log.Error(op + ":" + err.Error())
+		sl.FromContext(ctx, p.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
 		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad json: %w", err).Error())
 	}
 
-	id, err := p.service.Create(c.Request().Context(), post)
+	id, err := p.service.Create(ctx, post)
 	if err != nil {
-		p.log.Error(op + ":" + err.Error())
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return p.fail(ctx, c, op, err)
 	}
 
 	return c.JSON(http.StatusOK, id)
 }
+
+type draftRequest struct {
+	AuthorID  int       `json:"author_id"`
+	Body      string    `json:"body"`
+	PublishAt time.Time `json:"publish_at"`
+}
+
+// ListDrafts returns the drafts and scheduled posts of the user in the path.
+func (p *PostHandler) ListDrafts(c echo.Context) error {
+	const op = "PostHandler.ListDrafts"
+	ctx, span := tracing.Start(c.Request().Context(), op)
+	defer span.End()
+
+	authorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, p.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	posts, err := p.service.ListDrafts(ctx, authorID)
+	if err != nil {
+		return p.fail(ctx, c, op, err)
+	}
+
+	return c.JSON(http.StatusOK, posts)
+}
+
+// GetDraft returns an unpublished post of the author given by the
+// "author_id" query parameter.
+func (p *PostHandler) GetDraft(c echo.Context) error {
+	const op = "PostHandler.GetDraft"
+	ctx, span := tracing.Start(c.Request().Context(), op)
+	defer span.End()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, p.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+	authorID, err := strconv.Atoi(c.QueryParam("author_id"))
+	if err != nil {
+		sl.FromContext(ctx, p.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	post, err := p.service.GetDraft(ctx, id, authorID)
+	if err != nil {
+		return p.fail(ctx, c, op, err)
+	}
+
+	return c.JSON(http.StatusOK, post)
+}
+
+func (p *PostHandler) UpdateDraft(c echo.Context) error {
+	const op = "PostHandler.UpdateDraft"
+	ctx, span := tracing.Start(c.Request().Context(), op)
+	defer span.End()
+
+	id, req, err := p.bindDraft(c)
+	if err != nil {
+		sl.FromContext(ctx, p.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	if err := p.service.UpdateDraft(ctx, id, req.AuthorID, req.Body); err != nil {
+		return p.fail(ctx, c, op, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (p *PostHandler) Reschedule(c echo.Context) error {
+	const op = "PostHandler.Reschedule"
+	ctx, span := tracing.Start(c.Request().Context(), op)
+	defer span.End()
+
+	id, req, err := p.bindDraft(c)
+	if err != nil {
+		sl.FromContext(ctx, p.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	if err := p.service.Reschedule(ctx, id, req.AuthorID, req.PublishAt); err != nil {
+		return p.fail(ctx, c, op, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Cancel turns a scheduled post back into a draft. The author is given by
+// the "author_id" query parameter.
+func (p *PostHandler) Cancel(c echo.Context) error {
+	const op = "PostHandler.Cancel"
+	ctx, span := tracing.Start(c.Request().Context(), op)
+	defer span.End()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, p.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+	authorID, err := strconv.Atoi(c.QueryParam("author_id"))
+	if err != nil {
+		sl.FromContext(ctx, p.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	if err := p.service.Cancel(ctx, id, authorID); err != nil {
+		return p.fail(ctx, c, op, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (p *PostHandler) Publish(c echo.Context) error {
+	const op = "PostHandler.Publish"
+	ctx, span := tracing.Start(c.Request().Context(), op)
+	defer span.End()
+
+	id, req, err := p.bindDraft(c)
+	if err != nil {
+		sl.FromContext(ctx, p.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	if err := p.service.Publish(ctx, id, req.AuthorID); err != nil {
+		return p.fail(ctx, c, op, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (p *PostHandler) bindDraft(c echo.Context) (int, draftRequest, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, draftRequest{}, fmt.Errorf("bad params: %w", err)
+	}
+
+	var req draftRequest
+	if err := c.Bind(&req); err != nil {
+		return 0, draftRequest{}, fmt.Errorf("bad json: %w", err)
+	}
+
+	return id, req, nil
+}
+
+// Status is the status errors of PostService are answered with, and
+// whether the error is one it knows. Posts that were published while being
+// edited as drafts are reported as not found: they are no longer drafts.
+// The gRPC API maps the same statuses to its codes.
+func Status(err error) (int, bool) {
+	if status, ok := auth.HTTPStatus(err); ok {
+		return status, true
+	}
+	switch {
+	case errors.Is(err, repo.ErrPostNotFound):
+		return http.StatusNotFound, true
+	case errors.Is(err, repo.ErrPostExpired), errors.Is(err, repo.ErrPostRemoved):
+		return http.StatusGone, true
+	case errors.Is(err, post_service.ErrBlocked):
+		return http.StatusUnprocessableEntity, true
+	}
+	return http.StatusBadRequest, false
+}
+
+func (p *PostHandler) fail(ctx context.Context, c echo.Context, op string, err error) error {
+	status, ok := Status(err)
+	if !ok {
+		sl.FromContext(ctx, p.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+	}
+	switch status {
+	case http.StatusNotFound, http.StatusGone, http.StatusUnprocessableEntity:
+		return c.String(status, err.Error())
+	}
+	return c.JSON(status, err.Error())
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/AtIasShrugged/antisocial/internal/auth"
 	"github.com/AtIasShrugged/antisocial/internal/domain/models"
 	post_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/post"
 	postRepo "github.com/AtIasShrugged/antisocial/internal/repository/post"
@@ -147,7 +148,6 @@ func TestCreate(t *testing.T) {
 	defer ctrl.Finish()
 
 	e := echo.New()
-	ctx := context.Background()
 	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 
 	repo := repoMock.NewMockPostRepository(ctrl)
@@ -156,13 +156,14 @@ func TestCreate(t *testing.T) {
 		Body:     "test",
 	}
 	postId := 1
-	repo.EXPECT().Create(ctx, postBody).Return(postId, nil).Times(1)
+	repo.EXPECT().Create(gomock.Any(), postBody).Return(postId, nil).Times(1)
 
 	service := post.New(repo, log)
 	handler := post_handler.New(service, log)
 
 	postJson := `{"author_id":1,"body":"test"}`
 	req := httptest.NewRequest(http.MethodPost, "/posts/create", strings.NewReader(postJson))
+	req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{UserID: 1, Role: models.RoleUser}))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 
@@ -204,7 +205,6 @@ func TestCreateRepoError(t *testing.T) {
 	defer ctrl.Finish()
 
 	e := echo.New()
-	ctx := context.Background()
 	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 
 	postBody := models.Post{
@@ -212,13 +212,14 @@ func TestCreateRepoError(t *testing.T) {
 		Body:     "test",
 	}
 	repo := repoMock.NewMockPostRepository(ctrl)
-	repo.EXPECT().Create(ctx, postBody).Return(0, fmt.Errorf("db is down")).Times(1)
+	repo.EXPECT().Create(gomock.Any(), postBody).Return(0, fmt.Errorf("db is down")).Times(1)
 
 	service := post.New(repo, log)
 	handler := post_handler.New(service, log)
 
 	postJson := `{"author_id":1,"body":"test"}`
 	req := httptest.NewRequest(http.MethodPost, "/posts/create", strings.NewReader(postJson))
+	req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{UserID: 1, Role: models.RoleUser}))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 
@@ -229,3 +230,51 @@ func TestCreateRepoError(t *testing.T) {
 		assert.Equal(t, `"db is down"`+"\n", rec.Body.String())
 	}
 }
+
+func TestGetByIDExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	ctx := context.Background()
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	reqID := 1
+	repo := repoMock.NewMockPostRepository(ctrl)
+	repo.EXPECT().GetByID(ctx, reqID).Return(models.Post{}, postRepo.ErrPostExpired).Times(1)
+
+	service := post.New(repo, log)
+	handler := post_handler.New(service, log)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+	c.SetPath("/posts/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(strconv.Itoa(reqID))
+	if assert.NoError(t, handler.GetByID(c)) {
+		assert.Equal(t, http.StatusGone, rec.Code)
+		assert.Equal(t, "post has expired", rec.Body.String())
+	}
+}
+
+func TestCreateAsAnotherUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	service := post.New(repoMock.NewMockPostRepository(ctrl), log)
+	handler := post_handler.New(service, log)
+
+	req := httptest.NewRequest(http.MethodPost, "/posts/create", strings.NewReader(`{"author_id":1,"body":"test"}`))
+	req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{UserID: 2, Role: models.RoleUser}))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	if assert.NoError(t, handler.Create(e.NewContext(req, rec))) {
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	}
+}
@@ -0,0 +1,70 @@
+package post_handler_test
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	post_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/post"
+	repoMock "github.com/AtIasShrugged/antisocial/internal/repository/post/mocks"
+	"github.com/AtIasShrugged/antisocial/internal/service/post"
+	"github.com/AtIasShrugged/antisocial/internal/tracing"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/mock/gomock"
+)
+
+// TestGetByIDSpanTree follows a request from the route down to the service.
+// The repository is a mock, so its span and the statement spans below it are
+// left to TestQueryTracer, which checks them against a real repository span.
+func TestGetByIDSpanTree(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	repo := repoMock.NewMockPostRepository(ctrl)
+	repo.EXPECT().GetByID(gomock.Any(), 1).Return(models.Post{ID: 1, AuthorID: 1, Body: "test"}, nil).Times(1)
+
+	e := echo.New()
+	e.Use(tracing.Middleware())
+	e.GET("/posts/:id", post_handler.New(post.New(repo, log), log).GetByID)
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Spans are exported as they end, innermost first.
+	spans := exporter.GetSpans()
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name
+	}
+	require.Equal(t, []string{
+		"PostService.GetByID",
+		"PostHandler.GetByID",
+		"GET /posts/:id",
+	}, names)
+
+	wantParent := trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7}
+	for i := len(spans) - 1; i >= 0; i-- {
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[i].SpanContext.TraceID().String())
+		require.Equal(t, wantParent, spans[i].Parent.SpanID(), spans[i].Name)
+		wantParent = spans[i].SpanContext.SpanID()
+	}
+}
@@ -0,0 +1,99 @@
+package post_handler
+
+import (
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+)
+
+var authorQuery = openapi.Param{Name: "author_id", In: "query", Description: "The author of the post.", Required: true, Type: 0}
+
+// Operations describes the routes of PostHandler.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/posts/:id", ID: "getPost", Tags: []string{"posts"},
+		Summary: "Get a published post",
+		Params:  []openapi.Param{openapi.PathParam("id", "Post ID.")},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The post.", models.Post{}),
+			http.StatusBadRequest: openapi.Error("The ID is not a number."),
+			http.StatusNotFound:   openapi.Error("There is no such post, or the caller may not see it."),
+			http.StatusGone:       openapi.Error("The post expired or was removed."),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/posts", ID: "createPost", Tags: []string{"posts"},
+		Summary:     "Create a post",
+		Description: "Publishes the post right away, or keeps it as a draft or scheduled post depending on its status. Returns the ID of the post.",
+		Body:        openapi.JSONBody(models.Post{}),
+		Responses: map[int]openapi.Response{
+			http.StatusOK:                  openapi.JSON("The ID of the new post.", 0),
+			http.StatusBadRequest:          openapi.Error("The post is invalid."),
+			http.StatusUnprocessableEntity: openapi.Error("The post contains blocked content."),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/users/:id/drafts", ID: "listDrafts", Tags: []string{"drafts"},
+		Summary: "List the drafts and scheduled posts of a user",
+		Params:  []openapi.Param{openapi.PathParam("id", "User ID.")},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The drafts and scheduled posts.", []models.Post{}),
+			http.StatusBadRequest: openapi.Error("The ID is not a number."),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/posts/:id/draft", ID: "getDraft", Tags: []string{"drafts"},
+		Summary: "Get a draft or scheduled post",
+		Params:  []openapi.Param{openapi.PathParam("id", "Post ID."), authorQuery},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The draft.", models.Post{}),
+			http.StatusBadRequest: openapi.Error("A parameter is not a number."),
+			http.StatusNotFound:   openapi.Error("There is no such draft."),
+		},
+	},
+	{
+		Method: http.MethodPatch, Path: "/posts/:id", ID: "updateDraft", Tags: []string{"drafts"},
+		Summary: "Replace the body of a draft or scheduled post",
+		Params:  []openapi.Param{openapi.PathParam("id", "Post ID.")},
+		Body:    openapi.JSONBody(draftRequest{}),
+		Responses: map[int]openapi.Response{
+			http.StatusNoContent:           openapi.NoContent("The draft was updated."),
+			http.StatusBadRequest:          openapi.Error("The request is invalid."),
+			http.StatusNotFound:            openapi.Error("There is no such draft."),
+			http.StatusUnprocessableEntity: openapi.Error("The body contains blocked content."),
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/posts/:id/schedule", ID: "reschedulePost", Tags: []string{"drafts"},
+		Summary: "Set or move the publish time of a draft or scheduled post",
+		Params:  []openapi.Param{openapi.PathParam("id", "Post ID.")},
+		Body:    openapi.JSONBody(draftRequest{}),
+		Responses: map[int]openapi.Response{
+			http.StatusNoContent:  openapi.NoContent("The post is scheduled."),
+			http.StatusBadRequest: openapi.Error("The request is invalid."),
+			http.StatusNotFound:   openapi.Error("There is no such draft."),
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/posts/:id/schedule", ID: "cancelPost", Tags: []string{"drafts"},
+		Summary: "Turn a scheduled post back into a draft",
+		Params:  []openapi.Param{openapi.PathParam("id", "Post ID."), authorQuery},
+		Responses: map[int]openapi.Response{
+			http.StatusNoContent:  openapi.NoContent("The post is a draft again."),
+			http.StatusBadRequest: openapi.Error("A parameter is not a number."),
+			http.StatusNotFound:   openapi.Error("There is no such draft, or it went out already."),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/posts/:id/publish", ID: "publishPost", Tags: []string{"drafts"},
+		Summary: "Publish a draft or scheduled post right away",
+		Params:  []openapi.Param{openapi.PathParam("id", "Post ID.")},
+		Body:    openapi.JSONBody(draftRequest{}),
+		Responses: map[int]openapi.Response{
+			http.StatusNoContent:  openapi.NoContent("The post is published."),
+			http.StatusBadRequest: openapi.Error("The request is invalid."),
+			http.StatusNotFound:   openapi.Error("There is no such draft."),
+		},
+	},
+}
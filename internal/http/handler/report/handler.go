@@ -0,0 +1,195 @@
+package report_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	repo "github.com/AtIasShrugged/antisocial/internal/repository/report"
+	"github.com/AtIasShrugged/antisocial/internal/service/moderation"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+)
+
+type ModerationService interface {
+	Report(ctx context.Context, report models.Report) (models.Report, error)
+	Get(ctx context.Context, id int) (models.Report, error)
+	List(ctx context.Context, filter models.ReportFilter) ([]models.Report, error)
+	Assign(ctx context.Context, id int, moderatorID *int) error
+	Act(ctx context.Context, action models.ModerationAction) (models.Report, error)
+}
+
+type ReportHandler struct {
+	service ModerationService
+	log     *slog.Logger
+}
+
+func New(service ModerationService, log *slog.Logger) *ReportHandler {
+	return &ReportHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// Create files a report against the post or account in the body.
+func (h *ReportHandler) Create(c echo.Context) error {
+	const op = "ReportHandler.Create"
+	ctx := c.Request().Context()
+
+	var report models.Report
+	if err := c.Bind(&report); err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad json: %w", err).Error())
+	}
+
+	report, err := h.service.Report(ctx, report)
+	if err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// List returns the moderation queue, filtered by the optional "status",
+// "reason", "target", "account_id", "assignee_id" and "unassigned" query
+// parameters and paged with "after" and "limit".
+func (h *ReportHandler) List(c echo.Context) error {
+	const op = "ReportHandler.List"
+	ctx := c.Request().Context()
+
+	filter := models.ReportFilter{
+		Status: models.ReportStatus(c.QueryParam("status")),
+		Reason: models.ReportReason(c.QueryParam("reason")),
+		Target: models.ReportTarget(c.QueryParam("target")),
+	}
+	err := echo.QueryParamsBinder(c).
+		Int("account_id", &filter.AccountID).
+		Int("assignee_id", &filter.AssigneeID).
+		Bool("unassigned", &filter.Unassigned).
+		Int("after", &filter.After).
+		Int("limit", &filter.Limit).
+		BindError()
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	reports, err := h.service.List(ctx, filter)
+	if err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.JSON(http.StatusOK, reports)
+}
+
+func (h *ReportHandler) GetByID(c echo.Context) error {
+	const op = "ReportHandler.GetByID"
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	report, err := h.service.Get(ctx, id)
+	if err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+type assignRequest struct {
+	ModeratorID int `json:"moderator_id"`
+}
+
+func (h *ReportHandler) Assign(c echo.Context) error {
+	const op = "ReportHandler.Assign"
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	var req assignRequest
+	if err := c.Bind(&req); err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad json: %w", err).Error())
+	}
+
+	if err := h.service.Assign(ctx, id, &req.ModeratorID); err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Unassign returns a report to the queue.
+func (h *ReportHandler) Unassign(c echo.Context) error {
+	const op = "ReportHandler.Unassign"
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	if err := h.service.Assign(ctx, id, nil); err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Act resolves a report with the action in the body and returns the report.
+// The moderator is the authenticated user.
+func (h *ReportHandler) Act(c echo.Context) error {
+	const op = "ReportHandler.Act"
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	var action models.ModerationAction
+	if err := c.Bind(&action); err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad json: %w", err).Error())
+	}
+	action.ReportID = id
+
+	report, err := h.service.Act(ctx, action)
+	if err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+func (h *ReportHandler) fail(ctx context.Context, c echo.Context, op string, err error) error {
+	if status, ok := auth.HTTPStatus(err); ok {
+		return c.JSON(status, err.Error())
+	}
+	switch {
+	case errors.Is(err, repo.ErrReportNotFound), errors.Is(err, repo.ErrTargetNotFound):
+		return c.JSON(http.StatusNotFound, err.Error())
+	case errors.Is(err, repo.ErrReportResolved), errors.Is(err, repo.ErrAlreadyReported):
+		return c.JSON(http.StatusConflict, err.Error())
+	case errors.Is(err, moderation.ErrInvalidReport), errors.Is(err, moderation.ErrInvalidAction),
+		errors.Is(err, moderation.ErrInvalidFilter):
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+	return c.JSON(http.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,86 @@
+package report_handler
+
+import (
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+)
+
+// Operations describes the routes of ReportHandler.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodPost, Path: "/reports", ID: "createReport", Tags: []string{"reports"},
+		Summary: "Report a post or an account",
+		Body:    openapi.JSONBody(models.Report{}),
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The report.", models.Report{}),
+			http.StatusBadRequest: openapi.Error("The report is invalid."),
+			http.StatusNotFound:   openapi.Error("The reported post or account doesn't exist."),
+			http.StatusConflict:   openapi.Error("The caller already reported it."),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/reports", ID: "listReports", Tags: []string{"moderation"},
+		Summary: "List the moderation queue",
+		Params: []openapi.Param{
+			openapi.Query("status", "Only reports in this status.", models.ReportStatus("")),
+			openapi.Query("reason", "Only reports for this reason.", models.ReportReason("")),
+			openapi.Query("target", "Only reports against this kind of target.", models.ReportTarget("")),
+			openapi.Query("account_id", "Only reports against this account.", 0),
+			openapi.Query("assignee_id", "Only reports assigned to this moderator.", 0),
+			openapi.Query("unassigned", "Only reports assigned to no one.", false),
+			openapi.Query("after", "Only reports after this ID.", 0),
+			openapi.Query("limit", "How many reports to return.", 0),
+		},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The reports.", []models.Report{}),
+			http.StatusBadRequest: openapi.Error("The filter is invalid."),
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/reports/:id", ID: "getReport", Tags: []string{"moderation"},
+		Summary: "Get a report with the actions taken on it",
+		Params:  []openapi.Param{openapi.PathParam("id", "Report ID.")},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The report.", models.Report{}),
+			http.StatusBadRequest: openapi.Error("The ID is not a number."),
+			http.StatusNotFound:   openapi.Error("There is no such report."),
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/admin/reports/:id/assignee", ID: "assignReport", Tags: []string{"moderation"},
+		Summary: "Assign a report to a moderator",
+		Params:  []openapi.Param{openapi.PathParam("id", "Report ID.")},
+		Body:    openapi.JSONBody(assignRequest{}),
+		Responses: map[int]openapi.Response{
+			http.StatusNoContent:  openapi.NoContent("The report is assigned."),
+			http.StatusBadRequest: openapi.Error("The request is invalid."),
+			http.StatusNotFound:   openapi.Error("There is no such report."),
+			http.StatusConflict:   openapi.Error("The report is resolved already."),
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/admin/reports/:id/assignee", ID: "unassignReport", Tags: []string{"moderation"},
+		Summary: "Return a report to the queue",
+		Params:  []openapi.Param{openapi.PathParam("id", "Report ID.")},
+		Responses: map[int]openapi.Response{
+			http.StatusNoContent:  openapi.NoContent("The report is back in the queue."),
+			http.StatusBadRequest: openapi.Error("The ID is not a number."),
+			http.StatusNotFound:   openapi.Error("There is no such report."),
+			http.StatusConflict:   openapi.Error("The report is resolved already."),
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/reports/:id/actions", ID: "actOnReport", Tags: []string{"moderation"},
+		Summary: "Resolve a report with an action",
+		Params:  []openapi.Param{openapi.PathParam("id", "Report ID.")},
+		Body:    openapi.JSONBody(models.ModerationAction{}),
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The resolved report.", models.Report{}),
+			http.StatusBadRequest: openapi.Error("The action is invalid."),
+			http.StatusNotFound:   openapi.Error("There is no such report."),
+			http.StatusConflict:   openapi.Error("The report is resolved already."),
+		},
+	},
+}
@@ -0,0 +1,102 @@
+package role_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/service/role"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+)
+
+type RoleService interface {
+	Get(ctx context.Context, userID int) (models.Role, error)
+	Set(ctx context.Context, userID int, role models.Role) error
+}
+
+type RoleHandler struct {
+	service RoleService
+	log     *slog.Logger
+}
+
+func New(service RoleService, log *slog.Logger) *RoleHandler {
+	return &RoleHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+type roleResponse struct {
+	UserID      int         `json:"user_id"`
+	Role        models.Role `json:"role"`
+	Permissions []string    `json:"permissions"`
+}
+
+type roleRequest struct {
+	Role models.Role `json:"role"`
+}
+
+// Get returns the role of the user in the path and what it grants.
+func (h *RoleHandler) Get(c echo.Context) error {
+	const op = "RoleHandler.Get"
+	ctx := c.Request().Context()
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	r, err := h.service.Get(ctx, userID)
+	if err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+
+	return c.JSON(http.StatusOK, roleResponse{UserID: userID, Role: r, Permissions: auth.Grants(r)})
+}
+
+// Set grants the role in the body to the user in the path.
+func (h *RoleHandler) Set(c echo.Context) error {
+	const op = "RoleHandler.Set"
+	ctx := c.Request().Context()
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad params: %w", err).Error())
+	}
+
+	var req roleRequest
+	if err := c.Bind(&req); err != nil {
+		sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+		return c.JSON(http.StatusBadRequest, fmt.Errorf("bad json: %w", err).Error())
+	}
+
+	if err := h.service.Set(ctx, userID, req.Role); err != nil {
+		return h.fail(ctx, c, op, err)
+	}
+	sl.FromContext(ctx, h.log).WarnContext(ctx, "role changed",
+		slog.Int("target_user_id", userID), slog.String("role", string(req.Role)))
+
+	return c.JSON(http.StatusOK, roleResponse{UserID: userID, Role: req.Role, Permissions: auth.Grants(req.Role)})
+}
+
+func (h *RoleHandler) fail(ctx context.Context, c echo.Context, op string, err error) error {
+	if status, ok := auth.HTTPStatus(err); ok {
+		return c.JSON(status, err.Error())
+	}
+	switch {
+	case errors.Is(err, role.ErrInvalidRole):
+		return c.JSON(http.StatusBadRequest, err.Error())
+	case errors.Is(err, role.ErrBootstrapAdmin), errors.Is(err, role.ErrOwnRole):
+		return c.JSON(http.StatusConflict, err.Error())
+	}
+	sl.FromContext(ctx, h.log).ErrorContext(ctx, "request failed", sl.Op(op), sl.Err(err))
+	return c.JSON(http.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,31 @@
+package role_handler
+
+import (
+	"net/http"
+
+	"github.com/AtIasShrugged/antisocial/internal/http/openapi"
+)
+
+// Operations describes the routes of RoleHandler.
+var Operations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/admin/users/:id/role", ID: "getRole", Tags: []string{"roles"},
+		Summary: "Get the role of a user and what it grants",
+		Params:  []openapi.Param{openapi.PathParam("id", "User ID.")},
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The role.", roleResponse{}),
+			http.StatusBadRequest: openapi.Error("The ID is not a number."),
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/admin/users/:id/role", ID: "setRole", Tags: []string{"roles"},
+		Summary: "Grant a role to a user",
+		Params:  []openapi.Param{openapi.PathParam("id", "User ID.")},
+		Body:    openapi.JSONBody(roleRequest{}),
+		Responses: map[int]openapi.Response{
+			http.StatusOK:         openapi.JSON("The new role.", roleResponse{}),
+			http.StatusBadRequest: openapi.Error("The role is invalid."),
+			http.StatusConflict:   openapi.Error("The role of bootstrap admins and of the caller can't change."),
+		},
+	},
+}
@@ -5,36 +5,258 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/AtIasShrugged/antisocial/internal/audit"
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/blobstore"
+	"github.com/AtIasShrugged/antisocial/internal/blobstore/local"
+	"github.com/AtIasShrugged/antisocial/internal/blobstore/s3"
 	"github.com/AtIasShrugged/antisocial/internal/config"
+	grpc_server "github.com/AtIasShrugged/antisocial/internal/grpc"
+	"github.com/AtIasShrugged/antisocial/internal/http/apiversion"
+	account_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/account"
+	attachment_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/attachment"
+	audit_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/audit"
+	blocklist_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/blocklist"
+	docs_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/docs"
+	follow_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/follow"
+	graphql_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/graphql"
+	health_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/health"
+	loglevel_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/loglevel"
+	notification_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/notification"
+	poll_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/poll"
 	post_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/post"
+	report_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/report"
+	role_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/role"
+	"github.com/AtIasShrugged/antisocial/internal/http/requestid"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/internal/ratelimit"
+	account_repo "github.com/AtIasShrugged/antisocial/internal/repository/account"
+	attachment_repo "github.com/AtIasShrugged/antisocial/internal/repository/attachment"
+	audit_repo "github.com/AtIasShrugged/antisocial/internal/repository/audit"
+	blocklist_repo "github.com/AtIasShrugged/antisocial/internal/repository/blocklist"
+	follow_repo "github.com/AtIasShrugged/antisocial/internal/repository/follow"
+	health_repo "github.com/AtIasShrugged/antisocial/internal/repository/health"
+	linkpreview_repo "github.com/AtIasShrugged/antisocial/internal/repository/linkpreview"
+	notification_repo "github.com/AtIasShrugged/antisocial/internal/repository/notification"
+	poll_repo "github.com/AtIasShrugged/antisocial/internal/repository/poll"
 	post_repo "github.com/AtIasShrugged/antisocial/internal/repository/post"
+	ratelimit_repo "github.com/AtIasShrugged/antisocial/internal/repository/ratelimit"
+	report_repo "github.com/AtIasShrugged/antisocial/internal/repository/report"
+	role_repo "github.com/AtIasShrugged/antisocial/internal/repository/role"
+	spam_repo "github.com/AtIasShrugged/antisocial/internal/repository/spam"
+	"github.com/AtIasShrugged/antisocial/internal/service/account"
+	"github.com/AtIasShrugged/antisocial/internal/service/attachment"
+	"github.com/AtIasShrugged/antisocial/internal/service/blocklist"
+	"github.com/AtIasShrugged/antisocial/internal/service/follow"
+	"github.com/AtIasShrugged/antisocial/internal/service/health"
+	"github.com/AtIasShrugged/antisocial/internal/service/linkpreview"
+	"github.com/AtIasShrugged/antisocial/internal/service/moderation"
+	"github.com/AtIasShrugged/antisocial/internal/service/notification"
+	"github.com/AtIasShrugged/antisocial/internal/service/poll"
 	"github.com/AtIasShrugged/antisocial/internal/service/post"
+	"github.com/AtIasShrugged/antisocial/internal/service/role"
+	"github.com/AtIasShrugged/antisocial/internal/service/spam"
+	"github.com/AtIasShrugged/antisocial/internal/tracing"
+	"github.com/AtIasShrugged/antisocial/internal/unfurl"
+	"github.com/AtIasShrugged/antisocial/libs/logger"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/AtIasShrugged/antisocial/migrations"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"google.golang.org/grpc"
 )
 
-func Router(ctx context.Context, log *slog.Logger, cfg *config.Config) (*echo.Echo, error) {
+// Router builds the HTTP API and, over the same services, the gRPC one.
+func Router(ctx context.Context, log *slog.Logger, cfg *config.Config, healthService *health.HealthService, levels *logger.Levels) (*echo.Echo, *grpc.Server, error) {
 	e := echo.New()
+	if cfg.Server.TrustProxyHeaders {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
+	e.Use(requestid.Middleware(log))
 	e.Use(middleware.Logger())
+	e.Use(tracing.Middleware())
+	e.Use(metrics.Middleware())
 	e.Use(middleware.Recover())
+	// Responses of versioned routes are shaped for their version; see
+	// apiversion.Shape.
+	e.JSONSerializer = apiversion.NewSerializer()
 
-	dsn := fmt.Sprintf("%s://%s:%s@%s:%s/%s?sslmode=disable", cfg.DB.Driver, cfg.DB.User, cfg.DB.Pass, cfg.DB.Host, cfg.DB.Port, cfg.DB.Name)
-	pool, err := pgxpool.New(ctx, dsn)
+	poolCfg, err := pgxpool.ParseConfig(cfg.DB.DSN())
+	if err != nil {
+		log.Error("Failed to parse DB config: "+err.Error(), sl.Err(err))
+		return nil, nil, err
+	}
+	poolCfg.ConnConfig.Tracer = tracing.QueryTracer{Log: log}
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
-		log.Error("Failed to open DB connection: "+err.Error(), err)
-		return nil, err
+		log.Error("Failed to open DB connection: "+err.Error(), sl.Err(err))
+		return nil, nil, err
 	}
 	log.Info(fmt.Sprintf("Connected to %s on port %s", cfg.DB.Driver, cfg.DB.Port))
+	if err := metrics.RegisterPool(pool); err != nil {
+		log.Error("Failed to register pool metrics: "+err.Error(), sl.Err(err))
+		return nil, nil, err
+	}
+
+	blobs, err := newBlobStore(cfg.Storage)
+	if err != nil {
+		log.Error("Failed to set up blob storage: "+err.Error(), sl.Err(err))
+		return nil, nil, err
+	}
+
+	latestMigration, err := migrations.Latest()
+	if err != nil {
+		log.Error("Failed to read migrations: "+err.Error(), sl.Err(err))
+		return nil, nil, err
+	}
+	healthRepo := health_repo.New(pool, log)
+	healthService.Add("database", health.DatabaseCheck(healthRepo))
+	healthService.Add("migrations", health.MigrationsCheck(healthRepo, latestMigration))
 
 	postRepo := post_repo.New(pool, log)
+	attachmentRepo := attachment_repo.New(pool, log)
+	linkPreviewRepo := linkpreview_repo.New(pool, log)
+	pollRepo := poll_repo.New(pool, log)
+	notificationRepo := notification_repo.New(pool, log)
+	reportRepo := report_repo.New(pool, log)
+
+	limitStore, err := newRateLimitStore(cfg.RateLimit, pool, log)
+	if err != nil {
+		log.Error("Failed to set up rate limits: "+err.Error(), sl.Err(err))
+		return nil, nil, err
+	}
+	limiter, err := ratelimit.New(limitStore, cfg.RateLimit, log)
+	if err != nil {
+		log.Error("Failed to set up rate limits: "+err.Error(), sl.Err(err))
+		return nil, nil, err
+	}
+	go limiter.RunSweeper(ctx, cfg.RateLimit.SweepInterval)
+
+	fetcher := unfurl.NewFetcher(unfurl.Config{
+		Timeout:      cfg.LinkPreview.FetchTimeout,
+		MaxBodySize:  cfg.LinkPreview.MaxBodySize,
+		MaxRedirects: cfg.LinkPreview.MaxRedirects,
+		UserAgent:    cfg.LinkPreview.UserAgent,
+	})
+	linkPreviewService := linkpreview.New(linkPreviewRepo, fetcher, cfg.LinkPreview, log)
+	go linkPreviewService.Run(ctx, cfg.LinkPreview.WorkerInterval)
+
+	spamService, err := spam.New(spam_repo.New(pool, log), cfg.Spam, log)
+	if err != nil {
+		log.Error("Failed to set up spam filter: "+err.Error(), sl.Err(err))
+		return nil, nil, err
+	}
 
-	postService := post.New(postRepo, log)
+	auditTrail := audit.New(audit_repo.New(pool, log), log)
+	blocklistService := blocklist.New(blocklist_repo.New(pool, log), auditTrail, log)
+	if err := blocklistService.Reload(ctx); err != nil {
+		log.Error("Failed to load blocklist: "+err.Error(), sl.Err(err))
+		return nil, nil, err
+	}
+	go blocklistService.RunReloader(ctx, cfg.Blocklist.ReloadInterval)
 
-	postHandler := post_handler.New(postService, log)
+	attachmentService := attachment.New(attachmentRepo, blobs, cfg.Media, log)
+	go attachmentService.RunGC(ctx, cfg.Media.GCInterval)
+	go attachmentService.RunProcessor(ctx, cfg.Media.ProcessInterval)
+	followService := follow.New(follow_repo.New(pool, log), log)
+	postOptions := []post.Option{
+		post.WithLinkPreviews(linkPreviewService),
+		post.WithAttachmentRemover(attachmentService),
+		post.WithFollowGraph(followService),
+		post.WithScreener(blocklistService),
+	}
+	if cfg.Spam.Enabled {
+		postOptions = append(postOptions, post.WithClassifier(spamService))
+	}
+	postService := post.New(postRepo, log, postOptions...)
+	go postService.RunScheduler(ctx, cfg.Posts.ScheduleInterval)
+	go postService.RunReaper(ctx, cfg.Posts.ReapInterval)
+	notificationService := notification.New(notificationRepo, log)
+	pollService := poll.New(pollRepo, notificationService, log)
+	go pollService.RunCloser(ctx, cfg.Polls.CloseInterval)
+	roleService := role.New(role_repo.New(pool, log), cfg.Auth.Admins, auditTrail, log)
+	moderationService := moderation.New(reportRepo, notificationService, auditTrail, roleService, log,
+		moderation.WithSpamTrainer(spamService),
+	)
+	accountService := account.New(account_repo.New(pool, log), notificationService, auditTrail, roleService, log)
+
+	spec, err := Spec()
+	if err != nil {
+		log.Error("Failed to describe the API: "+err.Error(), sl.Err(err))
+		return nil, nil, err
+	}
+	docsHandler, err := docs_handler.New(spec)
+	if err != nil {
+		log.Error("Failed to describe the API: "+err.Error(), sl.Err(err))
+		return nil, nil, err
+	}
 
-	e.GET("/posts/:id", postHandler.GetByID)
-	e.POST("/posts/create", postHandler.Create)
+	graphqlHandler, err := graphql_handler.New(postService, accountService, cfg.GraphQL, log)
+	if err != nil {
+		log.Error("Failed to build the GraphQL schema: "+err.Error(), sl.Err(err))
+		return nil, nil, err
+	}
 
-	return e, nil
+	tokens := auth.NewTokens(cfg.Auth.TokenSecret.Reveal())
+	e.Use(auth.Middleware(tokens, roleService, accountService, limiter, log))
+	e.Use(auth.Require(permissions, log))
+	e.Use(audit.Middleware())
+
+	mount(e, handlers{
+		health:       health_handler.New(healthService),
+		post:         post_handler.New(postService, log),
+		attachment:   attachment_handler.New(attachmentService, log),
+		poll:         poll_handler.New(pollService, log),
+		notification: notification_handler.New(notificationService, log),
+		report:       report_handler.New(moderationService, log),
+		role:         role_handler.New(roleService, log),
+		logLevel:     loglevel_handler.New(levels, cfg.Admin, auditTrail, log),
+		audit:        audit_handler.New(auditTrail, log),
+		account:      account_handler.New(accountService, log),
+		follow:       follow_handler.New(followService, log),
+		blocklist:    blocklist_handler.New(blocklistService, log),
+		docs:         docsHandler,
+		graphql:      graphqlHandler,
+	}, routeLimits{
+		read:        limiter.Middleware(ratelimit.Read),
+		write:       limiter.Middleware(ratelimit.Write),
+		uploadLimit: max(cfg.Media.MaxImageSize, cfg.Media.MaxVideoSize) + 1<<20,
+		deprecated:  deprecation(cfg.API.LegacyDeprecatedAt, cfg.API.LegacySunset),
+	})
+
+	grpcServer := grpc_server.New(cfg.GRPC, auth.UnaryInterceptor(tokens, roleService, accountService, limiter, log), limiter,
+		healthService, postService, accountService, followService, log)
+
+	return e, grpcServer, nil
+}
+
+func newRateLimitStore(cfg config.RateLimitConfig, pool *pgxpool.Pool, log *slog.Logger) (ratelimit.Store, error) {
+	switch cfg.Store {
+	case "memory":
+		return ratelimit.NewMemoryStore(), nil
+	case "postgres":
+		return ratelimit.NewPostgresStore(ratelimit_repo.New(pool, log)), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+}
+
+func newBlobStore(cfg config.StorageConfig) (blobstore.BlobStore, error) {
+	switch cfg.Driver {
+	case "local":
+		return local.New(cfg.Local.Root)
+	case "s3":
+		return s3.New(s3.Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey.Reveal(),
+		}, nil)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
 }
@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/http/apiversion"
+	account_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/account"
+	attachment_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/attachment"
+	audit_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/audit"
+	blocklist_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/blocklist"
+	docs_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/docs"
+	follow_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/follow"
+	graphql_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/graphql"
+	health_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/health"
+	loglevel_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/loglevel"
+	notification_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/notification"
+	poll_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/poll"
+	post_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/post"
+	report_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/report"
+	role_handler "github.com/AtIasShrugged/antisocial/internal/http/handler/role"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// handlers are what the routes dispatch to.
+type handlers struct {
+	health       *health_handler.HealthHandler
+	post         *post_handler.PostHandler
+	attachment   *attachment_handler.AttachmentHandler
+	poll         *poll_handler.PollHandler
+	notification *notification_handler.NotificationHandler
+	report       *report_handler.ReportHandler
+	role         *role_handler.RoleHandler
+	logLevel     *loglevel_handler.LogLevelHandler
+	audit        *audit_handler.AuditHandler
+	account      *account_handler.AccountHandler
+	follow       *follow_handler.FollowHandler
+	blocklist    *blocklist_handler.BlocklistHandler
+	docs         *docs_handler.DocsHandler
+	graphql      *graphql_handler.GraphQLHandler
+}
+
+// routeLimits are the per-route middlewares that depend on configuration.
+type routeLimits struct {
+	read, write echo.MiddlewareFunc
+	uploadLimit int64
+	// deprecated marks the legacy alias of the route template successor.
+	deprecated func(successor string) echo.MiddlewareFunc
+}
+
+// apiPrefix is where the current version of the API is served.
+const apiPrefix = "/v1"
+
+// legacyAliases maps routes of the API to the unversioned paths they were
+// first served at. Those still answer, as deprecated aliases, until the
+// sunset configured in config.APIConfig. Routes added since have no alias.
+var legacyAliases = map[string]string{
+	"GET /v1/posts/:id":             "/posts/:id",
+	"POST /v1/posts":                "/posts/create",
+	"GET /v1/posts/:id/draft":       "/posts/:id/draft",
+	"PATCH /v1/posts/:id":           "/posts/:id",
+	"PUT /v1/posts/:id/schedule":    "/posts/:id/schedule",
+	"DELETE /v1/posts/:id/schedule": "/posts/:id/schedule",
+	"POST /v1/posts/:id/publish":    "/posts/:id/publish",
+	"GET /v1/users/:id/drafts":      "/users/:id/drafts",
+
+	"POST /v1/attachments":    "/attachments",
+	"GET /v1/attachments/:id": "/attachments/:id",
+
+	"GET /v1/polls/:id":        "/polls/:id",
+	"POST /v1/polls/:id/votes": "/polls/:id/votes",
+
+	"GET /v1/users/:id/notifications": "/users/:id/notifications",
+
+	"POST /v1/reports": "/reports",
+
+	"PUT /v1/users/:id/following/:followee":    "/users/:id/following/:followee",
+	"DELETE /v1/users/:id/following/:followee": "/users/:id/following/:followee",
+	"GET /v1/users/:id/state":                  "/users/:id/state",
+	"POST /v1/users/:id/appeals":               "/users/:id/appeals",
+
+	"GET /v1/admin/log-level":               "/admin/log-level",
+	"PUT /v1/admin/log-level":               "/admin/log-level",
+	"PUT /v1/admin/log-level/:component":    "/admin/log-level/:component",
+	"DELETE /v1/admin/log-level/:component": "/admin/log-level/:component",
+	"GET /v1/admin/reports":                 "/admin/reports",
+	"GET /v1/admin/reports/:id":             "/admin/reports/:id",
+	"PUT /v1/admin/reports/:id/assignee":    "/admin/reports/:id/assignee",
+	"DELETE /v1/admin/reports/:id/assignee": "/admin/reports/:id/assignee",
+	"POST /v1/admin/reports/:id/actions":    "/admin/reports/:id/actions",
+	"GET /v1/admin/users/:id/role":          "/admin/users/:id/role",
+	"PUT /v1/admin/users/:id/role":          "/admin/users/:id/role",
+	"GET /v1/admin/audit-events":            "/admin/audit-events",
+	"PUT /v1/admin/users/:id/state":         "/admin/users/:id/state",
+	"GET /v1/admin/appeals":                 "/admin/appeals",
+	"GET /v1/admin/appeals/:id":             "/admin/appeals/:id",
+	"POST /v1/admin/appeals/:id/decision":   "/admin/appeals/:id/decision",
+	"GET /v1/admin/blocklist":               "/admin/blocklist",
+	"POST /v1/admin/blocklist":              "/admin/blocklist",
+	"DELETE /v1/admin/blocklist/:id":        "/admin/blocklist/:id",
+}
+
+// permissions is what each route requires, keyed by method and path. A
+// route missing here answers 403 to everyone; see auth.Require. Legacy
+// aliases require what their successors do.
+var permissions = withLegacyAliases(map[string]auth.Permission{
+	"GET /metrics": auth.Public,
+	"GET /healthz": auth.Public,
+	"GET /readyz":  auth.Public,
+
+	"GET /openapi.json": auth.Public,
+	"GET /docs":         auth.Public,
+
+	// GraphQL resolvers authorize through the services, as the routes
+	// they stand in for do.
+	"GET /graphql":  auth.Public,
+	"POST /graphql": auth.Public,
+
+	"GET /v1/posts/:id":             auth.Public,
+	"POST /v1/posts":                auth.PostsCreate,
+	"GET /v1/posts/:id/draft":       auth.PostsReadDrafts,
+	"PATCH /v1/posts/:id":           auth.PostsUpdate,
+	"PUT /v1/posts/:id/schedule":    auth.PostsUpdate,
+	"DELETE /v1/posts/:id/schedule": auth.PostsUpdate,
+	"POST /v1/posts/:id/publish":    auth.PostsUpdate,
+	"GET /v1/users/:id/drafts":      auth.PostsReadDrafts,
+
+	"POST /v1/attachments":    auth.AttachmentsCreate,
+	"GET /v1/attachments/:id": auth.Public,
+
+	"GET /v1/polls/:id":        auth.Public,
+	"POST /v1/polls/:id/votes": auth.PollsVote,
+
+	"GET /v1/users/:id/notifications": auth.NotificationsRead,
+
+	"POST /v1/reports": auth.ReportsCreate,
+
+	"PUT /v1/users/:id/following/:followee":    auth.FollowsManage,
+	"DELETE /v1/users/:id/following/:followee": auth.FollowsManage,
+	"GET /v1/users/:id/state":                  auth.AccountsRead,
+	"POST /v1/users/:id/appeals":               auth.AppealsCreate,
+
+	"GET /v1/admin/log-level":               auth.LogsManage,
+	"PUT /v1/admin/log-level":               auth.LogsManage,
+	"PUT /v1/admin/log-level/:component":    auth.LogsManage,
+	"DELETE /v1/admin/log-level/:component": auth.LogsManage,
+	"GET /v1/admin/reports":                 auth.ReportsRead,
+	"GET /v1/admin/reports/:id":             auth.ReportsRead,
+	"PUT /v1/admin/reports/:id/assignee":    auth.ReportsAssign,
+	"DELETE /v1/admin/reports/:id/assignee": auth.ReportsAssign,
+	"POST /v1/admin/reports/:id/actions":    auth.ReportsResolve,
+	"GET /v1/admin/users/:id/role":          auth.RolesRead,
+	"PUT /v1/admin/users/:id/role":          auth.RolesManage,
+	"GET /v1/admin/audit-events":            auth.AuditRead,
+	"PUT /v1/admin/users/:id/state":         auth.AccountsManage,
+	"GET /v1/admin/appeals":                 auth.AppealsReview,
+	"GET /v1/admin/appeals/:id":             auth.AppealsReview,
+	"POST /v1/admin/appeals/:id/decision":   auth.AppealsReview,
+	"GET /v1/admin/blocklist":               auth.BlocklistManage,
+	"POST /v1/admin/blocklist":              auth.BlocklistManage,
+	"DELETE /v1/admin/blocklist/:id":        auth.BlocklistManage,
+})
+
+func withLegacyAliases(perms map[string]auth.Permission) map[string]auth.Permission {
+	for route, alias := range legacyAliases {
+		method, _, _ := strings.Cut(route, " ")
+		perms[method+" "+alias] = perms[route]
+	}
+	return perms
+}
+
+// deprecation has legacy aliases announce their successors, and when they
+// stop being served.
+func deprecation(since, sunset time.Time) func(successor string) echo.MiddlewareFunc {
+	return func(successor string) echo.MiddlewareFunc {
+		return apiversion.Deprecated(successor, since, sunset)
+	}
+}
+
+// api mounts routes of the current version, and their legacy aliases.
+type api struct {
+	e          *echo.Echo
+	deprecated func(successor string) echo.MiddlewareFunc
+}
+
+func (a api) add(method, path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) {
+	route := apiPrefix + path
+	m = append([]echo.MiddlewareFunc{apiversion.Middleware(apiversion.V1)}, m...)
+	a.e.Add(method, route, h, m...)
+	if alias, ok := legacyAliases[method+" "+route]; ok {
+		a.e.Add(method, alias, h, append([]echo.MiddlewareFunc{a.deprecated(route)}, m...)...)
+	}
+}
+
+func (a api) GET(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) {
+	a.add(http.MethodGet, path, h, m...)
+}
+
+func (a api) POST(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) {
+	a.add(http.MethodPost, path, h, m...)
+}
+
+func (a api) PUT(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) {
+	a.add(http.MethodPut, path, h, m...)
+}
+
+func (a api) PATCH(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) {
+	a.add(http.MethodPatch, path, h, m...)
+}
+
+func (a api) DELETE(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) {
+	a.add(http.MethodDelete, path, h, m...)
+}
+
+func mount(e *echo.Echo, h handlers, limits routeLimits) {
+	read, write := limits.read, limits.write
+
+	e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+	e.GET("/healthz", h.health.Live)
+	e.GET("/readyz", h.health.Ready)
+
+	e.GET("/openapi.json", h.docs.Spec)
+	e.GET("/docs", h.docs.UI)
+
+	e.GET("/graphql", h.graphql.Serve, read)
+	e.POST("/graphql", h.graphql.Serve, read)
+
+	v1 := api{e: e, deprecated: limits.deprecated}
+
+	v1.GET("/posts/:id", h.post.GetByID, read)
+	v1.POST("/posts", h.post.Create, write)
+	v1.GET("/posts/:id/draft", h.post.GetDraft, read)
+	v1.PATCH("/posts/:id", h.post.UpdateDraft, write)
+	v1.PUT("/posts/:id/schedule", h.post.Reschedule, write)
+	v1.DELETE("/posts/:id/schedule", h.post.Cancel, write)
+	v1.POST("/posts/:id/publish", h.post.Publish, write)
+	v1.GET("/users/:id/drafts", h.post.ListDrafts, read)
+
+	v1.POST("/attachments", h.attachment.Upload, write, middleware.BodyLimit(fmt.Sprintf("%dB", limits.uploadLimit)))
+	v1.GET("/attachments/:id", h.attachment.GetByID, read)
+
+	v1.GET("/polls/:id", h.poll.GetByID, read)
+	v1.POST("/polls/:id/votes", h.poll.Vote, write)
+
+	v1.GET("/users/:id/notifications", h.notification.List, read)
+
+	v1.POST("/reports", h.report.Create, write)
+
+	v1.PUT("/users/:id/following/:followee", h.follow.Follow, write)
+	v1.DELETE("/users/:id/following/:followee", h.follow.Unfollow, write)
+	v1.GET("/users/:id/state", h.account.GetState, read)
+	v1.POST("/users/:id/appeals", h.account.Appeal, write)
+
+	// Everything under /admin needs a permission that plain users lack.
+	v1.GET("/admin/log-level", h.logLevel.Get)
+	v1.PUT("/admin/log-level", h.logLevel.SetGlobal)
+	v1.PUT("/admin/log-level/:component", h.logLevel.SetOverride)
+	v1.DELETE("/admin/log-level/:component", h.logLevel.RemoveOverride)
+
+	v1.GET("/admin/reports", h.report.List)
+	v1.GET("/admin/reports/:id", h.report.GetByID)
+	v1.PUT("/admin/reports/:id/assignee", h.report.Assign)
+	v1.DELETE("/admin/reports/:id/assignee", h.report.Unassign)
+	v1.POST("/admin/reports/:id/actions", h.report.Act)
+
+	v1.GET("/admin/users/:id/role", h.role.Get)
+	v1.PUT("/admin/users/:id/role", h.role.Set)
+	v1.PUT("/admin/users/:id/state", h.account.SetState)
+
+	v1.GET("/admin/appeals", h.account.ListAppeals)
+	v1.GET("/admin/appeals/:id", h.account.GetAppeal)
+	v1.POST("/admin/appeals/:id/decision", h.account.Decide)
+
+	v1.GET("/admin/blocklist", h.blocklist.List)
+	v1.POST("/admin/blocklist", h.blocklist.Create)
+	v1.DELETE("/admin/blocklist/:id", h.blocklist.Delete)
+
+	v1.GET("/admin/audit-events", h.audit.List)
+}
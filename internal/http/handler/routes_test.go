@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/libs/logger/handlers/slogdiscard"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// want is every route but the legacy aliases and the permission it must
+// require. Adding a route means adding it here too, deliberately.
+var want = map[string]auth.Permission{
+	"GET /metrics": auth.Public,
+	"GET /healthz": auth.Public,
+	"GET /readyz":  auth.Public,
+
+	"GET /openapi.json": auth.Public,
+	"GET /docs":         auth.Public,
+
+	"GET /graphql":  auth.Public,
+	"POST /graphql": auth.Public,
+
+	"GET /v1/posts/:id":             auth.Public,
+	"POST /v1/posts":                auth.PostsCreate,
+	"GET /v1/posts/:id/draft":       auth.PostsReadDrafts,
+	"PATCH /v1/posts/:id":           auth.PostsUpdate,
+	"PUT /v1/posts/:id/schedule":    auth.PostsUpdate,
+	"DELETE /v1/posts/:id/schedule": auth.PostsUpdate,
+	"POST /v1/posts/:id/publish":    auth.PostsUpdate,
+	"GET /v1/users/:id/drafts":      auth.PostsReadDrafts,
+
+	"POST /v1/attachments":    auth.AttachmentsCreate,
+	"GET /v1/attachments/:id": auth.Public,
+
+	"GET /v1/polls/:id":        auth.Public,
+	"POST /v1/polls/:id/votes": auth.PollsVote,
+
+	"GET /v1/users/:id/notifications": auth.NotificationsRead,
+
+	"POST /v1/reports": auth.ReportsCreate,
+
+	"PUT /v1/users/:id/following/:followee":    auth.FollowsManage,
+	"DELETE /v1/users/:id/following/:followee": auth.FollowsManage,
+	"GET /v1/users/:id/state":                  auth.AccountsRead,
+	"POST /v1/users/:id/appeals":               auth.AppealsCreate,
+
+	"GET /v1/admin/log-level":               auth.LogsManage,
+	"PUT /v1/admin/log-level":               auth.LogsManage,
+	"PUT /v1/admin/log-level/:component":    auth.LogsManage,
+	"DELETE /v1/admin/log-level/:component": auth.LogsManage,
+	"GET /v1/admin/reports":                 auth.ReportsRead,
+	"GET /v1/admin/reports/:id":             auth.ReportsRead,
+	"PUT /v1/admin/reports/:id/assignee":    auth.ReportsAssign,
+	"DELETE /v1/admin/reports/:id/assignee": auth.ReportsAssign,
+	"POST /v1/admin/reports/:id/actions":    auth.ReportsResolve,
+	"GET /v1/admin/users/:id/role":          auth.RolesRead,
+	"PUT /v1/admin/users/:id/role":          auth.RolesManage,
+	"GET /v1/admin/audit-events":            auth.AuditRead,
+	"PUT /v1/admin/users/:id/state":         auth.AccountsManage,
+	"GET /v1/admin/appeals":                 auth.AppealsReview,
+	"GET /v1/admin/appeals/:id":             auth.AppealsReview,
+	"POST /v1/admin/appeals/:id/decision":   auth.AppealsReview,
+	"GET /v1/admin/blocklist":               auth.BlocklistManage,
+	"POST /v1/admin/blocklist":              auth.BlocklistManage,
+	"DELETE /v1/admin/blocklist/:id":        auth.BlocklistManage,
+}
+
+func mounted(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	pass := func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	e := echo.New()
+	mount(e, handlers{}, routeLimits{
+		read: pass, write: pass, uploadLimit: 1 << 20,
+		deprecated: func(string) echo.MiddlewareFunc { return pass },
+	})
+	return e
+}
+
+// successors maps each legacy alias to the route it stands for.
+func successors() map[string]string {
+	s := make(map[string]string, len(legacyAliases))
+	for route, alias := range legacyAliases {
+		method, _, _ := strings.Cut(route, " ")
+		s[method+" "+alias] = route
+	}
+	return s
+}
+
+func TestEveryRouteHasItsPermission(t *testing.T) {
+	e := mounted(t)
+	aliases := successors()
+
+	got := make(map[string]auth.Permission)
+	gotAliases := make(map[string]bool)
+	for _, r := range e.Routes() {
+		if r.Method == echo.RouteNotFound {
+			continue
+		}
+		route := r.Method + " " + r.Path
+		perm, ok := permissions[route]
+		if !assert.True(t, ok, "%s has no permission", route) {
+			continue
+		}
+		if successor, ok := aliases[route]; ok {
+			assert.Equal(t, want[successor], perm, "%s requires other than %s", route, successor)
+			gotAliases[route] = true
+			continue
+		}
+		got[route] = perm
+	}
+	assert.Equal(t, want, got)
+	assert.Len(t, gotAliases, len(legacyAliases), "legacy aliases aren't all mounted")
+	assert.Len(t, permissions, len(want)+len(legacyAliases), "permissions lists routes that aren't mounted")
+}
+
+func TestAdminRoutesAreClosedToUsers(t *testing.T) {
+	for route, perm := range permissions {
+		_, path, _ := strings.Cut(route, " ")
+		if !strings.HasPrefix(strings.TrimPrefix(path, apiPrefix), "/admin/") {
+			continue
+		}
+		assert.NotEqual(t, auth.Public, perm, route)
+		assert.False(t, auth.Can(models.RoleUser, perm), "users may call %s", route)
+	}
+}
+
+func TestUnlistedRouteIsClosed(t *testing.T) {
+	e := echo.New()
+	e.Use(auth.Require(permissions, slogdiscard.NewDiscardLogger()))
+	e.GET("/secret", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/secret", nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{UserID: 1, Role: models.RoleAdmin}))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,185 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// CheckResponse reports how a response the API sent differs from what d
+// describes: a status the operation doesn't list, a body where none is
+// described, or a body that doesn't fit the schema. It is how tests keep
+// the document honest.
+func (d *Document) CheckResponse(method, echoPath string, status int, contentType string, body []byte) error {
+	op, ok := d.Paths[Path(echoPath)][strings.ToLower(method)]
+	if !ok {
+		return fmt.Errorf("%s %s is not described", method, echoPath)
+	}
+	r, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		return fmt.Errorf("%s %s: status %d is not described", method, echoPath, status)
+	}
+	if len(r.Content) == 0 {
+		if len(body) > 0 {
+			return fmt.Errorf("%s %s: status %d has a body but none is described", method, echoPath, status)
+		}
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%s %s: bad content type %q: %w", method, echoPath, contentType, err)
+	}
+	mt, ok := matchContent(r.Content, mediaType)
+	if !ok {
+		return fmt.Errorf("%s %s: status %d is not described as %s", method, echoPath, status, mediaType)
+	}
+
+	var v any
+	switch mediaType {
+	case "application/json":
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return fmt.Errorf("%s %s: bad json: %w", method, echoPath, err)
+		}
+	case "text/plain":
+		v = string(body)
+	default:
+		return nil
+	}
+	if err := d.Check(mt.Schema, v, "body"); err != nil {
+		return fmt.Errorf("%s %s: status %d: %w", method, echoPath, status, err)
+	}
+	return nil
+}
+
+// matchContent finds the media type of content covering mediaType, which
+// may be described by a range such as "image/*".
+func matchContent(content map[string]MediaType, mediaType string) (MediaType, bool) {
+	if mt, ok := content[mediaType]; ok {
+		return mt, true
+	}
+	major, _, _ := strings.Cut(mediaType, "/")
+	if mt, ok := content[major+"/*"]; ok {
+		return mt, true
+	}
+	mt, ok := content["*/*"]
+	return mt, ok
+}
+
+// Check reports where v, as decoded from JSON with numbers kept as
+// json.Number, doesn't fit s.
+func (d *Document) Check(s *Schema, v any, at string) error {
+	if s.Ref != "" {
+		ref, ok := d.Components.Schemas[strings.TrimPrefix(s.Ref, refPrefix)]
+		if !ok {
+			return fmt.Errorf("%s: unknown schema %s", at, s.Ref)
+		}
+		return d.Check(ref, v, at)
+	}
+
+	if len(s.AnyOf) > 0 {
+		errs := make([]string, 0, len(s.AnyOf))
+		for _, alt := range s.AnyOf {
+			err := d.Check(alt, v, at)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return fmt.Errorf("%s: fits none of the alternatives: %s", at, strings.Join(errs, "; "))
+	}
+
+	if types := typesOf(s); len(types) > 0 {
+		got := jsonType(v)
+		fits := false
+		for _, t := range types {
+			fits = fits || t == got || (t == "number" && got == "integer")
+		}
+		if !fits {
+			return fmt.Errorf("%s: got %s, want %s", at, got, strings.Join(types, " or "))
+		}
+	}
+
+	if len(s.Enum) > 0 && v != nil {
+		fits := false
+		for _, e := range s.Enum {
+			fits = fits || fmt.Sprint(e) == fmt.Sprint(v)
+		}
+		if !fits {
+			return fmt.Errorf("%s: %v is not one of %v", at, v, s.Enum)
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: %s is missing", at, name)
+			}
+		}
+		for name, value := range v {
+			fs, ok := s.Properties[name]
+			if !ok {
+				switch extra := s.AdditionalProperties.(type) {
+				case bool:
+					if !extra {
+						return fmt.Errorf("%s: %s is not described", at, name)
+					}
+					continue
+				case *Schema:
+					fs = extra
+				default:
+					continue
+				}
+			}
+			if err := d.Check(fs, value, at+"."+name); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				if err := d.Check(s.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func typesOf(s *Schema) []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	}
+	return nil
+}
+
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
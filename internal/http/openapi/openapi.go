@@ -0,0 +1,327 @@
+// Package openapi describes the HTTP API as an OpenAPI 3.1 document. Handler
+// packages list their operations with Go values standing in for request and
+// response bodies, and Build derives the schemas from those types, so the
+// description follows the models as they change.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI version of the documents built here.
+const Version = "3.1.0"
+
+// bearerScheme is the name of the security scheme of authenticated routes.
+const bearerScheme = "bearerAuth"
+
+// Operation describes a route. Bodies are given as example values of the
+// type that goes over the wire, such as models.Post{}.
+type Operation struct {
+	Method string
+	// Path is the Echo path, e.g. "/posts/:id".
+	Path        string
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+	Params      []Param
+	Body        *Body
+	Responses   map[int]Response
+	// Authenticated routes need a bearer token, and may answer 401 and 403
+	// on top of their own responses.
+	Authenticated bool
+	Deprecated    bool
+}
+
+// Route is the key of the operation in the route tables, e.g.
+// "GET /posts/:id".
+func (o Operation) Route() string {
+	return o.Method + " " + o.Path
+}
+
+type Param struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	// Type is an example value of the parameter, e.g. 0 for an integer.
+	Type any
+}
+
+// PathParam is the integer ID in the path segment name.
+func PathParam(name, description string) Param {
+	return Param{Name: name, In: "path", Description: description, Required: true, Type: 0}
+}
+
+// Query is an optional query parameter of the type of example.
+func Query(name, description string, example any) Param {
+	return Param{Name: name, In: "query", Description: description, Type: example}
+}
+
+type Body struct {
+	ContentType string
+	Type        any
+	// Schema is used as is instead of deriving one from Type.
+	Schema *Schema
+}
+
+// JSONBody is a JSON request body of the type of example.
+func JSONBody(example any) *Body {
+	return &Body{ContentType: "application/json", Type: example}
+}
+
+type Response struct {
+	Description string
+	// ContentType is empty for responses without a body.
+	ContentType string
+	Type        any
+	Schema      *Schema
+}
+
+// JSON is a JSON response of the type of example.
+func JSON(description string, example any) Response {
+	return Response{Description: description, ContentType: "application/json", Type: example}
+}
+
+// NoContent is a response without a body.
+func NoContent(description string) Response {
+	return Response{Description: description}
+}
+
+// Binary is a response of raw bytes of contentType.
+func Binary(description, contentType string) Response {
+	return Response{Description: description, ContentType: contentType, Schema: &Schema{}}
+}
+
+// Text is a plain text response.
+func Text(description string) Response {
+	return Response{Description: description, ContentType: "text/plain", Schema: &Schema{Type: "string"}}
+}
+
+// Error is an error response. Errors are a bare string explaining what went
+// wrong, sent as JSON or as plain text.
+func Error(description string) Response {
+	return Response{Description: description, ContentType: errorContent}
+}
+
+// errorContent marks error responses, which Build expands to both of the
+// content types errors come in.
+const errorContent = "error"
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       Info                                   `json:"info"`
+	Paths      map[string]map[string]*OperationObject `json:"paths"`
+	Components Components                             `json:"components"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type OperationObject struct {
+	OperationID string                    `json:"operationId"`
+	Summary     string                    `json:"summary,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Tags        []string                  `json:"tags,omitempty"`
+	Parameters  []ParameterObject         `json:"parameters,omitempty"`
+	RequestBody *RequestBodyObject        `json:"requestBody,omitempty"`
+	Responses   map[string]ResponseObject `json:"responses"`
+	Security    []map[string][]string     `json:"security,omitempty"`
+	Deprecated  bool                      `json:"deprecated,omitempty"`
+}
+
+type ParameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBodyObject struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type ResponseObject struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+var pathParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// Build describes ops. It fails on operations that contradict themselves or
+// each other, such as two with the same ID or a path parameter that isn't
+// described, and on types it can't describe.
+func Build(info Info, ops []Operation, enums ...Enum) (*Document, error) {
+	g := newGenerator(enums)
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]map[string]*OperationObject),
+		Components: Components{
+			Schemas: g.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerScheme: {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+	g.schemas["Error"] = &Schema{Type: "string", Description: "What went wrong."}
+
+	ids := make(map[string]string)
+	for _, op := range ops {
+		if op.ID == "" {
+			return nil, fmt.Errorf("%s: operation has no ID", op.Route())
+		}
+		if route, ok := ids[op.ID]; ok {
+			return nil, fmt.Errorf("%s: operation ID %q is taken by %s", op.Route(), op.ID, route)
+		}
+		ids[op.ID] = op.Route()
+
+		o, err := g.operation(op)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op.Route(), err)
+		}
+		p := Path(op.Path)
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = make(map[string]*OperationObject)
+		}
+		method := strings.ToLower(op.Method)
+		if _, ok := doc.Paths[p][method]; ok {
+			return nil, fmt.Errorf("%s: described twice", op.Route())
+		}
+		doc.Paths[p][method] = o
+	}
+
+	return doc, nil
+}
+
+// Path turns an Echo path into an OpenAPI one: "/posts/:id" becomes
+// "/posts/{id}".
+func Path(echoPath string) string {
+	return pathParam.ReplaceAllString(echoPath, "{$1}")
+}
+
+func (g *generator) operation(op Operation) (*OperationObject, error) {
+	o := &OperationObject{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   make(map[string]ResponseObject),
+		Deprecated:  op.Deprecated,
+	}
+
+	described := make(map[string]bool)
+	for _, p := range op.Params {
+		s, err := g.of(p.Type)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", p.Name, err)
+		}
+		o.Parameters = append(o.Parameters, ParameterObject{
+			Name:        p.Name,
+			In:          p.In,
+			Description: p.Description,
+			Required:    p.Required,
+			Schema:      s,
+		})
+		if p.In == "path" {
+			described[p.Name] = true
+		}
+	}
+	for _, m := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+		if !described[m[1]] {
+			return nil, fmt.Errorf("path parameter %s is not described", m[1])
+		}
+		delete(described, m[1])
+	}
+	for name := range described {
+		return nil, fmt.Errorf("path parameter %s is not in the path", name)
+	}
+
+	if op.Body != nil {
+		s := op.Body.Schema
+		if s == nil {
+			var err error
+			if s, err = g.of(op.Body.Type); err != nil {
+				return nil, fmt.Errorf("request body: %w", err)
+			}
+		}
+		o.RequestBody = &RequestBodyObject{
+			Required: true,
+			Content:  map[string]MediaType{op.Body.ContentType: {Schema: s}},
+		}
+	}
+
+	responses := make(map[int]Response, len(op.Responses)+2)
+	for status, r := range op.Responses {
+		responses[status] = r
+	}
+	if op.Authenticated {
+		o.Security = []map[string][]string{{bearerScheme: {}}}
+		if _, ok := responses[http.StatusUnauthorized]; !ok {
+			responses[http.StatusUnauthorized] = Error("The request carries no valid bearer token.")
+		}
+		if _, ok := responses[http.StatusForbidden]; !ok {
+			responses[http.StatusForbidden] = Error("The caller lacks the permission.")
+		}
+	}
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("no responses")
+	}
+
+	statuses := make([]int, 0, len(responses))
+	for status := range responses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		r := responses[status]
+		ro := ResponseObject{Description: r.Description}
+		switch r.ContentType {
+		case "":
+		case errorContent:
+			ref := &Schema{Ref: refPrefix + "Error"}
+			ro.Content = map[string]MediaType{
+				"application/json": {Schema: ref},
+				"text/plain":       {Schema: ref},
+			}
+		default:
+			s := r.Schema
+			if s == nil {
+				var err error
+				if s, err = g.of(r.Type); err != nil {
+					return nil, fmt.Errorf("response %d: %w", status, err)
+				}
+			}
+			ro.Content = map[string]MediaType{r.ContentType: {Schema: s}}
+		}
+		o.Responses[strconv.Itoa(status)] = ro
+	}
+
+	return o, nil
+}
@@ -0,0 +1,117 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type color string
+
+type base struct {
+	ID      int       `json:"id"`
+	Created time.Time `json:"created_at"`
+}
+
+type widget struct {
+	base
+	ID       string            `json:"widget_id"`
+	Name     string            `json:"name"`
+	Color    color             `json:"color,omitempty"`
+	Parts    []part            `json:"parts"`
+	Parent   *widget           `json:"parent,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Secret   string            `json:"-"`
+	Checksum []byte            `json:"checksum,omitempty"`
+	internal int
+}
+
+type part struct {
+	Count int `json:"count"`
+}
+
+func build(t *testing.T, ops ...Operation) *Document {
+	t.Helper()
+	doc, err := Build(Info{Title: "test", Version: "1"}, ops, EnumOf[color]("red", "blue"))
+	require.NoError(t, err)
+	return doc
+}
+
+var getWidget = Operation{
+	Method: http.MethodGet, Path: "/widgets/:id", ID: "getWidget",
+	Params: []Param{PathParam("id", "Widget ID")},
+	Responses: map[int]Response{
+		http.StatusOK:       JSON("The widget.", widget{}),
+		http.StatusNotFound: Error("No such widget."),
+	},
+	Authenticated: true,
+}
+
+func TestBuild(t *testing.T) {
+	doc := build(t, getWidget)
+
+	op := doc.Paths["/widgets/{id}"]["get"]
+	require.NotNil(t, op)
+	require.Equal(t, []map[string][]string{{bearerScheme: {}}}, op.Security)
+	require.Contains(t, op.Responses, "401")
+	require.Contains(t, op.Responses, "403")
+	require.Equal(t, &Schema{Ref: refPrefix + "Widget"}, op.Responses["200"].Content["application/json"].Schema)
+
+	w := doc.Components.Schemas["Widget"]
+	require.Equal(t, []string{"widget_id", "name", "parts", "created_at", "id"}, w.Required)
+	require.Equal(t, &Schema{Type: "string", Enum: []any{color("red"), color("blue")}}, w.Properties["color"])
+	require.Equal(t, &Schema{Type: []string{"array", "null"}, Items: &Schema{Ref: refPrefix + "Part"}}, w.Properties["parts"])
+	require.Equal(t, &Schema{Ref: refPrefix + "Widget"}, w.Properties["parent"])
+	require.Equal(t, &Schema{Type: "string", Format: "date-time"}, w.Properties["created_at"])
+	require.NotContains(t, w.Properties, "Secret")
+	require.NotContains(t, w.Properties, "internal")
+
+	_, err := json.Marshal(doc)
+	require.NoError(t, err)
+}
+
+func TestBuildRejectsInconsistentOperations(t *testing.T) {
+	info := Info{Title: "test", Version: "1"}
+
+	undescribed := getWidget
+	undescribed.Params = nil
+	_, err := Build(info, []Operation{undescribed})
+	require.ErrorContains(t, err, "path parameter id is not described")
+
+	_, err = Build(info, []Operation{getWidget, {Method: http.MethodDelete, Path: "/widgets/:id", ID: "getWidget"}})
+	require.ErrorContains(t, err, "taken")
+
+	_, err = Build(info, []Operation{{Method: http.MethodGet, Path: "/x", ID: "x", Responses: map[int]Response{
+		http.StatusOK: JSON("", struct{ A int }{}),
+	}}})
+	require.ErrorContains(t, err, "anonymous")
+}
+
+func TestCheckResponse(t *testing.T) {
+	doc := build(t, getWidget)
+	check := func(status int, contentType, body string) error {
+		return doc.CheckResponse(http.MethodGet, "/widgets/:id", status, contentType, []byte(body))
+	}
+
+	ok := `{"id": 1, "created_at": "2024-01-01T00:00:00Z", "widget_id": "w", "name": "n", "parts": null}`
+	require.NoError(t, check(http.StatusOK, "application/json; charset=UTF-8", ok))
+	require.NoError(t, check(http.StatusOK, "application/json",
+		`{"id": 1, "created_at": "", "widget_id": "w", "name": "n", "color": "red", "parts": [{"count": 2}],
+		  "parent": {"id": 2, "created_at": "", "widget_id": "v", "name": "m", "parts": []}}`))
+	require.NoError(t, check(http.StatusNotFound, "text/plain; charset=UTF-8", "widget not found"))
+	require.NoError(t, check(http.StatusNotFound, "application/json", `"widget not found"`))
+
+	require.ErrorContains(t, check(http.StatusConflict, "application/json", `"conflict"`), "status 409 is not described")
+	require.ErrorContains(t, check(http.StatusOK, "application/json", `{"id": 1}`), "is missing")
+	require.ErrorContains(t, check(http.StatusOK, "application/json",
+		`{"id": 1, "created_at": "", "widget_id": "w", "name": "n", "parts": null, "extra": true}`), "extra is not described")
+	require.ErrorContains(t, check(http.StatusOK, "application/json",
+		`{"id": "1", "created_at": "", "widget_id": "w", "name": "n", "parts": null}`), "body.id: got string, want integer")
+	require.ErrorContains(t, check(http.StatusOK, "application/json",
+		`{"id": 1, "created_at": "", "widget_id": "w", "name": "n", "color": "green", "parts": null}`), "not one of")
+	require.ErrorContains(t, check(http.StatusOK, "text/html", "<p>"), "not described as text/html")
+	require.ErrorContains(t, doc.CheckResponse(http.MethodPost, "/widgets", http.StatusOK, "", nil), "not described")
+}
@@ -0,0 +1,314 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Schema is a JSON Schema as OpenAPI 3.1 uses it, limited to what Go values
+// encode to.
+type Schema struct {
+	Ref string `json:"$ref,omitempty"`
+	// Type is a type name, or a list of them for values that may be null.
+	Type            any                `json:"type,omitempty"`
+	Format          string             `json:"format,omitempty"`
+	ContentEncoding string             `json:"contentEncoding,omitempty"`
+	Description     string             `json:"description,omitempty"`
+	Enum            []any              `json:"enum,omitempty"`
+	Properties      map[string]*Schema `json:"properties,omitempty"`
+	Required        []string           `json:"required,omitempty"`
+	// AdditionalProperties is false for structs, whose fields are all
+	// known, and the schema of the values for maps.
+	AdditionalProperties any       `json:"additionalProperties,omitempty"`
+	Items                *Schema   `json:"items,omitempty"`
+	AnyOf                []*Schema `json:"anyOf,omitempty"`
+}
+
+const refPrefix = "#/components/schemas/"
+
+// Enum lists the values a type may take, for types such as
+// models.PostStatus whose values are constants.
+type Enum struct {
+	Type   reflect.Type
+	Values []any
+}
+
+// EnumOf lists values as those of their type.
+func EnumOf[T any](values ...T) Enum {
+	e := Enum{Type: reflect.TypeOf((*T)(nil)).Elem()}
+	for _, v := range values {
+		e.Values = append(e.Values, v)
+	}
+	return e
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+	jsonMarshaler  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler  = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// generator derives schemas from Go types the way encoding/json encodes
+// them. Structs become components, referred to by name.
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+	enums   map[reflect.Type][]any
+}
+
+func newGenerator(enums []Enum) *generator {
+	g := &generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+		enums:   make(map[reflect.Type][]any),
+	}
+	for _, e := range enums {
+		g.enums[e.Type] = e.Values
+	}
+	return g
+}
+
+// of returns the schema of the type of example; nil stands for any value.
+// Like fields without omitempty, a nil slice, map or pointer is sent as
+// null, as a list handler does when there is nothing to list.
+func (g *generator) of(example any) (*Schema, error) {
+	if example == nil {
+		return &Schema{}, nil
+	}
+	t := reflect.TypeOf(example)
+	s, err := g.schema(t)
+	if err != nil {
+		return nil, err
+	}
+	if nullable(t) {
+		s = orNull(s)
+	}
+	return s, nil
+}
+
+func (g *generator) schema(t reflect.Type) (*Schema, error) {
+	s, err := g.plain(t)
+	if err != nil {
+		return nil, err
+	}
+	if values, ok := g.enums[t]; ok {
+		s.Enum = values
+	}
+	return s, nil
+}
+
+// plain is the schema of t regardless of the values it may take.
+func (g *generator) plain(t reflect.Type) (*Schema, error) {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	case t == durationType:
+		return &Schema{Type: "integer", Description: "Duration in nanoseconds."}, nil
+	case t == rawMessageType:
+		return &Schema{}, nil
+	case t.Implements(textMarshaler) || reflect.PointerTo(t).Implements(textMarshaler):
+		return &Schema{Type: "string"}, nil
+	case t.Implements(jsonMarshaler) || reflect.PointerTo(t).Implements(jsonMarshaler):
+		return &Schema{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", ContentEncoding: "base64"}, nil
+		}
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return g.component(t)
+	}
+	return nil, fmt.Errorf("can't describe %s", t)
+}
+
+// component registers the schema of struct t and refers to it.
+func (g *generator) component(t reflect.Type) (*Schema, error) {
+	if name, ok := g.names[t]; ok {
+		return &Schema{Ref: refPrefix + name}, nil
+	}
+	name, err := g.name(t)
+	if err != nil {
+		return nil, err
+	}
+	// Register before describing the fields so that recursive types end.
+	g.names[t] = name
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: false}
+	g.schemas[name] = s
+
+	if err := g.fields(t, s); err != nil {
+		return nil, err
+	}
+	return &Schema{Ref: refPrefix + name}, nil
+}
+
+// name picks the component name of t: its own, capitalized, or prefixed
+// with its package where that is taken.
+func (g *generator) name(t reflect.Type) (string, error) {
+	if t.Name() == "" {
+		return "", fmt.Errorf("can't name %s: anonymous structs can't be components", t)
+	}
+	name := capitalize(t.Name())
+	if _, taken := g.schemas[name]; !taken {
+		return name, nil
+	}
+	pkg := strings.TrimSuffix(path.Base(t.PkgPath()), "_handler")
+	prefixed := capitalize(pkg) + name
+	if _, taken := g.schemas[prefixed]; taken {
+		return "", fmt.Errorf("can't name %s: %s is taken", t, prefixed)
+	}
+	return prefixed, nil
+}
+
+// fields adds the fields of struct t to s, and then those of its embedded
+// structs, which encoding/json promotes unless a field of t shadows them.
+func (g *generator) fields(t reflect.Type, s *Schema) error {
+	embedded := make([]reflect.Type, 0)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		omitempty := hasOption(opts, "omitempty")
+		var fs *Schema
+		var err error
+		if hasOption(opts, "string") {
+			fs = &Schema{Type: "string"}
+		} else if fs, err = g.schema(ft); err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+		}
+		if !omitempty && nullable(ft) {
+			fs = orNull(fs)
+		}
+		g.property(s, name, fs, !omitempty)
+	}
+
+	for _, et := range embedded {
+		promoted := &Schema{Properties: make(map[string]*Schema)}
+		if err := g.fields(et, promoted); err != nil {
+			return err
+		}
+		required := make(map[string]bool)
+		for _, name := range promoted.Required {
+			required[name] = true
+		}
+		names := make([]string, 0, len(promoted.Properties))
+		for name := range promoted.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			g.property(s, name, promoted.Properties[name], required[name])
+		}
+	}
+	return nil
+}
+
+// property adds a field to s unless one of that name is already there.
+func (g *generator) property(s *Schema, name string, fs *Schema, required bool) {
+	if _, ok := s.Properties[name]; ok {
+		return
+	}
+	s.Properties[name] = fs
+	if required {
+		s.Required = append(s.Required, name)
+	}
+}
+
+func hasOption(opts, option string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// nullable reports whether the zero value of t encodes to null.
+func nullable(t reflect.Type) bool {
+	if t == rawMessageType {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// orNull widens s to accept null as well.
+func orNull(s *Schema) *Schema {
+	switch typ := s.Type.(type) {
+	case string:
+		s.Type = []string{typ, "null"}
+		return s
+	case nil:
+		if s.Ref == "" && s.AnyOf == nil {
+			// Anything already includes null.
+			return s
+		}
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
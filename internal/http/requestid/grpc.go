@@ -0,0 +1,38 @@
+package requestid
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryInterceptor is Middleware for gRPC: the ID comes from and goes back
+// in the "x-request-id" metadata, and the logger carries the full method
+// name in place of the HTTP method and route.
+func UnaryInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	key := strings.ToLower(Header)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if ids := md.Get(key); len(ids) > 0 {
+				id = ids[0]
+			}
+		}
+		if !valid(id) {
+			id = generate()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(key, id))
+
+		ctx = context.WithValue(ctx, ctxKey{}, id)
+		ctx = sl.WithLogger(ctx, log.With(
+			slog.String("request_id", id),
+			slog.String("rpc", info.FullMethod),
+		))
+
+		return next(ctx, req)
+	}
+}
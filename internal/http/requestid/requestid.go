@@ -0,0 +1,80 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	Header = echo.HeaderXRequestID
+
+	// maxLen bounds an accepted ID so clients can't bloat every log line.
+	maxLen = 128
+)
+
+type ctxKey struct{}
+
+// Middleware takes the request ID from the X-Request-ID header, or generates
+// one when it is missing or malformed, and echoes it in the response. The
+// request context gets the ID and a child of log carrying the ID, method and
+// matched route, for use with sl.FromContext.
+func Middleware(log *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			id := req.Header.Get(Header)
+			if !valid(id) {
+				id = generate()
+			}
+			c.Response().Header().Set(Header, id)
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			ctx := context.WithValue(req.Context(), ctxKey{}, id)
+			ctx = sl.WithLogger(ctx, log.With(
+				slog.String("request_id", id),
+				slog.String("method", req.Method),
+				slog.String("route", route),
+			))
+			c.SetRequest(req.WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
+
+// FromContext returns the ID of the request ctx belongs to, or "" outside a
+// request.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// valid accepts printable ASCII without spaces, which keeps IDs safe to
+// echo in headers and to write to logs.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func generate() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,79 @@
+package requestid
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// serve runs one request through the middleware and returns the response
+// together with the lines logged by the handler.
+func serve(t *testing.T, header string) (*httptest.ResponseRecorder, []map[string]any) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	e := echo.New()
+	e.Use(Middleware(log))
+	e.GET("/things/:id", func(c echo.Context) error {
+		ctx := sl.With(c.Request().Context(), log, sl.UserID(7))
+		require.Equal(t, c.Response().Header().Get(Header), FromContext(ctx))
+		sl.FromContext(ctx, log).ErrorContext(ctx, "request failed", sl.Op("Test"))
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/things/1", nil)
+	if header != "" {
+		req.Header.Set(Header, header)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	var lines []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(line, &entry))
+		lines = append(lines, entry)
+	}
+	return rec, lines
+}
+
+func TestMiddlewareGeneratesID(t *testing.T) {
+	rec, lines := serve(t, "")
+
+	id := rec.Header().Get(Header)
+	require.Len(t, id, 32)
+	require.Len(t, lines, 1)
+	require.Equal(t, id, lines[0]["request_id"])
+	require.Equal(t, "GET", lines[0]["method"])
+	require.Equal(t, "/things/:id", lines[0]["route"])
+	require.Equal(t, float64(7), lines[0]["user_id"])
+	require.Equal(t, "Test", lines[0]["op"])
+}
+
+func TestMiddlewareAcceptsClientID(t *testing.T) {
+	rec, lines := serve(t, "edge-1234")
+
+	require.Equal(t, "edge-1234", rec.Header().Get(Header))
+	require.Equal(t, "edge-1234", lines[0]["request_id"])
+}
+
+func TestMiddlewareReplacesInvalidID(t *testing.T) {
+	for _, id := range []string{"has space", "line\nbreak", strings.Repeat("x", maxLen+1)} {
+		rec, lines := serve(t, id)
+
+		got := rec.Header().Get(Header)
+		require.NotEqual(t, id, got)
+		require.Len(t, got, 32)
+		require.Equal(t, got, lines[0]["request_id"])
+	}
+}
@@ -2,22 +2,104 @@ package server
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"net"
+	"net/http"
+	"time"
 
 	"github.com/AtIasShrugged/antisocial/internal/config"
 	"github.com/AtIasShrugged/antisocial/internal/http/handler"
+	"github.com/AtIasShrugged/antisocial/internal/service/health"
+	"github.com/AtIasShrugged/antisocial/internal/tracing"
+	"github.com/AtIasShrugged/antisocial/libs/logger"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
 	_ "github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc"
 )
 
-func Run(log *slog.Logger, cfg *config.Config) {
-	ctx := context.Background()
-	router, err := handler.Router(ctx, log, cfg)
+// Run serves the HTTP and gRPC APIs until ctx is cancelled, then shuts down
+// gracefully: readiness starts failing, load balancers get DrainDelay to
+// route traffic elsewhere, and in-flight requests get ShutdownTimeout to
+// finish.
+func Run(ctx context.Context, log *slog.Logger, levels *logger.Levels, cfg *config.Config) {
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
 	if err != nil {
-		log.Error("Failed to create router: "+err.Error(), err)
+		log.Error("Failed to set up tracing: "+err.Error(), sl.Err(err))
+		return
 	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Error("Failed to flush traces: "+err.Error(), sl.Err(err))
+		}
+	}()
 
-	err = router.Start(":" + cfg.Server.Port)
+	// Background workers outlive the signal until the server has drained.
+	workersCtx, stopWorkers := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopWorkers()
+
+	healthService := health.New(cfg.Health)
+	router, grpcServer, err := handler.Router(workersCtx, log, cfg, healthService, levels)
+	if err != nil {
+		log.Error("Failed to create router: "+err.Error(), sl.Err(err))
+		return
+	}
+
+	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
 	if err != nil {
-		log.Error("Failed to start server: "+err.Error(), err)
+		log.Error("Failed to listen for gRPC: "+err.Error(), sl.Err(err))
+		return
+	}
+
+	serveErr := make(chan error, 2)
+	go func() {
+		serveErr <- router.Start(":" + cfg.Server.Port)
+	}()
+	go func() {
+		serveErr <- grpcServer.Serve(grpcListener)
+	}()
+	log.Info("Serving gRPC on port " + cfg.GRPC.Port)
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Error("Failed to start server: "+err.Error(), sl.Err(err))
+		}
+		// Whichever server is still up goes down with the other.
+		grpcServer.Stop()
+		_ = router.Close()
+		return
+	case <-ctx.Done():
+	}
+
+	log.Info("Shutting down", slog.Duration("drain_delay", cfg.Server.DrainDelay))
+	healthService.Drain()
+	time.Sleep(cfg.Server.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	grpcStopped := make(chan struct{})
+	go func() {
+		stopGRPC(shutdownCtx, grpcServer)
+		close(grpcStopped)
+	}()
+	if err := router.Shutdown(shutdownCtx); err != nil {
+		log.Error("Failed to shut down server: "+err.Error(), sl.Err(err))
+	}
+	<-grpcStopped
+}
+
+// stopGRPC lets in-flight calls finish until ctx is done, then cuts them
+// off.
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
 	}
 }
@@ -0,0 +1,337 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrMalformed = errors.New("malformed image container")
+
+	exifHeader = []byte("Exif\x00\x00")
+	xmpHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	pngMagic   = []byte("\x89PNG\r\n\x1a\n")
+
+	// rawProfile prefixes the keyword of the text chunks ImageMagick and
+	// friends keep whole EXIF and XMP blocks in.
+	rawProfile = []byte("Raw profile type")
+)
+
+const tagOrientation = 0x0112
+
+// StripMetadata removes EXIF and XMP blocks (which carry GPS coordinates,
+// camera serials and the like) without re-encoding pixel data. For JPEG the
+// EXIF orientation is preserved so viewers keep rotating the image correctly.
+func StripMetadata(data []byte, contentType string) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	case "image/gif":
+		return stripGIF(data)
+	default:
+		return data, nil
+	}
+}
+
+// Orientation returns the EXIF orientation (1-8) of a JPEG, or 1 if absent.
+func Orientation(data []byte) int {
+	orientation := 1
+	_ = walkJPEG(data, func(marker byte, payload []byte) bool {
+		if marker == 0xE1 && bytes.HasPrefix(payload, exifHeader) {
+			if o := exifOrientation(payload[len(exifHeader):]); o != 0 {
+				orientation = o
+			}
+			return false
+		}
+		return true
+	})
+	return orientation
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrMalformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	// EXIF goes right after SOI, but JFIF insists on coming first, so the
+	// replacement follows any leading APP0 segments.
+	var orientation []byte
+	if o := Orientation(data); o != 1 {
+		orientation = orientationSegment(o)
+	}
+
+	rest, err := jpegSegments(data, func(marker byte, segment, payload []byte) {
+		if orientation != nil && marker != 0xE0 {
+			out.Write(orientation)
+			orientation = nil
+		}
+		if marker == 0xE1 && (bytes.HasPrefix(payload, exifHeader) || bytes.HasPrefix(payload, xmpHeader)) {
+			return
+		}
+		out.Write(segment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	out.Write(rest)
+
+	return out.Bytes(), nil
+}
+
+// jpegSegments calls fn for every marker segment up to and including SOS and
+// returns the remaining entropy-coded data.
+func jpegSegments(data []byte, fn func(marker byte, segment, payload []byte)) ([]byte, error) {
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return nil, ErrMalformed
+		}
+		marker := data[i+1]
+		if marker == 0xFF {
+			i++
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, ErrMalformed
+		}
+		fn(marker, data[i:end], data[i+4:end])
+		i = end
+		if marker == 0xDA {
+			return data[i:], nil
+		}
+	}
+	return nil, ErrMalformed
+}
+
+func walkJPEG(data []byte, fn func(marker byte, payload []byte) bool) error {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return ErrMalformed
+	}
+	stop := false
+	_, err := jpegSegments(data, func(marker byte, _, payload []byte) {
+		if !stop && !fn(marker, payload) {
+			stop = true
+		}
+	})
+	return err
+}
+
+// exifOrientation reads the orientation tag from IFD0 of a TIFF structure.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < count; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == tagOrientation {
+			o := int(order.Uint16(tiff[entry+8:]))
+			if o < 1 || o > 8 {
+				return 0
+			}
+			return o
+		}
+	}
+	return 0
+}
+
+// orientationSegment builds an APP1 segment holding nothing but the
+// orientation tag.
+func orientationSegment(orientation int) []byte {
+	payload := []byte{}
+	payload = append(payload, exifHeader...)
+	payload = append(payload, 'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08)
+	payload = binary.BigEndian.AppendUint16(payload, 1)
+	payload = binary.BigEndian.AppendUint16(payload, tagOrientation)
+	payload = binary.BigEndian.AppendUint16(payload, 3) // SHORT
+	payload = binary.BigEndian.AppendUint32(payload, 1)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(orientation))
+	payload = append(payload, 0x00, 0x00)
+	payload = binary.BigEndian.AppendUint32(payload, 0) // no next IFD
+
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngMagic) {
+		return nil, ErrMalformed
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngMagic)
+
+	i := len(pngMagic)
+	for i < len(data) {
+		if i+12 > len(data) {
+			return nil, ErrMalformed
+		}
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, ErrMalformed
+		}
+		typ := string(data[i+4 : i+8])
+		chunk := data[i+8 : i+8+length]
+
+		drop := typ == "eXIf" ||
+			(typ == "iTXt" && bytes.HasPrefix(chunk, []byte("XML:com.adobe.xmp\x00"))) ||
+			((typ == "tEXt" || typ == "zTXt" || typ == "iTXt") && bytes.HasPrefix(chunk, rawProfile))
+		if !drop {
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+
+	return out.Bytes(), nil
+}
+
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrMalformed
+	}
+
+	body := make([]byte, 0, len(data))
+	i := 12
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, ErrMalformed
+		}
+		fourcc := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2
+		if end > len(data) {
+			if i+8+size != len(data) {
+				return nil, ErrMalformed
+			}
+			end = len(data)
+		}
+
+		switch fourcc {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte{}, data[i:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= 0x08 | 0x04 // EXIF and XMP present flags
+			}
+			body = append(body, chunk...)
+		default:
+			body = append(body, data[i:end]...)
+		}
+		i = end
+	}
+
+	out := make([]byte, 0, len(body)+12)
+	out = append(out, "RIFF"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(body)+4))
+	out = append(out, "WEBP"...)
+	return append(out, body...), nil
+}
+
+// stripGIF drops comment extensions and every application extension but the
+// ones that make animations loop, which is where XMP lives in a GIF.
+func stripGIF(data []byte) ([]byte, error) {
+	if len(data) < 13 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+		return nil, ErrMalformed
+	}
+
+	i := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		i += 3 << (flags&0x07 + 1) // global color table
+	}
+	if i > len(data) {
+		return nil, ErrMalformed
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:i])
+
+	for i < len(data) {
+		start := i
+		switch data[i] {
+		case 0x3B: // trailer
+			out.WriteByte(0x3B)
+			return out.Bytes(), nil
+		case 0x2C: // image descriptor
+			if i+11 > len(data) {
+				return nil, ErrMalformed
+			}
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1) // local color table
+			}
+			i++ // LZW minimum code size
+			end, err := gifSubBlocks(data, i)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(data[start:end])
+			i = end
+		case 0x21: // extension
+			if i+2 > len(data) {
+				return nil, ErrMalformed
+			}
+			label := data[i+1]
+			end, err := gifSubBlocks(data, i+2)
+			if err != nil {
+				return nil, err
+			}
+			keep := label != 0xFE // comment
+			if label == 0xFF {
+				app := data[i+2 : end]
+				keep = bytes.HasPrefix(app, []byte("\x0bNETSCAPE2.0")) ||
+					bytes.HasPrefix(app, []byte("\x0bANIMEXTS1.0"))
+			}
+			if keep {
+				out.Write(data[start:end])
+			}
+			i = end
+		default:
+			return nil, ErrMalformed
+		}
+	}
+
+	return nil, ErrMalformed
+}
+
+// gifSubBlocks returns the offset just past the data sub-blocks starting at
+// i, terminator included.
+func gifSubBlocks(data []byte, i int) (int, error) {
+	for {
+		if i >= len(data) {
+			return 0, ErrMalformed
+		}
+		size := int(data[i])
+		i += 1 + size
+		if size == 0 {
+			return i, nil
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Package imageproc turns uploaded images into web-ready variants. It is pure
+// Go (no cgo, no external binaries) so it runs on any host.
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	_ "image/gif"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels guards against decompression bombs: a tiny file can declare
+// enormous dimensions and exhaust memory on decode.
+const MaxPixels = 50_000_000
+
+var ErrTooManyPixels = errors.New("image dimensions too large")
+
+type VariantSpec struct {
+	Name    string
+	MaxSide int
+}
+
+// DefaultVariants are generated for every image attachment.
+var DefaultVariants = []VariantSpec{
+	{Name: "thumbnail", MaxSide: 320},
+	{Name: "medium", MaxSide: 1024},
+	{Name: "original", MaxSide: 2048},
+}
+
+type Variant struct {
+	Name        string
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+type Result struct {
+	Width    int
+	Height   int
+	Blurhash string
+	// Original is the upload with metadata stripped but pixels untouched.
+	Original []byte
+	Variants []Variant
+}
+
+// Process decodes data, applies EXIF orientation and renders specs. Variants
+// are re-encoded from pixels, so they never carry any metadata.
+func Process(data []byte, contentType string, specs []VariantSpec) (Result, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, fmt.Errorf("can't read image header: %w", err)
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return Result{}, ErrTooManyPixels
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Result{}, fmt.Errorf("can't decode image: %w", err)
+	}
+	if contentType == "image/jpeg" {
+		src = orient(src, Orientation(data))
+	}
+
+	original, err := StripMetadata(data, contentType)
+	if err != nil {
+		return Result{}, fmt.Errorf("can't strip metadata: %w", err)
+	}
+
+	bounds := src.Bounds()
+	res := Result{
+		Width:    bounds.Dx(),
+		Height:   bounds.Dy(),
+		Original: original,
+	}
+
+	res.Blurhash = Blurhash(resize(src, 32), 4, 3)
+
+	// Formats that may carry transparency stay lossless.
+	encode, outType := encodeJPEG, "image/jpeg"
+	if contentType == "image/png" || contentType == "image/gif" {
+		encode, outType = png.Encode, "image/png"
+	}
+
+	for _, spec := range specs {
+		img := resize(src, spec.MaxSide)
+		var buf bytes.Buffer
+		if err := encode(&buf, img); err != nil {
+			return Result{}, fmt.Errorf("can't encode %s variant: %w", spec.Name, err)
+		}
+		b := img.Bounds()
+		res.Variants = append(res.Variants, Variant{
+			Name:        spec.Name,
+			ContentType: outType,
+			Width:       b.Dx(),
+			Height:      b.Dy(),
+			Data:        buf.Bytes(),
+		})
+	}
+
+	return res, nil
+}
+
+func encodeJPEG(w io.Writer, img image.Image) error {
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+}
+
+// resize scales img down so its longest side is at most maxSide. Images that
+// already fit are returned unchanged; nothing is ever upscaled.
+func resize(img image.Image, maxSide int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+	if w >= h {
+		h = max(1, h*maxSide/w)
+		w = maxSide
+	} else {
+		w = max(1, w*maxSide/h)
+		h = maxSide
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// orient applies an EXIF orientation so the pixels are upright.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	swap := orientation >= 5
+	dw, dh := w, h
+	if swap {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
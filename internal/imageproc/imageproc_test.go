@@ -0,0 +1,203 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func solid(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// exifSegment builds an APP1 segment with an orientation tag and a GPS IFD
+// pointer followed by a recognisable marker string.
+func exifSegment(orientation int) []byte {
+	tiff := []byte{'I', 'I', 0x2A, 0x00, 0x08, 0x00, 0x00, 0x00}
+	tiff = binary.LittleEndian.AppendUint16(tiff, 2)
+	tiff = binary.LittleEndian.AppendUint16(tiff, tagOrientation)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint32(tiff, uint32(orientation))
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x8825) // GPSInfo
+	tiff = binary.LittleEndian.AppendUint16(tiff, 4)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 38)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 0)
+	tiff = append(tiff, "GPS-SECRET-52.5200N"...)
+
+	payload := append(append([]byte{}, exifHeader...), tiff...)
+	seg := []byte{0xFF, 0xE1}
+	seg = binary.BigEndian.AppendUint16(seg, uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+func jpegWithExif(t *testing.T, img image.Image, orientation int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	raw := buf.Bytes()
+	return append(append(append([]byte{}, raw[:2]...), exifSegment(orientation)...), raw[2:]...)
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	out = append(out, typ...)
+	out = append(out, data...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(append([]byte(typ), data...)))
+}
+
+func TestStripJPEGKeepsOrientationDropsGPS(t *testing.T) {
+	data := jpegWithExif(t, solid(8, 4, color.White), 6)
+	require.Equal(t, 6, Orientation(data))
+
+	stripped, err := StripMetadata(data, "image/jpeg")
+	require.NoError(t, err)
+	require.NotContains(t, string(stripped), "GPS-SECRET")
+	require.Equal(t, 6, Orientation(stripped))
+
+	_, err = jpeg.Decode(bytes.NewReader(stripped))
+	require.NoError(t, err)
+}
+
+func TestStripJPEGKeepsJFIFFirst(t *testing.T) {
+	jfif := []byte{0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00, 0x01, 0x01, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00}
+	data := jpegWithExif(t, solid(8, 4, color.White), 6)
+	data = append(append(append([]byte{}, data[:2]...), jfif...), data[2:]...)
+
+	stripped, err := StripMetadata(data, "image/jpeg")
+	require.NoError(t, err)
+	require.Equal(t, jfif, stripped[2:2+len(jfif)])
+	app1 := stripped[2+len(jfif):]
+	require.Equal(t, []byte{0xFF, 0xE1}, app1[:2])
+	require.Equal(t, exifHeader, app1[4:4+len(exifHeader)])
+	require.Equal(t, 6, Orientation(stripped))
+}
+
+func TestStripPNG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, solid(2, 2, color.Black)))
+	raw := buf.Bytes()
+
+	// Insert an eXIf chunk right after IHDR (8 byte magic + 25 byte chunk).
+	data := append([]byte{}, raw[:33]...)
+	data = append(data, pngChunk("eXIf", []byte("MM\x00\x2aGPS-SECRET"))...)
+	data = append(data, raw[33:]...)
+
+	stripped, err := StripMetadata(data, "image/png")
+	require.NoError(t, err)
+	require.NotContains(t, string(stripped), "GPS-SECRET")
+	require.Equal(t, raw, stripped)
+}
+
+func TestStripPNGRawProfiles(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, solid(2, 2, color.Black)))
+	raw := buf.Bytes()
+
+	data := append([]byte{}, raw[:33]...)
+	data = append(data, pngChunk("tEXt", []byte("Raw profile type exif\x00\nexif\n20\nGPS-SECRET"))...)
+	data = append(data, pngChunk("zTXt", []byte("Raw profile type xmp\x00\x00GPS-SECRET"))...)
+	data = append(data, pngChunk("tEXt", []byte("Software\x00paint"))...)
+	data = append(data, raw[33:]...)
+
+	stripped, err := StripMetadata(data, "image/png")
+	require.NoError(t, err)
+	require.NotContains(t, string(stripped), "GPS-SECRET")
+	require.Contains(t, string(stripped), "Software\x00paint")
+}
+
+func TestStripGIF(t *testing.T) {
+	frame := image.NewPaletted(image.Rect(0, 0, 2, 2), palette.Plan9)
+	var buf bytes.Buffer
+	require.NoError(t, gif.EncodeAll(&buf, &gif.GIF{
+		Image: []*image.Paletted{frame, frame},
+		Delay: []int{10, 10},
+	}))
+	raw := buf.Bytes()
+
+	// Put an XMP application extension and a comment before the trailer.
+	xmp := append([]byte{0x21, 0xFF, 0x0B}, "XMP DataXMP"...)
+	xmp = append(xmp, 10)
+	xmp = append(xmp, "GPS-SECRET"...)
+	xmp = append(xmp, 0x00)
+	comment := append([]byte{0x21, 0xFE, 10}, "GPS-SECRET"...)
+	comment = append(comment, 0x00)
+	data := append([]byte{}, raw[:len(raw)-1]...)
+	data = append(append(data, xmp...), comment...)
+	data = append(data, 0x3B)
+
+	stripped, err := StripMetadata(data, "image/gif")
+	require.NoError(t, err)
+	require.NotContains(t, string(stripped), "GPS-SECRET")
+	require.Equal(t, raw, stripped)
+
+	decoded, err := gif.DecodeAll(bytes.NewReader(stripped))
+	require.NoError(t, err)
+	require.Len(t, decoded.Image, 2)
+	require.Equal(t, 0, decoded.LoopCount)
+}
+
+func TestProcess(t *testing.T) {
+	data := jpegWithExif(t, solid(4000, 1000, color.RGBA{R: 200, G: 10, B: 10, A: 255}), 6)
+
+	res, err := Process(data, "image/jpeg", DefaultVariants)
+	require.NoError(t, err)
+
+	// Orientation 6 rotates by 90 degrees.
+	require.Equal(t, 1000, res.Width)
+	require.Equal(t, 4000, res.Height)
+	require.NotContains(t, string(res.Original), "GPS-SECRET")
+
+	require.Len(t, res.Variants, 3)
+	want := map[string][2]int{
+		"thumbnail": {80, 320},
+		"medium":    {256, 1024},
+		"original":  {512, 2048},
+	}
+	for _, v := range res.Variants {
+		require.Equal(t, want[v.Name], [2]int{v.Width, v.Height}, v.Name)
+		require.Equal(t, "image/jpeg", v.ContentType)
+		require.NotContains(t, string(v.Data), "Exif")
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(v.Data))
+		require.NoError(t, err)
+		require.Equal(t, v.Width, cfg.Width)
+	}
+}
+
+func TestProcessRejectsBombs(t *testing.T) {
+	// A PNG header declaring 100000x100000 pixels with no real pixel data.
+	ihdr := binary.BigEndian.AppendUint32(nil, 100000)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, 100000)
+	ihdr = append(ihdr, 8, 2, 0, 0, 0)
+	data := append(append([]byte{}, pngMagic...), pngChunk("IHDR", ihdr)...)
+
+	_, err := Process(data, "image/png", DefaultVariants)
+	require.ErrorIs(t, err, ErrTooManyPixels)
+}
+
+func TestBlurhashBlack(t *testing.T) {
+	// Well-known hash of an all-black image with 4x3 components.
+	require.Equal(t, "L00000fQfQfQfQfQfQfQfQfQfQfQ", Blurhash(solid(16, 16, color.Black), 4, 3))
+}
+
+func TestBlurhashLength(t *testing.T) {
+	hash := Blurhash(solid(16, 9, color.RGBA{R: 30, G: 140, B: 220, A: 255}), 4, 3)
+	require.Len(t, hash, 4+2*(4*3))
+	require.True(t, strings.HasPrefix(hash, "L"), hash)
+}
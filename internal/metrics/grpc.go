@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "gRPC calls handled, by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of gRPC calls, by method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	grpcInFlight = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_in_flight",
+		Help:      "gRPC calls being served.",
+	})
+)
+
+// UnaryInterceptor is Middleware for gRPC. Methods are only ever the ones
+// registered with the server, so they are safe to use as labels; calls to
+// unknown methods never reach interceptors.
+func UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		grpcInFlight.Inc()
+		defer grpcInFlight.Dec()
+
+		start := time.Now()
+		resp, err := next(ctx, req)
+
+		labels := []string{info.FullMethod, status.Code(err).String()}
+		grpcRequests.WithLabelValues(labels...).Inc()
+		grpcDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+		return resp, err
+	}
+}
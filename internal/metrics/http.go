@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unmatchedRoute labels requests no route matched, so that scanners probing
+// random paths can't blow up the label cardinality.
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests handled, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests, by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	httpInFlight = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "HTTP requests being served.",
+	})
+)
+
+// Middleware records every request against the route template it matched,
+// e.g. "/posts/:id", rather than the raw path.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			httpInFlight.Inc()
+			defer httpInFlight.Dec()
+
+			start := time.Now()
+			err := next(c)
+			elapsed := time.Since(start).Seconds()
+
+			// Errors are only turned into responses by the error handler
+			// further up, so derive the status they will get here.
+			status := c.Response().Status
+			if err != nil {
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				} else if !c.Response().Committed {
+					status = http.StatusInternalServerError
+				}
+			}
+
+			route := c.Path()
+			if route == "" || status == http.StatusNotFound && isWildcard(route) {
+				route = unmatchedRoute
+			}
+
+			labels := []string{c.Request().Method, route, strconv.Itoa(status)}
+			httpRequests.WithLabelValues(labels...).Inc()
+			httpDuration.WithLabelValues(labels...).Observe(elapsed)
+
+			return err
+		}
+	}
+}
+
+// isWildcard reports whether route is the catch-all Echo reports for
+// requests that matched no route.
+func isWildcard(route string) bool {
+	return route == "/*"
+}
@@ -0,0 +1,154 @@
+// Package metrics holds the Prometheus collectors of the service and the
+// handler that exposes them.
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "antisocial"
+
+// Registry holds every collector of the service. A dedicated registry keeps
+// metrics registered by third-party packages out of /metrics.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	// QueryDuration is the latency of repository methods, labelled by the
+	// repository and method named in their op constant.
+	QueryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Latency of repository methods.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method"})
+
+	PostsCreated = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_created_total",
+		Help:      "Posts created, by initial status.",
+	}, []string{"status"})
+
+	PostsPublished = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_scheduled_published_total",
+		Help:      "Scheduled posts published by the scheduler.",
+	})
+
+	PostsReaped = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_reaped_total",
+		Help:      "Expired ephemeral posts deleted by the reaper.",
+	})
+
+	AttachmentsUploaded = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "attachments_uploaded_total",
+		Help:      "Media uploads accepted, by content type.",
+	}, []string{"content_type"})
+
+	AttachmentsProcessed = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "attachments_processed_total",
+		Help:      "Images run through the processor, by result.",
+	}, []string{"result"})
+
+	LinkPreviewsFetched = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "link_previews_fetched_total",
+		Help:      "Link preview fetches, by result.",
+	}, []string{"result"})
+
+	PollVotes = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "poll_votes_total",
+		Help:      "Poll ballots cast.",
+	})
+
+	ReportsFiled = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reports_filed_total",
+		Help:      "Reports filed by users, by target and reason.",
+	}, []string{"target", "reason"})
+
+	ModerationActions = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "moderation_actions_total",
+		Help:      "Reports resolved by moderators, by action.",
+	}, []string{"action"})
+
+	AccountStateChanges = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "account_state_changes_total",
+		Help:      "Accounts put in a state by moderators, by state.",
+	}, []string{"state"})
+
+	AppealDecisions = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "appeal_decisions_total",
+		Help:      "Suspension appeals decided by moderators, by outcome.",
+	}, []string{"outcome"})
+
+	BlocklistMatches = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "blocklist_matches_total",
+		Help:      "Posts and drafts that matched the blocklist, by the strictest severity matched.",
+	}, []string{"severity"})
+
+	RateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Requests turned away by rate limits, by route class.",
+	}, []string{"class"})
+
+	SpamVerdicts = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "spam_verdicts_total",
+		Help:      "New posts classified by the spam filter, by verdict.",
+	}, []string{"verdict"})
+
+	AuditEvents = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_events_total",
+		Help:      "Audit events recorded, by action.",
+	}, []string{"action"})
+
+	AuditFailures = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_failures_total",
+		Help:      "Audit events that could not be recorded.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics of Registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveQuery records the time elapsed since start against op, which has
+// the "Repository.Method" form used by repositories:
+//
+//	defer metrics.ObserveQuery(op, time.Now())
+func ObserveQuery(op string, start time.Time) {
+	repository, method, ok := strings.Cut(op, ".")
+	if !ok {
+		repository, method = "unknown", op
+	}
+	QueryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMiddlewareLabelsByRoute(t *testing.T) {
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/things/:id", func(c echo.Context) error {
+		if c.Param("id") == "missing" {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return c.String(http.StatusOK, "ok")
+	})
+
+	before := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/things/:id", "200"))
+	for _, path := range []string{"/things/1", "/things/2", "/things/missing", "/nope/1", "/nope/2"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	require.Equal(t, before+2, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/things/:id", "200")))
+	require.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/things/:id", "404")))
+	require.Equal(t, 2.0, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, unmatchedRoute, "404")))
+	require.Equal(t, 0.0, testutil.ToFloat64(httpInFlight))
+}
+
+func TestObserveQuery(t *testing.T) {
+	ObserveQuery("PostRepository.GetByID", time.Now().Add(-20*time.Millisecond))
+	ObserveQuery("PostRepository.GetByID", time.Now())
+
+	var m dto.Metric
+	require.NoError(t, QueryDuration.WithLabelValues("PostRepository", "GetByID").(prometheus.Histogram).Write(&m))
+	require.Equal(t, uint64(2), m.GetHistogram().GetSampleCount())
+	require.GreaterOrEqual(t, m.GetHistogram().GetSampleSum(), 0.02)
+}
+
+func TestHandlerServesTextFormat(t *testing.T) {
+	PostsCreated.WithLabelValues("published").Inc()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain"))
+	require.Contains(t, rec.Body.String(), `antisocial_posts_created_total{status="published"}`)
+	require.Contains(t, rec.Body.String(), "go_goroutines")
+}
+
+func TestUnaryInterceptorLabelsByCode(t *testing.T) {
+	intercept := UnaryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Things/Get"}
+	for _, err := range []error{nil, nil, status.Error(codes.NotFound, "nope")} {
+		_, _ = intercept(context.Background(), nil, info, func(context.Context, any) (any, error) { return nil, err })
+	}
+
+	require.Equal(t, 2.0, testutil.ToFloat64(grpcRequests.WithLabelValues("/test.Things/Get", "OK")))
+	require.Equal(t, 1.0, testutil.ToFloat64(grpcRequests.WithLabelValues("/test.Things/Get", "NotFound")))
+	require.Equal(t, 0.0, testutil.ToFloat64(grpcInFlight))
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reads pgxpool statistics at scrape time.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired        *prometheus.Desc
+	idle            *prometheus.Desc
+	constructing    *prometheus.Desc
+	total           *prometheus.Desc
+	max             *prometheus.Desc
+	acquires        *prometheus.Desc
+	emptyAcquires   *prometheus.Desc
+	canceled        *prometheus.Desc
+	acquireDuration *prometheus.Desc
+}
+
+// RegisterPool exposes the statistics of pool.
+func RegisterPool(pool *pgxpool.Pool) error {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return Registry.Register(&poolCollector{
+		pool:         pool,
+		acquired:     desc("acquired_connections", "Connections currently checked out of the pool."),
+		idle:         desc("idle_connections", "Idle connections in the pool."),
+		constructing: desc("constructing_connections", "Connections being established."),
+		total:        desc("connections", "Connections in the pool, in any state."),
+		max:          desc("max_connections", "Maximum size of the pool."),
+		acquires:     desc("acquires_total", "Successful connection acquires."),
+		// The pool doesn't report how many callers are queued right now;
+		// acquires that had to wait for a connection are the closest signal.
+		emptyAcquires:   desc("waited_acquires_total", "Acquires that waited because the pool was empty."),
+		canceled:        desc("canceled_acquires_total", "Acquires canceled by their context."),
+		acquireDuration: desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+	})
+}
+
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(p, ch)
+}
+
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := p.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(p.acquired, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(p.idle, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(p.constructing, prometheus.GaugeValue, float64(s.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(p.total, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(p.max, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(p.acquires, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(p.emptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(p.canceled, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(p.acquireDuration, prometheus.CounterValue, s.AcquireDuration().Seconds())
+}
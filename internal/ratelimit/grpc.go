@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryInterceptor is Middleware for gRPC, with the class of every method
+// keyed by its full name. Calls share their buckets with HTTP requests of
+// the same class. Methods missing from classes are not limited, as routes
+// mounted without a limiter aren't.
+func (l *Limiter) UnaryInterceptor(classes map[string]Class) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		const op = "Limiter.UnaryInterceptor"
+
+		class, ok := classes[info.FullMethod]
+		if !l.enabled || !ok {
+			return next(ctx, req)
+		}
+
+		key, limit := l.bucket(ctx, auth.PeerIP(ctx), class)
+		res, err := l.store.Take(ctx, key, limit)
+		if err != nil {
+			sl.FromContext(ctx, l.log).ErrorContext(ctx, "can't check rate limit", sl.Op(op), sl.Err(err))
+			return next(ctx, req)
+		}
+
+		md := metadata.Pairs(
+			strings.ToLower(HeaderLimit), strconv.Itoa(limit.Burst),
+			strings.ToLower(HeaderRemaining), strconv.Itoa(res.Remaining),
+			strings.ToLower(HeaderReset), strconv.Itoa(ceilSeconds(res.Reset)),
+		)
+		if !res.Allowed {
+			retryAfter := max(1, ceilSeconds(res.RetryAfter))
+			md.Set("retry-after", strconv.Itoa(retryAfter))
+			_ = grpc.SetHeader(ctx, md)
+			metrics.RateLimited.WithLabelValues(string(class)).Inc()
+			return nil, status.Error(codes.ResourceExhausted, fmt.Sprintf("rate limit exceeded, retry in %ds", retryAfter))
+		}
+		_ = grpc.SetHeader(ctx, md)
+		return next(ctx, req)
+	}
+}
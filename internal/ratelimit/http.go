@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+	HeaderReset     = "RateLimit-Reset"
+	HeaderPolicy    = "RateLimit-Policy"
+)
+
+// Limiter picks the bucket of every request and turns away requests whose
+// bucket is empty.
+type Limiter struct {
+	store     Store
+	enabled   bool
+	anonymous Limit
+	classes   map[Class]Limit
+	log       *slog.Logger
+}
+
+func New(store Store, cfg config.RateLimitConfig, log *slog.Logger) (*Limiter, error) {
+	l := &Limiter{
+		store:   store,
+		enabled: cfg.Enabled,
+		classes: make(map[Class]Limit, 3),
+		log:     log,
+	}
+
+	var err error
+	if l.anonymous, err = ParseLimit(cfg.Anonymous); err != nil {
+		return nil, fmt.Errorf("anonymous: %w", err)
+	}
+	for class, spec := range map[Class]string{Read: cfg.Read, Write: cfg.Write, Auth: cfg.Auth} {
+		if l.classes[class], err = ParseLimit(spec); err != nil {
+			return nil, fmt.Errorf("%s: %w", class, err)
+		}
+	}
+	return l, nil
+}
+
+// Middleware limits the routes of class. Authentication attempts are
+// counted per IP, other requests per user, or per IP while anonymous.
+func (l *Limiter) Middleware(class Class) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if !l.enabled {
+			return next
+		}
+		return func(c echo.Context) error {
+			const op = "Limiter.Middleware"
+			ctx := c.Request().Context()
+
+			key, limit := l.bucket(ctx, c.RealIP(), class)
+			res, err := l.store.Take(ctx, key, limit)
+			if err != nil {
+				// Failing open beats failing every request while the store
+				// is down.
+				sl.FromContext(ctx, l.log).ErrorContext(ctx, "can't check rate limit", sl.Op(op), sl.Err(err))
+				return next(c)
+			}
+
+			h := c.Response().Header()
+			h.Set(HeaderLimit, strconv.Itoa(limit.Burst))
+			h.Set(HeaderRemaining, strconv.Itoa(res.Remaining))
+			h.Set(HeaderReset, strconv.Itoa(ceilSeconds(res.Reset)))
+			h.Set(HeaderPolicy, fmt.Sprintf("%d;w=%d", limit.Burst, ceilSeconds(limit.refillTime())))
+
+			if !res.Allowed {
+				metrics.RateLimited.WithLabelValues(string(class)).Inc()
+				return tooManyRequests(c, res)
+			}
+			return next(c)
+		}
+	}
+}
+
+// AuthFailed counts a request from ip that failed to authenticate against
+// the Auth class, and returns how long ip must wait before trying again, or
+// zero while it may go on.
+func (l *Limiter) AuthFailed(ctx context.Context, ip string) time.Duration {
+	const op = "Limiter.AuthFailed"
+
+	if !l.enabled {
+		return 0
+	}
+	key, limit := l.bucket(ctx, ip, Auth)
+	res, err := l.store.Take(ctx, key, limit)
+	if err != nil {
+		sl.FromContext(ctx, l.log).ErrorContext(ctx, "can't check rate limit", sl.Op(op), sl.Err(err))
+		return 0
+	}
+	if res.Allowed {
+		return 0
+	}
+	metrics.RateLimited.WithLabelValues(string(Auth)).Inc()
+	return max(time.Second, res.RetryAfter)
+}
+
+func (l *Limiter) bucket(ctx context.Context, ip string, class Class) (string, Limit) {
+	if class == Auth {
+		return "auth:ip:" + ip, l.classes[Auth]
+	}
+	if id, ok := auth.UserID(ctx); ok {
+		return fmt.Sprintf("%s:user:%d", class, id), l.classes[class]
+	}
+	return "anonymous:ip:" + ip, l.anonymous
+}
+
+// RunSweeper drops buckets that have refilled every interval, until ctx is
+// cancelled.
+func (l *Limiter) RunSweeper(ctx context.Context, interval time.Duration) {
+	const op = "Limiter.RunSweeper"
+
+	idle := l.anonymous.refillTime()
+	for _, limit := range l.classes {
+		idle = max(idle, limit.refillTime())
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.store.Sweep(ctx, idle); err != nil {
+				l.log.Error(op+": "+err.Error(), sl.Op(op), sl.Err(err))
+			}
+		}
+	}
+}
+
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// tooManyRequests answers with an RFC 9457 problem and Retry-After.
+func tooManyRequests(c echo.Context, res Result) error {
+	retryAfter := max(1, ceilSeconds(res.RetryAfter))
+	c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(retryAfter))
+
+	body, err := json.Marshal(problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusTooManyRequests),
+		Status: http.StatusTooManyRequests,
+		Detail: fmt.Sprintf("rate limit exceeded, retry in %ds", retryAfter),
+	})
+	if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusTooManyRequests, "application/problem+json", body)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,90 @@
+// Package ratelimit throttles clients with token buckets: per IP for
+// anonymous requests and auth attempts, per user and route class otherwise.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrBadLimit = errors.New(`rate limit must look like "30/1m"`)
+
+// Class groups routes that share a budget.
+type Class string
+
+const (
+	Read  Class = "read"
+	Write Class = "write"
+	Auth  Class = "auth"
+)
+
+// Limit describes a token bucket holding up to Burst tokens and refilling at
+// Rate tokens per second. Every request takes a token.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseLimit parses "<count>/<period>", such as "30/1m": count requests per
+// period, all of which may come at once.
+func ParseLimit(s string) (Limit, error) {
+	countStr, periodStr, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("%w: %q", ErrBadLimit, s)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(countStr))
+	if err != nil || count < 1 {
+		return Limit{}, fmt.Errorf("%w: %q", ErrBadLimit, s)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(periodStr))
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("%w: %q", ErrBadLimit, s)
+	}
+	return Limit{Rate: float64(count) / period.Seconds(), Burst: count}, nil
+}
+
+// refillTime is how long an empty bucket takes to fill up.
+func (l Limit) refillTime() time.Duration {
+	return seconds(float64(l.Burst) / l.Rate)
+}
+
+// Result is the state of a bucket after a request took from it.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until the next token, when not allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	res := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Max(0, s) * float64(time.Second))
+}
+
+// Store keeps the buckets.
+type Store interface {
+	// Take refills the bucket for key and takes a token from it if it has
+	// one.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// Sweep forgets buckets untouched for idle. Buckets idle for longer than
+	// their refill time are full, the same as no bucket at all.
+	Sweep(ctx context.Context, idle time.Duration) error
+}
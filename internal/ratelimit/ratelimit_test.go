@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("30/1m")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Rate: 0.5, Burst: 30}, limit)
+	assert.Equal(t, time.Minute, limit.refillTime())
+
+	for _, s := range []string{"", "30", "0/1m", "-1/1m", "x/1m", "30/", "30/0s", "30/-1m"} {
+		_, err := ParseLimit(s)
+		assert.ErrorIs(t, err, ErrBadLimit, s)
+	}
+}
+
+func TestMemoryStoreRefills(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 2}
+
+	for range 2 {
+		res, err := store.Take(context.Background(), "k", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+
+	res, err := store.Take(context.Background(), "k", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 2*time.Second, res.Reset)
+
+	// Other keys have their own buckets.
+	res, err = store.Take(context.Background(), "other", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	now = now.Add(1500 * time.Millisecond)
+	res, err = store.Take(context.Background(), "k", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	require.NoError(t, store.Sweep(context.Background(), time.Second))
+	assert.Len(t, store.buckets, 1)
+	assert.Contains(t, store.buckets, "k")
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit) (Result, error) {
+	return Result{}, errors.New("store is down")
+}
+
+func (failingStore) Sweep(context.Context, time.Duration) error {
+	return errors.New("store is down")
+}
+
+func newLimiter(t *testing.T, store Store) *Limiter {
+	t.Helper()
+	l, err := New(store, config.RateLimitConfig{
+		Enabled:   true,
+		Anonymous: "1/1m",
+		Read:      "3/1m",
+		Write:     "2/1m",
+		Auth:      "1/15m",
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	return l
+}
+
+func serve(e *echo.Echo, path, ip string, userID int) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	req.RemoteAddr = ip + ":1234"
+	if userID != 0 {
+		req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{UserID: userID, Role: models.RoleUser}))
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	e := echo.New()
+	l := newLimiter(t, NewMemoryStore())
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusNoContent) }
+	e.POST("/write", ok, l.Middleware(Write))
+	e.POST("/login", ok, l.Middleware(Auth))
+
+	rec := serve(e, "/write", "10.0.0.1", 7)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get(HeaderLimit))
+	assert.Equal(t, "1", rec.Header().Get(HeaderRemaining))
+	assert.Equal(t, "30", rec.Header().Get(HeaderReset))
+	assert.Equal(t, "2;w=60", rec.Header().Get(HeaderPolicy))
+
+	// The user keeps their budget across addresses.
+	assert.Equal(t, http.StatusNoContent, serve(e, "/write", "10.0.0.2", 7).Code)
+	rec = serve(e, "/write", "10.0.0.3", 7)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get(echo.HeaderRetryAfter))
+	assert.Equal(t, "application/problem+json", rec.Header().Get(echo.HeaderContentType))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Too Many Requests","status":429,"detail":"rate limit exceeded, retry in 30s"}`, rec.Body.String())
+
+	// Anonymous requests are counted per address.
+	assert.Equal(t, http.StatusNoContent, serve(e, "/write", "10.0.0.1", 0).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(e, "/write", "10.0.0.1", 0).Code)
+	assert.Equal(t, http.StatusNoContent, serve(e, "/write", "10.0.0.2", 0).Code)
+
+	// Auth attempts are counted per address even when a user is known.
+	assert.Equal(t, http.StatusNoContent, serve(e, "/login", "10.0.0.1", 7).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(e, "/login", "10.0.0.1", 8).Code)
+}
+
+func TestAuthFailed(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Unix(0, 0)
+	store.now = func() time.Time { return now }
+	l := newLimiter(t, store)
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{UserID: 7, Role: models.RoleUser})
+
+	assert.Zero(t, l.AuthFailed(ctx, "10.0.0.1"))
+	// Failures are counted per address whoever the request claims to be.
+	assert.Equal(t, 15*time.Minute, l.AuthFailed(context.Background(), "10.0.0.1"))
+	assert.Zero(t, l.AuthFailed(ctx, "10.0.0.2"))
+}
+
+func TestMiddlewareFailsOpen(t *testing.T) {
+	e := echo.New()
+	l := newLimiter(t, failingStore{})
+	e.POST("/write", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) }, l.Middleware(Write))
+
+	for range 3 {
+		rec := serve(e, "/write", "10.0.0.1", 7)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, rec.Header().Get(HeaderLimit))
+	}
+}
+
+func TestNewRejectsBadLimits(t *testing.T) {
+	_, err := New(NewMemoryStore(), config.RateLimitConfig{Anonymous: "1/1m", Read: "1/1m", Write: "lots", Auth: "1/1m"}, slog.Default())
+	assert.ErrorIs(t, err, ErrBadLimit)
+}
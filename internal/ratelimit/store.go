@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	ratelimit_repo "github.com/AtIasShrugged/antisocial/internal/repository/ratelimit"
+)
+
+// MemoryStore keeps buckets in process, for a single instance.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return newResult(limit, b.tokens, allowed), nil
+}
+
+func (s *MemoryStore) Sweep(_ context.Context, idle time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-idle)
+	for key, b := range s.buckets {
+		if b.updated.Before(cutoff) {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
+
+// PostgresStore keeps buckets in the database, so that all instances share
+// them.
+type PostgresStore struct {
+	repo ratelimit_repo.RateLimitRepository
+}
+
+func NewPostgresStore(repo ratelimit_repo.RateLimitRepository) *PostgresStore {
+	return &PostgresStore{repo: repo}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	tokens, allowed, err := s.repo.Take(ctx, key, limit.Rate, limit.Burst)
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(limit, tokens, allowed), nil
+}
+
+func (s *PostgresStore) Sweep(ctx context.Context, idle time.Duration) error {
+	_, err := s.repo.DeleteIdle(ctx, idle)
+	return err
+}
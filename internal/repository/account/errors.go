@@ -0,0 +1,9 @@
+package account_repo
+
+import "errors"
+
+var (
+	ErrAppealNotFound = errors.New("appeal not found")
+	ErrAppealOpen     = errors.New("an appeal is already under review")
+	ErrAppealDecided  = errors.New("appeal is already decided")
+)
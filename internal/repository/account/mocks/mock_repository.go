@@ -0,0 +1,129 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/account/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/account/repository.go -destination=internal/repository/account/mocks/mock_repository.go
+//
+
+// Package mock_account_repo is a generated GoMock package.
+package mock_account_repo
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/AtIasShrugged/antisocial/internal/domain/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAccountRepository is a mock of AccountRepository interface.
+type MockAccountRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountRepositoryMockRecorder
+}
+
+// MockAccountRepositoryMockRecorder is the mock recorder for MockAccountRepository.
+type MockAccountRepositoryMockRecorder struct {
+	mock *MockAccountRepository
+}
+
+// NewMockAccountRepository creates a new mock instance.
+func NewMockAccountRepository(ctrl *gomock.Controller) *MockAccountRepository {
+	mock := &MockAccountRepository{ctrl: ctrl}
+	mock.recorder = &MockAccountRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccountRepository) EXPECT() *MockAccountRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateAppeal mocks base method.
+func (m *MockAccountRepository) CreateAppeal(ctx context.Context, appeal models.Appeal) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAppeal", ctx, appeal)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAppeal indicates an expected call of CreateAppeal.
+func (mr *MockAccountRepositoryMockRecorder) CreateAppeal(ctx, appeal any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAppeal", reflect.TypeOf((*MockAccountRepository)(nil).CreateAppeal), ctx, appeal)
+}
+
+// DecideAppeal mocks base method.
+func (m *MockAccountRepository) DecideAppeal(ctx context.Context, appeal models.Appeal) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecideAppeal", ctx, appeal)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecideAppeal indicates an expected call of DecideAppeal.
+func (mr *MockAccountRepositoryMockRecorder) DecideAppeal(ctx, appeal any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecideAppeal", reflect.TypeOf((*MockAccountRepository)(nil).DecideAppeal), ctx, appeal)
+}
+
+// Get mocks base method.
+func (m *MockAccountRepository) Get(ctx context.Context, userID int) (models.AccountStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userID)
+	ret0, _ := ret[0].(models.AccountStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockAccountRepositoryMockRecorder) Get(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockAccountRepository)(nil).Get), ctx, userID)
+}
+
+// GetAppeal mocks base method.
+func (m *MockAccountRepository) GetAppeal(ctx context.Context, id int) (models.Appeal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAppeal", ctx, id)
+	ret0, _ := ret[0].(models.Appeal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAppeal indicates an expected call of GetAppeal.
+func (mr *MockAccountRepositoryMockRecorder) GetAppeal(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppeal", reflect.TypeOf((*MockAccountRepository)(nil).GetAppeal), ctx, id)
+}
+
+// ListAppeals mocks base method.
+func (m *MockAccountRepository) ListAppeals(ctx context.Context, filter models.AppealFilter) ([]models.Appeal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAppeals", ctx, filter)
+	ret0, _ := ret[0].([]models.Appeal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAppeals indicates an expected call of ListAppeals.
+func (mr *MockAccountRepositoryMockRecorder) ListAppeals(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppeals", reflect.TypeOf((*MockAccountRepository)(nil).ListAppeals), ctx, filter)
+}
+
+// Set mocks base method.
+func (m *MockAccountRepository) Set(ctx context.Context, status models.AccountStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockAccountRepositoryMockRecorder) Set(ctx, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockAccountRepository)(nil).Set), ctx, status)
+}
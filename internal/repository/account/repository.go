@@ -0,0 +1,205 @@
+package account_repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/internal/repository/postgres"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AccountRepository interface {
+	Get(ctx context.Context, userID int) (models.AccountStatus, error)
+	Set(ctx context.Context, status models.AccountStatus) error
+	CreateAppeal(ctx context.Context, appeal models.Appeal) (int, error)
+	GetAppeal(ctx context.Context, id int) (models.Appeal, error)
+	ListAppeals(ctx context.Context, filter models.AppealFilter) ([]models.Appeal, error)
+	DecideAppeal(ctx context.Context, appeal models.Appeal) error
+}
+
+type Repository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func New(pool *pgxpool.Pool, log *slog.Logger) *Repository {
+	return &Repository{
+		db:  pool,
+		log: log,
+	}
+}
+
+// conn joins the transaction of ctx, if any.
+func (r *Repository) conn(ctx context.Context) postgres.Conn {
+	return postgres.ConnFrom(ctx, r.db)
+}
+
+const appealColumns = `id, user_id, body, status, reviewer_id, decision, created_at, decided_at`
+
+// Get returns the state of userID, which is models.AccountActive unless
+// another was set and hasn't expired.
+func (r *Repository) Get(ctx context.Context, userID int) (models.AccountStatus, error) {
+	const op = "AccountRepository.Get"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `SELECT state, reason, expires_at, changed_by, action_id, changed_at FROM account_states
+		WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > now())`
+	status := models.AccountStatus{UserID: userID}
+	err := r.conn(ctx).QueryRow(ctx, query, userID).
+		Scan(&status.State, &status.Reason, &status.ExpiresAt, &status.ChangedBy, &status.ActionID, &status.ChangedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.AccountStatus{UserID: userID, State: models.AccountActive}, nil
+		}
+		return models.AccountStatus{}, fmt.Errorf("can't query account state: %s", err.Error())
+	}
+
+	return status, nil
+}
+
+// Set puts an account in status.State. Setting models.AccountActive lifts
+// whatever state it was in.
+func (r *Repository) Set(ctx context.Context, status models.AccountStatus) error {
+	const op = "AccountRepository.Set"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	if status.State == models.AccountActive {
+		if _, err := r.conn(ctx).Exec(ctx, `DELETE FROM account_states WHERE user_id = $1`, status.UserID); err != nil {
+			return fmt.Errorf("can't lift account state: %s", err.Error())
+		}
+		return nil
+	}
+
+	query := `INSERT INTO account_states (user_id, state, reason, expires_at, changed_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE
+			SET state = $2, reason = $3, expires_at = $4, changed_by = $5, action_id = NULL, changed_at = now()`
+	_, err := r.conn(ctx).Exec(ctx, query, status.UserID, status.State, status.Reason, status.ExpiresAt, status.ChangedBy)
+	if err != nil {
+		return fmt.Errorf("can't set account state: %s", err.Error())
+	}
+
+	return nil
+}
+
+// CreateAppeal files an open appeal. A user may have one open at a time.
+func (r *Repository) CreateAppeal(ctx context.Context, appeal models.Appeal) (int, error) {
+	const op = "AccountRepository.CreateAppeal"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `INSERT INTO appeals (user_id, body) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+		RETURNING id`
+	var id int
+	if err := r.conn(ctx).QueryRow(ctx, query, appeal.UserID, appeal.Body).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrAppealOpen
+		}
+		return 0, fmt.Errorf("can't insert appeal: %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *Repository) GetAppeal(ctx context.Context, id int) (models.Appeal, error) {
+	const op = "AccountRepository.GetAppeal"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	rows, err := r.conn(ctx).Query(ctx, `SELECT `+appealColumns+` FROM appeals WHERE id = $1`, id)
+	if err != nil {
+		return models.Appeal{}, fmt.Errorf("can't query appeal: %s", err.Error())
+	}
+	appeal, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByPos[models.Appeal])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Appeal{}, ErrAppealNotFound
+		}
+		return models.Appeal{}, fmt.Errorf("can't scan appeal: %s", err.Error())
+	}
+
+	return appeal, nil
+}
+
+// ListAppeals returns the appeals matching filter, oldest first.
+func (r *Repository) ListAppeals(ctx context.Context, filter models.AppealFilter) ([]models.Appeal, error) {
+	const op = "AccountRepository.ListAppeals"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `SELECT ` + appealColumns + ` FROM appeals
+		WHERE ($1 = '' OR status = $1)
+			AND ($2 = 0 OR user_id = $2)
+			AND id > $3
+		ORDER BY id
+		LIMIT $4`
+	rows, err := r.conn(ctx).Query(ctx, query, string(filter.Status), filter.UserID, filter.After, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't query appeals: %s", err.Error())
+	}
+
+	appeals, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Appeal])
+	if err != nil {
+		return nil, fmt.Errorf("can't scan appeals: %s", err.Error())
+	}
+
+	return appeals, nil
+}
+
+// DecideAppeal records the decision on an open appeal. Granting it lifts
+// the suspension of the appellant, all or nothing; a suspension that was
+// since replaced by another state stays.
+func (r *Repository) DecideAppeal(ctx context.Context, appeal models.Appeal) error {
+	const op = "AccountRepository.DecideAppeal"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't create transaction: %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		status models.AppealStatus
+		userID int
+	)
+	err = tx.QueryRow(ctx, `SELECT status, user_id FROM appeals WHERE id = $1 FOR UPDATE`, appeal.ID).Scan(&status, &userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrAppealNotFound
+		}
+		return fmt.Errorf("can't lock appeal: %s", err.Error())
+	}
+	if status != models.AppealOpen {
+		return ErrAppealDecided
+	}
+
+	query := `UPDATE appeals SET status = $2, reviewer_id = $3, decision = $4, decided_at = now() WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, appeal.ID, appeal.Status, appeal.ReviewerID, appeal.Decision); err != nil {
+		return fmt.Errorf("can't decide appeal: %s", err.Error())
+	}
+
+	if appeal.Status == models.AppealGranted {
+		_, err := tx.Exec(ctx, `DELETE FROM account_states WHERE user_id = $1 AND state = 'suspended'`, userID)
+		if err != nil {
+			return fmt.Errorf("can't lift suspension: %s", err.Error())
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit transaction: %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+package attachment_repo
+
+import "errors"
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrAttachmentInUse    = errors.New("attachment is attached to a post")
+)
@@ -0,0 +1,160 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/attachment/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/attachment/repository.go -destination=internal/repository/attachment/mocks/mock_repository.go
+//
+
+// Package mock_attachment_repo is a generated GoMock package.
+package mock_attachment_repo
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/AtIasShrugged/antisocial/internal/domain/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAttachmentRepository is a mock of AttachmentRepository interface.
+type MockAttachmentRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttachmentRepositoryMockRecorder
+}
+
+// MockAttachmentRepositoryMockRecorder is the mock recorder for MockAttachmentRepository.
+type MockAttachmentRepositoryMockRecorder struct {
+	mock *MockAttachmentRepository
+}
+
+// NewMockAttachmentRepository creates a new mock instance.
+func NewMockAttachmentRepository(ctrl *gomock.Controller) *MockAttachmentRepository {
+	mock := &MockAttachmentRepository{ctrl: ctrl}
+	mock.recorder = &MockAttachmentRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttachmentRepository) EXPECT() *MockAttachmentRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimPending mocks base method.
+func (m *MockAttachmentRepository) ClaimPending(ctx context.Context, staleBefore time.Time, limit int) ([]models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimPending", ctx, staleBefore, limit)
+	ret0, _ := ret[0].([]models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimPending indicates an expected call of ClaimPending.
+func (mr *MockAttachmentRepositoryMockRecorder) ClaimPending(ctx, staleBefore, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPending", reflect.TypeOf((*MockAttachmentRepository)(nil).ClaimPending), ctx, staleBefore, limit)
+}
+
+// CompleteProcessing mocks base method.
+func (m *MockAttachmentRepository) CompleteProcessing(ctx context.Context, attachment models.Attachment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteProcessing", ctx, attachment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteProcessing indicates an expected call of CompleteProcessing.
+func (mr *MockAttachmentRepositoryMockRecorder) CompleteProcessing(ctx, attachment any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteProcessing", reflect.TypeOf((*MockAttachmentRepository)(nil).CompleteProcessing), ctx, attachment)
+}
+
+// Create mocks base method.
+func (m *MockAttachmentRepository) Create(ctx context.Context, attachment models.Attachment) (models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, attachment)
+	ret0, _ := ret[0].(models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAttachmentRepositoryMockRecorder) Create(ctx, attachment any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAttachmentRepository)(nil).Create), ctx, attachment)
+}
+
+// DeleteOrphan mocks base method.
+func (m *MockAttachmentRepository) DeleteOrphan(ctx context.Context, id int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrphan", ctx, id)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOrphan indicates an expected call of DeleteOrphan.
+func (mr *MockAttachmentRepositoryMockRecorder) DeleteOrphan(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrphan", reflect.TypeOf((*MockAttachmentRepository)(nil).DeleteOrphan), ctx, id)
+}
+
+// GetByID mocks base method.
+func (m *MockAttachmentRepository) GetByID(ctx context.Context, id int) (models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockAttachmentRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockAttachmentRepository)(nil).GetByID), ctx, id)
+}
+
+// ListOrphans mocks base method.
+func (m *MockAttachmentRepository) ListOrphans(ctx context.Context, olderThan time.Time, limit int) ([]models.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrphans", ctx, olderThan, limit)
+	ret0, _ := ret[0].([]models.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrphans indicates an expected call of ListOrphans.
+func (mr *MockAttachmentRepositoryMockRecorder) ListOrphans(ctx, olderThan, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrphans", reflect.TypeOf((*MockAttachmentRepository)(nil).ListOrphans), ctx, olderThan, limit)
+}
+
+// MarkFailed mocks base method.
+func (m *MockAttachmentRepository) MarkFailed(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFailed indicates an expected call of MarkFailed.
+func (mr *MockAttachmentRepositoryMockRecorder) MarkFailed(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockAttachmentRepository)(nil).MarkFailed), ctx, id)
+}
+
+// Visible mocks base method.
+func (m *MockAttachmentRepository) Visible(ctx context.Context, id, viewerID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Visible", ctx, id, viewerID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Visible indicates an expected call of Visible.
+func (mr *MockAttachmentRepositoryMockRecorder) Visible(ctx, id, viewerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Visible", reflect.TypeOf((*MockAttachmentRepository)(nil).Visible), ctx, id, viewerID)
+}
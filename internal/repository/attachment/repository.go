@@ -0,0 +1,267 @@
+package attachment_repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment models.Attachment) (models.Attachment, error)
+	GetByID(ctx context.Context, id int) (models.Attachment, error)
+	Visible(ctx context.Context, id int, viewerID int) (bool, error)
+	ClaimPending(ctx context.Context, staleBefore time.Time, limit int) ([]models.Attachment, error)
+	CompleteProcessing(ctx context.Context, attachment models.Attachment) error
+	MarkFailed(ctx context.Context, id int) error
+	ListOrphans(ctx context.Context, olderThan time.Time, limit int) ([]models.Attachment, error)
+	DeleteOrphan(ctx context.Context, id int) ([]string, error)
+}
+
+type Repository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func New(pool *pgxpool.Pool, log *slog.Logger) *Repository {
+	return &Repository{
+		db:  pool,
+		log: log,
+	}
+}
+
+const attachmentColumns = `id, owner_id, blob_key, content_type, size, status,
+	COALESCE(width, 0), COALESCE(height, 0), COALESCE(blurhash, ''), created_at`
+
+func scanAttachment(row pgx.Row) (models.Attachment, error) {
+	var a models.Attachment
+	err := row.Scan(&a.ID, &a.OwnerID, &a.BlobKey, &a.ContentType, &a.Size, &a.Status,
+		&a.Width, &a.Height, &a.Blurhash, &a.CreatedAt)
+	return a, err
+}
+
+func collectAttachments(rows pgx.Rows) ([]models.Attachment, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Attachment, error) {
+		return scanAttachment(row)
+	})
+}
+
+func (r *Repository) Create(ctx context.Context, attachment models.Attachment) (models.Attachment, error) {
+	const op = "AttachmentRepository.Create"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `INSERT INTO attachments (owner_id, blob_key, content_type, size, status)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
+	err := r.db.QueryRow(ctx, query,
+		attachment.OwnerID, attachment.BlobKey, attachment.ContentType, attachment.Size, attachment.Status,
+	).Scan(&attachment.ID, &attachment.CreatedAt)
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("can't insert attachment: %s", err.Error())
+	}
+
+	return attachment, nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int) (models.Attachment, error) {
+	const op = "AttachmentRepository.GetByID"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = $1`
+	a, err := scanAttachment(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Attachment{}, ErrAttachmentNotFound
+		}
+		return models.Attachment{}, fmt.Errorf("can't scan attachment: %s", err.Error())
+	}
+
+	variantsQuery := `SELECT name, blob_key, content_type, width, height, size
+		FROM attachment_variants WHERE attachment_id = $1 ORDER BY width`
+	rows, err := r.db.Query(ctx, variantsQuery, id)
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("can't query variants: %s", err.Error())
+	}
+	a.Variants, err = pgx.CollectRows(rows, pgx.RowToStructByPos[models.AttachmentVariant])
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("can't scan variants: %s", err.Error())
+	}
+
+	return a, nil
+}
+
+// Visible reports whether viewerID may see an attachment: always when they
+// uploaded it, otherwise only through a post carrying it that they may see.
+func (r *Repository) Visible(ctx context.Context, id int, viewerID int) (bool, error) {
+	const op = "AttachmentRepository.Visible"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `SELECT EXISTS (SELECT 1 FROM attachments WHERE id = $1 AND owner_id = $2)
+		OR EXISTS (
+			SELECT 1 FROM post_attachments pa
+			WHERE pa.attachment_id = $1 AND post_visible_to(pa.post_id, $2)
+		)`
+
+	var visible bool
+	if err := r.db.QueryRow(ctx, query, id, viewerID).Scan(&visible); err != nil {
+		return false, fmt.Errorf("can't check attachment visibility: %s", err.Error())
+	}
+
+	return visible, nil
+}
+
+// ClaimPending marks up to limit attachments awaiting processing as taken by
+// the caller. Rows claimed by another instance are skipped; claims older than
+// staleBefore are considered abandoned and handed out again.
+func (r *Repository) ClaimPending(ctx context.Context, staleBefore time.Time, limit int) ([]models.Attachment, error) {
+	const op = "AttachmentRepository.ClaimPending"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `UPDATE attachments SET processing_started_at = now()
+		WHERE id IN (
+			SELECT id FROM attachments
+			WHERE status = 'processing'
+			  AND (processing_started_at IS NULL OR processing_started_at < $1)
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + attachmentColumns
+	rows, err := r.db.Query(ctx, query, staleBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't claim attachments: %s", err.Error())
+	}
+
+	claimed, err := collectAttachments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("can't scan attachments: %s", err.Error())
+	}
+
+	return claimed, nil
+}
+
+// CompleteProcessing stores the variants and metadata of a processed
+// attachment and marks it ready.
+func (r *Repository) CompleteProcessing(ctx context.Context, attachment models.Attachment) error {
+	const op = "AttachmentRepository.CompleteProcessing"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("can't create transaction: %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	variantQuery := `INSERT INTO attachment_variants (attachment_id, name, blob_key, content_type, width, height, size)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (attachment_id, name) DO UPDATE
+		SET blob_key = EXCLUDED.blob_key, content_type = EXCLUDED.content_type,
+		    width = EXCLUDED.width, height = EXCLUDED.height, size = EXCLUDED.size`
+	for _, v := range attachment.Variants {
+		_, err := tx.Exec(ctx, variantQuery, attachment.ID, v.Name, v.BlobKey, v.ContentType, v.Width, v.Height, v.Size)
+		if err != nil {
+			return fmt.Errorf("can't insert variant: %s", err.Error())
+		}
+	}
+
+	query := `UPDATE attachments
+		SET status = 'ready', size = $2, width = $3, height = $4, blurhash = $5, processing_started_at = NULL
+		WHERE id = $1`
+	tag, err := tx.Exec(ctx, query, attachment.ID, attachment.Size, attachment.Width, attachment.Height, attachment.Blurhash)
+	if err != nil {
+		return fmt.Errorf("can't update attachment: %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAttachmentNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit transaction: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *Repository) MarkFailed(ctx context.Context, id int) error {
+	const op = "AttachmentRepository.MarkFailed"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `UPDATE attachments SET status = 'failed', processing_started_at = NULL WHERE id = $1`
+	if _, err := r.db.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("can't update attachment: %s", err.Error())
+	}
+
+	return nil
+}
+
+// ListOrphans returns uploads created before olderThan that no post refers to.
+func (r *Repository) ListOrphans(ctx context.Context, olderThan time.Time, limit int) ([]models.Attachment, error) {
+	const op = "AttachmentRepository.ListOrphans"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `SELECT ` + attachmentColumns + `
+		FROM attachments a
+		WHERE a.created_at < $1
+		  AND NOT EXISTS (SELECT 1 FROM post_attachments pa WHERE pa.attachment_id = a.id)
+		ORDER BY a.created_at
+		LIMIT $2`
+	rows, err := r.db.Query(ctx, query, olderThan, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't query orphans: %s", err.Error())
+	}
+
+	orphans, err := collectAttachments(rows)
+	if err != nil {
+		return nil, fmt.Errorf("can't scan orphans: %s", err.Error())
+	}
+
+	return orphans, nil
+}
+
+// DeleteOrphan removes the attachment row unless a post picked it up in the
+// meantime, in which case ErrAttachmentInUse is returned. It returns the blob
+// keys of the original and all variants, which the caller must delete.
+func (r *Repository) DeleteOrphan(ctx context.Context, id int) ([]string, error) {
+	const op = "AttachmentRepository.DeleteOrphan"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `WITH variants AS (
+			SELECT blob_key FROM attachment_variants WHERE attachment_id = $1
+		), deleted AS (
+			DELETE FROM attachments a
+			WHERE a.id = $1
+			  AND NOT EXISTS (SELECT 1 FROM post_attachments pa WHERE pa.attachment_id = a.id)
+			RETURNING a.blob_key
+		)
+		SELECT blob_key FROM deleted
+		UNION ALL
+		SELECT blob_key FROM variants WHERE EXISTS (SELECT 1 FROM deleted)`
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("can't delete attachment: %s", err.Error())
+	}
+
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("can't delete attachment: %s", err.Error())
+	}
+	if len(keys) == 0 {
+		return nil, ErrAttachmentInUse
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,179 @@
+package audit_repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/internal/repository/postgres"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// chainLock is the advisory lock that serializes appends, so that every
+// event links to the one committed right before it.
+const chainLock = 0x61756469740001
+
+// Sealer returns the hash of event given the hash of the event before it,
+// which is empty for the first event.
+type Sealer func(prev []byte, event models.AuditEvent) ([]byte, error)
+
+type AuditRepository interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Append(ctx context.Context, event models.AuditEvent, seal Sealer) (models.AuditEvent, error)
+	List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+	Scan(ctx context.Context, afterID int64, limit int) ([]models.AuditEvent, error)
+}
+
+type Repository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func New(pool *pgxpool.Pool, log *slog.Logger) *Repository {
+	return &Repository{
+		db:  pool,
+		log: log,
+	}
+}
+
+// conn joins the transaction of ctx, if any.
+func (r *Repository) conn(ctx context.Context) postgres.Conn {
+	return postgres.ConnFrom(ctx, r.db)
+}
+
+const eventColumns = `id, actor_id, action, target_type, target_id, ip, user_agent,
+	before, after, created_at, prev_hash, hash`
+
+// InTx runs fn in a transaction that Append, and every other repository
+// given the context of fn, joins.
+func (r *Repository) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return postgres.InTx(ctx, r.db, fn)
+}
+
+// Append adds event to the end of the chain. Before and After must already
+// be marshalled to json.RawMessage, so that what is stored is what was
+// sealed.
+func (r *Repository) Append(ctx context.Context, event models.AuditEvent, seal Sealer) (models.AuditEvent, error) {
+	const op = "AuditRepository.Append"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return models.AuditEvent{}, fmt.Errorf("can't create transaction: %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, chainLock); err != nil {
+		return models.AuditEvent{}, fmt.Errorf("can't lock audit chain: %s", err.Error())
+	}
+
+	err = tx.QueryRow(ctx, `SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1`).Scan(&event.PrevHash)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return models.AuditEvent{}, fmt.Errorf("can't read audit chain head: %s", err.Error())
+		}
+		event.PrevHash = []byte{}
+	}
+	err = tx.QueryRow(ctx, `SELECT nextval(pg_get_serial_sequence('audit_events', 'id'))`).Scan(&event.ID)
+	if err != nil {
+		return models.AuditEvent{}, fmt.Errorf("can't allocate audit event id: %s", err.Error())
+	}
+
+	if event.Hash, err = seal(event.PrevHash, event); err != nil {
+		return models.AuditEvent{}, err
+	}
+
+	query := `INSERT INTO audit_events (` + eventColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	_, err = tx.Exec(ctx, query,
+		event.ID, event.ActorID, event.Action, event.TargetType, event.TargetID, event.IP, event.UserAgent,
+		event.Before, event.After, event.CreatedAt, event.PrevHash, event.Hash,
+	)
+	if err != nil {
+		return models.AuditEvent{}, fmt.Errorf("can't insert audit event: %s", err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.AuditEvent{}, fmt.Errorf("can't commit transaction: %s", err.Error())
+	}
+
+	return event, nil
+}
+
+// List returns the events matching filter, newest first.
+func (r *Repository) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	const op = "AuditRepository.List"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `SELECT ` + eventColumns + ` FROM audit_events
+		WHERE ($1 = 0 OR actor_id = $1)
+			AND ($2 = '' OR action = $2)
+			AND ($3 = '' OR target_type = $3)
+			AND ($4 = '' OR target_id = $4)
+			AND ($5::timestamptz IS NULL OR created_at >= $5)
+			AND ($6::timestamptz IS NULL OR created_at < $6)
+			AND ($7 = 0 OR id < $7)
+		ORDER BY id DESC
+		LIMIT $8`
+	rows, err := r.conn(ctx).Query(ctx, query,
+		filter.ActorID, string(filter.Action), string(filter.TargetType), filter.TargetID,
+		filter.Since, filter.Until, filter.Before, filter.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("can't query audit events: %s", err.Error())
+	}
+
+	events, err := pgx.CollectRows(rows, scanEvent)
+	if err != nil {
+		return nil, fmt.Errorf("can't scan audit events: %s", err.Error())
+	}
+
+	return events, nil
+}
+
+// Scan returns up to limit events after afterID in chain order.
+func (r *Repository) Scan(ctx context.Context, afterID int64, limit int) ([]models.AuditEvent, error) {
+	const op = "AuditRepository.Scan"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `SELECT ` + eventColumns + ` FROM audit_events WHERE id > $1 ORDER BY id LIMIT $2`
+	rows, err := r.conn(ctx).Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't query audit events: %s", err.Error())
+	}
+
+	events, err := pgx.CollectRows(rows, scanEvent)
+	if err != nil {
+		return nil, fmt.Errorf("can't scan audit events: %s", err.Error())
+	}
+
+	return events, nil
+}
+
+func scanEvent(row pgx.CollectableRow) (models.AuditEvent, error) {
+	var (
+		event         models.AuditEvent
+		before, after []byte
+	)
+	err := row.Scan(
+		&event.ID, &event.ActorID, &event.Action, &event.TargetType, &event.TargetID, &event.IP, &event.UserAgent,
+		&before, &after, &event.CreatedAt, &event.PrevHash, &event.Hash,
+	)
+	if before != nil {
+		event.Before = json.RawMessage(before)
+	}
+	if after != nil {
+		event.After = json.RawMessage(after)
+	}
+	return event, err
+}
@@ -0,0 +1,8 @@
+package blocklist_repo
+
+import "errors"
+
+var (
+	ErrEntryNotFound = errors.New("blocklist entry not found")
+	ErrEntryExists   = errors.New("blocklist entry already exists")
+)
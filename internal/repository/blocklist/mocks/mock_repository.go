@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/blocklist/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/blocklist/repository.go -destination=internal/repository/blocklist/mocks/mock_repository.go
+//
+
+// Package mock_blocklist_repo is a generated GoMock package.
+package mock_blocklist_repo
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/AtIasShrugged/antisocial/internal/domain/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBlocklistRepository is a mock of BlocklistRepository interface.
+type MockBlocklistRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlocklistRepositoryMockRecorder
+}
+
+// MockBlocklistRepositoryMockRecorder is the mock recorder for MockBlocklistRepository.
+type MockBlocklistRepositoryMockRecorder struct {
+	mock *MockBlocklistRepository
+}
+
+// NewMockBlocklistRepository creates a new mock instance.
+func NewMockBlocklistRepository(ctrl *gomock.Controller) *MockBlocklistRepository {
+	mock := &MockBlocklistRepository{ctrl: ctrl}
+	mock.recorder = &MockBlocklistRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBlocklistRepository) EXPECT() *MockBlocklistRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockBlocklistRepository) Create(ctx context.Context, entry models.BlocklistEntry) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, entry)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockBlocklistRepositoryMockRecorder) Create(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockBlocklistRepository)(nil).Create), ctx, entry)
+}
+
+// Delete mocks base method.
+func (m *MockBlocklistRepository) Delete(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockBlocklistRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBlocklistRepository)(nil).Delete), ctx, id)
+}
+
+// Get mocks base method.
+func (m *MockBlocklistRepository) Get(ctx context.Context, id int) (models.BlocklistEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(models.BlocklistEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockBlocklistRepositoryMockRecorder) Get(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockBlocklistRepository)(nil).Get), ctx, id)
+}
+
+// List mocks base method.
+func (m *MockBlocklistRepository) List(ctx context.Context) ([]models.BlocklistEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]models.BlocklistEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockBlocklistRepositoryMockRecorder) List(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockBlocklistRepository)(nil).List), ctx)
+}
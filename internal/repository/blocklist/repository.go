@@ -0,0 +1,121 @@
+package blocklist_repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/internal/repository/postgres"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BlocklistRepository interface {
+	List(ctx context.Context) ([]models.BlocklistEntry, error)
+	Get(ctx context.Context, id int) (models.BlocklistEntry, error)
+	Create(ctx context.Context, entry models.BlocklistEntry) (int, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type Repository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func New(pool *pgxpool.Pool, log *slog.Logger) *Repository {
+	return &Repository{
+		db:  pool,
+		log: log,
+	}
+}
+
+// conn joins the transaction of ctx, if any.
+func (r *Repository) conn(ctx context.Context) postgres.Conn {
+	return postgres.ConnFrom(ctx, r.db)
+}
+
+const entryColumns = `id, kind, pattern, regex, severity, replacement, comment, created_by, created_at, updated_at`
+
+// List returns every entry, oldest first. The lists are small enough to be
+// compiled as a whole, so there is no paging.
+func (r *Repository) List(ctx context.Context) ([]models.BlocklistEntry, error) {
+	const op = "BlocklistRepository.List"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	rows, err := r.conn(ctx).Query(ctx, `SELECT `+entryColumns+` FROM blocklist_entries ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("can't query blocklist: %s", err.Error())
+	}
+
+	entries, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.BlocklistEntry])
+	if err != nil {
+		return nil, fmt.Errorf("can't scan blocklist: %s", err.Error())
+	}
+
+	return entries, nil
+}
+
+func (r *Repository) Get(ctx context.Context, id int) (models.BlocklistEntry, error) {
+	const op = "BlocklistRepository.Get"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	rows, err := r.conn(ctx).Query(ctx, `SELECT `+entryColumns+` FROM blocklist_entries WHERE id = $1`, id)
+	if err != nil {
+		return models.BlocklistEntry{}, fmt.Errorf("can't query blocklist entry: %s", err.Error())
+	}
+	entry, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByPos[models.BlocklistEntry])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.BlocklistEntry{}, ErrEntryNotFound
+		}
+		return models.BlocklistEntry{}, fmt.Errorf("can't scan blocklist entry: %s", err.Error())
+	}
+
+	return entry, nil
+}
+
+// Create adds entry. The same pattern may be listed once per kind.
+func (r *Repository) Create(ctx context.Context, entry models.BlocklistEntry) (int, error) {
+	const op = "BlocklistRepository.Create"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `INSERT INTO blocklist_entries (kind, pattern, regex, severity, replacement, comment, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT DO NOTHING
+		RETURNING id`
+	var id int
+	err := r.conn(ctx).QueryRow(ctx, query, entry.Kind, entry.Pattern, entry.Regex, entry.Severity,
+		entry.Replacement, entry.Comment, entry.CreatedBy).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrEntryExists
+		}
+		return 0, fmt.Errorf("can't insert blocklist entry: %s", err.Error())
+	}
+
+	return id, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	const op = "BlocklistRepository.Delete"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	tag, err := r.conn(ctx).Exec(ctx, `DELETE FROM blocklist_entries WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("can't delete blocklist entry: %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrEntryNotFound
+	}
+
+	return nil
+}
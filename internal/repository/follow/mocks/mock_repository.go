@@ -0,0 +1,83 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/follow/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/follow/repository.go -destination=internal/repository/follow/mocks/mock_repository.go
+//
+
+// Package mock_follow_repo is a generated GoMock package.
+package mock_follow_repo
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFollowRepository is a mock of FollowRepository interface.
+type MockFollowRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockFollowRepositoryMockRecorder
+}
+
+// MockFollowRepositoryMockRecorder is the mock recorder for MockFollowRepository.
+type MockFollowRepositoryMockRecorder struct {
+	mock *MockFollowRepository
+}
+
+// NewMockFollowRepository creates a new mock instance.
+func NewMockFollowRepository(ctrl *gomock.Controller) *MockFollowRepository {
+	mock := &MockFollowRepository{ctrl: ctrl}
+	mock.recorder = &MockFollowRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFollowRepository) EXPECT() *MockFollowRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Follow mocks base method.
+func (m *MockFollowRepository) Follow(ctx context.Context, followerID, followeeID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Follow", ctx, followerID, followeeID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Follow indicates an expected call of Follow.
+func (mr *MockFollowRepositoryMockRecorder) Follow(ctx, followerID, followeeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Follow", reflect.TypeOf((*MockFollowRepository)(nil).Follow), ctx, followerID, followeeID)
+}
+
+// Follows mocks base method.
+func (m *MockFollowRepository) Follows(ctx context.Context, followerID, followeeID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Follows", ctx, followerID, followeeID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Follows indicates an expected call of Follows.
+func (mr *MockFollowRepositoryMockRecorder) Follows(ctx, followerID, followeeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Follows", reflect.TypeOf((*MockFollowRepository)(nil).Follows), ctx, followerID, followeeID)
+}
+
+// Unfollow mocks base method.
+func (m *MockFollowRepository) Unfollow(ctx context.Context, followerID, followeeID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unfollow", ctx, followerID, followeeID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unfollow indicates an expected call of Unfollow.
+func (mr *MockFollowRepositoryMockRecorder) Unfollow(ctx, followerID, followeeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unfollow", reflect.TypeOf((*MockFollowRepository)(nil).Unfollow), ctx, followerID, followeeID)
+}
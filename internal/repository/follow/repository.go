@@ -0,0 +1,72 @@
+package follow_repo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FollowRepository interface {
+	Follow(ctx context.Context, followerID, followeeID int) error
+	Unfollow(ctx context.Context, followerID, followeeID int) error
+	Follows(ctx context.Context, followerID, followeeID int) (bool, error)
+}
+
+type Repository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func New(pool *pgxpool.Pool, log *slog.Logger) *Repository {
+	return &Repository{
+		db:  pool,
+		log: log,
+	}
+}
+
+// Follow makes followerID follow followeeID. Following twice is a no-op.
+func (r *Repository) Follow(ctx context.Context, followerID, followeeID int) error {
+	const op = "FollowRepository.Follow"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	if _, err := r.db.Exec(ctx, query, followerID, followeeID); err != nil {
+		return fmt.Errorf("can't insert follow: %s", err.Error())
+	}
+
+	return nil
+}
+
+// Unfollow undoes Follow. Unfollowing someone not followed is a no-op.
+func (r *Repository) Unfollow(ctx context.Context, followerID, followeeID int) error {
+	const op = "FollowRepository.Unfollow"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`
+	if _, err := r.db.Exec(ctx, query, followerID, followeeID); err != nil {
+		return fmt.Errorf("can't delete follow: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *Repository) Follows(ctx context.Context, followerID, followeeID int) (bool, error) {
+	const op = "FollowRepository.Follows"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)`
+	var follows bool
+	if err := r.db.QueryRow(ctx, query, followerID, followeeID).Scan(&follows); err != nil {
+		return false, fmt.Errorf("can't query follow: %s", err.Error())
+	}
+
+	return follows, nil
+}
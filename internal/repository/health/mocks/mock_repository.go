@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/health/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/health/repository.go -destination=internal/repository/health/mocks/mock_repository.go
+//
+
+// Package mock_health_repo is a generated GoMock package.
+package mock_health_repo
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockHealthRepository is a mock of HealthRepository interface.
+type MockHealthRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockHealthRepositoryMockRecorder
+}
+
+// MockHealthRepositoryMockRecorder is the mock recorder for MockHealthRepository.
+type MockHealthRepositoryMockRecorder struct {
+	mock *MockHealthRepository
+}
+
+// NewMockHealthRepository creates a new mock instance.
+func NewMockHealthRepository(ctrl *gomock.Controller) *MockHealthRepository {
+	mock := &MockHealthRepository{ctrl: ctrl}
+	mock.recorder = &MockHealthRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHealthRepository) EXPECT() *MockHealthRepositoryMockRecorder {
+	return m.recorder
+}
+
+// MigrationVersion mocks base method.
+func (m *MockHealthRepository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrationVersion", ctx)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// MigrationVersion indicates an expected call of MigrationVersion.
+func (mr *MockHealthRepositoryMockRecorder) MigrationVersion(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrationVersion", reflect.TypeOf((*MockHealthRepository)(nil).MigrationVersion), ctx)
+}
+
+// Ping mocks base method.
+func (m *MockHealthRepository) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockHealthRepositoryMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockHealthRepository)(nil).Ping), ctx)
+}
@@ -0,0 +1,99 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/linkpreview/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/linkpreview/repository.go -destination=internal/repository/linkpreview/mocks/mock_repository.go
+//
+
+// Package mock_linkpreview_repo is a generated GoMock package.
+package mock_linkpreview_repo
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/AtIasShrugged/antisocial/internal/domain/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLinkPreviewRepository is a mock of LinkPreviewRepository interface.
+type MockLinkPreviewRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLinkPreviewRepositoryMockRecorder
+}
+
+// MockLinkPreviewRepositoryMockRecorder is the mock recorder for MockLinkPreviewRepository.
+type MockLinkPreviewRepositoryMockRecorder struct {
+	mock *MockLinkPreviewRepository
+}
+
+// NewMockLinkPreviewRepository creates a new mock instance.
+func NewMockLinkPreviewRepository(ctrl *gomock.Controller) *MockLinkPreviewRepository {
+	mock := &MockLinkPreviewRepository{ctrl: ctrl}
+	mock.recorder = &MockLinkPreviewRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLinkPreviewRepository) EXPECT() *MockLinkPreviewRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimPending mocks base method.
+func (m *MockLinkPreviewRepository) ClaimPending(ctx context.Context, staleBefore time.Time, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimPending", ctx, staleBefore, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimPending indicates an expected call of ClaimPending.
+func (mr *MockLinkPreviewRepositoryMockRecorder) ClaimPending(ctx, staleBefore, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPending", reflect.TypeOf((*MockLinkPreviewRepository)(nil).ClaimPending), ctx, staleBefore, limit)
+}
+
+// Enqueue mocks base method.
+func (m *MockLinkPreviewRepository) Enqueue(ctx context.Context, postID int, urls []string, staleBefore time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", ctx, postID, urls, staleBefore)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockLinkPreviewRepositoryMockRecorder) Enqueue(ctx, postID, urls, staleBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockLinkPreviewRepository)(nil).Enqueue), ctx, postID, urls, staleBefore)
+}
+
+// MarkFailed mocks base method.
+func (m *MockLinkPreviewRepository) MarkFailed(ctx context.Context, url string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, url)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFailed indicates an expected call of MarkFailed.
+func (mr *MockLinkPreviewRepositoryMockRecorder) MarkFailed(ctx, url any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockLinkPreviewRepository)(nil).MarkFailed), ctx, url)
+}
+
+// Save mocks base method.
+func (m *MockLinkPreviewRepository) Save(ctx context.Context, preview models.LinkPreview) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, preview)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockLinkPreviewRepositoryMockRecorder) Save(ctx, preview any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockLinkPreviewRepository)(nil).Save), ctx, preview)
+}
@@ -0,0 +1,128 @@
+package linkpreview_repo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LinkPreviewRepository interface {
+	Enqueue(ctx context.Context, postID int, urls []string, staleBefore time.Time) error
+	ClaimPending(ctx context.Context, staleBefore time.Time, limit int) ([]string, error)
+	Save(ctx context.Context, preview models.LinkPreview) error
+	MarkFailed(ctx context.Context, url string) error
+}
+
+type Repository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func New(pool *pgxpool.Pool, log *slog.Logger) *Repository {
+	return &Repository{
+		db:  pool,
+		log: log,
+	}
+}
+
+// Enqueue links urls to the post and schedules a fetch for every url that is
+// not cached yet or whose cache entry was fetched before staleBefore.
+func (r *Repository) Enqueue(ctx context.Context, postID int, urls []string, staleBefore time.Time) error {
+	const op = "LinkPreviewRepository.Enqueue"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("can't create transaction: %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	upsert := `INSERT INTO link_previews (url) VALUES ($1)
+		ON CONFLICT (url) DO UPDATE SET status = 'pending'
+		WHERE link_previews.status <> 'pending' AND link_previews.fetched_at < $2`
+	link := `INSERT INTO post_link_previews (post_id, url, position) VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`
+	for i, url := range urls {
+		if _, err := tx.Exec(ctx, upsert, url, staleBefore); err != nil {
+			return fmt.Errorf("can't enqueue link preview: %s", err.Error())
+		}
+		if _, err := tx.Exec(ctx, link, postID, url, i); err != nil {
+			return fmt.Errorf("can't link preview to post: %s", err.Error())
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit transaction: %s", err.Error())
+	}
+
+	return nil
+}
+
+// ClaimPending hands out up to limit urls awaiting a fetch, skipping rows
+// another instance is working on.
+func (r *Repository) ClaimPending(ctx context.Context, staleBefore time.Time, limit int) ([]string, error) {
+	const op = "LinkPreviewRepository.ClaimPending"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `UPDATE link_previews SET claimed_at = now()
+		WHERE url IN (
+			SELECT url FROM link_previews
+			WHERE status = 'pending'
+			  AND (claimed_at IS NULL OR claimed_at < $1)
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING url`
+	rows, err := r.db.Query(ctx, query, staleBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't claim link previews: %s", err.Error())
+	}
+
+	urls, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("can't scan link previews: %s", err.Error())
+	}
+
+	return urls, nil
+}
+
+func (r *Repository) Save(ctx context.Context, preview models.LinkPreview) error {
+	const op = "LinkPreviewRepository.Save"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `UPDATE link_previews
+		SET status = 'ready', title = $2, description = $3, image_url = $4, site_name = $5,
+		    fetched_at = now(), claimed_at = NULL
+		WHERE url = $1`
+	_, err := r.db.Exec(ctx, query, preview.URL, preview.Title, preview.Description, preview.ImageURL, preview.SiteName)
+	if err != nil {
+		return fmt.Errorf("can't save link preview: %s", err.Error())
+	}
+
+	return nil
+}
+
+// MarkFailed caches a failed fetch so the url is not retried until it goes
+// stale like any other entry.
+func (r *Repository) MarkFailed(ctx context.Context, url string) error {
+	const op = "LinkPreviewRepository.MarkFailed"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `UPDATE link_previews SET status = 'failed', fetched_at = now(), claimed_at = NULL WHERE url = $1`
+	if _, err := r.db.Exec(ctx, query, url); err != nil {
+		return fmt.Errorf("can't update link preview: %s", err.Error())
+	}
+
+	return nil
+}
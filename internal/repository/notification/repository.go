@@ -0,0 +1,73 @@
+package notification_repo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type NotificationRepository interface {
+	CreateMany(ctx context.Context, notifications []models.Notification) error
+	ListByUser(ctx context.Context, userID int, limit int) ([]models.Notification, error)
+}
+
+type Repository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func New(pool *pgxpool.Pool, log *slog.Logger) *Repository {
+	return &Repository{
+		db:  pool,
+		log: log,
+	}
+}
+
+func (r *Repository) CreateMany(ctx context.Context, notifications []models.Notification) error {
+	const op = "NotificationRepository.CreateMany"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	rows := make([][]any, 0, len(notifications))
+	for _, n := range notifications {
+		rows = append(rows, []any{n.UserID, n.Kind, n.Payload})
+	}
+
+	_, err := r.db.CopyFrom(ctx,
+		pgx.Identifier{"notifications"},
+		[]string{"user_id", "kind", "payload"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return fmt.Errorf("can't insert notifications: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (r *Repository) ListByUser(ctx context.Context, userID int, limit int) ([]models.Notification, error) {
+	const op = "NotificationRepository.ListByUser"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `SELECT id, user_id, kind, payload, created_at, read_at
+		FROM notifications WHERE user_id = $1 ORDER BY id DESC LIMIT $2`
+	rows, err := r.db.Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't query notifications: %s", err.Error())
+	}
+
+	notifications, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Notification])
+	if err != nil {
+		return nil, fmt.Errorf("can't scan notifications: %s", err.Error())
+	}
+
+	return notifications, nil
+}
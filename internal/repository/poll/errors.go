@@ -0,0 +1,8 @@
+package poll_repo
+
+import "errors"
+
+var (
+	ErrPollNotFound = errors.New("poll not found")
+	ErrAlreadyVoted = errors.New("user has already voted in this poll")
+)
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/poll/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/poll/repository.go -destination=internal/repository/poll/mocks/mock_repository.go
+//
+
+// Package mock_poll_repo is a generated GoMock package.
+package mock_poll_repo
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/AtIasShrugged/antisocial/internal/domain/models"
+	poll_repo "github.com/AtIasShrugged/antisocial/internal/repository/poll"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPollRepository is a mock of PollRepository interface.
+type MockPollRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPollRepositoryMockRecorder
+}
+
+// MockPollRepositoryMockRecorder is the mock recorder for MockPollRepository.
+type MockPollRepositoryMockRecorder struct {
+	mock *MockPollRepository
+}
+
+// NewMockPollRepository creates a new mock instance.
+func NewMockPollRepository(ctrl *gomock.Controller) *MockPollRepository {
+	mock := &MockPollRepository{ctrl: ctrl}
+	mock.recorder = &MockPollRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPollRepository) EXPECT() *MockPollRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimClosed mocks base method.
+func (m *MockPollRepository) ClaimClosed(ctx context.Context, now time.Time, limit int) ([]poll_repo.ClosedPoll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimClosed", ctx, now, limit)
+	ret0, _ := ret[0].([]poll_repo.ClosedPoll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimClosed indicates an expected call of ClaimClosed.
+func (mr *MockPollRepositoryMockRecorder) ClaimClosed(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimClosed", reflect.TypeOf((*MockPollRepository)(nil).ClaimClosed), ctx, now, limit)
+}
+
+// GetByID mocks base method.
+func (m *MockPollRepository) GetByID(ctx context.Context, id, viewerID int) (models.Poll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id, viewerID)
+	ret0, _ := ret[0].(models.Poll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockPollRepositoryMockRecorder) GetByID(ctx, id, viewerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPollRepository)(nil).GetByID), ctx, id, viewerID)
+}
+
+// ReleaseClosed mocks base method.
+func (m *MockPollRepository) ReleaseClosed(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseClosed", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseClosed indicates an expected call of ReleaseClosed.
+func (mr *MockPollRepositoryMockRecorder) ReleaseClosed(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseClosed", reflect.TypeOf((*MockPollRepository)(nil).ReleaseClosed), ctx, id)
+}
+
+// Vote mocks base method.
+func (m *MockPollRepository) Vote(ctx context.Context, pollID, userID int, optionIDs []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Vote", ctx, pollID, userID, optionIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Vote indicates an expected call of Vote.
+func (mr *MockPollRepositoryMockRecorder) Vote(ctx, pollID, userID, optionIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Vote", reflect.TypeOf((*MockPollRepository)(nil).Vote), ctx, pollID, userID, optionIDs)
+}
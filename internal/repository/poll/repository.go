@@ -0,0 +1,187 @@
+package poll_repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ClosedPoll is a poll that expired and whose participants must be told.
+type ClosedPoll struct {
+	ID       int
+	PostID   int
+	AuthorID int
+	VoterIDs []int
+}
+
+type PollRepository interface {
+	GetByID(ctx context.Context, id int, viewerID int) (models.Poll, error)
+	Vote(ctx context.Context, pollID int, userID int, optionIDs []int) error
+	ClaimClosed(ctx context.Context, now time.Time, limit int) ([]ClosedPoll, error)
+	ReleaseClosed(ctx context.Context, id int) error
+}
+
+type Repository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func New(pool *pgxpool.Pool, log *slog.Logger) *Repository {
+	return &Repository{
+		db:  pool,
+		log: log,
+	}
+}
+
+// GetByID returns the poll of a post viewerID may see, with full tallies.
+// Voted reports whether viewerID has cast a ballot; hiding results is up to
+// the caller.
+func (r *Repository) GetByID(ctx context.Context, id int, viewerID int) (models.Poll, error) {
+	const op = "PollRepository.GetByID"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `SELECT p.id, p.post_id, p.multiple, p.expires_at, p.expires_at <= now(),
+			EXISTS (SELECT 1 FROM poll_voters v WHERE v.poll_id = p.id AND v.user_id = $2),
+			(SELECT count(*) FROM poll_voters v WHERE v.poll_id = p.id)
+		FROM polls p
+		WHERE p.id = $1 AND post_visible_to(p.post_id, $2)`
+
+	var (
+		poll  models.Poll
+		total int
+	)
+	err := r.db.QueryRow(ctx, query, id, viewerID).Scan(
+		&poll.ID, &poll.PostID, &poll.Multiple, &poll.ExpiresAt, &poll.Closed, &poll.Voted, &total,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Poll{}, ErrPollNotFound
+		}
+		return models.Poll{}, fmt.Errorf("can't scan poll: %s", err.Error())
+	}
+	poll.TotalVotes = &total
+
+	optionsQuery := `SELECT o.id, o.text, count(v.option_id)
+		FROM poll_options o
+		LEFT JOIN poll_votes v ON v.option_id = o.id
+		WHERE o.poll_id = $1
+		GROUP BY o.id
+		ORDER BY o.position`
+	rows, err := r.db.Query(ctx, optionsQuery, id)
+	if err != nil {
+		return models.Poll{}, fmt.Errorf("can't query poll options: %s", err.Error())
+	}
+	poll.Options, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.PollOption, error) {
+		var (
+			o     models.PollOption
+			votes int
+		)
+		err := row.Scan(&o.ID, &o.Text, &votes)
+		o.Votes = &votes
+		return o, err
+	})
+	if err != nil {
+		return models.Poll{}, fmt.Errorf("can't scan poll options: %s", err.Error())
+	}
+
+	return poll, nil
+}
+
+// Vote records a ballot. The caller validates optionIDs against the poll.
+func (r *Repository) Vote(ctx context.Context, pollID int, userID int, optionIDs []int) error {
+	const op = "PollRepository.Vote"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("can't create transaction: %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	voterQuery := `INSERT INTO poll_voters (poll_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	tag, err := tx.Exec(ctx, voterQuery, pollID, userID)
+	if err != nil {
+		return fmt.Errorf("can't insert voter: %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAlreadyVoted
+	}
+
+	voteQuery := `INSERT INTO poll_votes (poll_id, user_id, option_id) VALUES ($1, $2, $3)`
+	for _, optionID := range optionIDs {
+		if _, err := tx.Exec(ctx, voteQuery, pollID, userID, optionID); err != nil {
+			return fmt.Errorf("can't insert vote: %s", err.Error())
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit transaction: %s", err.Error())
+	}
+
+	return nil
+}
+
+// ClaimClosed marks up to limit expired polls as notified and returns them
+// with their participants. Rows locked by another instance are skipped.
+func (r *Repository) ClaimClosed(ctx context.Context, now time.Time, limit int) ([]ClosedPoll, error) {
+	const op = "PollRepository.ClaimClosed"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `WITH claimed AS (
+			UPDATE polls SET notified_at = now()
+			WHERE id IN (
+				SELECT pl.id FROM polls pl
+				JOIN visible_posts ps ON ps.id = pl.post_id
+				WHERE pl.notified_at IS NULL AND pl.expires_at <= $1
+				ORDER BY pl.expires_at
+				LIMIT $2
+				FOR UPDATE OF pl SKIP LOCKED
+			)
+			RETURNING id, post_id
+		)
+		SELECT c.id, c.post_id, p.author_id,
+			COALESCE(array_agg(v.user_id) FILTER (WHERE v.user_id IS NOT NULL), '{}')
+		FROM claimed c
+		JOIN posts p ON p.id = c.post_id
+		LEFT JOIN poll_voters v ON v.poll_id = c.id
+		GROUP BY c.id, c.post_id, p.author_id`
+	rows, err := r.db.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't claim closed polls: %s", err.Error())
+	}
+
+	closed, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ClosedPoll, error) {
+		var c ClosedPoll
+		err := row.Scan(&c.ID, &c.PostID, &c.AuthorID, &c.VoterIDs)
+		return c, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't scan closed polls: %s", err.Error())
+	}
+
+	return closed, nil
+}
+
+// ReleaseClosed undoes a claim so the poll is picked up again.
+func (r *Repository) ReleaseClosed(ctx context.Context, id int) error {
+	const op = "PollRepository.ReleaseClosed"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	if _, err := r.db.Exec(ctx, `UPDATE polls SET notified_at = NULL WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("can't release poll: %s", err.Error())
+	}
+
+	return nil
+}
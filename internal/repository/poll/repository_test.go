@@ -0,0 +1,53 @@
+package poll_repo_test
+
+import (
+	"context"
+	"testing"
+
+	poll_repo "github.com/AtIasShrugged/antisocial/internal/repository/poll"
+	"github.com/AtIasShrugged/antisocial/internal/repository/postgres/postgrestest"
+	"github.com/AtIasShrugged/antisocial/libs/logger/handlers/slogdiscard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Polls follow the visibility of their post: those of limited accounts are
+// for followers only, and those of held posts for their author only.
+func TestGetByIDFollowsPostVisibility(t *testing.T) {
+	pool := postgrestest.New(t)
+	ctx := context.Background()
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO posts (id, author_id, body, spam_verdict) VALUES (1, 1, 'limited', 'allow'), (2, 2, 'held', 'hold');
+		INSERT INTO polls (id, post_id, expires_at) VALUES (1, 1, now() + interval '1 day'), (2, 2, now() + interval '1 day');
+		INSERT INTO account_states (user_id, state, reason, expires_at, changed_by)
+			VALUES (1, 'limited', 'spam', now() + interval '1 day', 9);
+		INSERT INTO follows (follower_id, followee_id) VALUES (3, 1);
+	`)
+	require.NoError(t, err)
+
+	repo := poll_repo.New(pool, slogdiscard.NewDiscardLogger())
+	for _, tc := range []struct {
+		name     string
+		pollID   int
+		viewerID int
+		visible  bool
+	}{
+		{"limited author", 1, 1, true},
+		{"follower of limited author", 1, 3, true},
+		{"stranger to limited author", 1, 4, false},
+		{"anonymous viewer of limited author", 1, 0, false},
+		{"author of held post", 2, 2, true},
+		{"anyone else viewing a held post", 2, 3, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			poll, err := repo.GetByID(ctx, tc.pollID, tc.viewerID)
+			if !tc.visible {
+				assert.ErrorIs(t, err, poll_repo.ErrPollNotFound)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.pollID, poll.ID)
+		})
+	}
+}
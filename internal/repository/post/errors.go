@@ -3,5 +3,8 @@ package post_repo
 import "errors"
 
 var (
-	ErrPostNotFound = errors.New("post not found")
+	ErrPostNotFound           = errors.New("post not found")
+	ErrPostExpired            = errors.New("post has expired")
+	ErrPostRemoved            = errors.New("post was removed by moderators")
+	ErrAttachmentNotAvailable = errors.New("attachment not found or owned by another user")
 )
@@ -12,8 +12,10 @@ package mock_post_repo
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/AtIasShrugged/antisocial/internal/domain/models"
+	post_repo "github.com/AtIasShrugged/antisocial/internal/repository/post"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -55,6 +57,21 @@ func (mr *MockPostRepositoryMockRecorder) Create(ctx, post any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPostRepository)(nil).Create), ctx, post)
 }
 
+// DeleteExpired mocks base method.
+func (m *MockPostRepository) DeleteExpired(ctx context.Context, now time.Time, limit int) ([]post_repo.ExpiredPost, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", ctx, now, limit)
+	ret0, _ := ret[0].([]post_repo.ExpiredPost)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockPostRepositoryMockRecorder) DeleteExpired(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockPostRepository)(nil).DeleteExpired), ctx, now, limit)
+}
+
 // GetByID mocks base method.
 func (m *MockPostRepository) GetByID(ctx context.Context, id int) (models.Post, error) {
 	m.ctrl.T.Helper()
@@ -69,3 +86,91 @@ func (mr *MockPostRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockPostRepository)(nil).GetByID), ctx, id)
 }
+
+// GetDraft mocks base method.
+func (m *MockPostRepository) GetDraft(ctx context.Context, id, authorID int) (models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDraft", ctx, id, authorID)
+	ret0, _ := ret[0].(models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDraft indicates an expected call of GetDraft.
+func (mr *MockPostRepositoryMockRecorder) GetDraft(ctx, id, authorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDraft", reflect.TypeOf((*MockPostRepository)(nil).GetDraft), ctx, id, authorID)
+}
+
+// GetMany mocks base method.
+func (m *MockPostRepository) GetMany(ctx context.Context, ids []int) (map[int]models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMany", ctx, ids)
+	ret0, _ := ret[0].(map[int]models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMany indicates an expected call of GetMany.
+func (mr *MockPostRepositoryMockRecorder) GetMany(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMany", reflect.TypeOf((*MockPostRepository)(nil).GetMany), ctx, ids)
+}
+
+// ListDrafts mocks base method.
+func (m *MockPostRepository) ListDrafts(ctx context.Context, authorID int) ([]models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDrafts", ctx, authorID)
+	ret0, _ := ret[0].([]models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDrafts indicates an expected call of ListDrafts.
+func (mr *MockPostRepositoryMockRecorder) ListDrafts(ctx, authorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDrafts", reflect.TypeOf((*MockPostRepository)(nil).ListDrafts), ctx, authorID)
+}
+
+// PublishDue mocks base method.
+func (m *MockPostRepository) PublishDue(ctx context.Context, now time.Time, limit int) ([]models.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishDue", ctx, now, limit)
+	ret0, _ := ret[0].([]models.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishDue indicates an expected call of PublishDue.
+func (mr *MockPostRepositoryMockRecorder) PublishDue(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishDue", reflect.TypeOf((*MockPostRepository)(nil).PublishDue), ctx, now, limit)
+}
+
+// SetSchedule mocks base method.
+func (m *MockPostRepository) SetSchedule(ctx context.Context, id, authorID int, status models.PostStatus, publishAt *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSchedule", ctx, id, authorID, status, publishAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSchedule indicates an expected call of SetSchedule.
+func (mr *MockPostRepositoryMockRecorder) SetSchedule(ctx, id, authorID, status, publishAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSchedule", reflect.TypeOf((*MockPostRepository)(nil).SetSchedule), ctx, id, authorID, status, publishAt)
+}
+
+// UpdateDraft mocks base method.
+func (m *MockPostRepository) UpdateDraft(ctx context.Context, id, authorID int, body string, spam *models.SpamClassification, hold *models.ModerationHold) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDraft", ctx, id, authorID, body, spam, hold)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDraft indicates an expected call of UpdateDraft.
+func (mr *MockPostRepositoryMockRecorder) UpdateDraft(ctx, id, authorID, body, spam, hold any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDraft", reflect.TypeOf((*MockPostRepository)(nil).UpdateDraft), ctx, id, authorID, body, spam, hold)
+}
@@ -2,17 +2,37 @@ package post_repo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/internal/tracing"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PostRepository interface {
 	GetByID(ctx context.Context, id int) (models.Post, error)
+	GetMany(ctx context.Context, ids []int) (map[int]models.Post, error)
 	Create(ctx context.Context, post models.Post) (int, error)
+	GetDraft(ctx context.Context, id int, authorID int) (models.Post, error)
+	ListDrafts(ctx context.Context, authorID int) ([]models.Post, error)
+	UpdateDraft(ctx context.Context, id int, authorID int, body string, spam *models.SpamClassification, hold *models.ModerationHold) error
+	SetSchedule(ctx context.Context, id int, authorID int, status models.PostStatus, publishAt *time.Time) error
+	PublishDue(ctx context.Context, now time.Time, limit int) ([]models.Post, error)
+	DeleteExpired(ctx context.Context, now time.Time, limit int) ([]ExpiredPost, error)
+}
+
+// ExpiredPost is an ephemeral post that has been reaped, along with the
+// attachments it carried.
+type ExpiredPost struct {
+	ID            int
+	AttachmentIDs []int
 }
 
 type Repository struct {
@@ -27,52 +47,659 @@ func New(pool *pgxpool.Pool, log *slog.Logger) *Repository {
 	}
 }
 
+// GetByID returns a published post. Drafts and scheduled posts are private
+// to their author and only reachable through GetDraft. Ephemeral posts past
+// their expiry yield ErrPostExpired, whether or not they have been reaped,
+// and posts taken down by moderators yield ErrPostRemoved. Posts the spam
+// filter didn't allow come with their classification, and posts of authors
+// who aren't active with the author's state, for the caller to hide.
 func (r *Repository) GetByID(ctx context.Context, id int) (models.Post, error) {
 	const op = "PostRepository.GetByID"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
-	query := `SELECT * FROM posts WHERE id = $1`
+	query := `SELECT p.id, p.author_id, p.body, p.expires_at, COALESCE(p.expires_at <= now(), false), p.removed_at IS NOT NULL,
+			p.spam_verdict, p.spam_score, COALESCE(s.state, '')
+		FROM posts p
+		LEFT JOIN account_states s ON s.user_id = p.author_id AND (s.expires_at IS NULL OR s.expires_at > now())
+		WHERE p.id = $1 AND p.status = 'published'`
 	row := r.db.QueryRow(ctx, query, id)
 
-	var post models.Post
-	err := row.Scan(&post.ID, &post.AuthorID, &post.Body)
+	var (
+		post             models.Post
+		expired, removed bool
+		spam             models.SpamClassification
+	)
+	err := row.Scan(&post.ID, &post.AuthorID, &post.Body, &post.ExpiresAt, &expired, &removed,
+		&spam.Verdict, &spam.Score, &post.AuthorState)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			r.log.Error(op + ":" + err.Error())
+			return models.Post{}, r.missing(ctx, id)
+		}
+		return models.Post{}, fmt.Errorf("can't scan post: %s", err.Error())
+	}
+	if removed {
+		return models.Post{}, ErrPostRemoved
+	}
+	if expired {
+		return models.Post{}, ErrPostExpired
+	}
+	if spam.Verdict != models.SpamAllow {
+		post.Spam = &spam
+	}
+
+	if err := r.loadRelations(ctx, &post); err != nil {
+		return models.Post{}, err
+	}
+
+	return post, nil
+}
+
+// GetMany returns those of the published posts ids that can still be read,
+// keyed by ID, with the same spam classification and author state as
+// GetByID. Posts that are missing, expired or removed are left out rather
+// than told apart.
+func (r *Repository) GetMany(ctx context.Context, ids []int) (map[int]models.Post, error) {
+	const op = "PostRepository.GetMany"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT p.id, p.author_id, p.body, p.expires_at, p.spam_verdict, p.spam_score, COALESCE(s.state, '')
+		FROM posts p
+		LEFT JOIN account_states s ON s.user_id = p.author_id AND (s.expires_at IS NULL OR s.expires_at > now())
+		WHERE p.id = ANY($1) AND p.status = 'published' AND p.removed_at IS NULL
+			AND (p.expires_at IS NULL OR p.expires_at > now())`
+	rows, err := r.db.Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("can't query posts: %s", err.Error())
+	}
+	list, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Post, error) {
+		var (
+			post models.Post
+			spam models.SpamClassification
+		)
+		err := row.Scan(&post.ID, &post.AuthorID, &post.Body, &post.ExpiresAt, &spam.Verdict, &spam.Score, &post.AuthorState)
+		if spam.Verdict != models.SpamAllow {
+			post.Spam = &spam
+		}
+		return post, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't scan posts: %s", err.Error())
+	}
+
+	refs := make([]*models.Post, len(list))
+	for i := range list {
+		refs[i] = &list[i]
+	}
+	if err := r.loadRelations(ctx, refs...); err != nil {
+		return nil, err
+	}
+
+	posts := make(map[int]models.Post, len(list))
+	for _, post := range list {
+		posts[post.ID] = post
+	}
+	return posts, nil
+}
+
+// missing tells a post that never existed from one that expired and was
+// reaped.
+func (r *Repository) missing(ctx context.Context, id int) error {
+	var reaped bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM expired_posts WHERE post_id = $1)`, id).Scan(&reaped)
+	if err != nil {
+		return fmt.Errorf("can't look up tombstone: %s", err.Error())
+	}
+	if reaped {
+		return ErrPostExpired
+	}
+	return ErrPostNotFound
+}
+
+// GetDraft returns an unpublished post of authorID, with its spam
+// classification if the filter didn't allow it.
+func (r *Repository) GetDraft(ctx context.Context, id int, authorID int) (models.Post, error) {
+	const op = "PostRepository.GetDraft"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT id, author_id, body, status, publish_at, COALESCE(ttl_seconds, 0), spam_verdict, spam_score FROM posts
+		WHERE id = $1 AND author_id = $2 AND status <> 'published'`
+
+	var (
+		post models.Post
+		spam models.SpamClassification
+	)
+	err := r.db.QueryRow(ctx, query, id, authorID).Scan(&post.ID, &post.AuthorID, &post.Body, &post.Status, &post.PublishAt, &post.TTLSeconds,
+		&spam.Verdict, &spam.Score)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return models.Post{}, ErrPostNotFound
 		}
-		r.log.Error(op + ":" + err.Error())
 		return models.Post{}, fmt.Errorf("can't scan post: %s", err.Error())
 	}
+	if spam.Verdict != models.SpamAllow {
+		post.Spam = &spam
+	}
+
+	if err := r.loadRelations(ctx, &post); err != nil {
+		return models.Post{}, err
+	}
 
 	return post, nil
 }
 
+// ListDrafts returns the drafts and scheduled posts of authorID, most recent
+// first. Relations are not loaded.
+func (r *Repository) ListDrafts(ctx context.Context, authorID int) ([]models.Post, error) {
+	const op = "PostRepository.ListDrafts"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `SELECT id, author_id, body, status, publish_at, COALESCE(ttl_seconds, 0) FROM posts
+		WHERE author_id = $1 AND status <> 'published'
+		ORDER BY created_at DESC, id DESC`
+	rows, err := r.db.Query(ctx, query, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("can't query drafts: %s", err.Error())
+	}
+
+	posts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Post, error) {
+		var p models.Post
+		err := row.Scan(&p.ID, &p.AuthorID, &p.Body, &p.Status, &p.PublishAt, &p.TTLSeconds)
+		return p, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't scan drafts: %s", err.Error())
+	}
+
+	return posts, nil
+}
+
+// UpdateDraft replaces the body of an unpublished post of authorID along
+// with its spam classification, if any, and holds it for moderation if
+// asked to. A post already held or limited stays so whatever its new body.
+func (r *Repository) UpdateDraft(ctx context.Context, id int, authorID int, body string, spam *models.SpamClassification, hold *models.ModerationHold) error {
+	const op = "PostRepository.UpdateDraft"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("can't create transaction: %s", err.Error())
+	}
+
+	var verdict models.SpamVerdict
+	query := `SELECT spam_verdict FROM posts WHERE id = $1 AND author_id = $2 AND status <> 'published' FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, id, authorID).Scan(&verdict); err != nil {
+		r.rollback(ctx, tx, op)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		return fmt.Errorf("can't lock post: %s", err.Error())
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE posts SET body = $2 WHERE id = $1`, id, body); err != nil {
+		r.rollback(ctx, tx, op)
+		return fmt.Errorf("can't update post: %s", err.Error())
+	}
+	if spam != nil && verdict == models.SpamAllow {
+		if err := applySpamVerdict(ctx, tx, id, authorID, spam); err != nil {
+			r.rollback(ctx, tx, op)
+			return err
+		}
+	}
+	if hold != nil && verdict == models.SpamAllow {
+		if err := applyHold(ctx, tx, id, authorID, hold); err != nil {
+			r.rollback(ctx, tx, op)
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit transaction: %s", err.Error())
+	}
+
+	return nil
+}
+
+// SetSchedule moves an unpublished post of authorID to status. The status
+// check happens under the row lock, so a post the scheduler has already
+// published is reported as not found rather than silently rescheduled.
+func (r *Repository) SetSchedule(ctx context.Context, id int, authorID int, status models.PostStatus, publishAt *time.Time) error {
+	const op = "PostRepository.SetSchedule"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `UPDATE posts
+		SET status = $3, publish_at = $4,
+		    published_at = CASE WHEN $3::text = 'published' THEN now() END,
+		    expires_at = CASE WHEN $3::text = 'published' THEN now() + make_interval(secs => ttl_seconds) END
+		WHERE id = $1 AND author_id = $2 AND status <> 'published'`
+	tag, err := r.db.Exec(ctx, query, id, authorID, status, publishAt)
+	if err != nil {
+		return fmt.Errorf("can't schedule post: %s", err.Error())
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPostNotFound
+	}
+
+	return nil
+}
+
+// PublishDue publishes up to limit scheduled posts whose time has come and
+// returns them, with the spam classification of those the filter didn't
+// allow. Rows locked by another instance are skipped, so every post
+// is published exactly once however many schedulers run.
+func (r *Repository) PublishDue(ctx context.Context, now time.Time, limit int) ([]models.Post, error) {
+	const op = "PostRepository.PublishDue"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `UPDATE posts
+		SET status = 'published', published_at = publish_at,
+		    expires_at = publish_at + make_interval(secs => ttl_seconds)
+		WHERE id IN (
+			SELECT id FROM posts
+			WHERE status = 'scheduled' AND publish_at <= $1
+			ORDER BY publish_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, author_id, body, spam_verdict, spam_score`
+	rows, err := r.db.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't publish due posts: %s", err.Error())
+	}
+
+	posts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Post, error) {
+		var (
+			p    = models.Post{Status: models.PostPublished}
+			spam models.SpamClassification
+		)
+		err := row.Scan(&p.ID, &p.AuthorID, &p.Body, &spam.Verdict, &spam.Score)
+		if spam.Verdict != models.SpamAllow {
+			p.Spam = &spam
+		}
+		return p, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't scan published posts: %s", err.Error())
+	}
+
+	return posts, nil
+}
+
+// DeleteExpired hard-deletes up to limit ephemeral posts that expired by now,
+// leaving tombstones behind, and returns them with the attachments they
+// carried. Rows locked by another instance are skipped.
+func (r *Repository) DeleteExpired(ctx context.Context, now time.Time, limit int) ([]ExpiredPost, error) {
+	const op = "PostRepository.DeleteExpired"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	query := `WITH expired AS (
+			SELECT id, expires_at FROM posts
+			WHERE expires_at <= $1
+			ORDER BY expires_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		), tombstones AS (
+			INSERT INTO expired_posts (post_id, expired_at)
+			SELECT id, expires_at FROM expired
+			ON CONFLICT DO NOTHING
+		), deleted AS (
+			DELETE FROM posts p USING expired e WHERE p.id = e.id
+			RETURNING p.id
+		)
+		SELECT d.id, COALESCE(array_agg(pa.attachment_id) FILTER (WHERE pa.attachment_id IS NOT NULL), '{}')
+		FROM deleted d
+		LEFT JOIN post_attachments pa ON pa.post_id = d.id
+		GROUP BY d.id`
+	rows, err := r.db.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("can't delete expired posts: %s", err.Error())
+	}
+
+	expired, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ExpiredPost, error) {
+		var e ExpiredPost
+		err := row.Scan(&e.ID, &e.AttachmentIDs)
+		return e, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't scan expired posts: %s", err.Error())
+	}
+
+	return expired, nil
+}
+
+// loadRelations fills in the attachments, link previews and poll of posts,
+// a query per relation however many posts there are.
+func (r *Repository) loadRelations(ctx context.Context, posts ...*models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]int, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+
+	attachments, err := r.attachments(ctx, ids)
+	if err != nil {
+		return err
+	}
+	previews, err := r.linkPreviews(ctx, ids)
+	if err != nil {
+		return err
+	}
+	polls, err := r.polls(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		post.Attachments = attachments[post.ID]
+		post.LinkPreviews = previews[post.ID]
+		post.Poll = polls[post.ID]
+	}
+	return nil
+}
+
+// attachments loads the ordered attachments of posts with their variants,
+// keyed by post ID.
+func (r *Repository) attachments(ctx context.Context, postIDs []int) (map[int][]models.PostAttachment, error) {
+	query := `SELECT pa.post_id, pa.attachment_id, pa.alt_text, a.content_type, a.status,
+			COALESCE(a.width, 0), COALESCE(a.height, 0), COALESCE(a.blurhash, '')
+		FROM post_attachments pa
+		JOIN attachments a ON a.id = pa.attachment_id
+		WHERE pa.post_id = ANY($1)
+		ORDER BY pa.post_id, pa.position`
+	rows, err := r.db.Query(ctx, query, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("can't query attachments: %s", err.Error())
+	}
+	attachments := map[int][]models.PostAttachment{}
+	var (
+		postID int
+		a      models.PostAttachment
+	)
+	_, err = pgx.ForEachRow(rows, []any{&postID, &a.AttachmentID, &a.AltText, &a.ContentType, &a.Status, &a.Width, &a.Height, &a.Blurhash}, func() error {
+		attachments[postID] = append(attachments[postID], a)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't scan attachments: %s", err.Error())
+	}
+	if len(attachments) == 0 {
+		return attachments, nil
+	}
+
+	variantsQuery := `SELECT v.attachment_id, v.name, v.content_type, v.width, v.height, v.size
+		FROM attachment_variants v
+		JOIN post_attachments pa ON pa.attachment_id = v.attachment_id
+		WHERE pa.post_id = ANY($1)
+		ORDER BY v.width`
+	rows, err = r.db.Query(ctx, variantsQuery, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("can't query variants: %s", err.Error())
+	}
+	variants := map[int][]models.AttachmentVariant{}
+	var (
+		attachmentID int
+		v            models.AttachmentVariant
+	)
+	_, err = pgx.ForEachRow(rows, []any{&attachmentID, &v.Name, &v.ContentType, &v.Width, &v.Height, &v.Size}, func() error {
+		variants[attachmentID] = append(variants[attachmentID], v)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't scan variants: %s", err.Error())
+	}
+	for _, list := range attachments {
+		for i := range list {
+			list[i].Variants = variants[list[i].AttachmentID]
+		}
+	}
+
+	return attachments, nil
+}
+
+// linkPreviews loads the previews of posts that have been fetched so far,
+// keyed by post ID.
+func (r *Repository) linkPreviews(ctx context.Context, postIDs []int) (map[int][]models.LinkPreview, error) {
+	query := `SELECT plp.post_id, lp.url, lp.title, lp.description, lp.image_url, lp.site_name
+		FROM post_link_previews plp
+		JOIN link_previews lp ON lp.url = plp.url
+		WHERE plp.post_id = ANY($1) AND lp.status = 'ready'
+		ORDER BY plp.post_id, plp.position`
+	rows, err := r.db.Query(ctx, query, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("can't query link previews: %s", err.Error())
+	}
+	previews := map[int][]models.LinkPreview{}
+	var (
+		postID int
+		lp     models.LinkPreview
+	)
+	_, err = pgx.ForEachRow(rows, []any{&postID, &lp.URL, &lp.Title, &lp.Description, &lp.ImageURL, &lp.SiteName}, func() error {
+		previews[postID] = append(previews[postID], lp)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't scan link previews: %s", err.Error())
+	}
+
+	return previews, nil
+}
+
+// polls loads the polls of posts, keyed by post ID, without tallies: those
+// depend on who is asking and are served by the poll endpoints.
+func (r *Repository) polls(ctx context.Context, postIDs []int) (map[int]*models.Poll, error) {
+	query := `SELECT post_id, id, multiple, expires_at, expires_at <= now() FROM polls WHERE post_id = ANY($1)`
+	rows, err := r.db.Query(ctx, query, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("can't query polls: %s", err.Error())
+	}
+	polls := map[int]*models.Poll{}
+	byID := map[int]*models.Poll{}
+	var (
+		postID int
+		p      models.Poll
+	)
+	_, err = pgx.ForEachRow(rows, []any{&postID, &p.ID, &p.Multiple, &p.ExpiresAt, &p.Closed}, func() error {
+		poll := p
+		poll.Options = []models.PollOption{}
+		polls[postID] = &poll
+		byID[poll.ID] = &poll
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't scan polls: %s", err.Error())
+	}
+	if len(byID) == 0 {
+		return polls, nil
+	}
+
+	pollIDs := make([]int, 0, len(byID))
+	for id := range byID {
+		pollIDs = append(pollIDs, id)
+	}
+	rows, err = r.db.Query(ctx, `SELECT poll_id, id, text FROM poll_options WHERE poll_id = ANY($1) ORDER BY poll_id, position`, pollIDs)
+	if err != nil {
+		return nil, fmt.Errorf("can't query poll options: %s", err.Error())
+	}
+	var (
+		pollID int
+		o      models.PollOption
+	)
+	_, err = pgx.ForEachRow(rows, []any{&pollID, &o.ID, &o.Text}, func() error {
+		byID[pollID].Options = append(byID[pollID].Options, o)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't scan poll options: %s", err.Error())
+	}
+
+	return polls, nil
+}
+
 func (r *Repository) Create(ctx context.Context, post models.Post) (int, error) {
 	const op = "PostRepository.Create"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
 	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		r.log.Error(op + ":" + err.Error())
 		return 0, fmt.Errorf("can't create transaction: %s", err.Error())
 	}
 
-	query := `INSERT INTO posts (author_id, body) VALUES ($1, $2) RETURNING id`
+	status := post.Status
+	if status == "" {
+		status = models.PostPublished
+	}
+	var ttl *int
+	if post.TTLSeconds > 0 {
+		ttl = &post.TTLSeconds
+	}
+	query := `INSERT INTO posts (author_id, body, status, publish_at, ttl_seconds, published_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5,
+			CASE WHEN $3::text = 'published' THEN now() END,
+			CASE WHEN $3::text = 'published' THEN now() + make_interval(secs => $5::int) END)
+		RETURNING id`
 	var id int
-	err = tx.QueryRow(ctx, query, post.AuthorID, post.Body).Scan(&id)
+	err = tx.QueryRow(ctx, query, post.AuthorID, post.Body, status, post.PublishAt, ttl).Scan(&id)
 	if err != nil {
-		rollbackErr := tx.Rollback(ctx)
-		if rollbackErr != nil {
-			r.log.Error(op + ":" + rollbackErr.Error())
-			return 0, fmt.Errorf("can't rollback transaction: %s", rollbackErr.Error())
-		}
-		r.log.Error(op + ":" + err.Error())
+		r.rollback(ctx, tx, op)
 		return 0, fmt.Errorf("can't insert post: %s", err.Error())
 	}
 
+	// Attachments must belong to the author; anything else is treated as missing.
+	attachQuery := `INSERT INTO post_attachments (post_id, attachment_id, position, alt_text)
+		SELECT $1, id, $3, $4 FROM attachments WHERE id = $2 AND owner_id = $5`
+	for i, a := range post.Attachments {
+		tag, err := tx.Exec(ctx, attachQuery, id, a.AttachmentID, i, a.AltText, post.AuthorID)
+		if err == nil && tag.RowsAffected() == 0 {
+			err = ErrAttachmentNotAvailable
+		}
+		if err != nil {
+			r.rollback(ctx, tx, op)
+			if errors.Is(err, ErrAttachmentNotAvailable) {
+				return 0, err
+			}
+			return 0, fmt.Errorf("can't attach media: %s", err.Error())
+		}
+	}
+
+	if post.Poll != nil {
+		if err := insertPoll(ctx, tx, id, post.Poll); err != nil {
+			r.rollback(ctx, tx, op)
+			return 0, err
+		}
+	}
+
+	if post.Spam != nil {
+		if err := applySpamVerdict(ctx, tx, id, post.AuthorID, post.Spam); err != nil {
+			r.rollback(ctx, tx, op)
+			return 0, err
+		}
+	}
+	if post.Hold != nil {
+		if err := applyHold(ctx, tx, id, post.AuthorID, post.Hold); err != nil {
+			r.rollback(ctx, tx, op)
+			return 0, err
+		}
+	}
+
 	if err := tx.Commit(ctx); err != nil {
-		r.log.Error(op + ":" + err.Error())
 		return 0, fmt.Errorf("can't commit transaction: %s", err.Error())
 	}
 
 	return id, nil
 }
+
+// applySpamVerdict stores the spam classification of a post. Held posts go
+// to the moderation queue as a spam report filed by no one (reporter 0).
+func applySpamVerdict(ctx context.Context, tx pgx.Tx, postID int, authorID int, spam *models.SpamClassification) error {
+	_, err := tx.Exec(ctx, `UPDATE posts SET spam_verdict = $2, spam_score = $3 WHERE id = $1`, postID, spam.Verdict, spam.Score)
+	if err != nil {
+		return fmt.Errorf("can't store spam verdict: %s", err.Error())
+	}
+	if spam.Verdict != models.SpamHold {
+		return nil
+	}
+
+	comment := fmt.Sprintf("Held by the spam filter: score %.2f", spam.Score)
+	if len(spam.Reasons) > 0 {
+		comment += " (" + strings.Join(spam.Reasons, ", ") + ")"
+	}
+	query := `INSERT INTO reports (reporter_id, target, post_id, account_id, reason, comment)
+		VALUES (0, 'post', $1, $2, 'spam', $3)
+		ON CONFLICT DO NOTHING`
+	if _, err := tx.Exec(ctx, query, postID, authorID, comment); err != nil {
+		return fmt.Errorf("can't file spam report: %s", err.Error())
+	}
+	return nil
+}
+
+// applyHold hides a post the way the spam filter holds one and files a
+// report for it by no one (reporter 0). A post the spam filter already
+// limited stays limited; one it already held keeps the spam report, as a
+// reporter has a single open report per post.
+func applyHold(ctx context.Context, tx pgx.Tx, postID int, authorID int, hold *models.ModerationHold) error {
+	_, err := tx.Exec(ctx, `UPDATE posts SET spam_verdict = 'hold' WHERE id = $1 AND spam_verdict = 'allow'`, postID)
+	if err != nil {
+		return fmt.Errorf("can't hold post: %s", err.Error())
+	}
+
+	query := `INSERT INTO reports (reporter_id, target, post_id, account_id, reason, comment)
+		VALUES (0, 'post', $1, $2, $3, $4)
+		ON CONFLICT DO NOTHING`
+	if _, err := tx.Exec(ctx, query, postID, authorID, hold.Reason, hold.Comment); err != nil {
+		return fmt.Errorf("can't file moderation report: %s", err.Error())
+	}
+	return nil
+}
+
+// rollback aborts tx after a failed statement. The statement's error is the
+// one worth returning, so a rollback failure is only logged.
+func (r *Repository) rollback(ctx context.Context, tx pgx.Tx, op string) {
+	if err := tx.Rollback(ctx); err != nil {
+		sl.FromContext(ctx, r.log).ErrorContext(ctx, "can't rollback transaction", sl.Op(op), sl.Err(err))
+	}
+}
+
+func insertPoll(ctx context.Context, tx pgx.Tx, postID int, poll *models.Poll) error {
+	var pollID int
+	query := `INSERT INTO polls (post_id, multiple, expires_at) VALUES ($1, $2, $3) RETURNING id`
+	if err := tx.QueryRow(ctx, query, postID, poll.Multiple, poll.ExpiresAt).Scan(&pollID); err != nil {
+		return fmt.Errorf("can't insert poll: %s", err.Error())
+	}
+
+	optionQuery := `INSERT INTO poll_options (poll_id, position, text) VALUES ($1, $2, $3)`
+	for i, o := range poll.Options {
+		if _, err := tx.Exec(ctx, optionQuery, pollID, i, o.Text); err != nil {
+			return fmt.Errorf("can't insert poll option: %s", err.Error())
+		}
+	}
+
+	return nil
+}
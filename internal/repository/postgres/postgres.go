@@ -0,0 +1,55 @@
+// Package postgres lets repositories share a transaction: statements run on
+// the transaction carried by the context when there is one, and on the pool
+// otherwise.
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Conn is what repositories run statements on. Begin starts a transaction
+// on the pool, or a savepoint within the transaction of the context.
+type Conn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type ctxKey struct{}
+
+// ConnFrom returns the transaction of ctx, or pool if ctx has none.
+func ConnFrom(ctx context.Context, pool *pgxpool.Pool) Conn {
+	if tx, ok := ctx.Value(ctxKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return pool
+}
+
+// InTx runs fn in a transaction and commits it if fn returns nil. Every
+// repository called with the context given to fn joins the transaction. A
+// transaction already in ctx is joined rather than nested.
+func InTx(ctx context.Context, pool *pgxpool.Pool, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(ctxKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("can't create transaction: %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, ctxKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit transaction: %s", err.Error())
+	}
+	return nil
+}
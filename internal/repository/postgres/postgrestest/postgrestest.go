@@ -0,0 +1,79 @@
+// Package postgrestest gives repository tests a migrated database of their
+// own. Tests using it are skipped unless TEST_DATABASE_URL names a Postgres
+// database they may create schemas in.
+package postgrestest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AtIasShrugged/antisocial/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+const envURL = "TEST_DATABASE_URL"
+
+// New returns a pool whose statements run in a fresh schema with every
+// migration applied. The schema is dropped when the test ends.
+func New(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv(envURL)
+	if url == "" {
+		t.Skip(envURL + " is not set")
+	}
+	ctx := context.Background()
+
+	b := make([]byte, 6)
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+	schema := "test_" + hex.EncodeToString(b)
+
+	admin, err := pgxpool.New(ctx, url)
+	require.NoError(t, err)
+	t.Cleanup(admin.Close)
+	_, err = admin.Exec(ctx, "CREATE SCHEMA "+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	})
+
+	cfg, err := pgxpool.ParseConfig(url)
+	require.NoError(t, err)
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	for _, name := range upMigrations(t) {
+		sql, err := fs.ReadFile(migrations.FS, name)
+		require.NoError(t, err)
+		_, err = pool.Exec(ctx, string(sql))
+		require.NoError(t, err, name)
+	}
+	return pool
+}
+
+// upMigrations lists the up migrations by version.
+func upMigrations(t *testing.T) []string {
+	t.Helper()
+
+	names, err := fs.Glob(migrations.FS, "*.up.sql")
+	require.NoError(t, err)
+	version := func(name string) int {
+		prefix, _, _ := strings.Cut(name, "_")
+		v, err := strconv.Atoi(prefix)
+		require.NoError(t, err, name)
+		return v
+	}
+	sort.Slice(names, func(i, j int) bool { return version(names[i]) < version(names[j]) })
+	return names
+}
@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/ratelimit/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/ratelimit/repository.go -destination=internal/repository/ratelimit/mocks/mock_repository.go
+//
+
+// Package mock_ratelimit_repo is a generated GoMock package.
+package mock_ratelimit_repo
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRateLimitRepository is a mock of RateLimitRepository interface.
+type MockRateLimitRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRateLimitRepositoryMockRecorder
+}
+
+// MockRateLimitRepositoryMockRecorder is the mock recorder for MockRateLimitRepository.
+type MockRateLimitRepositoryMockRecorder struct {
+	mock *MockRateLimitRepository
+}
+
+// NewMockRateLimitRepository creates a new mock instance.
+func NewMockRateLimitRepository(ctrl *gomock.Controller) *MockRateLimitRepository {
+	mock := &MockRateLimitRepository{ctrl: ctrl}
+	mock.recorder = &MockRateLimitRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRateLimitRepository) EXPECT() *MockRateLimitRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteIdle mocks base method.
+func (m *MockRateLimitRepository) DeleteIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdle", ctx, idle)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteIdle indicates an expected call of DeleteIdle.
+func (mr *MockRateLimitRepositoryMockRecorder) DeleteIdle(ctx, idle any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdle", reflect.TypeOf((*MockRateLimitRepository)(nil).DeleteIdle), ctx, idle)
+}
+
+// Take mocks base method.
+func (m *MockRateLimitRepository) Take(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Take", ctx, key, rate, burst)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Take indicates an expected call of Take.
+func (mr *MockRateLimitRepositoryMockRecorder) Take(ctx, key, rate, burst any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Take", reflect.TypeOf((*MockRateLimitRepository)(nil).Take), ctx, key, rate, burst)
+}
@@ -0,0 +1,70 @@
+package ratelimit_repo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RateLimitRepository interface {
+	Take(ctx context.Context, key string, rate float64, burst int) (tokens float64, allowed bool, err error)
+	DeleteIdle(ctx context.Context, idle time.Duration) (int64, error)
+}
+
+type Repository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func New(pool *pgxpool.Pool, log *slog.Logger) *Repository {
+	return &Repository{
+		db:  pool,
+		log: log,
+	}
+}
+
+// Take refills the bucket for key at rate tokens per second up to burst and
+// takes a token from it if there is one. The row lock of the upsert makes
+// concurrent takes from several instances queue up instead of racing.
+func (r *Repository) Take(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	const op = "RateLimitRepository.Take"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, $3::float8 - 1, true, now())
+		ON CONFLICT (key) DO UPDATE SET
+			allowed = LEAST($3::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $2) >= 1,
+			tokens = LEAST($3::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $2)
+				- CASE WHEN LEAST($3::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $2) >= 1 THEN 1 ELSE 0 END,
+			updated_at = now()
+		RETURNING tokens, allowed`
+	var (
+		tokens  float64
+		allowed bool
+	)
+	if err := r.db.QueryRow(ctx, query, key, rate, burst).Scan(&tokens, &allowed); err != nil {
+		return 0, false, fmt.Errorf("can't take from rate limit bucket: %s", err.Error())
+	}
+
+	return tokens, allowed, nil
+}
+
+// DeleteIdle drops buckets untouched for idle.
+func (r *Repository) DeleteIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	const op = "RateLimitRepository.DeleteIdle"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	tag, err := r.db.Exec(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < now() - make_interval(secs => $1)`, idle.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("can't delete idle rate limit buckets: %s", err.Error())
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,10 @@
+package report_repo
+
+import "errors"
+
+var (
+	ErrReportNotFound  = errors.New("report not found")
+	ErrReportResolved  = errors.New("report is already resolved")
+	ErrTargetNotFound  = errors.New("reported post not found")
+	ErrAlreadyReported = errors.New("already reported")
+)
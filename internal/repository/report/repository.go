@@ -0,0 +1,245 @@
+package report_repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/internal/repository/postgres"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReportRepository interface {
+	Create(ctx context.Context, report models.Report) (int, error)
+	GetByID(ctx context.Context, id int) (models.Report, error)
+	List(ctx context.Context, filter models.ReportFilter) ([]models.Report, error)
+	Assign(ctx context.Context, id int, assigneeID *int) error
+	Resolve(ctx context.Context, action models.ModerationAction) (models.ModerationAction, error)
+}
+
+type Repository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func New(pool *pgxpool.Pool, log *slog.Logger) *Repository {
+	return &Repository{
+		db:  pool,
+		log: log,
+	}
+}
+
+// conn joins the transaction of ctx, if any.
+func (r *Repository) conn(ctx context.Context) postgres.Conn {
+	return postgres.ConnFrom(ctx, r.db)
+}
+
+const reportColumns = `id, reporter_id, target, post_id, account_id, reason, comment,
+	status, assignee_id, created_at, resolved_at`
+
+// Create files an open report. Post reports must name a visible post, whose
+// author becomes the reported account.
+func (r *Repository) Create(ctx context.Context, report models.Report) (int, error) {
+	const op = "ReportRepository.Create"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	if report.Target == models.ReportPost {
+		query := `SELECT author_id FROM visible_posts WHERE id = $1`
+		err := r.conn(ctx).QueryRow(ctx, query, *report.PostID).Scan(&report.AccountID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return 0, ErrTargetNotFound
+			}
+			return 0, fmt.Errorf("can't look up reported post: %s", err.Error())
+		}
+	}
+
+	query := `INSERT INTO reports (reporter_id, target, post_id, account_id, reason, comment)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT DO NOTHING
+		RETURNING id`
+	var id int
+	err := r.conn(ctx).QueryRow(ctx, query,
+		report.ReporterID, report.Target, report.PostID, report.AccountID, report.Reason, report.Comment,
+	).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrAlreadyReported
+		}
+		return 0, fmt.Errorf("can't insert report: %s", err.Error())
+	}
+
+	return id, nil
+}
+
+// GetByID returns a report along with the actions taken on it.
+func (r *Repository) GetByID(ctx context.Context, id int) (models.Report, error) {
+	const op = "ReportRepository.GetByID"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	rows, err := r.conn(ctx).Query(ctx, `SELECT `+reportColumns+` FROM reports WHERE id = $1`, id)
+	if err != nil {
+		return models.Report{}, fmt.Errorf("can't query report: %s", err.Error())
+	}
+	report, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByPos[models.Report])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Report{}, ErrReportNotFound
+		}
+		return models.Report{}, fmt.Errorf("can't scan report: %s", err.Error())
+	}
+
+	actionsQuery := `SELECT id, report_id, moderator_id, action, rationale, expires_at, created_at
+		FROM moderation_actions WHERE report_id = $1 ORDER BY id`
+	rows, err = r.conn(ctx).Query(ctx, actionsQuery, id)
+	if err != nil {
+		return models.Report{}, fmt.Errorf("can't query moderation actions: %s", err.Error())
+	}
+	report.Actions, err = pgx.CollectRows(rows, pgx.RowToStructByPos[models.ModerationAction])
+	if err != nil {
+		return models.Report{}, fmt.Errorf("can't scan moderation actions: %s", err.Error())
+	}
+
+	return report, nil
+}
+
+// List returns the reports matching filter, oldest first.
+func (r *Repository) List(ctx context.Context, filter models.ReportFilter) ([]models.Report, error) {
+	const op = "ReportRepository.List"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `SELECT ` + reportColumns + ` FROM reports
+		WHERE ($1 = '' OR status = $1)
+			AND ($2 = '' OR reason = $2)
+			AND ($3 = '' OR target = $3)
+			AND ($4 = 0 OR account_id = $4)
+			AND ($5 = 0 OR assignee_id = $5)
+			AND (NOT $6 OR assignee_id IS NULL)
+			AND id > $7
+		ORDER BY id
+		LIMIT $8`
+	rows, err := r.conn(ctx).Query(ctx, query,
+		string(filter.Status), string(filter.Reason), string(filter.Target),
+		filter.AccountID, filter.AssigneeID, filter.Unassigned, filter.After, filter.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("can't query reports: %s", err.Error())
+	}
+
+	reports, err := pgx.CollectRows(rows, pgx.RowToStructByPos[models.Report])
+	if err != nil {
+		return nil, fmt.Errorf("can't scan reports: %s", err.Error())
+	}
+
+	return reports, nil
+}
+
+// Assign hands an open report to a moderator, or back to the queue when
+// assigneeID is nil.
+func (r *Repository) Assign(ctx context.Context, id int, assigneeID *int) error {
+	const op = "ReportRepository.Assign"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `UPDATE reports
+		SET assignee_id = CASE WHEN status = 'open' THEN $2 ELSE assignee_id END
+		WHERE id = $1
+		RETURNING status`
+	var status models.ReportStatus
+	if err := r.conn(ctx).QueryRow(ctx, query, id, assigneeID).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrReportNotFound
+		}
+		return fmt.Errorf("can't assign report: %s", err.Error())
+	}
+	if status != models.ReportOpen {
+		return ErrReportResolved
+	}
+
+	return nil
+}
+
+// Resolve records action against an open report, applies its effect and
+// closes the report, all or nothing. The caller checks that the action fits
+// the report.
+func (r *Repository) Resolve(ctx context.Context, action models.ModerationAction) (models.ModerationAction, error) {
+	const op = "ReportRepository.Resolve"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	tx, err := r.conn(ctx).Begin(ctx)
+	if err != nil {
+		return models.ModerationAction{}, fmt.Errorf("can't create transaction: %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		status    models.ReportStatus
+		postID    *int
+		accountID int
+	)
+	query := `SELECT status, post_id, account_id FROM reports WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, action.ReportID).Scan(&status, &postID, &accountID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.ModerationAction{}, ErrReportNotFound
+		}
+		return models.ModerationAction{}, fmt.Errorf("can't lock report: %s", err.Error())
+	}
+	if status != models.ReportOpen {
+		return models.ModerationAction{}, ErrReportResolved
+	}
+
+	actionQuery := `INSERT INTO moderation_actions (report_id, moderator_id, action, rationale, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+	err = tx.QueryRow(ctx, actionQuery, action.ReportID, action.ModeratorID, action.Action, action.Rationale, action.ExpiresAt).
+		Scan(&action.ID, &action.CreatedAt)
+	if err != nil {
+		return models.ModerationAction{}, fmt.Errorf("can't insert moderation action: %s", err.Error())
+	}
+
+	switch action.Action {
+	case models.ActionDismiss:
+		// Dismissing the report of a post held by the spam filter releases it.
+		if postID != nil {
+			_, err = tx.Exec(ctx, `UPDATE posts SET spam_verdict = 'allow' WHERE id = $1 AND spam_verdict = 'hold'`, *postID)
+		}
+	case models.ActionRemovePost:
+		if postID != nil {
+			_, err = tx.Exec(ctx, `UPDATE posts SET removed_at = COALESCE(removed_at, now()) WHERE id = $1`, *postID)
+		}
+	case models.ActionSuspend:
+		// A suspension would let a deactivated account sign in again to
+		// appeal, so it leaves those alone.
+		_, err = tx.Exec(ctx, `INSERT INTO account_states (user_id, state, reason, expires_at, changed_by, action_id)
+			VALUES ($1, 'suspended', $2, $3, $4, $5)
+			ON CONFLICT (user_id) DO UPDATE
+				SET state = 'suspended', reason = $2, expires_at = $3, changed_by = $4, action_id = $5, changed_at = now()
+				WHERE account_states.state <> 'deactivated'
+					OR (account_states.expires_at IS NOT NULL AND account_states.expires_at <= now())`,
+			accountID, action.Rationale, action.ExpiresAt, action.ModeratorID, action.ID)
+	}
+	if err != nil {
+		return models.ModerationAction{}, fmt.Errorf("can't apply %s: %s", action.Action, err.Error())
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE reports SET status = 'resolved', resolved_at = now() WHERE id = $1`, action.ReportID)
+	if err != nil {
+		return models.ModerationAction{}, fmt.Errorf("can't resolve report: %s", err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return models.ModerationAction{}, fmt.Errorf("can't commit transaction: %s", err.Error())
+	}
+
+	return action, nil
+}
@@ -0,0 +1,80 @@
+package role_repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/internal/repository/postgres"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RoleRepository interface {
+	Get(ctx context.Context, userID int) (models.Role, error)
+	Set(ctx context.Context, userID int, role models.Role, grantedBy int) error
+}
+
+type Repository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func New(pool *pgxpool.Pool, log *slog.Logger) *Repository {
+	return &Repository{
+		db:  pool,
+		log: log,
+	}
+}
+
+// conn joins the transaction of ctx, if any.
+func (r *Repository) conn(ctx context.Context) postgres.Conn {
+	return postgres.ConnFrom(ctx, r.db)
+}
+
+// Get returns the role of userID, which is models.RoleUser unless another
+// was granted.
+func (r *Repository) Get(ctx context.Context, userID int) (models.Role, error) {
+	const op = "RoleRepository.Get"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	var role models.Role
+	err := r.conn(ctx).QueryRow(ctx, `SELECT role FROM user_roles WHERE user_id = $1`, userID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.RoleUser, nil
+		}
+		return "", fmt.Errorf("can't query role: %s", err.Error())
+	}
+
+	return role, nil
+}
+
+// Set grants role to userID. Granting models.RoleUser takes away any other
+// role.
+func (r *Repository) Set(ctx context.Context, userID int, role models.Role, grantedBy int) error {
+	const op = "RoleRepository.Set"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	if role == models.RoleUser {
+		if _, err := r.conn(ctx).Exec(ctx, `DELETE FROM user_roles WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("can't revoke role: %s", err.Error())
+		}
+		return nil
+	}
+
+	query := `INSERT INTO user_roles (user_id, role, granted_by) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET role = $2, granted_by = $3, granted_at = now()`
+	if _, err := r.conn(ctx).Exec(ctx, query, userID, role, grantedBy); err != nil {
+		return fmt.Errorf("can't grant role: %s", err.Error())
+	}
+
+	return nil
+}
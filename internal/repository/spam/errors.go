@@ -0,0 +1,5 @@
+package spam_repo
+
+import "errors"
+
+var ErrPostNotFound = errors.New("post not found")
@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/spam/repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/spam/repository.go -destination=internal/repository/spam/mocks/mock_repository.go
+//
+
+// Package mock_spam_repo is a generated GoMock package.
+package mock_spam_repo
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/AtIasShrugged/antisocial/internal/domain/models"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSpamRepository is a mock of SpamRepository interface.
+type MockSpamRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSpamRepositoryMockRecorder
+}
+
+// MockSpamRepositoryMockRecorder is the mock recorder for MockSpamRepository.
+type MockSpamRepositoryMockRecorder struct {
+	mock *MockSpamRepository
+}
+
+// NewMockSpamRepository creates a new mock instance.
+func NewMockSpamRepository(ctrl *gomock.Controller) *MockSpamRepository {
+	mock := &MockSpamRepository{ctrl: ctrl}
+	mock.recorder = &MockSpamRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSpamRepository) EXPECT() *MockSpamRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Model mocks base method.
+func (m *MockSpamRepository) Model(ctx context.Context, tokens []string) (models.SpamModel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Model", ctx, tokens)
+	ret0, _ := ret[0].(models.SpamModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Model indicates an expected call of Model.
+func (mr *MockSpamRepositoryMockRecorder) Model(ctx, tokens any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Model", reflect.TypeOf((*MockSpamRepository)(nil).Model), ctx, tokens)
+}
+
+// PostBody mocks base method.
+func (m *MockSpamRepository) PostBody(ctx context.Context, postID int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PostBody", ctx, postID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PostBody indicates an expected call of PostBody.
+func (mr *MockSpamRepositoryMockRecorder) PostBody(ctx, postID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PostBody", reflect.TypeOf((*MockSpamRepository)(nil).PostBody), ctx, postID)
+}
+
+// Signals mocks base method.
+func (m *MockSpamRepository) Signals(ctx context.Context, authorID int, body string, duplicatesSince, recentSince time.Time) (models.SpamSignals, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Signals", ctx, authorID, body, duplicatesSince, recentSince)
+	ret0, _ := ret[0].(models.SpamSignals)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Signals indicates an expected call of Signals.
+func (mr *MockSpamRepositoryMockRecorder) Signals(ctx, authorID, body, duplicatesSince, recentSince any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Signals", reflect.TypeOf((*MockSpamRepository)(nil).Signals), ctx, authorID, body, duplicatesSince, recentSince)
+}
+
+// Train mocks base method.
+func (m *MockSpamRepository) Train(ctx context.Context, postID int, spam bool, tokens []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Train", ctx, postID, spam, tokens)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Train indicates an expected call of Train.
+func (mr *MockSpamRepositoryMockRecorder) Train(ctx, postID, spam, tokens any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Train", reflect.TypeOf((*MockSpamRepository)(nil).Train), ctx, postID, spam, tokens)
+}
@@ -0,0 +1,168 @@
+package spam_repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SpamRepository interface {
+	Signals(ctx context.Context, authorID int, body string, duplicatesSince, recentSince time.Time) (models.SpamSignals, error)
+	Model(ctx context.Context, tokens []string) (models.SpamModel, error)
+	PostBody(ctx context.Context, postID int) (string, error)
+	Train(ctx context.Context, postID int, spam bool, tokens []string) error
+}
+
+type Repository struct {
+	db  *pgxpool.Pool
+	log *slog.Logger
+}
+
+func New(pool *pgxpool.Pool, log *slog.Logger) *Repository {
+	return &Repository{
+		db:  pool,
+		log: log,
+	}
+}
+
+// Signals looks up what the heuristics need to know about a new post by
+// authorID, before it is stored.
+func (r *Repository) Signals(ctx context.Context, authorID int, body string, duplicatesSince, recentSince time.Time) (models.SpamSignals, error) {
+	const op = "SpamRepository.Signals"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	query := `SELECT
+			(SELECT count(DISTINCT author_id) FROM posts
+				WHERE md5(body) = md5($2) AND created_at > $3 AND author_id <> $1),
+			(SELECT count(*) FROM posts WHERE author_id = $1 AND created_at > $4),
+			(SELECT min(created_at) FROM posts WHERE author_id = $1)`
+	var signals models.SpamSignals
+	err := r.db.QueryRow(ctx, query, authorID, body, duplicatesSince, recentSince).
+		Scan(&signals.DuplicateAuthors, &signals.RecentPosts, &signals.FirstPostAt)
+	if err != nil {
+		return models.SpamSignals{}, fmt.Errorf("can't query spam signals: %s", err.Error())
+	}
+
+	return signals, nil
+}
+
+// Model returns the size of the training set and the counts of tokens.
+// Tokens the model never saw are left out.
+func (r *Repository) Model(ctx context.Context, tokens []string) (models.SpamModel, error) {
+	const op = "SpamRepository.Model"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	model := models.SpamModel{Tokens: make(map[string]models.SpamTokenCounts, len(tokens))}
+	err := r.db.QueryRow(ctx, `SELECT count(*) FILTER (WHERE spam), count(*) FILTER (WHERE NOT spam) FROM spam_training`).
+		Scan(&model.SpamPosts, &model.HamPosts)
+	if err != nil {
+		return models.SpamModel{}, fmt.Errorf("can't count training posts: %s", err.Error())
+	}
+
+	rows, err := r.db.Query(ctx, `SELECT token, spam, ham FROM spam_tokens WHERE token = ANY($1)`, tokens)
+	if err != nil {
+		return models.SpamModel{}, fmt.Errorf("can't query spam tokens: %s", err.Error())
+	}
+	var (
+		token  string
+		counts models.SpamTokenCounts
+	)
+	_, err = pgx.ForEachRow(rows, []any{&token, &counts.Spam, &counts.Ham}, func() error {
+		model.Tokens[token] = counts
+		return nil
+	})
+	if err != nil {
+		return models.SpamModel{}, fmt.Errorf("can't scan spam tokens: %s", err.Error())
+	}
+
+	return model, nil
+}
+
+// PostBody returns the body of a post, whatever its state.
+func (r *Repository) PostBody(ctx context.Context, postID int) (string, error) {
+	const op = "SpamRepository.PostBody"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	var body string
+	if err := r.db.QueryRow(ctx, `SELECT body FROM posts WHERE id = $1`, postID).Scan(&body); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrPostNotFound
+		}
+		return "", fmt.Errorf("can't query post: %s", err.Error())
+	}
+
+	return body, nil
+}
+
+// Train counts tokens of postID as spam or ham. A post already learned with
+// the same label is skipped; one learned with the other label is moved over.
+func (r *Repository) Train(ctx context.Context, postID int, spam bool, tokens []string) error {
+	const op = "SpamRepository.Train"
+	defer metrics.ObserveQuery(op, time.Now())
+	ctx = sl.WithOp(ctx, op)
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("can't create transaction: %s", err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		wasSpam   bool
+		oldTokens []string
+	)
+	err = tx.QueryRow(ctx, `SELECT spam, tokens FROM spam_training WHERE post_id = $1 FOR UPDATE`, postID).
+		Scan(&wasSpam, &oldTokens)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		tag, err := tx.Exec(ctx, `INSERT INTO spam_training (post_id, spam, tokens) VALUES ($1, $2, $3)
+			ON CONFLICT (post_id) DO NOTHING`, postID, spam, tokens)
+		if err != nil {
+			return fmt.Errorf("can't record training post: %s", err.Error())
+		}
+		if tag.RowsAffected() == 0 {
+			// Learned concurrently by someone else.
+			return nil
+		}
+	case err != nil:
+		return fmt.Errorf("can't lock training post: %s", err.Error())
+	case wasSpam == spam:
+		return nil
+	default:
+		forget := `UPDATE spam_tokens
+			SET spam = spam - CASE WHEN $2 THEN 1 ELSE 0 END, ham = ham - CASE WHEN $2 THEN 0 ELSE 1 END
+			WHERE token = ANY($1)`
+		if _, err := tx.Exec(ctx, forget, oldTokens, wasSpam); err != nil {
+			return fmt.Errorf("can't forget training post: %s", err.Error())
+		}
+		_, err = tx.Exec(ctx, `UPDATE spam_training SET spam = $2, tokens = $3, trained_at = now() WHERE post_id = $1`,
+			postID, spam, tokens)
+		if err != nil {
+			return fmt.Errorf("can't update training post: %s", err.Error())
+		}
+	}
+
+	learn := `INSERT INTO spam_tokens (token, spam, ham)
+		SELECT t, CASE WHEN $2 THEN 1 ELSE 0 END, CASE WHEN $2 THEN 0 ELSE 1 END FROM unnest($1::text[]) t
+		ON CONFLICT (token) DO UPDATE SET spam = spam_tokens.spam + EXCLUDED.spam, ham = spam_tokens.ham + EXCLUDED.ham`
+	if _, err := tx.Exec(ctx, learn, tokens, spam); err != nil {
+		return fmt.Errorf("can't count tokens: %s", err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("can't commit transaction: %s", err.Error())
+	}
+
+	return nil
+}
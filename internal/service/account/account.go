@@ -0,0 +1,287 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	account_repo "github.com/AtIasShrugged/antisocial/internal/repository/account"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+)
+
+const (
+	maxReasonLen     = 2000
+	maxAppealLen     = 5000
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+type Notifier interface {
+	Notify(ctx context.Context, userIDs []int, kind models.NotificationKind, payload any) error
+}
+
+type Auditor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Record(ctx context.Context, event models.AuditEvent) error
+}
+
+type RoleSource interface {
+	Role(ctx context.Context, userID int) (models.Role, error)
+}
+
+// AccountService puts accounts in states that restrict them, and lets
+// suspended users appeal.
+type AccountService struct {
+	repo     account_repo.AccountRepository
+	notifier Notifier
+	auditor  Auditor
+	roles    RoleSource
+	log      *slog.Logger
+	now      func() time.Time
+}
+
+func New(repo account_repo.AccountRepository, notifier Notifier, auditor Auditor, roles RoleSource, log *slog.Logger) *AccountService {
+	return &AccountService{
+		repo:     repo,
+		notifier: notifier,
+		auditor:  auditor,
+		roles:    roles,
+		log:      log,
+		now:      time.Now,
+	}
+}
+
+// State returns the state of userID. It backs authentication and so checks
+// no permission.
+func (a *AccountService) State(ctx context.Context, userID int) (models.AccountState, error) {
+	status, err := a.repo.Get(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return status.State, nil
+}
+
+// Get returns the state of userID and why it is in it. Users looking at
+// their own account don't get to see who changed it.
+func (a *AccountService) Get(ctx context.Context, userID int) (models.AccountStatus, error) {
+	if err := auth.Authorize(ctx, auth.AccountsRead, auth.Owner(userID)); err != nil {
+		return models.AccountStatus{}, err
+	}
+
+	status, err := a.repo.Get(ctx, userID)
+	if err != nil {
+		return models.AccountStatus{}, err
+	}
+	if auth.Authorize(ctx, auth.AccountsRead, nil) != nil {
+		status.ChangedBy, status.ActionID = 0, nil
+	}
+	return status, nil
+}
+
+// Set puts the account of status.UserID in status.State on behalf of the
+// user of ctx, who must outrank them. Every change needs a reason, and every
+// state but models.AccountActive an expiry.
+func (a *AccountService) Set(ctx context.Context, status models.AccountStatus) (models.AccountStatus, error) {
+	if err := auth.Authorize(ctx, auth.AccountsManage, nil); err != nil {
+		return models.AccountStatus{}, err
+	}
+	if err := a.validateStatus(&status); err != nil {
+		return models.AccountStatus{}, err
+	}
+	status.ChangedBy, _ = auth.UserID(ctx)
+	if status.ChangedBy == status.UserID {
+		return models.AccountStatus{}, ErrOwnAccount
+	}
+	target, err := a.roles.Role(ctx, status.UserID)
+	if err != nil {
+		return models.AccountStatus{}, err
+	}
+	if err := auth.AuthorizeOver(ctx, target); err != nil {
+		return models.AccountStatus{}, err
+	}
+
+	previous, err := a.repo.Get(ctx, status.UserID)
+	if err != nil {
+		return models.AccountStatus{}, err
+	}
+	err = a.auditor.InTx(ctx, func(ctx context.Context) error {
+		if err := a.repo.Set(ctx, status); err != nil {
+			return err
+		}
+		return a.auditor.Record(ctx, models.AuditEvent{
+			Action:     models.AuditAccountStateChange,
+			TargetType: models.AuditTargetUser,
+			TargetID:   strconv.Itoa(status.UserID),
+			Before:     stateOf(previous),
+			After:      stateOf(status),
+		})
+	})
+	if err != nil {
+		return models.AccountStatus{}, err
+	}
+	metrics.AccountStateChanges.WithLabelValues(string(status.State)).Inc()
+
+	return a.repo.Get(ctx, status.UserID)
+}
+
+func (a *AccountService) validateStatus(status *models.AccountStatus) error {
+	if status.UserID <= 0 {
+		return fmt.Errorf("%w: user_id is required", ErrInvalidState)
+	}
+	if !slices.Contains(models.AccountStates, status.State) {
+		return fmt.Errorf("%w: unknown state %q", ErrInvalidState, status.State)
+	}
+	status.Reason = strings.TrimSpace(status.Reason)
+	if status.Reason == "" || utf8.RuneCountInString(status.Reason) > maxReasonLen {
+		return fmt.Errorf("%w: reason must be 1 to %d characters", ErrInvalidState, maxReasonLen)
+	}
+
+	switch {
+	case status.State == models.AccountActive && status.ExpiresAt != nil:
+		return fmt.Errorf("%w: an active account can't expire", ErrInvalidState)
+	case status.State != models.AccountActive && (status.ExpiresAt == nil || !status.ExpiresAt.After(a.now())):
+		return fmt.Errorf("%w: expires_at must be in the future", ErrInvalidState)
+	}
+	return nil
+}
+
+// accountState is the state of an account around a change, as audited.
+type accountState struct {
+	State     models.AccountState `json:"state"`
+	Reason    string              `json:"reason,omitempty"`
+	ExpiresAt *time.Time          `json:"expires_at,omitempty"`
+}
+
+func stateOf(status models.AccountStatus) accountState {
+	return accountState{State: status.State, Reason: status.Reason, ExpiresAt: status.ExpiresAt}
+}
+
+// Appeal files an appeal against the suspension of appeal.UserID. Only one
+// may be under review at a time.
+func (a *AccountService) Appeal(ctx context.Context, appeal models.Appeal) (models.Appeal, error) {
+	if err := auth.Authorize(ctx, auth.AppealsCreate, auth.Owner(appeal.UserID)); err != nil {
+		return models.Appeal{}, err
+	}
+	appeal.Body = strings.TrimSpace(appeal.Body)
+	if appeal.Body == "" || utf8.RuneCountInString(appeal.Body) > maxAppealLen {
+		return models.Appeal{}, fmt.Errorf("%w: body must be 1 to %d characters", ErrInvalidAppeal, maxAppealLen)
+	}
+
+	status, err := a.repo.Get(ctx, appeal.UserID)
+	if err != nil {
+		return models.Appeal{}, err
+	}
+	if status.State != models.AccountSuspended {
+		return models.Appeal{}, ErrNotSuspended
+	}
+
+	id, err := a.repo.CreateAppeal(ctx, appeal)
+	if err != nil {
+		return models.Appeal{}, err
+	}
+	return a.repo.GetAppeal(ctx, id)
+}
+
+func (a *AccountService) GetAppeal(ctx context.Context, id int) (models.Appeal, error) {
+	if err := auth.Authorize(ctx, auth.AppealsReview, nil); err != nil {
+		return models.Appeal{}, err
+	}
+	return a.repo.GetAppeal(ctx, id)
+}
+
+// ListAppeals returns a page of the appeal queue, oldest first.
+func (a *AccountService) ListAppeals(ctx context.Context, filter models.AppealFilter) ([]models.Appeal, error) {
+	if err := auth.Authorize(ctx, auth.AppealsReview, nil); err != nil {
+		return nil, err
+	}
+	switch filter.Status {
+	case "", models.AppealOpen, models.AppealGranted, models.AppealDenied:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, filter.Status)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	filter.Limit = min(filter.Limit, maxListLimit)
+
+	return a.repo.ListAppeals(ctx, filter)
+}
+
+// Decide settles an open appeal on behalf of the user of ctx and tells the
+// appellant. Granting it lifts the suspension. The decision stands even if
+// the notification can't be sent.
+func (a *AccountService) Decide(ctx context.Context, decision models.Appeal) (models.Appeal, error) {
+	const op = "AccountService.Decide"
+
+	if err := auth.Authorize(ctx, auth.AppealsReview, nil); err != nil {
+		return models.Appeal{}, err
+	}
+	if decision.Status != models.AppealGranted && decision.Status != models.AppealDenied {
+		return models.Appeal{}, fmt.Errorf("%w: status must be %q or %q", ErrInvalidAppeal, models.AppealGranted, models.AppealDenied)
+	}
+	decision.Decision = strings.TrimSpace(decision.Decision)
+	if decision.Decision == "" || utf8.RuneCountInString(decision.Decision) > maxReasonLen {
+		return models.Appeal{}, fmt.Errorf("%w: decision must be 1 to %d characters", ErrInvalidAppeal, maxReasonLen)
+	}
+	reviewerID, _ := auth.UserID(ctx)
+	decision.ReviewerID = &reviewerID
+
+	appeal, err := a.repo.GetAppeal(ctx, decision.ID)
+	if err != nil {
+		return models.Appeal{}, err
+	}
+	if appeal.Status != models.AppealOpen {
+		return models.Appeal{}, account_repo.ErrAppealDecided
+	}
+	if appeal.UserID == reviewerID {
+		return models.Appeal{}, ErrOwnAppeal
+	}
+
+	err = a.auditor.InTx(ctx, func(ctx context.Context) error {
+		if err := a.repo.DecideAppeal(ctx, decision); err != nil {
+			return err
+		}
+		return a.auditor.Record(ctx, models.AuditEvent{
+			Action:     models.AuditAppealDecide,
+			TargetType: models.AuditTargetAppeal,
+			TargetID:   strconv.Itoa(appeal.ID),
+			Before:     appealState{Status: appeal.Status, UserID: appeal.UserID},
+			After:      appealState{Status: decision.Status, UserID: appeal.UserID, Decision: decision.Decision},
+		})
+	})
+	if err != nil {
+		return models.Appeal{}, err
+	}
+	metrics.AppealDecisions.WithLabelValues(string(decision.Status)).Inc()
+
+	payload := appealNotice{AppealID: appeal.ID, Status: decision.Status, Decision: decision.Decision}
+	if err := a.notifier.Notify(ctx, []int{appeal.UserID}, models.NotificationAppealDecided, payload); err != nil {
+		sl.FromContext(ctx, a.log).ErrorContext(ctx, "can't notify appellant",
+			sl.Op(op), slog.Int("appeal_id", appeal.ID), sl.Err(err))
+	}
+
+	return a.repo.GetAppeal(ctx, decision.ID)
+}
+
+// appealState is the state of an appeal around a decision, as audited.
+type appealState struct {
+	Status   models.AppealStatus `json:"status"`
+	UserID   int                 `json:"user_id"`
+	Decision string              `json:"decision,omitempty"`
+}
+
+// appealNotice is the payload of the notification sent to an appellant. It
+// leaves out who decided.
+type appealNotice struct {
+	AppealID int                 `json:"appeal_id"`
+	Status   models.AppealStatus `json:"status"`
+	Decision string              `json:"decision"`
+}
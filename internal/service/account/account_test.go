@@ -0,0 +1,230 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/audit/audittest"
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	account_repo "github.com/AtIasShrugged/antisocial/internal/repository/account"
+	repoMock "github.com/AtIasShrugged/antisocial/internal/repository/account/mocks"
+	"github.com/AtIasShrugged/antisocial/internal/service/notification/notificationtest"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var testNow = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// roles holds the role of every user who isn't a plain user.
+type roles map[int]models.Role
+
+func (r roles) Role(_ context.Context, userID int) (models.Role, error) {
+	if role, ok := r[userID]; ok {
+		return role, nil
+	}
+	return models.RoleUser, nil
+}
+
+func as(id int, role models.Role, state models.AccountState) context.Context {
+	return auth.WithPrincipal(context.Background(), auth.Principal{UserID: id, Role: role, State: state})
+}
+
+func newService(t *testing.T) (*AccountService, *repoMock.MockAccountRepository, *notificationtest.Recorder, *audittest.Recorder) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	repo := repoMock.NewMockAccountRepository(ctrl)
+	notifier := &notificationtest.Recorder{}
+	auditor := &audittest.Recorder{}
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	service := New(repo, notifier, auditor, roles{1: models.RoleAdmin, 4: models.RoleModerator}, log)
+	service.now = func() time.Time { return testNow }
+	return service, repo, notifier, auditor
+}
+
+func timePtr(t time.Time) *time.Time { return &t }
+
+func intPtr(i int) *int { return &i }
+
+func TestSet(t *testing.T) {
+	service, repo, _, auditor := newService(t)
+	ctx := as(3, models.RoleModerator, models.AccountActive)
+
+	change := models.AccountStatus{UserID: 7, State: models.AccountLimited, Reason: " brigading ", ExpiresAt: timePtr(testNow.Add(time.Hour))}
+	stored := change
+	stored.Reason, stored.ChangedBy = "brigading", 3
+	gomock.InOrder(
+		repo.EXPECT().Get(ctx, 7).Return(models.AccountStatus{UserID: 7, State: models.AccountActive}, nil),
+		repo.EXPECT().Set(ctx, stored).Return(nil),
+		repo.EXPECT().Get(ctx, 7).Return(stored, nil),
+	)
+
+	status, err := service.Set(ctx, change)
+	require.NoError(t, err)
+	require.Equal(t, stored, status)
+
+	require.Len(t, auditor.Events, 1)
+	event := auditor.Events[0]
+	require.Equal(t, models.AuditAccountStateChange, event.Action)
+	require.Equal(t, "7", event.TargetID)
+	before, err := json.Marshal(event.Before)
+	require.NoError(t, err)
+	after, err := json.Marshal(event.After)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"state":"active"}`, string(before))
+	require.JSONEq(t, `{"state":"limited","reason":"brigading","expires_at":"2024-06-01T13:00:00Z"}`, string(after))
+}
+
+func TestSetRejects(t *testing.T) {
+	service, _, _, _ := newService(t)
+	ctx := as(3, models.RoleModerator, models.AccountActive)
+	later := timePtr(testNow.Add(time.Hour))
+
+	for name, tc := range map[string]struct {
+		status models.AccountStatus
+		err    error
+	}{
+		"unknown state":   {models.AccountStatus{UserID: 7, State: "banned", Reason: "x", ExpiresAt: later}, ErrInvalidState},
+		"no reason":       {models.AccountStatus{UserID: 7, State: models.AccountSuspended, Reason: " ", ExpiresAt: later}, ErrInvalidState},
+		"no expiry":       {models.AccountStatus{UserID: 7, State: models.AccountSuspended, Reason: "x"}, ErrInvalidState},
+		"past expiry":     {models.AccountStatus{UserID: 7, State: models.AccountSuspended, Reason: "x", ExpiresAt: timePtr(testNow)}, ErrInvalidState},
+		"expiring active": {models.AccountStatus{UserID: 7, State: models.AccountActive, Reason: "x", ExpiresAt: later}, ErrInvalidState},
+		"own account":     {models.AccountStatus{UserID: 3, State: models.AccountActive, Reason: "x"}, ErrOwnAccount},
+	} {
+		_, err := service.Set(ctx, tc.status)
+		require.ErrorIs(t, err, tc.err, name)
+	}
+
+	_, err := service.Set(as(1, models.RoleUser, models.AccountActive),
+		models.AccountStatus{UserID: 7, State: models.AccountActive, Reason: "x"})
+	require.ErrorIs(t, err, auth.ErrForbidden)
+}
+
+func TestSetRespectsRank(t *testing.T) {
+	service, repo, _, auditor := newService(t)
+	later := timePtr(testNow.Add(time.Hour))
+	limit := func(userID int) models.AccountStatus {
+		return models.AccountStatus{UserID: userID, State: models.AccountLimited, Reason: "x", ExpiresAt: later}
+	}
+
+	// User 1 is a configured admin and user 4 a moderator.
+	moderator := as(3, models.RoleModerator, models.AccountActive)
+	for _, target := range []int{1, 4} {
+		_, err := service.Set(moderator, limit(target))
+		require.ErrorIs(t, err, auth.ErrForbidden, target)
+	}
+	_, err := service.Set(as(2, models.RoleAdmin, models.AccountActive), limit(1))
+	require.ErrorIs(t, err, auth.ErrForbidden)
+	require.Empty(t, auditor.Events)
+
+	admin := as(1, models.RoleAdmin, models.AccountActive)
+	repo.EXPECT().Get(admin, 4).Return(models.AccountStatus{UserID: 4, State: models.AccountActive}, nil).Times(2)
+	repo.EXPECT().Set(admin, gomock.Any()).Return(nil).Times(1)
+	_, err = service.Set(admin, limit(4))
+	require.NoError(t, err)
+}
+
+func TestGetHidesModeratorFromOwner(t *testing.T) {
+	service, repo, _, _ := newService(t)
+	stored := models.AccountStatus{UserID: 7, State: models.AccountSuspended, Reason: "spam",
+		ExpiresAt: timePtr(testNow.Add(time.Hour)), ChangedBy: 3}
+	repo.EXPECT().Get(gomock.Any(), 7).Return(stored, nil).Times(2)
+
+	status, err := service.Get(as(7, models.RoleUser, models.AccountSuspended), 7)
+	require.NoError(t, err)
+	require.Zero(t, status.ChangedBy)
+	require.Equal(t, "spam", status.Reason)
+
+	status, err = service.Get(as(3, models.RoleModerator, models.AccountActive), 7)
+	require.NoError(t, err)
+	require.Equal(t, 3, status.ChangedBy)
+
+	_, err = service.Get(as(8, models.RoleUser, models.AccountActive), 7)
+	require.ErrorIs(t, err, auth.ErrForbidden)
+}
+
+func TestAppeal(t *testing.T) {
+	service, repo, _, _ := newService(t)
+	ctx := as(7, models.RoleUser, models.AccountSuspended)
+
+	filed := models.Appeal{ID: 1, UserID: 7, Body: "it was a joke", Status: models.AppealOpen}
+	gomock.InOrder(
+		repo.EXPECT().Get(ctx, 7).Return(models.AccountStatus{UserID: 7, State: models.AccountSuspended}, nil),
+		repo.EXPECT().CreateAppeal(ctx, models.Appeal{UserID: 7, Body: "it was a joke"}).Return(1, nil),
+		repo.EXPECT().GetAppeal(ctx, 1).Return(filed, nil),
+	)
+	appeal, err := service.Appeal(ctx, models.Appeal{UserID: 7, Body: " it was a joke\n"})
+	require.NoError(t, err)
+	require.Equal(t, filed, appeal)
+
+	_, err = service.Appeal(ctx, models.Appeal{UserID: 7, Body: " "})
+	require.ErrorIs(t, err, ErrInvalidAppeal)
+
+	_, err = service.Appeal(ctx, models.Appeal{UserID: 8, Body: "let them back"})
+	require.ErrorIs(t, err, auth.ErrForbidden)
+
+	limited := as(9, models.RoleUser, models.AccountLimited)
+	repo.EXPECT().Get(limited, 9).Return(models.AccountStatus{UserID: 9, State: models.AccountLimited}, nil)
+	_, err = service.Appeal(limited, models.Appeal{UserID: 9, Body: "why"})
+	require.ErrorIs(t, err, ErrNotSuspended)
+}
+
+func TestDecideNotifiesAppellant(t *testing.T) {
+	service, repo, notifier, auditor := newService(t)
+	ctx := as(3, models.RoleModerator, models.AccountActive)
+	notifier.Err = errors.New("db is down")
+
+	open := models.Appeal{ID: 1, UserID: 7, Body: "it was a joke", Status: models.AppealOpen}
+	decided := open
+	decided.Status, decided.Decision, decided.ReviewerID = models.AppealGranted, "fair enough", intPtr(3)
+	gomock.InOrder(
+		repo.EXPECT().GetAppeal(ctx, 1).Return(open, nil),
+		repo.EXPECT().DecideAppeal(ctx, models.Appeal{ID: 1, Status: models.AppealGranted, Decision: "fair enough", ReviewerID: intPtr(3)}).Return(nil),
+		repo.EXPECT().GetAppeal(ctx, 1).Return(decided, nil),
+	)
+
+	// A failing notifier must not undo the decision.
+	appeal, err := service.Decide(ctx, models.Appeal{ID: 1, Status: models.AppealGranted, Decision: "fair enough "})
+	require.NoError(t, err)
+	require.Equal(t, decided, appeal)
+
+	require.Equal(t, []int{7}, notifier.UserIDs)
+	require.Equal(t, models.NotificationAppealDecided, notifier.Kind)
+	payload, err := json.Marshal(notifier.Payload)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"appeal_id":1,"status":"granted","decision":"fair enough"}`, string(payload))
+
+	require.Len(t, auditor.Events, 1)
+	require.Equal(t, models.AuditAppealDecide, auditor.Events[0].Action)
+	require.Equal(t, models.AuditTargetAppeal, auditor.Events[0].TargetType)
+}
+
+func TestDecideRejects(t *testing.T) {
+	service, repo, _, auditor := newService(t)
+	ctx := as(3, models.RoleModerator, models.AccountActive)
+
+	_, err := service.Decide(ctx, models.Appeal{ID: 1, Status: models.AppealOpen, Decision: "x"})
+	require.ErrorIs(t, err, ErrInvalidAppeal)
+	_, err = service.Decide(ctx, models.Appeal{ID: 1, Status: models.AppealDenied})
+	require.ErrorIs(t, err, ErrInvalidAppeal)
+
+	repo.EXPECT().GetAppeal(ctx, 2).Return(models.Appeal{ID: 2, UserID: 7, Status: models.AppealDenied}, nil)
+	_, err = service.Decide(ctx, models.Appeal{ID: 2, Status: models.AppealGranted, Decision: "x"})
+	require.ErrorIs(t, err, account_repo.ErrAppealDecided)
+
+	repo.EXPECT().GetAppeal(ctx, 3).Return(models.Appeal{ID: 3, UserID: 3, Status: models.AppealOpen}, nil)
+	_, err = service.Decide(ctx, models.Appeal{ID: 3, Status: models.AppealGranted, Decision: "x"})
+	require.ErrorIs(t, err, ErrOwnAppeal)
+
+	_, err = service.Decide(as(3, models.RoleModerator, models.AccountSuspended), models.Appeal{ID: 1, Status: models.AppealGranted, Decision: "x"})
+	require.ErrorIs(t, err, auth.ErrForbidden)
+
+	require.Empty(t, auditor.Events)
+}
@@ -0,0 +1,12 @@
+package account
+
+import "errors"
+
+var (
+	ErrInvalidState  = errors.New("invalid account state change")
+	ErrOwnAccount    = errors.New("can't change your own account state")
+	ErrNotSuspended  = errors.New("only suspended accounts can appeal")
+	ErrInvalidAppeal = errors.New("invalid appeal")
+	ErrOwnAppeal     = errors.New("can't decide your own appeal")
+	ErrInvalidFilter = errors.New("invalid appeal filter")
+)
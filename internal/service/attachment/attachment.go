@@ -0,0 +1,276 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/blobstore"
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	attachment_repo "github.com/AtIasShrugged/antisocial/internal/repository/attachment"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+)
+
+const gcBatchSize = 100
+
+type AttachmentService struct {
+	repo  attachment_repo.AttachmentRepository
+	blobs blobstore.BlobStore
+	cfg   config.MediaConfig
+	log   *slog.Logger
+	now   func() time.Time
+	// pending wakes the processor as soon as an image is uploaded.
+	pending chan struct{}
+}
+
+func New(repo attachment_repo.AttachmentRepository, blobs blobstore.BlobStore, cfg config.MediaConfig, log *slog.Logger) *AttachmentService {
+	return &AttachmentService{
+		repo:    repo,
+		blobs:   blobs,
+		cfg:     cfg,
+		log:     log,
+		now:     time.Now,
+		pending: make(chan struct{}, 1),
+	}
+}
+
+// Upload sniffs the content type of r, checks it against the size limit of
+// its media kind and stores it. size is the length announced by the client.
+func (a *AttachmentService) Upload(ctx context.Context, ownerID int, r io.Reader, size int64) (models.Attachment, error) {
+	const op = "AttachmentService.Upload"
+
+	if err := auth.Authorize(ctx, auth.AttachmentsCreate, auth.Owner(ownerID)); err != nil {
+		return models.Attachment{}, err
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		if errors.Is(err, io.EOF) {
+			return models.Attachment{}, ErrEmptyUpload
+		}
+		return models.Attachment{}, fmt.Errorf("can't read upload: %w", err)
+	}
+	head = head[:n]
+
+	contentType, kind, err := detectContentType(head)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	limit := a.cfg.MaxImageSize
+	if kind == kindVideo {
+		limit = a.cfg.MaxVideoSize
+	}
+	if size > limit {
+		return models.Attachment{}, ErrTooLarge
+	}
+
+	body := &limitedReader{r: io.MultiReader(bytes.NewReader(head), r), remaining: limit}
+	key, err := newBlobKey()
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	if err := a.blobs.Put(ctx, key, body, size, contentType); err != nil {
+		if errors.Is(err, ErrTooLarge) {
+			return models.Attachment{}, ErrTooLarge
+		}
+		return models.Attachment{}, err
+	}
+
+	// Images are served only once processing has stripped their metadata.
+	status := models.AttachmentReady
+	if kind == kindImage {
+		status = models.AttachmentProcessing
+	}
+
+	attachment, err := a.repo.Create(ctx, models.Attachment{
+		OwnerID:     ownerID,
+		BlobKey:     key,
+		ContentType: contentType,
+		Size:        body.read,
+		Status:      status,
+	})
+	if err != nil {
+		if delErr := a.blobs.Delete(ctx, key); delErr != nil {
+			sl.FromContext(ctx, a.log).ErrorContext(ctx, "can't clean up blob", sl.Op(op), sl.Err(delErr))
+		}
+		return models.Attachment{}, err
+	}
+
+	metrics.AttachmentsUploaded.WithLabelValues(contentType).Inc()
+
+	if status == models.AttachmentProcessing {
+		select {
+		case a.pending <- struct{}{}:
+		default:
+		}
+	}
+
+	return attachment, nil
+}
+
+// Open returns the content type, size and content of an attachment. An empty
+// variant selects the (metadata-stripped) original upload. Attachments the
+// user of ctx can't see through any post are not found, so media of hidden
+// posts doesn't stay reachable by ID.
+func (a *AttachmentService) Open(ctx context.Context, id int, variant string) (string, int64, io.ReadCloser, error) {
+	viewerID, _ := auth.UserID(ctx)
+	visible, err := a.repo.Visible(ctx, id, viewerID)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if !visible {
+		return "", 0, nil, attachment_repo.ErrAttachmentNotFound
+	}
+
+	attachment, err := a.repo.GetByID(ctx, id)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	switch attachment.Status {
+	case models.AttachmentProcessing:
+		return "", 0, nil, ErrProcessing
+	case models.AttachmentFailed:
+		return "", 0, nil, ErrProcessingFailed
+	}
+
+	key, contentType, size := attachment.BlobKey, attachment.ContentType, attachment.Size
+	if variant != "" {
+		found := false
+		for _, v := range attachment.Variants {
+			if v.Name == variant {
+				key, contentType, size, found = v.BlobKey, v.ContentType, v.Size, true
+				break
+			}
+		}
+		if !found {
+			return "", 0, nil, ErrVariantNotFound
+		}
+	}
+
+	rc, err := a.blobs.Get(ctx, key)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	return contentType, size, rc, nil
+}
+
+// CollectOrphans deletes uploads that were never attached to a post within
+// the configured TTL. It returns the number of removed attachments.
+func (a *AttachmentService) CollectOrphans(ctx context.Context) (int, error) {
+	removed := 0
+	for {
+		orphans, err := a.repo.ListOrphans(ctx, a.now().Add(-a.cfg.OrphanTTL), gcBatchSize)
+		if err != nil {
+			return removed, err
+		}
+
+		for _, orphan := range orphans {
+			err := a.deleteOrphan(ctx, orphan.ID)
+			if err != nil {
+				if errors.Is(err, attachment_repo.ErrAttachmentInUse) {
+					continue
+				}
+				return removed, err
+			}
+			removed++
+		}
+
+		if len(orphans) < gcBatchSize {
+			return removed, nil
+		}
+	}
+}
+
+// Delete removes the given attachments and their blobs right away instead of
+// waiting for them to be collected as orphans. Attachments still used by
+// another post are kept.
+func (a *AttachmentService) Delete(ctx context.Context, ids []int) error {
+	for _, id := range ids {
+		err := a.deleteOrphan(ctx, id)
+		if err != nil && !errors.Is(err, attachment_repo.ErrAttachmentInUse) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteOrphan deletes an attachment no post refers to, then its blobs. A
+// blob that can't be deleted is logged and left behind.
+func (a *AttachmentService) deleteOrphan(ctx context.Context, id int) error {
+	const op = "AttachmentService.deleteOrphan"
+
+	keys, err := a.repo.DeleteOrphan(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := a.blobs.Delete(ctx, key); err != nil && !errors.Is(err, blobstore.ErrBlobNotFound) {
+			sl.FromContext(ctx, a.log).ErrorContext(ctx, "can't delete blob", sl.Op(op), slog.String("key", key), sl.Err(err))
+		}
+	}
+	return nil
+}
+
+func (a *AttachmentService) RunGC(ctx context.Context, interval time.Duration) {
+	const op = "AttachmentService.RunGC"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := a.CollectOrphans(ctx)
+			if err != nil {
+				a.log.Error(op+": "+err.Error(), sl.Op(op), sl.Err(err))
+			}
+			if removed > 0 {
+				a.log.Info(op+": removed orphaned attachments", sl.Op(op), slog.Int("count", removed))
+			}
+		}
+	}
+}
+
+func newBlobKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("can't generate blob key: %w", err)
+	}
+	return "attachments/" + hex.EncodeToString(b), nil
+}
+
+// limitedReader fails with ErrTooLarge instead of silently truncating.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	read      int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,234 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/blobstore"
+	"github.com/AtIasShrugged/antisocial/internal/blobstore/local"
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	attachment_repo "github.com/AtIasShrugged/antisocial/internal/repository/attachment"
+	repoMock "github.com/AtIasShrugged/antisocial/internal/repository/attachment/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// asUser returns a context authenticated as a plain user.
+func asUser(id int) context.Context {
+	return auth.WithPrincipal(context.Background(), auth.Principal{UserID: id, Role: models.RoleUser})
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func testConfig() config.MediaConfig {
+	return config.MediaConfig{
+		MaxImageSize:      1 << 20,
+		MaxVideoSize:      1 << 20,
+		OrphanTTL:         time.Hour,
+		ProcessingTimeout: time.Minute,
+	}
+}
+
+func TestUpload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockAttachmentRepository(ctrl)
+	blobs, err := local.New(t.TempDir())
+	require.NoError(t, err)
+
+	ctx := asUser(1)
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	var stored models.Attachment
+	repo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, a models.Attachment) (models.Attachment, error) {
+			a.ID = 7
+			stored = a
+			return a, nil
+		}).Times(1)
+
+	service := New(repo, blobs, testConfig(), log)
+	a, err := service.Upload(ctx, 1, bytes.NewReader(pngHeader), int64(len(pngHeader)))
+	require.NoError(t, err)
+	require.Equal(t, 7, a.ID)
+	require.Equal(t, "image/png", a.ContentType)
+	require.Equal(t, int64(len(pngHeader)), a.Size)
+	require.Equal(t, models.AttachmentProcessing, a.Status)
+
+	rc, err := blobs.Get(ctx, stored.BlobKey)
+	require.NoError(t, err)
+	defer rc.Close()
+	got, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.Equal(t, pngHeader, got)
+}
+
+func TestUploadUnsupportedType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockAttachmentRepository(ctrl)
+	blobs, err := local.New(t.TempDir())
+	require.NoError(t, err)
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	service := New(repo, blobs, testConfig(), log)
+	body := []byte("#!/bin/sh\necho definitely an image\n")
+	_, err = service.Upload(asUser(1), 1, bytes.NewReader(body), int64(len(body)))
+	require.ErrorIs(t, err, ErrUnsupportedMediaType)
+}
+
+func TestUploadTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockAttachmentRepository(ctrl)
+	root := t.TempDir()
+	blobs, err := local.New(root)
+	require.NoError(t, err)
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	cfg := testConfig()
+	cfg.MaxImageSize = 64
+	service := New(repo, blobs, cfg, log)
+	body := append(append([]byte{}, pngHeader...), make([]byte, 100)...)
+
+	// Announced size already over the limit.
+	_, err = service.Upload(asUser(1), 1, bytes.NewReader(body), int64(len(body)))
+	require.ErrorIs(t, err, ErrTooLarge)
+
+	// Client lies about the size: the stream is cut off while storing.
+	_, err = service.Upload(asUser(1), 1, bytes.NewReader(body), 10)
+	require.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestOpenHidesInvisibleAttachments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockAttachmentRepository(ctrl)
+	blobs, err := local.New(t.TempDir())
+	require.NoError(t, err)
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	require.NoError(t, blobs.Put(context.Background(), "attachments/held", bytes.NewReader(pngHeader), 0, ""))
+	service := New(repo, blobs, testConfig(), log)
+
+	// Attachment 3 belongs to a post held for review: its uploader still
+	// sees it, anyone else, signed in or not, finds nothing.
+	repo.EXPECT().Visible(gomock.Any(), 3, 2).Return(false, nil).Times(1)
+	repo.EXPECT().Visible(gomock.Any(), 3, 0).Return(false, nil).Times(1)
+	repo.EXPECT().Visible(gomock.Any(), 3, 1).Return(true, nil).Times(1)
+	repo.EXPECT().GetByID(gomock.Any(), 3).Return(models.Attachment{
+		ID: 3, OwnerID: 1, BlobKey: "attachments/held", ContentType: "image/png",
+		Size: int64(len(pngHeader)), Status: models.AttachmentReady,
+	}, nil).Times(1)
+
+	_, _, _, err = service.Open(asUser(2), 3, "")
+	require.ErrorIs(t, err, attachment_repo.ErrAttachmentNotFound)
+	_, _, _, err = service.Open(context.Background(), 3, "")
+	require.ErrorIs(t, err, attachment_repo.ErrAttachmentNotFound)
+
+	contentType, _, rc, err := service.Open(asUser(1), 3, "")
+	require.NoError(t, err)
+	rc.Close()
+	require.Equal(t, "image/png", contentType)
+}
+
+func TestCollectOrphans(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockAttachmentRepository(ctrl)
+	blobs, err := local.New(t.TempDir())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	require.NoError(t, blobs.Put(ctx, "attachments/orphan", bytes.NewReader(pngHeader), 0, ""))
+	require.NoError(t, blobs.Put(ctx, "attachments/orphan.thumbnail", bytes.NewReader(pngHeader), 0, ""))
+	require.NoError(t, blobs.Put(ctx, "attachments/claimed", bytes.NewReader(pngHeader), 0, ""))
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo.EXPECT().ListOrphans(ctx, now.Add(-time.Hour), gcBatchSize).Return([]models.Attachment{
+		{ID: 1, BlobKey: "attachments/orphan"},
+		{ID: 2, BlobKey: "attachments/claimed"},
+	}, nil).Times(1)
+	repo.EXPECT().DeleteOrphan(ctx, 1).Return([]string{"attachments/orphan", "attachments/orphan.thumbnail"}, nil).Times(1)
+	repo.EXPECT().DeleteOrphan(ctx, 2).Return(nil, attachment_repo.ErrAttachmentInUse).Times(1)
+
+	service := New(repo, blobs, testConfig(), log)
+	service.now = func() time.Time { return now }
+
+	removed, err := service.CollectOrphans(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+
+	_, err = blobs.Get(ctx, "attachments/orphan")
+	require.ErrorIs(t, err, blobstore.ErrBlobNotFound)
+	_, err = blobs.Get(ctx, "attachments/orphan.thumbnail")
+	require.ErrorIs(t, err, blobstore.ErrBlobNotFound)
+	rc, err := blobs.Get(ctx, "attachments/claimed")
+	require.NoError(t, err)
+	rc.Close()
+}
+
+func TestProcessPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockAttachmentRepository(ctrl)
+	blobs, err := local.New(t.TempDir())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 600, 400))))
+	require.NoError(t, blobs.Put(ctx, "attachments/good", bytes.NewReader(img.Bytes()), 0, ""))
+	require.NoError(t, blobs.Put(ctx, "attachments/broken", bytes.NewReader(pngHeader), 0, ""))
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo.EXPECT().ClaimPending(ctx, now.Add(-time.Minute), processBatchSize).Return([]models.Attachment{
+		{ID: 1, BlobKey: "attachments/good", ContentType: "image/png", Status: models.AttachmentProcessing},
+		{ID: 2, BlobKey: "attachments/broken", ContentType: "image/png", Status: models.AttachmentProcessing},
+	}, nil).Times(1)
+
+	var completed models.Attachment
+	repo.EXPECT().CompleteProcessing(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, a models.Attachment) error {
+			completed = a
+			return nil
+		}).Times(1)
+	repo.EXPECT().MarkFailed(ctx, 2).Return(nil).Times(1)
+
+	service := New(repo, blobs, testConfig(), log)
+	service.now = func() time.Time { return now }
+
+	n, err := service.ProcessPending(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	require.Equal(t, 1, completed.ID)
+	require.Equal(t, 600, completed.Width)
+	require.Equal(t, 400, completed.Height)
+	require.NotEmpty(t, completed.Blurhash)
+	require.Len(t, completed.Variants, 3)
+	require.Equal(t, "thumbnail", completed.Variants[0].Name)
+	require.Equal(t, 320, completed.Variants[0].Width)
+
+	rc, err := blobs.Get(ctx, completed.Variants[0].BlobKey)
+	require.NoError(t, err)
+	rc.Close()
+}
@@ -0,0 +1,12 @@
+package attachment
+
+import "errors"
+
+var (
+	ErrEmptyUpload          = errors.New("upload is empty")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrTooLarge             = errors.New("upload exceeds size limit")
+	ErrProcessing           = errors.New("attachment is still processing")
+	ErrProcessingFailed     = errors.New("attachment could not be processed")
+	ErrVariantNotFound      = errors.New("variant not found")
+)
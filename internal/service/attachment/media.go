@@ -0,0 +1,33 @@
+package attachment
+
+import "net/http"
+
+// sniffLen is the number of leading bytes inspected for magic numbers.
+const sniffLen = 512
+
+type mediaKind int
+
+const (
+	kindImage mediaKind = iota
+	kindVideo
+)
+
+var allowedTypes = map[string]mediaKind{
+	"image/jpeg": kindImage,
+	"image/png":  kindImage,
+	"image/gif":  kindImage,
+	"image/webp": kindImage,
+	"video/mp4":  kindVideo,
+	"video/webm": kindVideo,
+}
+
+// detectContentType identifies the media type from magic bytes only; the
+// client-supplied Content-Type and file name are never trusted.
+func detectContentType(head []byte) (string, mediaKind, error) {
+	contentType := http.DetectContentType(head)
+	kind, ok := allowedTypes[contentType]
+	if !ok {
+		return "", 0, ErrUnsupportedMediaType
+	}
+	return contentType, kind, nil
+}
@@ -0,0 +1,127 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/imageproc"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+)
+
+const processBatchSize = 10
+
+// ProcessPending claims images awaiting processing and renders their variants.
+// It is safe to run on several instances at once. It returns the number of
+// attachments that were handled, successfully or not.
+func (a *AttachmentService) ProcessPending(ctx context.Context) (int, error) {
+	const op = "AttachmentService.ProcessPending"
+
+	claimed, err := a.repo.ClaimPending(ctx, a.now().Add(-a.cfg.ProcessingTimeout), processBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, attachment := range claimed {
+		err := a.process(ctx, attachment)
+		if err == nil {
+			metrics.AttachmentsProcessed.WithLabelValues("ready").Inc()
+			continue
+		}
+		if errors.Is(err, errUnprocessable) {
+			metrics.AttachmentsProcessed.WithLabelValues("failed").Inc()
+			a.log.Warn(op+": giving up on attachment", sl.Op(op), slog.Int("id", attachment.ID), sl.Err(err))
+			if err := a.repo.MarkFailed(ctx, attachment.ID); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		// Transient failure: the claim expires and another pass retries it.
+		a.log.Error(op+": "+err.Error(), sl.Op(op), slog.Int("id", attachment.ID), sl.Err(err))
+	}
+
+	return len(claimed), nil
+}
+
+var errUnprocessable = errors.New("unprocessable image")
+
+func (a *AttachmentService) process(ctx context.Context, attachment models.Attachment) error {
+	rc, err := a.blobs.Get(ctx, attachment.BlobKey)
+	if err != nil {
+		return fmt.Errorf("can't fetch original: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(rc, a.cfg.MaxImageSize+1))
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("can't read original: %w", err)
+	}
+
+	res, err := imageproc.Process(data, attachment.ContentType, imageproc.DefaultVariants)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUnprocessable, err)
+	}
+
+	// Overwrite the upload so the raw file with its EXIF block is gone.
+	if err := a.blobs.Put(ctx, attachment.BlobKey, bytes.NewReader(res.Original), int64(len(res.Original)), attachment.ContentType); err != nil {
+		return fmt.Errorf("can't store stripped original: %w", err)
+	}
+
+	attachment.Size = int64(len(res.Original))
+	attachment.Width = res.Width
+	attachment.Height = res.Height
+	attachment.Blurhash = res.Blurhash
+	attachment.Variants = attachment.Variants[:0]
+
+	for _, v := range res.Variants {
+		key := attachment.BlobKey + "." + v.Name
+		if err := a.blobs.Put(ctx, key, bytes.NewReader(v.Data), int64(len(v.Data)), v.ContentType); err != nil {
+			return fmt.Errorf("can't store %s variant: %w", v.Name, err)
+		}
+		attachment.Variants = append(attachment.Variants, models.AttachmentVariant{
+			Name:        v.Name,
+			BlobKey:     key,
+			ContentType: v.ContentType,
+			Width:       v.Width,
+			Height:      v.Height,
+			Size:        int64(len(v.Data)),
+		})
+	}
+
+	return a.repo.CompleteProcessing(ctx, attachment)
+}
+
+// RunProcessor processes pending images whenever one is uploaded on this
+// instance and at least every interval, until ctx is cancelled.
+func (a *AttachmentService) RunProcessor(ctx context.Context, interval time.Duration) {
+	const op = "AttachmentService.RunProcessor"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-a.pending:
+		}
+
+		// Drain the backlog before going back to sleep.
+		for {
+			n, err := a.ProcessPending(ctx)
+			if err != nil {
+				a.log.Error(op+": "+err.Error(), sl.Op(op), sl.Err(err))
+				break
+			}
+			if n < processBatchSize {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package blocklist
+
+// automaton is an Aho-Corasick automaton: it finds every occurrence of any
+// number of literal patterns in one pass over the text. It works on runes
+// so that offsets can be mapped back to the bytes of the original body.
+type automaton struct {
+	nodes []acNode
+	// lens are the lengths of the patterns in runes, by pattern index.
+	lens []int
+}
+
+type acNode struct {
+	next map[rune]int
+	fail int
+	// out are the patterns ending here, including those ending at the
+	// nodes the failure links lead to.
+	out []int
+}
+
+// acHit is an occurrence of pattern at runes [start, end) of the text.
+type acHit struct {
+	pattern    int
+	start, end int
+}
+
+func newAutomaton(patterns [][]rune) *automaton {
+	a := &automaton{
+		nodes: []acNode{{next: make(map[rune]int)}},
+		lens:  make([]int, len(patterns)),
+	}
+
+	for i, p := range patterns {
+		a.lens[i] = len(p)
+		n := 0
+		for _, r := range p {
+			next, ok := a.nodes[n].next[r]
+			if !ok {
+				next = len(a.nodes)
+				a.nodes = append(a.nodes, acNode{next: make(map[rune]int)})
+				a.nodes[n].next[r] = next
+			}
+			n = next
+		}
+		a.nodes[n].out = append(a.nodes[n].out, i)
+	}
+
+	// Failure links point at the longest proper suffix that is also a
+	// prefix of some pattern; going breadth first, that node is done
+	// before the ones that need it.
+	queue := make([]int, 0, len(a.nodes))
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for r, child := range a.nodes[n].next {
+			f := a.nodes[n].fail
+			for f != 0 && a.nodes[f].next[r] == 0 {
+				f = a.nodes[f].fail
+			}
+			if target, ok := a.nodes[f].next[r]; ok && target != child {
+				a.nodes[child].fail = target
+			}
+			fail := a.nodes[child].fail
+			a.nodes[child].out = append(a.nodes[child].out, a.nodes[fail].out...)
+			queue = append(queue, child)
+		}
+	}
+
+	return a
+}
+
+// find returns every occurrence of every pattern in text, overlapping ones
+// included, ordered by where they end.
+func (a *automaton) find(text []rune) []acHit {
+	hits := make([]acHit, 0)
+	n := 0
+	for i, r := range text {
+		for n != 0 && a.nodes[n].next[r] == 0 {
+			n = a.nodes[n].fail
+		}
+		n = a.nodes[n].next[r]
+		for _, p := range a.nodes[n].out {
+			hits = append(hits, acHit{pattern: p, start: i + 1 - a.lens[p], end: i + 1})
+		}
+	}
+	return hits
+}
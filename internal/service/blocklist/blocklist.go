@@ -0,0 +1,251 @@
+package blocklist
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	blocklist_repo "github.com/AtIasShrugged/antisocial/internal/repository/blocklist"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+)
+
+const (
+	maxPatternLen     = 256
+	maxReplacementLen = 64
+	maxCommentLen     = 500
+)
+
+type Auditor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Record(ctx context.Context, event models.AuditEvent) error
+}
+
+// BlocklistService screens posts against the terms, domains and URLs
+// admins listed. Screening works from a compiled copy of the list that is
+// swapped whenever the list is reloaded, so it never waits on the database.
+type BlocklistService struct {
+	repo    blocklist_repo.BlocklistRepository
+	auditor Auditor
+	log     *slog.Logger
+	matcher atomic.Pointer[matcher]
+}
+
+// New returns a service with an empty list; call Reload to load it.
+func New(repo blocklist_repo.BlocklistRepository, auditor Auditor, log *slog.Logger) *BlocklistService {
+	b := &BlocklistService{
+		repo:    repo,
+		auditor: auditor,
+		log:     log,
+	}
+	m, _ := compile(nil)
+	b.matcher.Store(m)
+	return b
+}
+
+// Screen runs body past the blocklist as last loaded.
+func (b *BlocklistService) Screen(body string) models.Screening {
+	s := b.matcher.Load().screen(body)
+	if s.Severity != "" {
+		metrics.BlocklistMatches.WithLabelValues(string(s.Severity)).Inc()
+	}
+	return s
+}
+
+// Reload compiles the list as stored and starts screening with it. Entries
+// that don't compile are logged and left out.
+func (b *BlocklistService) Reload(ctx context.Context) error {
+	const op = "BlocklistService.Reload"
+
+	entries, err := b.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	m, skipped := compile(entries)
+	for _, err := range skipped {
+		sl.FromContext(ctx, b.log).ErrorContext(ctx, "blocklist entry skipped", sl.Op(op), sl.Err(err))
+	}
+	b.matcher.Store(m)
+
+	return nil
+}
+
+// RunReloader reloads the list every interval until ctx is done, picking up
+// changes made through other instances.
+func (b *BlocklistService) RunReloader(ctx context.Context, interval time.Duration) {
+	const op = "BlocklistService.RunReloader"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.Reload(ctx); err != nil {
+				b.log.Error(op+": "+err.Error(), sl.Op(op), sl.Err(err))
+			}
+		}
+	}
+}
+
+// List returns every entry, oldest first.
+func (b *BlocklistService) List(ctx context.Context) ([]models.BlocklistEntry, error) {
+	if err := auth.Authorize(ctx, auth.BlocklistManage, nil); err != nil {
+		return nil, err
+	}
+	return b.repo.List(ctx)
+}
+
+// Create adds entry on behalf of the user of ctx. It takes effect on this
+// instance right away and on the others at their next reload.
+func (b *BlocklistService) Create(ctx context.Context, entry models.BlocklistEntry) (int, error) {
+	const op = "BlocklistService.Create"
+
+	if err := auth.Authorize(ctx, auth.BlocklistManage, nil); err != nil {
+		return 0, err
+	}
+	if err := normalize(&entry); err != nil {
+		return 0, err
+	}
+	entry.CreatedBy, _ = auth.UserID(ctx)
+
+	err := b.auditor.InTx(ctx, func(ctx context.Context) error {
+		id, err := b.repo.Create(ctx, entry)
+		if err != nil {
+			return err
+		}
+		entry.ID = id
+		return b.auditor.Record(ctx, models.AuditEvent{
+			Action:     models.AuditBlocklistAdd,
+			TargetType: models.AuditTargetBlocklist,
+			TargetID:   strconv.Itoa(id),
+			After:      entry,
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	// The entry is stored: if the reload fails, the reloader picks it up.
+	if err := b.Reload(ctx); err != nil {
+		sl.FromContext(ctx, b.log).ErrorContext(ctx, "can't reload blocklist", sl.Op(op), sl.Err(err))
+	}
+
+	return entry.ID, nil
+}
+
+// Delete removes the entry id.
+func (b *BlocklistService) Delete(ctx context.Context, id int) error {
+	const op = "BlocklistService.Delete"
+
+	if err := auth.Authorize(ctx, auth.BlocklistManage, nil); err != nil {
+		return err
+	}
+
+	entry, err := b.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = b.auditor.InTx(ctx, func(ctx context.Context) error {
+		if err := b.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return b.auditor.Record(ctx, models.AuditEvent{
+			Action:     models.AuditBlocklistRemove,
+			TargetType: models.AuditTargetBlocklist,
+			TargetID:   strconv.Itoa(id),
+			Before:     entry,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if err := b.Reload(ctx); err != nil {
+		sl.FromContext(ctx, b.log).ErrorContext(ctx, "can't reload blocklist", sl.Op(op), sl.Err(err))
+	}
+
+	return nil
+}
+
+// normalize checks entry and puts its pattern in the form the matcher
+// expects: domains lowercased, URL patterns without their scheme and with
+// their host lowercased.
+func normalize(entry *models.BlocklistEntry) error {
+	entry.Pattern = strings.TrimSpace(entry.Pattern)
+	if entry.Pattern == "" || utf8.RuneCountInString(entry.Pattern) > maxPatternLen {
+		return fmt.Errorf("%w: pattern must be 1 to %d characters", ErrInvalidEntry, maxPatternLen)
+	}
+	if entry.Regex && entry.Kind != models.BlockTerm {
+		return fmt.Errorf("%w: only terms may be regular expressions", ErrInvalidEntry)
+	}
+
+	switch entry.Kind {
+	case models.BlockTerm:
+		if entry.Regex {
+			if _, err := termRegexp(entry.Pattern); err != nil {
+				return fmt.Errorf("%w: %s", ErrInvalidEntry, err.Error())
+			}
+		}
+	case models.BlockDomain:
+		domain := strings.Trim(strings.ToLower(entry.Pattern), ".")
+		if !validDomain(domain) {
+			return fmt.Errorf("%w: %q is not a domain", ErrInvalidEntry, entry.Pattern)
+		}
+		entry.Pattern = domain
+	case models.BlockURL:
+		pattern := strings.TrimPrefix(strings.TrimPrefix(entry.Pattern, "https://"), "http://")
+		host, path, hasPath := strings.Cut(pattern, "/")
+		if host == "" || strings.ContainsAny(pattern, " \t\n") {
+			return fmt.Errorf("%w: %q is not a URL pattern", ErrInvalidEntry, entry.Pattern)
+		}
+		entry.Pattern = strings.ToLower(host)
+		if hasPath {
+			entry.Pattern += "/" + path
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidEntry, entry.Kind)
+	}
+
+	switch entry.Severity {
+	case models.BlockReject, models.BlockModerate:
+		if entry.Replacement != "" {
+			return fmt.Errorf("%w: only replace entries take a replacement", ErrInvalidEntry)
+		}
+	case models.BlockReplace:
+		if utf8.RuneCountInString(entry.Replacement) > maxReplacementLen {
+			return fmt.Errorf("%w: replacement must be at most %d characters", ErrInvalidEntry, maxReplacementLen)
+		}
+	default:
+		return fmt.Errorf("%w: unknown severity %q", ErrInvalidEntry, entry.Severity)
+	}
+
+	if utf8.RuneCountInString(entry.Comment) > maxCommentLen {
+		return fmt.Errorf("%w: comment must be at most %d characters", ErrInvalidEntry, maxCommentLen)
+	}
+	return nil
+}
+
+var domainLabel = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// validDomain reports whether domain is a hostname or an IP address.
+func validDomain(domain string) bool {
+	if net.ParseIP(domain) != nil {
+		return true
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if !domainLabel.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package blocklist
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/AtIasShrugged/antisocial/internal/audit/audittest"
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	blocklist_repo "github.com/AtIasShrugged/antisocial/internal/repository/blocklist"
+	repoMock "github.com/AtIasShrugged/antisocial/internal/repository/blocklist/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func as(id int, role models.Role) context.Context {
+	return auth.WithPrincipal(context.Background(), auth.Principal{UserID: id, Role: role})
+}
+
+func newService(t *testing.T) (*BlocklistService, *repoMock.MockBlocklistRepository, *audittest.Recorder) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	repo := repoMock.NewMockBlocklistRepository(ctrl)
+	auditor := &audittest.Recorder{}
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return New(repo, auditor, log), repo, auditor
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   models.BlocklistEntry
+		want    string
+		wantErr bool
+	}{
+		{name: "term", entry: models.BlocklistEntry{Kind: models.BlockTerm, Pattern: " darn ", Severity: models.BlockReplace}, want: "darn"},
+		{name: "regex", entry: models.BlocklistEntry{Kind: models.BlockTerm, Pattern: `d[a4]rn`, Regex: true, Severity: models.BlockReject}, want: `d[a4]rn`},
+		{name: "domain", entry: models.BlocklistEntry{Kind: models.BlockDomain, Pattern: "Scam.Example.", Severity: models.BlockReject}, want: "scam.example"},
+		{name: "url", entry: models.BlocklistEntry{Kind: models.BlockURL, Pattern: "https://Shop.example/Promo/*", Severity: models.BlockModerate}, want: "shop.example/Promo/*"},
+		{name: "empty", entry: models.BlocklistEntry{Kind: models.BlockTerm, Pattern: "  ", Severity: models.BlockReject}, wantErr: true},
+		{name: "bad regex", entry: models.BlocklistEntry{Kind: models.BlockTerm, Pattern: "(", Regex: true, Severity: models.BlockReject}, wantErr: true},
+		{name: "regex matching nothing", entry: models.BlocklistEntry{Kind: models.BlockTerm, Pattern: "x?", Regex: true, Severity: models.BlockReject}, wantErr: true},
+		{name: "regex domain", entry: models.BlocklistEntry{Kind: models.BlockDomain, Pattern: "scam", Regex: true, Severity: models.BlockReject}, wantErr: true},
+		{name: "bad domain", entry: models.BlocklistEntry{Kind: models.BlockDomain, Pattern: "scam.example/x", Severity: models.BlockReject}, wantErr: true},
+		{name: "url without host", entry: models.BlocklistEntry{Kind: models.BlockURL, Pattern: "https:///x", Severity: models.BlockReject}, wantErr: true},
+		{name: "unknown kind", entry: models.BlocklistEntry{Kind: "phrase", Pattern: "x", Severity: models.BlockReject}, wantErr: true},
+		{name: "unknown severity", entry: models.BlocklistEntry{Kind: models.BlockTerm, Pattern: "x", Severity: "warn"}, wantErr: true},
+		{name: "stray replacement", entry: models.BlocklistEntry{Kind: models.BlockTerm, Pattern: "x", Severity: models.BlockReject, Replacement: "y"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := normalize(&tt.entry)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidEntry)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, tt.entry.Pattern)
+		})
+	}
+}
+
+func TestCreateReloads(t *testing.T) {
+	service, repo, auditor := newService(t)
+	ctx := as(1, models.RoleAdmin)
+
+	entry := models.BlocklistEntry{Kind: models.BlockTerm, Pattern: "darn", Severity: models.BlockReplace}
+	stored := entry
+	stored.CreatedBy = 1
+	gomock.InOrder(
+		repo.EXPECT().Create(ctx, stored).Return(9, nil),
+		repo.EXPECT().List(ctx).Return([]models.BlocklistEntry{{ID: 9, Kind: models.BlockTerm, Pattern: "darn", Severity: models.BlockReplace}}, nil),
+	)
+
+	require.Empty(t, service.Screen("darn").Severity)
+	id, err := service.Create(ctx, entry)
+	require.NoError(t, err)
+	require.Equal(t, 9, id)
+	require.Equal(t, "****", service.Screen("darn").Body)
+
+	require.Len(t, auditor.Events, 1)
+	require.Equal(t, models.AuditBlocklistAdd, auditor.Events[0].Action)
+	require.Equal(t, "9", auditor.Events[0].TargetID)
+}
+
+func TestCreateSurvivesFailedReload(t *testing.T) {
+	service, repo, _ := newService(t)
+	ctx := as(1, models.RoleAdmin)
+
+	repo.EXPECT().Create(ctx, gomock.Any()).Return(9, nil)
+	repo.EXPECT().List(ctx).Return(nil, errors.New("can't query blocklist"))
+
+	_, err := service.Create(ctx, models.BlocklistEntry{Kind: models.BlockTerm, Pattern: "darn", Severity: models.BlockReject})
+	require.NoError(t, err)
+}
+
+func TestDelete(t *testing.T) {
+	service, repo, auditor := newService(t)
+	ctx := as(1, models.RoleAdmin)
+
+	entry := models.BlocklistEntry{ID: 4, Kind: models.BlockTerm, Pattern: "darn", Severity: models.BlockReject}
+	gomock.InOrder(
+		repo.EXPECT().Get(ctx, 4).Return(entry, nil),
+		repo.EXPECT().Delete(ctx, 4).Return(nil),
+		repo.EXPECT().List(ctx).Return(nil, nil),
+	)
+	require.NoError(t, service.Delete(ctx, 4))
+	require.Equal(t, entry, auditor.Events[0].Before)
+
+	repo.EXPECT().Get(ctx, 5).Return(models.BlocklistEntry{}, blocklist_repo.ErrEntryNotFound)
+	require.ErrorIs(t, service.Delete(ctx, 5), blocklist_repo.ErrEntryNotFound)
+}
+
+func TestManageNeedsAdmin(t *testing.T) {
+	service, _, _ := newService(t)
+	ctx := as(2, models.RoleModerator)
+
+	_, err := service.List(ctx)
+	require.ErrorIs(t, err, auth.ErrForbidden)
+	_, err = service.Create(ctx, models.BlocklistEntry{Kind: models.BlockTerm, Pattern: "darn", Severity: models.BlockReject})
+	require.ErrorIs(t, err, auth.ErrForbidden)
+	require.ErrorIs(t, service.Delete(ctx, 1), auth.ErrForbidden)
+}
@@ -0,0 +1,5 @@
+package blocklist
+
+import "errors"
+
+var ErrInvalidEntry = errors.New("invalid blocklist entry")
@@ -0,0 +1,274 @@
+package blocklist
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+)
+
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"'\x60]+`)
+
+// strictness orders severities: a post matching several entries gets the
+// treatment of the strictest one.
+var strictness = map[models.BlocklistSeverity]int{
+	models.BlockReplace:  1,
+	models.BlockModerate: 2,
+	models.BlockReject:   3,
+}
+
+// matcher is a compiled blocklist. It is immutable, so screening needs no
+// locks and a reload swaps in a new one.
+type matcher struct {
+	// terms are the literal terms, matched case-insensitively on word
+	// boundaries by the automaton.
+	terms     []models.BlocklistEntry
+	automaton *automaton
+
+	// regexes are the regular expression terms. any is their union: most
+	// bodies match none, and it tells so in one pass.
+	regexes []compiledRegex
+	any     *regexp.Regexp
+
+	// domains are keyed by the lowercased domain.
+	domains map[string]models.BlocklistEntry
+
+	// urls match links without their scheme; anyURL is their union.
+	urls   []compiledRegex
+	anyURL *regexp.Regexp
+
+	// replacements are what the matches of each entry are replaced with.
+	replacements map[int]string
+}
+
+type compiledRegex struct {
+	entry models.BlocklistEntry
+	re    *regexp.Regexp
+}
+
+// compile builds the matcher of entries. Entries that don't compile are left
+// out and returned with why, so that one bad entry doesn't take the whole
+// list down.
+func compile(entries []models.BlocklistEntry) (*matcher, []error) {
+	m := &matcher{
+		domains:      make(map[string]models.BlocklistEntry),
+		replacements: make(map[int]string),
+	}
+	skipped := make([]error, 0)
+	literals := make([][]rune, 0)
+	termSources := make([]string, 0)
+	urlSources := make([]string, 0)
+
+	for _, e := range entries {
+		m.replacements[e.ID] = e.Replacement
+		switch {
+		case e.Kind == models.BlockTerm && e.Regex:
+			re, err := termRegexp(e.Pattern)
+			if err != nil {
+				skipped = append(skipped, fmt.Errorf("entry %d: %w", e.ID, err))
+				continue
+			}
+			m.regexes = append(m.regexes, compiledRegex{entry: e, re: re})
+			termSources = append(termSources, "(?:"+e.Pattern+")")
+		case e.Kind == models.BlockTerm:
+			m.terms = append(m.terms, e)
+			literals = append(literals, []rune(strings.ToLower(e.Pattern)))
+		case e.Kind == models.BlockDomain:
+			m.domains[strings.ToLower(e.Pattern)] = e
+		case e.Kind == models.BlockURL:
+			source := urlSource(e.Pattern)
+			m.urls = append(m.urls, compiledRegex{entry: e, re: regexp.MustCompile("^" + source + "$")})
+			urlSources = append(urlSources, source)
+		default:
+			skipped = append(skipped, fmt.Errorf("entry %d: unknown kind %q", e.ID, e.Kind))
+		}
+	}
+
+	m.automaton = newAutomaton(literals)
+	if len(termSources) > 0 {
+		m.any = regexp.MustCompile("(?i)" + strings.Join(termSources, "|"))
+	}
+	if len(urlSources) > 0 {
+		m.anyURL = regexp.MustCompile("^(?:" + strings.Join(urlSources, "|") + ")$")
+	}
+
+	return m, skipped
+}
+
+// termRegexp compiles a regular expression term. Terms match regardless of
+// case, like literal ones.
+func termRegexp(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, err
+	}
+	if re.MatchString("") {
+		return nil, fmt.Errorf("%q matches empty text", pattern)
+	}
+	return re, nil
+}
+
+// urlSource turns a URL pattern, where "*" stands for anything, into a
+// regular expression.
+func urlSource(pattern string) string {
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return strings.Join(parts, ".*")
+}
+
+// screen runs body past the blocklist.
+func (m *matcher) screen(body string) models.Screening {
+	matches := slices.Concat(m.matchTerms(body), m.matchRegexes(body), m.matchLinks(body))
+	slices.SortFunc(matches, func(a, b models.BlocklistMatch) int {
+		return cmp.Or(cmp.Compare(a.Start, b.Start), cmp.Compare(b.End, a.End), cmp.Compare(a.EntryID, b.EntryID))
+	})
+
+	s := models.Screening{Body: body, Matches: matches}
+	for _, match := range matches {
+		if strictness[match.Severity] > strictness[s.Severity] {
+			s.Severity = match.Severity
+		}
+	}
+	if s.Severity != "" && s.Severity != models.BlockReject {
+		s.Body = m.replace(body, matches)
+	}
+	return s
+}
+
+// matchTerms finds the literal terms. Where a term starts or ends with a
+// letter or digit it must not run on into a longer word there, so that
+// "ass" doesn't match "class".
+func (m *matcher) matchTerms(body string) []models.BlocklistMatch {
+	if len(m.terms) == 0 {
+		return nil
+	}
+
+	// offsets[i] is where rune i starts in body, with one more for the end.
+	// Lowercasing rune by rune keeps the runes lined up with them.
+	text := make([]rune, 0, len(body))
+	offsets := make([]int, 0, len(body)+1)
+	for i, r := range body {
+		text = append(text, unicode.ToLower(r))
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(body))
+
+	matches := make([]models.BlocklistMatch, 0)
+	for _, hit := range m.automaton.find(text) {
+		if isWord(text[hit.start]) && hit.start > 0 && isWord(text[hit.start-1]) {
+			continue
+		}
+		if isWord(text[hit.end-1]) && hit.end < len(text) && isWord(text[hit.end]) {
+			continue
+		}
+		e := m.terms[hit.pattern]
+		matches = append(matches, models.BlocklistMatch{
+			EntryID:  e.ID,
+			Severity: e.Severity,
+			Start:    offsets[hit.start],
+			End:      offsets[hit.end],
+		})
+	}
+	return matches
+}
+
+func isWord(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+func (m *matcher) matchRegexes(body string) []models.BlocklistMatch {
+	if m.any == nil || !m.any.MatchString(body) {
+		return nil
+	}
+
+	matches := make([]models.BlocklistMatch, 0)
+	for _, r := range m.regexes {
+		for _, loc := range r.re.FindAllStringIndex(body, -1) {
+			matches = append(matches, models.BlocklistMatch{
+				EntryID:  r.entry.ID,
+				Severity: r.entry.Severity,
+				Start:    loc[0],
+				End:      loc[1],
+			})
+		}
+	}
+	return matches
+}
+
+// matchLinks checks the links of body against the domains, which cover
+// their subdomains too, and the URL patterns. A match spans the whole link.
+func (m *matcher) matchLinks(body string) []models.BlocklistMatch {
+	if len(m.domains) == 0 && len(m.urls) == 0 {
+		return nil
+	}
+
+	matches := make([]models.BlocklistMatch, 0)
+	for _, loc := range linkPattern.FindAllStringIndex(body, -1) {
+		u, err := url.Parse(body[loc[0]:loc[1]])
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+		add := func(e models.BlocklistEntry) {
+			matches = append(matches, models.BlocklistMatch{EntryID: e.ID, Severity: e.Severity, Start: loc[0], End: loc[1]})
+		}
+
+		for domain := host; domain != ""; {
+			if e, ok := m.domains[domain]; ok {
+				add(e)
+			}
+			_, parent, found := strings.Cut(domain, ".")
+			if !found {
+				break
+			}
+			domain = parent
+		}
+
+		if m.anyURL == nil {
+			continue
+		}
+		link := host + u.EscapedPath()
+		if u.RawQuery != "" {
+			link += "?" + u.RawQuery
+		}
+		if !m.anyURL.MatchString(link) {
+			continue
+		}
+		for _, r := range m.urls {
+			if r.re.MatchString(link) {
+				add(r.entry)
+			}
+		}
+	}
+	return matches
+}
+
+// replace swaps the matches of replace entries in body for their
+// replacement, skipping those overlapping one already replaced. matches
+// must be sorted by where they start.
+func (m *matcher) replace(body string, matches []models.BlocklistMatch) string {
+	var b strings.Builder
+	done := 0
+	for _, match := range matches {
+		if match.Severity != models.BlockReplace || match.Start < done {
+			continue
+		}
+		b.WriteString(body[done:match.Start])
+		if r := m.replacements[match.EntryID]; r != "" {
+			b.WriteString(r)
+		} else {
+			b.WriteString(strings.Repeat("*", utf8.RuneCountInString(body[match.Start:match.End])))
+		}
+		done = match.End
+	}
+	b.WriteString(body[done:])
+	return b.String()
+}
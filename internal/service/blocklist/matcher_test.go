@@ -0,0 +1,93 @@
+package blocklist
+
+import (
+	"testing"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutomaton(t *testing.T) {
+	a := newAutomaton([][]rune{[]rune("he"), []rune("she"), []rune("his"), []rune("hers")})
+
+	hits := a.find([]rune("ushers"))
+	require.Equal(t, []acHit{
+		{pattern: 1, start: 1, end: 4},
+		{pattern: 0, start: 2, end: 4},
+		{pattern: 3, start: 2, end: 6},
+	}, hits)
+
+	require.Empty(t, a.find([]rune("nothing")))
+	require.Empty(t, newAutomaton(nil).find([]rune("anything")))
+}
+
+func TestScreen(t *testing.T) {
+	entries := []models.BlocklistEntry{
+		{ID: 1, Kind: models.BlockTerm, Pattern: "darn", Severity: models.BlockReplace},
+		{ID: 2, Kind: models.BlockTerm, Pattern: "heck", Severity: models.BlockReplace, Replacement: "[removed]"},
+		{ID: 3, Kind: models.BlockTerm, Pattern: `fr[e3]{2}\s+money`, Regex: true, Severity: models.BlockModerate},
+		{ID: 4, Kind: models.BlockDomain, Pattern: "scam.example", Severity: models.BlockReject},
+		{ID: 5, Kind: models.BlockURL, Pattern: "shop.example/promo/*", Severity: models.BlockModerate},
+		{ID: 6, Kind: models.BlockTerm, Pattern: "gr8 deal!", Severity: models.BlockReplace},
+	}
+	m, skipped := compile(entries)
+	require.Empty(t, skipped)
+
+	tests := []struct {
+		name     string
+		body     string
+		want     string
+		severity models.BlocklistSeverity
+		entries  []int
+	}{
+		{name: "clean", body: "nothing to see here", want: "nothing to see here"},
+		{name: "masked", body: "Darn it", want: "**** it", severity: models.BlockReplace, entries: []int{1}},
+		{name: "replaced", body: "what the HECK, heck", want: "what the [removed], [removed]", severity: models.BlockReplace, entries: []int{2, 2}},
+		{name: "word boundaries", body: "darning heckle", want: "darning heckle"},
+		{name: "punctuation edge", body: "a gr8 deal!!", want: "a *********!", severity: models.BlockReplace, entries: []int{6}},
+		{name: "multibyte offsets", body: "über darn", want: "über ****", severity: models.BlockReplace, entries: []int{1}},
+		{name: "regex", body: "get FR33  money now", want: "get FR33  money now", severity: models.BlockModerate, entries: []int{3}},
+		{name: "subdomain", body: "see https://www.scam.example/x", want: "see https://www.scam.example/x", severity: models.BlockReject, entries: []int{4}},
+		{name: "lookalike domain", body: "see https://notscam.example", want: "see https://notscam.example"},
+		{name: "url pattern", body: "https://Shop.example/promo/42?ref=me", want: "https://Shop.example/promo/42?ref=me", severity: models.BlockModerate, entries: []int{5}},
+		{name: "url outside pattern", body: "https://shop.example/about", want: "https://shop.example/about"},
+		{name: "strictest wins", body: "darn, free money", want: "****, free money", severity: models.BlockModerate, entries: []int{1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := m.screen(tt.body)
+			require.Equal(t, tt.want, s.Body)
+			require.Equal(t, tt.severity, s.Severity)
+			ids := make([]int, 0)
+			for _, match := range s.Matches {
+				ids = append(ids, match.EntryID)
+			}
+			if tt.entries == nil {
+				tt.entries = []int{}
+			}
+			require.Equal(t, tt.entries, ids)
+		})
+	}
+}
+
+func TestScreenOverlappingReplacements(t *testing.T) {
+	m, _ := compile([]models.BlocklistEntry{
+		{ID: 1, Kind: models.BlockTerm, Pattern: "bad word", Severity: models.BlockReplace, Replacement: "x"},
+		{ID: 2, Kind: models.BlockTerm, Pattern: "word", Severity: models.BlockReplace, Replacement: "y"},
+	})
+
+	s := m.screen("a bad word and a word")
+	require.Equal(t, "a x and a y", s.Body)
+	require.Equal(t, models.BlocklistMatch{EntryID: 1, Severity: models.BlockReplace, Start: 2, End: 10}, s.Matches[0])
+}
+
+func TestCompileSkipsBadEntries(t *testing.T) {
+	m, skipped := compile([]models.BlocklistEntry{
+		{ID: 1, Kind: models.BlockTerm, Pattern: "(", Regex: true, Severity: models.BlockReject},
+		{ID: 2, Kind: models.BlockTerm, Pattern: "a*", Regex: true, Severity: models.BlockReject},
+		{ID: 3, Kind: models.BlockTerm, Pattern: "spam", Severity: models.BlockReject},
+	})
+	require.Len(t, skipped, 2)
+	require.Equal(t, models.BlockReject, m.screen("spam").Severity)
+	require.Empty(t, m.screen("aaa").Severity)
+}
@@ -0,0 +1,5 @@
+package follow
+
+import "errors"
+
+var ErrSelfFollow = errors.New("can't follow yourself")
@@ -0,0 +1,45 @@
+package follow
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	follow_repo "github.com/AtIasShrugged/antisocial/internal/repository/follow"
+)
+
+type FollowService struct {
+	repo follow_repo.FollowRepository
+	log  *slog.Logger
+}
+
+func New(repo follow_repo.FollowRepository, log *slog.Logger) *FollowService {
+	return &FollowService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// Follow makes followerID follow followeeID.
+func (f *FollowService) Follow(ctx context.Context, followerID, followeeID int) error {
+	if err := auth.Authorize(ctx, auth.FollowsManage, auth.Owner(followerID)); err != nil {
+		return err
+	}
+	if followerID == followeeID {
+		return ErrSelfFollow
+	}
+	return f.repo.Follow(ctx, followerID, followeeID)
+}
+
+func (f *FollowService) Unfollow(ctx context.Context, followerID, followeeID int) error {
+	if err := auth.Authorize(ctx, auth.FollowsManage, auth.Owner(followerID)); err != nil {
+		return err
+	}
+	return f.repo.Unfollow(ctx, followerID, followeeID)
+}
+
+// Follows reports whether followerID follows followeeID. It backs post
+// visibility and so checks no permission.
+func (f *FollowService) Follows(ctx context.Context, followerID, followeeID int) (bool, error) {
+	return f.repo.Follows(ctx, followerID, followeeID)
+}
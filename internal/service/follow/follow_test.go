@@ -0,0 +1,31 @@
+package follow
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	repoMock "github.com/AtIasShrugged/antisocial/internal/repository/follow/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFollow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockFollowRepository(ctrl)
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	service := New(repo, log)
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{UserID: 1, Role: models.RoleUser})
+
+	repo.EXPECT().Follow(ctx, 1, 2).Return(nil)
+	require.NoError(t, service.Follow(ctx, 1, 2))
+
+	require.ErrorIs(t, service.Follow(ctx, 1, 1), ErrSelfFollow)
+	require.ErrorIs(t, service.Follow(ctx, 2, 1), auth.ErrForbidden)
+	require.ErrorIs(t, service.Unfollow(ctx, 2, 1), auth.ErrForbidden)
+}
@@ -0,0 +1,163 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	health_repo "github.com/AtIasShrugged/antisocial/internal/repository/health"
+)
+
+const (
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+)
+
+// shutdownCheck is the name under which draining is reported.
+const shutdownCheck = "shutdown"
+
+var ErrShuttingDown = errors.New("shutting down")
+
+// Check reports whether a dependency is usable. It must honour ctx, which
+// carries the check timeout.
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// HealthService runs the readiness checks. Results of dependency checks are
+// cached for the configured TTL so frequent probes don't reach Postgres each
+// time; draining is reported live.
+type HealthService struct {
+	cfg    config.HealthConfig
+	checks []namedCheck
+	now    func() time.Time
+
+	draining atomic.Bool
+
+	// mu also serialises runs, so concurrent probes share one.
+	mu       sync.Mutex
+	cached   map[string]CheckResult
+	cachedAt time.Time
+}
+
+func New(cfg config.HealthConfig) *HealthService {
+	return &HealthService{
+		cfg: cfg,
+		now: time.Now,
+	}
+}
+
+// Add registers a readiness check. It must be called before serving probes.
+func (h *HealthService) Add(name string, check Check) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// Drain makes readiness fail from now on, so that load balancers stop
+// routing new requests here before the server shuts down.
+func (h *HealthService) Drain() {
+	h.draining.Store(true)
+}
+
+// Ready runs the readiness checks, or returns their cached results.
+func (h *HealthService) Ready(ctx context.Context) Report {
+	results := h.dependencies(ctx)
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(results)+1)}
+	for name, result := range results {
+		report.Checks[name] = result
+	}
+	if h.draining.Load() {
+		report.Checks[shutdownCheck] = CheckResult{Status: StatusFailing, Error: ErrShuttingDown.Error()}
+	} else {
+		report.Checks[shutdownCheck] = CheckResult{Status: StatusOK}
+	}
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFailing
+			break
+		}
+	}
+	return report
+}
+
+func (h *HealthService) dependencies(ctx context.Context) map[string]CheckResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cached != nil && h.now().Sub(h.cachedAt) < h.cfg.CacheTTL {
+		return h.cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Timeout)
+	defer cancel()
+
+	results := make(map[string]CheckResult, len(h.checks))
+	var (
+		wg  sync.WaitGroup
+		rmu sync.Mutex
+	)
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func(c namedCheck) {
+			defer wg.Done()
+
+			result := CheckResult{Status: StatusOK}
+			if err := c.check(ctx); err != nil {
+				result = CheckResult{Status: StatusFailing, Error: err.Error()}
+			}
+
+			rmu.Lock()
+			results[c.name] = result
+			rmu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	h.cached, h.cachedAt = results, h.now()
+	return results
+}
+
+// DatabaseCheck pings the database.
+func DatabaseCheck(repo health_repo.HealthRepository) Check {
+	return repo.Ping
+}
+
+// MigrationsCheck verifies that the schema is at version want, the latest
+// migration this build knows about, and that no migration failed halfway.
+func MigrationsCheck(repo health_repo.HealthRepository, want uint) Check {
+	return func(ctx context.Context) error {
+		version, dirty, err := repo.MigrationVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d is dirty", version)
+		}
+		if version != want {
+			return fmt.Errorf("schema is at version %d, want %d", version, want)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,99 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	repoMock "github.com/AtIasShrugged/antisocial/internal/repository/health/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newService(ttl time.Duration) (*HealthService, *time.Time) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	h := New(config.HealthConfig{Timeout: 50 * time.Millisecond, CacheTTL: ttl})
+	h.now = func() time.Time { return now }
+	return h, &now
+}
+
+func TestReadyCachesDependencyChecks(t *testing.T) {
+	h, now := newService(2 * time.Second)
+
+	calls := 0
+	h.Add("database", func(context.Context) error {
+		calls++
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		report := h.Ready(context.Background())
+		require.True(t, report.OK())
+	}
+	require.Equal(t, 1, calls)
+
+	*now = now.Add(2 * time.Second)
+	h.Ready(context.Background())
+	require.Equal(t, 2, calls)
+}
+
+func TestReadyReportsEveryCheck(t *testing.T) {
+	h, _ := newService(0)
+	h.Add("database", func(context.Context) error { return nil })
+	h.Add("cache", func(context.Context) error { return errors.New("connection refused") })
+
+	report := h.Ready(context.Background())
+	require.False(t, report.OK())
+	require.Equal(t, StatusFailing, report.Status)
+	require.Equal(t, CheckResult{Status: StatusOK}, report.Checks["database"])
+	require.Equal(t, CheckResult{Status: StatusFailing, Error: "connection refused"}, report.Checks["cache"])
+	require.Equal(t, CheckResult{Status: StatusOK}, report.Checks[shutdownCheck])
+}
+
+func TestReadyTimesOutSlowChecks(t *testing.T) {
+	h, _ := newService(0)
+	h.Add("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	report := h.Ready(context.Background())
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, StatusFailing, report.Checks["database"].Status)
+	require.Contains(t, report.Checks["database"].Error, "deadline exceeded")
+}
+
+func TestDrainFailsReadinessImmediately(t *testing.T) {
+	h, _ := newService(time.Hour)
+	h.Add("database", func(context.Context) error { return nil })
+
+	require.True(t, h.Ready(context.Background()).OK())
+
+	// The cached dependency results must not hide the shutdown.
+	h.Drain()
+	report := h.Ready(context.Background())
+	require.False(t, report.OK())
+	require.Equal(t, StatusOK, report.Checks["database"].Status)
+	require.Equal(t, StatusFailing, report.Checks[shutdownCheck].Status)
+}
+
+func TestMigrationsCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockHealthRepository(ctrl)
+	check := MigrationsCheck(repo, 8)
+	ctx := context.Background()
+
+	repo.EXPECT().MigrationVersion(ctx).Return(uint(8), false, nil).Times(1)
+	require.NoError(t, check(ctx))
+
+	repo.EXPECT().MigrationVersion(ctx).Return(uint(7), false, nil).Times(1)
+	require.EqualError(t, check(ctx), "schema is at version 7, want 8")
+
+	repo.EXPECT().MigrationVersion(ctx).Return(uint(8), true, nil).Times(1)
+	require.EqualError(t, check(ctx), "migration 8 is dirty")
+}
@@ -0,0 +1,121 @@
+package linkpreview
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	linkpreview_repo "github.com/AtIasShrugged/antisocial/internal/repository/linkpreview"
+	"github.com/AtIasShrugged/antisocial/internal/unfurl"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+)
+
+const fetchBatchSize = 10
+
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (models.LinkPreview, error)
+}
+
+type LinkPreviewService struct {
+	repo    linkpreview_repo.LinkPreviewRepository
+	fetcher Fetcher
+	cfg     config.LinkPreviewConfig
+	log     *slog.Logger
+	now     func() time.Time
+	// pending wakes the worker as soon as a post with links is created.
+	pending chan struct{}
+}
+
+func New(repo linkpreview_repo.LinkPreviewRepository, fetcher Fetcher, cfg config.LinkPreviewConfig, log *slog.Logger) *LinkPreviewService {
+	return &LinkPreviewService{
+		repo:    repo,
+		fetcher: fetcher,
+		cfg:     cfg,
+		log:     log,
+		now:     time.Now,
+		pending: make(chan struct{}, 1),
+	}
+}
+
+// Enqueue schedules previews for the links found in a post body. Fetching
+// happens in the background worker, never on the request path.
+func (l *LinkPreviewService) Enqueue(ctx context.Context, postID int, body string) error {
+	urls := unfurl.ExtractURLs(body)
+	if len(urls) == 0 {
+		return nil
+	}
+
+	if err := l.repo.Enqueue(ctx, postID, urls, l.now().Add(-l.cfg.CacheTTL)); err != nil {
+		return err
+	}
+
+	select {
+	case l.pending <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// FetchPending claims queued urls and unfurls them. It returns the number of
+// urls handled.
+func (l *LinkPreviewService) FetchPending(ctx context.Context) (int, error) {
+	const op = "LinkPreviewService.FetchPending"
+
+	urls, err := l.repo.ClaimPending(ctx, l.now().Add(-2*l.cfg.FetchTimeout), fetchBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, url := range urls {
+		fetchCtx, cancel := context.WithTimeout(ctx, l.cfg.FetchTimeout)
+		preview, err := l.fetcher.Fetch(fetchCtx, url)
+		cancel()
+
+		if err != nil {
+			metrics.LinkPreviewsFetched.WithLabelValues("failed").Inc()
+			l.log.Debug(op+": can't unfurl", slog.String("url", url), sl.Err(err))
+			if err := l.repo.MarkFailed(ctx, url); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err := l.repo.Save(ctx, preview); err != nil {
+			return 0, err
+		}
+		metrics.LinkPreviewsFetched.WithLabelValues("ok").Inc()
+	}
+
+	return len(urls), nil
+}
+
+// Run fetches pending previews whenever a post with links is created on this
+// instance and at least every interval, until ctx is cancelled.
+func (l *LinkPreviewService) Run(ctx context.Context, interval time.Duration) {
+	const op = "LinkPreviewService.Run"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-l.pending:
+		}
+
+		for {
+			n, err := l.FetchPending(ctx)
+			if err != nil {
+				l.log.Error(op+": "+err.Error(), sl.Op(op), sl.Err(err))
+				break
+			}
+			if n < fetchBatchSize {
+				break
+			}
+		}
+	}
+}
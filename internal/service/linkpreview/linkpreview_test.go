@@ -0,0 +1,74 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	repoMock "github.com/AtIasShrugged/antisocial/internal/repository/linkpreview/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type stubFetcher map[string]models.LinkPreview
+
+func (s stubFetcher) Fetch(_ context.Context, url string) (models.LinkPreview, error) {
+	preview, ok := s[url]
+	if !ok {
+		return models.LinkPreview{}, errors.New("unreachable")
+	}
+	return preview, nil
+}
+
+func testConfig() config.LinkPreviewConfig {
+	return config.LinkPreviewConfig{
+		FetchTimeout: time.Second,
+		CacheTTL:     time.Hour,
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockLinkPreviewRepository(ctrl)
+	ctx := context.Background()
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo.EXPECT().Enqueue(ctx, 5, []string{"https://a.test/", "https://b.test/x"}, now.Add(-time.Hour)).Return(nil).Times(1)
+
+	service := New(repo, stubFetcher{}, testConfig(), log)
+	service.now = func() time.Time { return now }
+
+	require.NoError(t, service.Enqueue(ctx, 5, "see https://a.test and https://b.test/x."))
+	require.NoError(t, service.Enqueue(ctx, 6, "no links here"))
+}
+
+func TestFetchPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockLinkPreviewRepository(ctrl)
+	ctx := context.Background()
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	ok := models.LinkPreview{URL: "https://a.test/", Title: "A"}
+	repo.EXPECT().ClaimPending(ctx, now.Add(-2*time.Second), fetchBatchSize).
+		Return([]string{"https://a.test/", "https://down.test/"}, nil).Times(1)
+	repo.EXPECT().Save(ctx, ok).Return(nil).Times(1)
+	repo.EXPECT().MarkFailed(ctx, "https://down.test/").Return(nil).Times(1)
+
+	service := New(repo, stubFetcher{"https://a.test/": ok}, testConfig(), log)
+	service.now = func() time.Time { return now }
+
+	n, err := service.FetchPending(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+}
@@ -0,0 +1,9 @@
+package moderation
+
+import "errors"
+
+var (
+	ErrInvalidReport = errors.New("invalid report")
+	ErrInvalidAction = errors.New("invalid moderation action")
+	ErrInvalidFilter = errors.New("invalid report filter")
+)
@@ -0,0 +1,338 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	report_repo "github.com/AtIasShrugged/antisocial/internal/repository/report"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+)
+
+const (
+	maxCommentLen    = 1000
+	maxRationaleLen  = 2000
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+type Notifier interface {
+	Notify(ctx context.Context, userIDs []int, kind models.NotificationKind, payload any) error
+}
+
+// Auditor records privileged actions. Record joins the transaction InTx
+// runs its function in, as do the repositories given that context.
+type Auditor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Record(ctx context.Context, event models.AuditEvent) error
+}
+
+type RoleSource interface {
+	Role(ctx context.Context, userID int) (models.Role, error)
+}
+
+// SpamTrainer learns from how moderators settle spam reports.
+type SpamTrainer interface {
+	Learn(ctx context.Context, postID int, spam bool) error
+}
+
+type ModerationService struct {
+	repo     report_repo.ReportRepository
+	notifier Notifier
+	auditor  Auditor
+	roles    RoleSource
+	trainer  SpamTrainer
+	log      *slog.Logger
+	now      func() time.Time
+}
+
+type Option func(*ModerationService)
+
+// WithSpamTrainer teaches trainer that a post reported as spam is spam when
+// moderators remove it, and isn't when they dismiss the report.
+func WithSpamTrainer(trainer SpamTrainer) Option {
+	return func(m *ModerationService) {
+		m.trainer = trainer
+	}
+}
+
+func New(repo report_repo.ReportRepository, notifier Notifier, auditor Auditor, roles RoleSource, log *slog.Logger, opts ...Option) *ModerationService {
+	m := &ModerationService{
+		repo:     repo,
+		notifier: notifier,
+		auditor:  auditor,
+		roles:    roles,
+		log:      log,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Report files a report against a post, or against an account when no post
+// is given.
+func (m *ModerationService) Report(ctx context.Context, report models.Report) (models.Report, error) {
+	if err := auth.Authorize(ctx, auth.ReportsCreate, auth.Owner(report.ReporterID)); err != nil {
+		return models.Report{}, err
+	}
+	if err := validateReport(&report); err != nil {
+		return models.Report{}, err
+	}
+
+	id, err := m.repo.Create(ctx, report)
+	if err != nil {
+		return models.Report{}, err
+	}
+	metrics.ReportsFiled.WithLabelValues(string(report.Target), string(report.Reason)).Inc()
+
+	return m.repo.GetByID(ctx, id)
+}
+
+func validateReport(report *models.Report) error {
+	if report.ReporterID <= 0 {
+		return fmt.Errorf("%w: reporter_id is required", ErrInvalidReport)
+	}
+	if !slices.Contains(models.ReportReasons, report.Reason) {
+		return fmt.Errorf("%w: unknown reason %q", ErrInvalidReport, report.Reason)
+	}
+	report.Comment = strings.TrimSpace(report.Comment)
+	if utf8.RuneCountInString(report.Comment) > maxCommentLen {
+		return fmt.Errorf("%w: comment must be at most %d characters", ErrInvalidReport, maxCommentLen)
+	}
+
+	switch {
+	case report.PostID != nil:
+		report.Target = models.ReportPost
+		report.AccountID = 0
+	case report.AccountID > 0:
+		if report.AccountID == report.ReporterID {
+			return fmt.Errorf("%w: can't report yourself", ErrInvalidReport)
+		}
+		report.Target = models.ReportAccount
+	default:
+		return fmt.Errorf("%w: post_id or account_id is required", ErrInvalidReport)
+	}
+	return nil
+}
+
+func (m *ModerationService) Get(ctx context.Context, id int) (models.Report, error) {
+	if err := auth.Authorize(ctx, auth.ReportsRead, nil); err != nil {
+		return models.Report{}, err
+	}
+	return m.repo.GetByID(ctx, id)
+}
+
+// List returns a page of the moderation queue, oldest first.
+func (m *ModerationService) List(ctx context.Context, filter models.ReportFilter) ([]models.Report, error) {
+	if err := auth.Authorize(ctx, auth.ReportsRead, nil); err != nil {
+		return nil, err
+	}
+	if filter.Status != "" && filter.Status != models.ReportOpen && filter.Status != models.ReportResolved {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, filter.Status)
+	}
+	if filter.Reason != "" && !slices.Contains(models.ReportReasons, filter.Reason) {
+		return nil, fmt.Errorf("%w: unknown reason %q", ErrInvalidFilter, filter.Reason)
+	}
+	if filter.Target != "" && filter.Target != models.ReportPost && filter.Target != models.ReportAccount {
+		return nil, fmt.Errorf("%w: unknown target %q", ErrInvalidFilter, filter.Target)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	filter.Limit = min(filter.Limit, maxListLimit)
+
+	return m.repo.List(ctx, filter)
+}
+
+// Assign hands an open report to moderatorID, or returns it to the queue
+// when moderatorID is nil.
+func (m *ModerationService) Assign(ctx context.Context, id int, moderatorID *int) error {
+	if err := auth.Authorize(ctx, auth.ReportsAssign, nil); err != nil {
+		return err
+	}
+	if moderatorID != nil && *moderatorID <= 0 {
+		return fmt.Errorf("%w: moderator_id must be positive", ErrInvalidAction)
+	}
+
+	report, err := m.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return m.auditor.InTx(ctx, func(ctx context.Context) error {
+		if err := m.repo.Assign(ctx, id, moderatorID); err != nil {
+			return err
+		}
+		return m.auditor.Record(ctx, models.AuditEvent{
+			Action:     models.AuditReportAssign,
+			TargetType: models.AuditTargetReport,
+			TargetID:   strconv.Itoa(id),
+			Before:     assignment{AssigneeID: report.AssigneeID},
+			After:      assignment{AssigneeID: moderatorID},
+		})
+	})
+}
+
+type assignment struct {
+	AssigneeID *int `json:"assignee_id"`
+}
+
+// Act resolves a report with action on behalf of the user of ctx and tells
+// the reported user, unless the report was dismissed. The action stands even
+// if the notification can't be sent.
+func (m *ModerationService) Act(ctx context.Context, action models.ModerationAction) (models.Report, error) {
+	const op = "ModerationService.Act"
+
+	if err := auth.Authorize(ctx, auth.ReportsResolve, nil); err != nil {
+		return models.Report{}, err
+	}
+	action.ModeratorID, _ = auth.UserID(ctx)
+	if err := validateAction(&action, m.now()); err != nil {
+		return models.Report{}, err
+	}
+
+	report, err := m.repo.GetByID(ctx, action.ReportID)
+	if err != nil {
+		return models.Report{}, err
+	}
+	if report.Status != models.ReportOpen {
+		return models.Report{}, report_repo.ErrReportResolved
+	}
+	if action.Action == models.ActionRemovePost && report.Target != models.ReportPost {
+		return models.Report{}, fmt.Errorf("%w: only post reports can remove a post", ErrInvalidAction)
+	}
+	if err := m.authorizeAction(ctx, action.Action, report); err != nil {
+		return models.Report{}, err
+	}
+
+	err = m.auditor.InTx(ctx, func(ctx context.Context) error {
+		resolved, err := m.repo.Resolve(ctx, action)
+		if err != nil {
+			return err
+		}
+		action = resolved
+		return m.auditor.Record(ctx, models.AuditEvent{
+			Action:     models.AuditReportResolve,
+			TargetType: models.AuditTargetReport,
+			TargetID:   strconv.Itoa(report.ID),
+			Before:     resolution{Status: report.Status, AccountID: report.AccountID, PostID: report.PostID},
+			After: resolution{
+				Status:    models.ReportResolved,
+				AccountID: report.AccountID,
+				PostID:    report.PostID,
+				Action:    &action,
+			},
+		})
+	})
+	if err != nil {
+		return models.Report{}, err
+	}
+	metrics.ModerationActions.WithLabelValues(string(action.Action)).Inc()
+
+	m.train(ctx, report, action.Action)
+
+	if action.Action != models.ActionDismiss {
+		payload := moderationNotice{
+			ReportID:  report.ID,
+			Action:    action.Action,
+			Reason:    report.Reason,
+			Rationale: action.Rationale,
+			PostID:    report.PostID,
+			ExpiresAt: action.ExpiresAt,
+		}
+		if err := m.notifier.Notify(ctx, []int{report.AccountID}, models.NotificationModerationAction, payload); err != nil {
+			sl.FromContext(ctx, m.log).ErrorContext(ctx, "can't notify reported user",
+				sl.Op(op), slog.Int("report_id", report.ID), sl.Err(err))
+		}
+	}
+
+	return m.repo.GetByID(ctx, action.ReportID)
+}
+
+// train feeds the outcome of a spam report to the spam trainer. Other
+// reasons and actions say nothing about whether a post is spam. A post
+// that can't be learned from is logged and the action stands.
+func (m *ModerationService) train(ctx context.Context, report models.Report, action models.ModerationActionKind) {
+	const op = "ModerationService.train"
+
+	if m.trainer == nil || report.Reason != models.ReasonSpam || report.PostID == nil {
+		return
+	}
+	if action != models.ActionDismiss && action != models.ActionRemovePost {
+		return
+	}
+	if err := m.trainer.Learn(ctx, *report.PostID, action == models.ActionRemovePost); err != nil {
+		sl.FromContext(ctx, m.log).ErrorContext(ctx, "can't train spam filter",
+			sl.Op(op), slog.Int("report_id", report.ID), sl.Err(err))
+	}
+}
+
+// moderationNotice is the payload of the notification sent to a reported
+// user. It leaves out who reported them and who moderated.
+type moderationNotice struct {
+	ReportID  int                         `json:"report_id"`
+	Action    models.ModerationActionKind `json:"action"`
+	Reason    models.ReportReason         `json:"reason"`
+	Rationale string                      `json:"rationale"`
+	PostID    *int                        `json:"post_id,omitempty"`
+	ExpiresAt *time.Time                  `json:"expires_at,omitempty"`
+}
+
+// resolution is the state of a report around a moderation action.
+type resolution struct {
+	Status    models.ReportStatus      `json:"status"`
+	AccountID int                      `json:"account_id"`
+	PostID    *int                     `json:"post_id,omitempty"`
+	Action    *models.ModerationAction `json:"action,omitempty"`
+}
+
+// authorizeAction checks the permissions an action needs beyond resolving
+// reports. Only users the moderator outranks can be suspended.
+func (m *ModerationService) authorizeAction(ctx context.Context, action models.ModerationActionKind, report models.Report) error {
+	switch action {
+	case models.ActionRemovePost:
+		return auth.Authorize(ctx, auth.PostsDelete, auth.Owner(report.AccountID))
+	case models.ActionSuspend:
+		if err := auth.Authorize(ctx, auth.UsersSuspend, auth.Owner(report.AccountID)); err != nil {
+			return err
+		}
+		target, err := m.roles.Role(ctx, report.AccountID)
+		if err != nil {
+			return err
+		}
+		return auth.AuthorizeOver(ctx, target)
+	}
+	return nil
+}
+
+func validateAction(action *models.ModerationAction, now time.Time) error {
+	switch action.Action {
+	case models.ActionDismiss, models.ActionRemovePost, models.ActionWarn, models.ActionSuspend:
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidAction, action.Action)
+	}
+	if action.ModeratorID <= 0 {
+		return fmt.Errorf("%w: moderator_id is required", ErrInvalidAction)
+	}
+	action.Rationale = strings.TrimSpace(action.Rationale)
+	if action.Rationale == "" || utf8.RuneCountInString(action.Rationale) > maxRationaleLen {
+		return fmt.Errorf("%w: rationale must be 1 to %d characters", ErrInvalidAction, maxRationaleLen)
+	}
+	// The rationale doubles as the reason of the suspension.
+	switch {
+	case action.Action == models.ActionSuspend && (action.ExpiresAt == nil || !action.ExpiresAt.After(now)):
+		return fmt.Errorf("%w: a suspension needs an expires_at in the future", ErrInvalidAction)
+	case action.Action != models.ActionSuspend && action.ExpiresAt != nil:
+		return fmt.Errorf("%w: only a suspension can have expires_at", ErrInvalidAction)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/audit/audittest"
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	report_repo "github.com/AtIasShrugged/antisocial/internal/repository/report"
+	repoMock "github.com/AtIasShrugged/antisocial/internal/repository/report/mocks"
+	"github.com/AtIasShrugged/antisocial/internal/service/notification/notificationtest"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func intPtr(i int) *int { return &i }
+
+// roles holds the role of every user who isn't a plain user.
+type roles map[int]models.Role
+
+func (r roles) Role(_ context.Context, userID int) (models.Role, error) {
+	if role, ok := r[userID]; ok {
+		return role, nil
+	}
+	return models.RoleUser, nil
+}
+
+func as(id int, role models.Role) context.Context {
+	return auth.WithPrincipal(context.Background(), auth.Principal{UserID: id, Role: role})
+}
+
+type recordingTrainer struct {
+	learned map[int]bool
+	err     error
+}
+
+func (r *recordingTrainer) Learn(_ context.Context, postID int, spam bool) error {
+	if r.learned == nil {
+		r.learned = make(map[int]bool)
+	}
+	r.learned[postID] = spam
+	return r.err
+}
+
+func newService(t *testing.T, opts ...Option) (*ModerationService, *repoMock.MockReportRepository, *notificationtest.Recorder, *audittest.Recorder) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	repo := repoMock.NewMockReportRepository(ctrl)
+	notifier := &notificationtest.Recorder{}
+	auditor := &audittest.Recorder{}
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	return New(repo, notifier, auditor, roles{1: models.RoleAdmin, 4: models.RoleModerator}, log, opts...), repo, notifier, auditor
+}
+
+func postReport(status models.ReportStatus) models.Report {
+	return models.Report{
+		ID:         1,
+		ReporterID: 5,
+		Target:     models.ReportPost,
+		PostID:     intPtr(10),
+		AccountID:  7,
+		Reason:     models.ReasonSpam,
+		Status:     status,
+	}
+}
+
+func TestReportPicksTarget(t *testing.T) {
+	service, repo, _, _ := newService(t)
+	ctx := as(5, models.RoleUser)
+
+	repo.EXPECT().Create(ctx, models.Report{
+		ReporterID: 5,
+		Target:     models.ReportPost,
+		PostID:     intPtr(10),
+		Reason:     models.ReasonSpam,
+		Comment:    "buy now",
+	}).Return(1, nil).Times(1)
+	repo.EXPECT().GetByID(ctx, 1).Return(postReport(models.ReportOpen), nil).Times(1)
+
+	// A post report is against its author, whatever account the body names.
+	report, err := service.Report(ctx, models.Report{
+		ReporterID: 5, PostID: intPtr(10), AccountID: 99, Reason: models.ReasonSpam, Comment: "  buy now ",
+	})
+	require.NoError(t, err)
+	require.Equal(t, 7, report.AccountID)
+
+	repo.EXPECT().Create(ctx, models.Report{
+		ReporterID: 5,
+		Target:     models.ReportAccount,
+		AccountID:  8,
+		Reason:     models.ReasonImpersonation,
+	}).Return(2, nil).Times(1)
+	repo.EXPECT().GetByID(ctx, 2).Return(models.Report{ID: 2}, nil).Times(1)
+
+	_, err = service.Report(ctx, models.Report{ReporterID: 5, AccountID: 8, Reason: models.ReasonImpersonation})
+	require.NoError(t, err)
+}
+
+func TestReportRejectsInvalid(t *testing.T) {
+	service, _, _, _ := newService(t)
+
+	for name, report := range map[string]models.Report{
+		"unknown reason": {ReporterID: 5, PostID: intPtr(10), Reason: "rude"},
+		"no target":      {ReporterID: 5, Reason: models.ReasonSpam},
+		"self":           {ReporterID: 5, AccountID: 5, Reason: models.ReasonSpam},
+	} {
+		_, err := service.Report(as(5, models.RoleUser), report)
+		require.ErrorIs(t, err, ErrInvalidReport, name)
+	}
+
+	_, err := service.Report(as(6, models.RoleUser), models.Report{ReporterID: 5, PostID: intPtr(10), Reason: models.ReasonSpam})
+	require.ErrorIs(t, err, auth.ErrForbidden)
+}
+
+func TestListClampsLimit(t *testing.T) {
+	service, repo, _, _ := newService(t)
+	ctx := as(3, models.RoleModerator)
+
+	repo.EXPECT().List(ctx, models.ReportFilter{Status: models.ReportOpen, Limit: defaultListLimit}).Return(nil, nil).Times(1)
+	_, err := service.List(ctx, models.ReportFilter{Status: models.ReportOpen})
+	require.NoError(t, err)
+
+	repo.EXPECT().List(ctx, models.ReportFilter{Limit: maxListLimit}).Return(nil, nil).Times(1)
+	_, err = service.List(ctx, models.ReportFilter{Limit: 10_000})
+	require.NoError(t, err)
+
+	_, err = service.List(ctx, models.ReportFilter{Status: "closed"})
+	require.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+func TestActNotifiesReportedUser(t *testing.T) {
+	service, repo, notifier, auditor := newService(t)
+	ctx := as(3, models.RoleModerator)
+
+	resolved := postReport(models.ReportResolved)
+	gomock.InOrder(
+		repo.EXPECT().GetByID(ctx, 1).Return(postReport(models.ReportOpen), nil),
+		repo.EXPECT().Resolve(ctx, models.ModerationAction{
+			ReportID: 1, ModeratorID: 3, Action: models.ActionRemovePost, Rationale: "link farm",
+		}).Return(models.ModerationAction{ID: 4, ReportID: 1, ModeratorID: 3, Action: models.ActionRemovePost, Rationale: "link farm"}, nil),
+		repo.EXPECT().GetByID(ctx, 1).Return(resolved, nil),
+	)
+
+	report, err := service.Act(ctx, models.ModerationAction{
+		ReportID: 1, ModeratorID: 3, Action: models.ActionRemovePost, Rationale: " link farm\n",
+	})
+	require.NoError(t, err)
+	require.Equal(t, resolved, report)
+
+	require.Equal(t, []int{7}, notifier.UserIDs)
+	require.Equal(t, models.NotificationModerationAction, notifier.Kind)
+	payload, err := json.Marshal(notifier.Payload)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"report_id":1,"action":"remove_post","reason":"spam","rationale":"link farm","post_id":10}`, string(payload))
+
+	require.Len(t, auditor.Events, 1)
+	event := auditor.Events[0]
+	require.Equal(t, models.AuditReportResolve, event.Action)
+	require.Equal(t, models.AuditTargetReport, event.TargetType)
+	require.Equal(t, "1", event.TargetID)
+	after, err := json.Marshal(event.After)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"status":"resolved","account_id":7,"post_id":10,"action":{
+		"id":4,"report_id":1,"moderator_id":3,"action":"remove_post","rationale":"link farm","created_at":"0001-01-01T00:00:00Z"
+	}}`, string(after))
+}
+
+func TestAssignIsAudited(t *testing.T) {
+	service, repo, _, auditor := newService(t)
+	ctx := as(3, models.RoleModerator)
+
+	gomock.InOrder(
+		repo.EXPECT().GetByID(ctx, 1).Return(postReport(models.ReportOpen), nil),
+		repo.EXPECT().Assign(ctx, 1, intPtr(3)).Return(nil),
+	)
+	require.NoError(t, service.Assign(ctx, 1, intPtr(3)))
+
+	require.Len(t, auditor.Events, 1)
+	before, err := json.Marshal(auditor.Events[0].Before)
+	require.NoError(t, err)
+	after, err := json.Marshal(auditor.Events[0].After)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"assignee_id":null}`, string(before))
+	require.JSONEq(t, `{"assignee_id":3}`, string(after))
+
+	repo.EXPECT().GetByID(ctx, 2).Return(models.Report{}, report_repo.ErrReportNotFound)
+	require.ErrorIs(t, service.Assign(ctx, 2, nil), report_repo.ErrReportNotFound)
+	require.Len(t, auditor.Events, 1)
+}
+
+func TestActDismissDoesNotNotify(t *testing.T) {
+	service, repo, notifier, _ := newService(t)
+	ctx := as(3, models.RoleModerator)
+
+	action := models.ModerationAction{ReportID: 1, ModeratorID: 3, Action: models.ActionDismiss, Rationale: "not spam"}
+	repo.EXPECT().GetByID(ctx, 1).Return(postReport(models.ReportOpen), nil).Times(2)
+	repo.EXPECT().Resolve(ctx, action).Return(action, nil).Times(1)
+
+	_, err := service.Act(ctx, action)
+	require.NoError(t, err)
+	require.Nil(t, notifier.UserIDs)
+}
+
+func TestActTrainsSpamFilter(t *testing.T) {
+	trainer := &recordingTrainer{err: errors.New("db is down")}
+	service, repo, _, _ := newService(t, WithSpamTrainer(trainer))
+	ctx := as(3, models.RoleModerator)
+
+	harassment := postReport(models.ReportOpen)
+	harassment.ID, harassment.PostID, harassment.Reason = 3, intPtr(12), models.ReasonHarassment
+	spamReport := func(id, postID int) models.Report {
+		r := postReport(models.ReportOpen)
+		r.ID, r.PostID = id, intPtr(postID)
+		return r
+	}
+
+	for _, tc := range []struct {
+		report models.Report
+		action models.ModerationActionKind
+	}{
+		{spamReport(1, 10), models.ActionRemovePost},
+		{spamReport(2, 11), models.ActionDismiss},
+		{harassment, models.ActionRemovePost},
+		{spamReport(4, 13), models.ActionWarn},
+	} {
+		action := models.ModerationAction{ReportID: tc.report.ID, ModeratorID: 3, Action: tc.action, Rationale: "x"}
+		repo.EXPECT().GetByID(ctx, tc.report.ID).Return(tc.report, nil).Times(2)
+		repo.EXPECT().Resolve(ctx, action).Return(action, nil)
+
+		// A trainer that fails must not undo the action.
+		_, err := service.Act(ctx, action)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, map[int]bool{10: true, 11: false}, trainer.learned)
+}
+
+func TestActKeepsActionWhenNotifyFails(t *testing.T) {
+	service, repo, notifier, _ := newService(t)
+	ctx := as(3, models.RoleModerator)
+	notifier.Err = errors.New("db is down")
+
+	action := models.ModerationAction{ReportID: 1, ModeratorID: 3, Action: models.ActionWarn, Rationale: "be nice"}
+	repo.EXPECT().GetByID(ctx, 1).Return(postReport(models.ReportOpen), nil).Times(2)
+	repo.EXPECT().Resolve(ctx, action).Return(action, nil).Times(1)
+
+	_, err := service.Act(ctx, action)
+	require.NoError(t, err)
+}
+
+// An action without its audit event is rolled back along with it, so the
+// reported user hears nothing of it.
+func TestActFailsWhenAuditFails(t *testing.T) {
+	service, repo, notifier, auditor := newService(t)
+	ctx := as(3, models.RoleModerator)
+	auditor.Err = errors.New("db is down")
+
+	action := models.ModerationAction{ReportID: 1, ModeratorID: 3, Action: models.ActionWarn, Rationale: "be nice"}
+	repo.EXPECT().GetByID(ctx, 1).Return(postReport(models.ReportOpen), nil).Times(1)
+	repo.EXPECT().Resolve(ctx, action).Return(action, nil).Times(1)
+
+	_, err := service.Act(ctx, action)
+	require.ErrorIs(t, err, auditor.Err)
+	require.Nil(t, notifier.UserIDs)
+	require.Empty(t, auditor.Events)
+}
+
+func TestActRejects(t *testing.T) {
+	service, repo, _, _ := newService(t)
+	ctx := as(3, models.RoleModerator)
+
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	for name, action := range map[string]models.ModerationAction{
+		"unknown action":         {ReportID: 1, ModeratorID: 3, Action: "ban", Rationale: "x"},
+		"no rationale":           {ReportID: 1, ModeratorID: 3, Action: models.ActionWarn, Rationale: "  "},
+		"endless suspension":     {ReportID: 1, ModeratorID: 3, Action: models.ActionSuspend, Rationale: "x"},
+		"suspension in the past": {ReportID: 1, ModeratorID: 3, Action: models.ActionSuspend, Rationale: "x", ExpiresAt: &past},
+		"expiring warning":       {ReportID: 1, ModeratorID: 3, Action: models.ActionWarn, Rationale: "x", ExpiresAt: &future},
+	} {
+		_, err := service.Act(ctx, action)
+		require.ErrorIs(t, err, ErrInvalidAction, name)
+	}
+
+	_, err := service.Act(as(3, models.RoleUser), models.ModerationAction{ReportID: 1, Action: models.ActionWarn, Rationale: "x"})
+	require.ErrorIs(t, err, auth.ErrForbidden)
+
+	account := postReport(models.ReportOpen)
+	account.Target, account.PostID = models.ReportAccount, nil
+	repo.EXPECT().GetByID(ctx, 1).Return(account, nil).Times(1)
+	_, err = service.Act(ctx, models.ModerationAction{ReportID: 1, ModeratorID: 3, Action: models.ActionRemovePost, Rationale: "x"})
+	require.ErrorIs(t, err, ErrInvalidAction)
+
+	repo.EXPECT().GetByID(ctx, 1).Return(postReport(models.ReportResolved), nil).Times(1)
+	_, err = service.Act(ctx, models.ModerationAction{ReportID: 1, ModeratorID: 3, Action: models.ActionWarn, Rationale: "x"})
+	require.ErrorIs(t, err, report_repo.ErrReportResolved)
+
+	// Moderators can't suspend the moderator or the configured admin that
+	// were reported.
+	for _, accountID := range []int{4, 1} {
+		reported := postReport(models.ReportOpen)
+		reported.AccountID = accountID
+		repo.EXPECT().GetByID(ctx, 1).Return(reported, nil).Times(1)
+		_, err = service.Act(ctx, models.ModerationAction{
+			ReportID: 1, ModeratorID: 3, Action: models.ActionSuspend, Rationale: "x", ExpiresAt: &future,
+		})
+		require.ErrorIs(t, err, auth.ErrForbidden, accountID)
+	}
+}
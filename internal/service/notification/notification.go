@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	notification_repo "github.com/AtIasShrugged/antisocial/internal/repository/notification"
+)
+
+const listLimit = 50
+
+type NotificationService struct {
+	repo notification_repo.NotificationRepository
+	log  *slog.Logger
+}
+
+func New(repo notification_repo.NotificationRepository, log *slog.Logger) *NotificationService {
+	return &NotificationService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// Notify sends the same notification to every user in userIDs, once each.
+func (n *NotificationService) Notify(ctx context.Context, userIDs []int, kind models.NotificationKind, payload any) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("can't encode notification payload: %w", err)
+	}
+
+	seen := make(map[int]bool, len(userIDs))
+	notifications := make([]models.Notification, 0, len(userIDs))
+	for _, id := range userIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		notifications = append(notifications, models.Notification{
+			UserID:  id,
+			Kind:    kind,
+			Payload: raw,
+		})
+	}
+
+	return n.repo.CreateMany(ctx, notifications)
+}
+
+func (n *NotificationService) List(ctx context.Context, userID int) ([]models.Notification, error) {
+	if err := auth.Authorize(ctx, auth.NotificationsRead, auth.Owner(userID)); err != nil {
+		return nil, err
+	}
+	return n.repo.ListByUser(ctx, userID, listLimit)
+}
@@ -0,0 +1,22 @@
+// Package notificationtest provides a notifier for tests of code that
+// notifies users.
+package notificationtest
+
+import (
+	"context"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+)
+
+// Recorder keeps the last notification it is given and fails with Err.
+type Recorder struct {
+	UserIDs []int
+	Kind    models.NotificationKind
+	Payload any
+	Err     error
+}
+
+func (r *Recorder) Notify(_ context.Context, userIDs []int, kind models.NotificationKind, payload any) error {
+	r.UserIDs, r.Kind, r.Payload = userIDs, kind, payload
+	return r.Err
+}
@@ -0,0 +1,8 @@
+package poll
+
+import "errors"
+
+var (
+	ErrPollClosed    = errors.New("poll is closed")
+	ErrInvalidChoice = errors.New("invalid poll choice")
+)
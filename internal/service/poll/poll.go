@@ -0,0 +1,161 @@
+package poll
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	poll_repo "github.com/AtIasShrugged/antisocial/internal/repository/poll"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+)
+
+const closeBatchSize = 50
+
+type Notifier interface {
+	Notify(ctx context.Context, userIDs []int, kind models.NotificationKind, payload any) error
+}
+
+type PollService struct {
+	repo     poll_repo.PollRepository
+	notifier Notifier
+	log      *slog.Logger
+	now      func() time.Time
+}
+
+func New(repo poll_repo.PollRepository, notifier Notifier, log *slog.Logger) *PollService {
+	return &PollService{
+		repo:     repo,
+		notifier: notifier,
+		log:      log,
+		now:      time.Now,
+	}
+}
+
+// Get returns the poll as seen by the user of ctx, or by nobody for
+// anonymous requests: tallies are only revealed once the viewer has voted or
+// the poll has closed.
+func (p *PollService) Get(ctx context.Context, id int) (models.Poll, error) {
+	viewerID, _ := auth.UserID(ctx)
+	return p.get(ctx, id, viewerID)
+}
+
+func (p *PollService) get(ctx context.Context, id int, viewerID int) (models.Poll, error) {
+	poll, err := p.repo.GetByID(ctx, id, viewerID)
+	if err != nil {
+		return models.Poll{}, err
+	}
+	return hideResults(poll), nil
+}
+
+// Vote casts a single ballot for vote.UserID and returns the updated results.
+func (p *PollService) Vote(ctx context.Context, id int, vote models.PollVote) (models.Poll, error) {
+	if err := auth.Authorize(ctx, auth.PollsVote, auth.Owner(vote.UserID)); err != nil {
+		return models.Poll{}, err
+	}
+
+	poll, err := p.repo.GetByID(ctx, id, vote.UserID)
+	if err != nil {
+		return models.Poll{}, err
+	}
+	if poll.Closed || !p.now().Before(poll.ExpiresAt) {
+		return models.Poll{}, ErrPollClosed
+	}
+	if poll.Voted {
+		return models.Poll{}, poll_repo.ErrAlreadyVoted
+	}
+	if err := validateChoice(poll, vote.OptionIDs); err != nil {
+		return models.Poll{}, err
+	}
+
+	if err := p.repo.Vote(ctx, id, vote.UserID, vote.OptionIDs); err != nil {
+		return models.Poll{}, err
+	}
+	metrics.PollVotes.Inc()
+
+	return p.get(ctx, id, vote.UserID)
+}
+
+func validateChoice(poll models.Poll, optionIDs []int) error {
+	if len(optionIDs) == 0 || (!poll.Multiple && len(optionIDs) > 1) {
+		return ErrInvalidChoice
+	}
+
+	valid := make(map[int]bool, len(poll.Options))
+	for _, o := range poll.Options {
+		valid[o.ID] = true
+	}
+	seen := make(map[int]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		if !valid[id] || seen[id] {
+			return ErrInvalidChoice
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+func hideResults(poll models.Poll) models.Poll {
+	if poll.Voted || poll.Closed {
+		return poll
+	}
+	poll.TotalVotes = nil
+	options := make([]models.PollOption, len(poll.Options))
+	for i, o := range poll.Options {
+		options[i] = models.PollOption{ID: o.ID, Text: o.Text}
+	}
+	poll.Options = options
+	return poll
+}
+
+// NotifyClosed tells the author and every voter of each newly expired poll
+// that results are in. It returns the number of polls handled.
+func (p *PollService) NotifyClosed(ctx context.Context) (int, error) {
+	const op = "PollService.NotifyClosed"
+
+	closed, err := p.repo.ClaimClosed(ctx, p.now(), closeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, c := range closed {
+		recipients := append([]int{c.AuthorID}, c.VoterIDs...)
+		payload := map[string]int{"poll_id": c.ID, "post_id": c.PostID}
+		if err := p.notifier.Notify(ctx, recipients, models.NotificationPollClosed, payload); err != nil {
+			p.log.Error(op+": "+err.Error(), sl.Op(op), slog.Int("poll_id", c.ID), sl.Err(err))
+			if err := p.repo.ReleaseClosed(ctx, c.ID); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return len(closed), nil
+}
+
+// RunCloser calls NotifyClosed every interval until ctx is cancelled.
+func (p *PollService) RunCloser(ctx context.Context, interval time.Duration) {
+	const op = "PollService.RunCloser"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				n, err := p.NotifyClosed(ctx)
+				if err != nil {
+					p.log.Error(op+": "+err.Error(), sl.Op(op), sl.Err(err))
+					break
+				}
+				if n < closeBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package poll
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	poll_repo "github.com/AtIasShrugged/antisocial/internal/repository/poll"
+	repoMock "github.com/AtIasShrugged/antisocial/internal/repository/poll/mocks"
+	"github.com/AtIasShrugged/antisocial/internal/service/notification/notificationtest"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// asUser returns a context authenticated as a plain user.
+func asUser(id int) context.Context {
+	return auth.WithPrincipal(context.Background(), auth.Principal{UserID: id, Role: models.RoleUser})
+}
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func intPtr(i int) *int { return &i }
+
+func openPoll(voted bool) models.Poll {
+	return models.Poll{
+		ID:         1,
+		PostID:     10,
+		ExpiresAt:  now.Add(time.Hour),
+		Voted:      voted,
+		TotalVotes: intPtr(3),
+		Options: []models.PollOption{
+			{ID: 11, Text: "yes", Votes: intPtr(2)},
+			{ID: 12, Text: "no", Votes: intPtr(1)},
+		},
+	}
+}
+
+func newService(t *testing.T) (*PollService, *repoMock.MockPollRepository, *notificationtest.Recorder) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	repo := repoMock.NewMockPollRepository(ctrl)
+	notifier := &notificationtest.Recorder{}
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	service := New(repo, notifier, log)
+	service.now = func() time.Time { return now }
+	return service, repo, notifier
+}
+
+func TestGetHidesResultsUntilVoted(t *testing.T) {
+	service, repo, _ := newService(t)
+	ctx := asUser(5)
+
+	repo.EXPECT().GetByID(ctx, 1, 5).Return(openPoll(false), nil).Times(1)
+	p, err := service.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Nil(t, p.TotalVotes)
+	for _, o := range p.Options {
+		require.Nil(t, o.Votes)
+	}
+
+	ctx = asUser(6)
+	repo.EXPECT().GetByID(ctx, 1, 6).Return(openPoll(true), nil).Times(1)
+	p, err = service.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, 3, *p.TotalVotes)
+	require.Equal(t, 2, *p.Options[0].Votes)
+}
+
+func TestGetShowsResultsWhenClosed(t *testing.T) {
+	service, repo, _ := newService(t)
+	ctx := context.Background()
+
+	closed := openPoll(false)
+	closed.Closed = true
+	repo.EXPECT().GetByID(ctx, 1, 0).Return(closed, nil).Times(1)
+
+	p, err := service.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, 3, *p.TotalVotes)
+}
+
+func TestVote(t *testing.T) {
+	service, repo, _ := newService(t)
+	ctx := asUser(5)
+
+	gomock.InOrder(
+		repo.EXPECT().GetByID(ctx, 1, 5).Return(openPoll(false), nil),
+		repo.EXPECT().Vote(ctx, 1, 5, []int{12}).Return(nil),
+		repo.EXPECT().GetByID(ctx, 1, 5).Return(openPoll(true), nil),
+	)
+
+	p, err := service.Vote(ctx, 1, models.PollVote{UserID: 5, OptionIDs: []int{12}})
+	require.NoError(t, err)
+	require.True(t, p.Voted)
+	require.NotNil(t, p.TotalVotes)
+}
+
+func TestVoteRejections(t *testing.T) {
+	ctx := asUser(5)
+
+	cases := []struct {
+		name    string
+		poll    func() models.Poll
+		options []int
+		err     error
+	}{
+		{"already voted", func() models.Poll { return openPoll(true) }, []int{11}, poll_repo.ErrAlreadyVoted},
+		{"expired", func() models.Poll { p := openPoll(false); p.ExpiresAt = now; return p }, []int{11}, ErrPollClosed},
+		{"single choice with two options", func() models.Poll { return openPoll(false) }, []int{11, 12}, ErrInvalidChoice},
+		{"unknown option", func() models.Poll { return openPoll(false) }, []int{99}, ErrInvalidChoice},
+		{"empty ballot", func() models.Poll { return openPoll(false) }, nil, ErrInvalidChoice},
+		{"duplicate option", func() models.Poll { p := openPoll(false); p.Multiple = true; return p }, []int{11, 11}, ErrInvalidChoice},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, repo, _ := newService(t)
+			repo.EXPECT().GetByID(ctx, 1, 5).Return(tc.poll(), nil).Times(1)
+
+			_, err := service.Vote(ctx, 1, models.PollVote{UserID: 5, OptionIDs: tc.options})
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestNotifyClosed(t *testing.T) {
+	service, repo, notifier := newService(t)
+	ctx := asUser(5)
+
+	repo.EXPECT().ClaimClosed(ctx, now, closeBatchSize).Return([]poll_repo.ClosedPoll{
+		{ID: 1, PostID: 10, AuthorID: 7, VoterIDs: []int{5, 6}},
+	}, nil).Times(1)
+
+	n, err := service.NotifyClosed(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []int{7, 5, 6}, notifier.UserIDs)
+	require.Equal(t, models.NotificationPollClosed, notifier.Kind)
+}
+
+func TestNotifyClosedReleasesOnFailure(t *testing.T) {
+	service, repo, notifier := newService(t)
+	ctx := asUser(5)
+	notifier.Err = errors.New("notifications are down")
+
+	repo.EXPECT().ClaimClosed(ctx, now, closeBatchSize).Return([]poll_repo.ClosedPoll{
+		{ID: 1, PostID: 10, AuthorID: 7},
+	}, nil).Times(1)
+	repo.EXPECT().ReleaseClosed(ctx, 1).Return(nil).Times(1)
+
+	_, err := service.NotifyClosed(ctx)
+	require.NoError(t, err)
+}
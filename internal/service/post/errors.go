@@ -0,0 +1,11 @@
+package post
+
+import "errors"
+
+var (
+	ErrTooManyAttachments = errors.New("too many attachments")
+	ErrInvalidPoll        = errors.New("invalid poll")
+	ErrInvalidSchedule    = errors.New("invalid schedule")
+	ErrInvalidTTL         = errors.New("invalid time-to-live")
+	ErrBlocked            = errors.New("post contains blocked content")
+)
@@ -0,0 +1,72 @@
+package post
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/internal/tracing"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+)
+
+const reapBatchSize = 100
+
+// ReapExpired hard-deletes ephemeral posts past their expiry along with their
+// attachments. It returns the number of posts deleted.
+//
+// Expired posts are hidden from readers as soon as they expire; reaping only
+// reclaims storage, so it may lag behind without anyone noticing.
+func (p *PostService) ReapExpired(ctx context.Context) (int, error) {
+	const op = "PostService.ReapExpired"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	expired, err := p.repo.DeleteExpired(ctx, p.now(), reapBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	metrics.PostsReaped.Add(float64(len(expired)))
+
+	if p.attachments != nil {
+		for _, post := range expired {
+			if len(post.AttachmentIDs) == 0 {
+				continue
+			}
+			// Attachments left behind here become orphans and are
+			// collected later, so a failure is logged but not retried.
+			if err := p.attachments.Delete(ctx, post.AttachmentIDs); err != nil {
+				p.log.Error(op+": can't delete attachments", sl.Op(op), slog.Int("post_id", post.ID), sl.Err(err))
+			}
+		}
+	}
+
+	return len(expired), nil
+}
+
+// RunReaper calls ReapExpired every interval until ctx is cancelled.
+func (p *PostService) RunReaper(ctx context.Context, interval time.Duration) {
+	const op = "PostService.RunReaper"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				n, err := p.ReapExpired(ctx)
+				if err != nil {
+					p.log.Error(op+": "+err.Error(), sl.Op(op), sl.Err(err))
+					break
+				}
+				if n < reapBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package post
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	post_repo "github.com/AtIasShrugged/antisocial/internal/repository/post"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingRemover struct {
+	ids []int
+	err error
+}
+
+func (r *recordingRemover) Delete(_ context.Context, ids []int) error {
+	r.ids = append(r.ids, ids...)
+	return r.err
+}
+
+func TestCreateEphemeral(t *testing.T) {
+	service, repo, _ := newService(t)
+	ctx := asUser(1)
+
+	// A client-supplied expiry is ignored: it is derived from the TTL on publication.
+	expiresAt := testNow.Add(time.Minute)
+	repo.EXPECT().Create(ctx, models.Post{AuthorID: 1, Body: "story", TTLSeconds: 86400}).Return(2, nil).Times(1)
+
+	id, err := service.Create(ctx, models.Post{AuthorID: 1, Body: "story", TTLSeconds: 86400, ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+	require.Equal(t, 2, id)
+}
+
+func TestCreateInvalidTTL(t *testing.T) {
+	service, _, _ := newService(t)
+	ctx := asUser(1)
+
+	for _, ttl := range []int{-1, 30, int(MaxTTL/time.Second) + 1} {
+		_, err := service.Create(ctx, models.Post{AuthorID: 1, Body: "x", TTLSeconds: ttl})
+		require.ErrorIs(t, err, ErrInvalidTTL, ttl)
+	}
+}
+
+func TestReapExpired(t *testing.T) {
+	service, repo, _ := newService(t)
+	ctx := asUser(1)
+
+	remover := &recordingRemover{err: errors.New("storage is down")}
+	service.attachments = remover
+
+	repo.EXPECT().DeleteExpired(ctx, testNow, reapBatchSize).Return([]post_repo.ExpiredPost{
+		{ID: 1, AttachmentIDs: []int{10, 11}},
+		{ID: 2},
+		{ID: 3, AttachmentIDs: []int{12}},
+	}, nil).Times(1)
+
+	// Attachments that can't be deleted are left to orphan collection.
+	n, err := service.ReapExpired(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []int{10, 11, 12}, remover.ids)
+}
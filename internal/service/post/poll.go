@@ -0,0 +1,58 @@
+package post
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+)
+
+const (
+	minPollOptions   = 2
+	maxPollOptions   = 4
+	maxPollOptionLen = 100
+	minPollDuration  = 5 * time.Minute
+	maxPollDuration  = 30 * 24 * time.Hour
+)
+
+// validatePoll checks poll and trims its options. The expiry is measured from
+// publishAt, when voting opens.
+func validatePoll(poll *models.Poll, publishAt time.Time) error {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return fmt.Errorf("%w: a poll needs %d to %d options", ErrInvalidPoll, minPollOptions, maxPollOptions)
+	}
+
+	seen := make(map[string]bool, len(poll.Options))
+	for i := range poll.Options {
+		text := strings.TrimSpace(poll.Options[i].Text)
+		if text == "" || utf8.RuneCountInString(text) > maxPollOptionLen {
+			return fmt.Errorf("%w: options must be 1 to %d characters", ErrInvalidPoll, maxPollOptionLen)
+		}
+		if seen[text] {
+			return fmt.Errorf("%w: duplicate option %q", ErrInvalidPoll, text)
+		}
+		seen[text] = true
+		poll.Options[i].Text = text
+	}
+
+	d := poll.ExpiresAt.Sub(publishAt)
+	if d < minPollDuration || d > maxPollDuration {
+		return fmt.Errorf("%w: expiry must be between %s and %s from publication", ErrInvalidPoll, minPollDuration, maxPollDuration)
+	}
+
+	return nil
+}
+
+// checkPollOpen reports whether a poll created with a draft still leaves
+// time to vote when the post goes out at publishAt.
+func checkPollOpen(poll *models.Poll, publishAt time.Time) error {
+	if poll == nil {
+		return nil
+	}
+	if poll.ExpiresAt.Sub(publishAt) < minPollDuration {
+		return fmt.Errorf("%w: the poll would close less than %s after publication", ErrInvalidPoll, minPollDuration)
+	}
+	return nil
+}
@@ -2,42 +2,345 @@ package post
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/AtIasShrugged/antisocial/internal/auth"
 	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
 	post_repo "github.com/AtIasShrugged/antisocial/internal/repository/post"
+	"github.com/AtIasShrugged/antisocial/internal/tracing"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
 )
 
+// MaxAttachments is the number of media items a single post may carry.
+const MaxAttachments = 4
+
+// Bounds on the time-to-live of ephemeral posts.
+const (
+	MinTTL = time.Minute
+	MaxTTL = 30 * 24 * time.Hour
+)
+
+// LinkPreviewer schedules link previews for the URLs in a new post.
+type LinkPreviewer interface {
+	Enqueue(ctx context.Context, postID int, body string) error
+}
+
+// AttachmentRemover deletes the attachments of reaped posts.
+type AttachmentRemover interface {
+	Delete(ctx context.Context, ids []int) error
+}
+
+// Classifier scores new posts for spam.
+type Classifier interface {
+	Classify(ctx context.Context, post models.Post) (models.SpamClassification, error)
+}
+
+// Screener checks bodies against the blocklist.
+type Screener interface {
+	Screen(body string) models.Screening
+}
+
+// FollowGraph tells who follows whom.
+type FollowGraph interface {
+	Follows(ctx context.Context, followerID, followeeID int) (bool, error)
+}
+
 type PostService struct {
-	repo post_repo.PostRepository
-	log  *slog.Logger
+	repo        post_repo.PostRepository
+	log         *slog.Logger
+	previews    LinkPreviewer
+	attachments AttachmentRemover
+	classifier  Classifier
+	screener    Screener
+	follows     FollowGraph
+	now         func() time.Time
+}
+
+type Option func(*PostService)
+
+// WithLinkPreviews makes Create hand new posts to previewer.
+func WithLinkPreviews(previewer LinkPreviewer) Option {
+	return func(p *PostService) {
+		p.previews = previewer
+	}
+}
+
+// WithAttachmentRemover makes the reaper delete the attachments of expired
+// posts through remover. Without it they are left to orphan collection.
+func WithAttachmentRemover(remover AttachmentRemover) Option {
+	return func(p *PostService) {
+		p.attachments = remover
+	}
+}
+
+// WithClassifier runs new posts and edited drafts through classifier, which
+// may hold them for review or shadow-limit them.
+func WithClassifier(classifier Classifier) Option {
+	return func(p *PostService) {
+		p.classifier = classifier
+	}
 }
 
-func New(repo post_repo.PostRepository, log *slog.Logger) *PostService {
-	return &PostService{
+// WithScreener runs new posts and edited drafts past the blocklist of
+// screener before the spam filter sees them.
+func WithScreener(screener Screener) Option {
+	return func(p *PostService) {
+		p.screener = screener
+	}
+}
+
+// WithFollowGraph lets the followers of limited accounts see their posts.
+// Without it those posts are visible to their author only.
+func WithFollowGraph(follows FollowGraph) Option {
+	return func(p *PostService) {
+		p.follows = follows
+	}
+}
+
+func New(repo post_repo.PostRepository, log *slog.Logger, opts ...Option) *PostService {
+	p := &PostService{
 		log:  log,
 		repo: repo,
+		now:  time.Now,
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
 func (p *PostService) GetByID(ctx context.Context, id int) (models.Post, error) {
 	const op = "PostService.GetByID"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
 
 	post, err := p.repo.GetByID(ctx, id)
 	if err != nil {
-		p.log.Error(op+": "+err.Error(), err)
+		tracing.RecordError(span, err)
+		return models.Post{}, err
+	}
+	visible, err := p.visible(ctx, post)
+	if err != nil {
+		tracing.RecordError(span, err)
 		return models.Post{}, err
 	}
+	if !visible {
+		return models.Post{}, post_repo.ErrPostNotFound
+	}
 	return post, nil
 }
 
+// GetMany returns those of the posts ids the user of ctx may see, keyed by
+// ID. Posts that are missing or hidden from the user are left out, as
+// GetByID would have them not found.
+func (p *PostService) GetMany(ctx context.Context, ids []int) (map[int]models.Post, error) {
+	const op = "PostService.GetMany"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	posts, err := p.repo.GetMany(ctx, ids)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	for id, post := range posts {
+		visible, err := p.visible(ctx, post)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return nil, err
+		}
+		if !visible {
+			delete(posts, id)
+		}
+	}
+	return posts, nil
+}
+
+// visible reports whether the user of ctx may see post. Posts look like any
+// other to their author. Posts the spam filter didn't allow and posts of
+// suspended or deactivated accounts don't exist for anyone else, and posts
+// of limited accounts only exist for their followers.
+func (p *PostService) visible(ctx context.Context, post models.Post) (bool, error) {
+	viewerID, ok := auth.UserID(ctx)
+	if ok && viewerID == post.AuthorID {
+		return true, nil
+	}
+	if post.Spam != nil {
+		return false, nil
+	}
+
+	switch post.AuthorState {
+	case models.AccountSuspended, models.AccountDeactivated:
+		return false, nil
+	case models.AccountLimited:
+		if !ok || p.follows == nil {
+			return false, nil
+		}
+		return p.follows.Follows(ctx, viewerID, post.AuthorID)
+	}
+	return true, nil
+}
+
+// held reports whether post is kept from the public, by the spam filter or by
+// a blocklist hold. Nothing is fetched for held posts: link previews would
+// let their links be visited all the same.
+func held(post models.Post) bool {
+	return post.Hold != nil || (post.Spam != nil && post.Spam.Verdict != models.SpamAllow)
+}
+
 func (p *PostService) Create(ctx context.Context, post models.Post) (int, error) {
 	const op = "PostService.Create"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if err := auth.Authorize(ctx, auth.PostsCreate, auth.Owner(post.AuthorID)); err != nil {
+		return 0, err
+	}
+
+	if len(post.Attachments) > MaxAttachments {
+		return 0, ErrTooManyAttachments
+	}
+	publishAt, err := p.normalizeStatus(&post)
+	if err != nil {
+		return 0, err
+	}
+	if err := validateTTL(&post); err != nil {
+		return 0, err
+	}
+	if post.Poll != nil {
+		if err := validatePoll(post.Poll, publishAt); err != nil {
+			return 0, err
+		}
+	}
+	if post.Body, post.Hold, err = p.screen(ctx, post.Body); err != nil {
+		return 0, err
+	}
+	post.Spam = p.classify(ctx, post)
 
 	id, err := p.repo.Create(ctx, post)
 	if err != nil {
-		p.log.Error(op+": "+err.Error(), err)
+		tracing.RecordError(span, err)
 		return 0, err
 	}
+
+	if post.Status == "" || post.Status == models.PostPublished {
+		metrics.PostsCreated.WithLabelValues(string(models.PostPublished)).Inc()
+		if !held(post) {
+			p.enqueuePreviews(ctx, id, post.Body)
+		}
+	} else {
+		metrics.PostsCreated.WithLabelValues(string(post.Status)).Inc()
+	}
+
 	return id, nil
 }
+
+// normalizeStatus checks the publish time of scheduled posts; a post without
+// a status is published immediately. It returns the time the post is expected
+// to go out; for drafts that is now, as nothing better is known.
+func (p *PostService) normalizeStatus(post *models.Post) (time.Time, error) {
+	now := p.now()
+
+	switch post.Status {
+	case "", models.PostPublished:
+		post.PublishAt = nil
+		return now, nil
+	case models.PostDraft:
+		post.PublishAt = nil
+		return now, nil
+	case models.PostScheduled:
+		if post.PublishAt == nil || !post.PublishAt.After(now) {
+			return time.Time{}, fmt.Errorf("%w: publish time must be in the future", ErrInvalidSchedule)
+		}
+		return *post.PublishAt, nil
+	default:
+		return time.Time{}, fmt.Errorf("%w: unknown status %q", ErrInvalidSchedule, post.Status)
+	}
+}
+
+// validateTTL checks the lifetime of an ephemeral post. The expiry itself is
+// set when the post is published.
+func validateTTL(post *models.Post) error {
+	post.ExpiresAt = nil
+	if post.TTLSeconds == 0 {
+		return nil
+	}
+
+	ttl := time.Duration(post.TTLSeconds) * time.Second
+	if post.TTLSeconds < 0 || ttl < MinTTL || ttl > MaxTTL {
+		return fmt.Errorf("%w: must be between %s and %s", ErrInvalidTTL, MinTTL, MaxTTL)
+	}
+	return nil
+}
+
+// screen runs body past the screener, if there is one. It returns the body
+// with the matches of replace entries swapped out, and a hold when a
+// moderate entry matched; a reject entry fails with ErrBlocked.
+func (p *PostService) screen(ctx context.Context, body string) (string, *models.ModerationHold, error) {
+	if p.screener == nil {
+		return body, nil, nil
+	}
+	s := p.screener.Screen(body)
+
+	switch s.Severity {
+	case models.BlockReject:
+		sl.FromContext(ctx, p.log).InfoContext(ctx, "post rejected by the blocklist", slog.Any("entries", entryIDs(s.Matches, models.BlockReject)))
+		return "", nil, ErrBlocked
+	case models.BlockModerate:
+		ids := make([]string, 0)
+		for _, id := range entryIDs(s.Matches, models.BlockModerate) {
+			ids = append(ids, strconv.Itoa(id))
+		}
+		return s.Body, &models.ModerationHold{
+			Reason:  models.ReasonOther,
+			Comment: "Held by the blocklist: entries " + strings.Join(ids, ", "),
+		}, nil
+	}
+	return s.Body, nil, nil
+}
+
+// entryIDs returns the distinct entries behind the matches of severity.
+func entryIDs(matches []models.BlocklistMatch, severity models.BlocklistSeverity) []int {
+	ids := make([]int, 0)
+	for _, m := range matches {
+		if m.Severity == severity && !slices.Contains(ids, m.EntryID) {
+			ids = append(ids, m.EntryID)
+		}
+	}
+	return ids
+}
+
+// classify runs post through the classifier, if there is one. The filter
+// is a safety net, not a gate: when it fails the post goes out unclassified.
+func (p *PostService) classify(ctx context.Context, post models.Post) *models.SpamClassification {
+	const op = "PostService.classify"
+
+	if p.classifier == nil {
+		return nil
+	}
+	c, err := p.classifier.Classify(ctx, post)
+	if err != nil {
+		sl.FromContext(ctx, p.log).ErrorContext(ctx, "can't classify post", sl.Op(op), sl.Err(err))
+		return nil
+	}
+	return &c
+}
+
+// enqueuePreviews hands a freshly published post to the link previewer. A
+// missing preview is cosmetic; it must not fail the post.
+func (p *PostService) enqueuePreviews(ctx context.Context, id int, body string) {
+	const op = "PostService.enqueuePreviews"
+
+	if p.previews == nil {
+		return
+	}
+	if err := p.previews.Enqueue(ctx, id, body); err != nil {
+		sl.FromContext(ctx, p.log).ErrorContext(ctx, "can't enqueue link previews", sl.Op(op), sl.Err(err))
+	}
+}
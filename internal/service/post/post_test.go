@@ -6,7 +6,9 @@ import (
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/AtIasShrugged/antisocial/internal/auth"
 	"github.com/AtIasShrugged/antisocial/internal/domain/models"
 	post_repo "github.com/AtIasShrugged/antisocial/internal/repository/post"
 	repoMock "github.com/AtIasShrugged/antisocial/internal/repository/post/mocks"
@@ -14,13 +16,18 @@ import (
 	"go.uber.org/mock/gomock"
 )
 
+// asUser returns a context authenticated as a plain user.
+func asUser(id int) context.Context {
+	return auth.WithPrincipal(context.Background(), auth.Principal{UserID: id, Role: models.RoleUser})
+}
+
 func TestGetByID(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	repo := repoMock.NewMockPostRepository(ctrl)
 
-	ctx := context.Background()
+	ctx := asUser(1)
 	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 
 	in := 1
@@ -50,7 +57,7 @@ func TestGetByIDError(t *testing.T) {
 
 	repo := repoMock.NewMockPostRepository(ctrl)
 
-	ctx := context.Background()
+	ctx := asUser(1)
 	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	repoErr := post_repo.ErrPostNotFound
 	in := 1
@@ -73,7 +80,7 @@ func TestCreate(t *testing.T) {
 
 	repo := repoMock.NewMockPostRepository(ctrl)
 
-	ctx := context.Background()
+	ctx := asUser(1)
 	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	in := models.Post{
 		AuthorID: 1,
@@ -94,7 +101,7 @@ func TestCreateError(t *testing.T) {
 
 	repo := repoMock.NewMockPostRepository(ctrl)
 
-	ctx := context.Background()
+	ctx := asUser(1)
 	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	repoErr := errors.New("can't insert post: db is down")
 	in := models.Post{
@@ -112,3 +119,130 @@ func TestCreateError(t *testing.T) {
 	)
 	require.Equal(t, 0, id)
 }
+
+type recordingPreviewer struct {
+	postID int
+	body   string
+}
+
+func (r *recordingPreviewer) Enqueue(_ context.Context, postID int, body string) error {
+	r.postID, r.body = postID, body
+	return errors.New("queue is down")
+}
+
+var testNow = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newService returns a service over a mock repository whose link previews
+// are recorded and whose clock stands still at testNow. opts add the
+// screener, classifier or whatever else a test is about.
+func newService(t *testing.T, opts ...Option) (*PostService, *repoMock.MockPostRepository, *recordingPreviewer) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	repo := repoMock.NewMockPostRepository(ctrl)
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	previewer := &recordingPreviewer{}
+
+	service := New(repo, log, append([]Option{WithLinkPreviews(previewer)}, opts...)...)
+	service.now = func() time.Time { return testNow }
+	return service, repo, previewer
+}
+
+func TestCreateEnqueuesLinkPreviews(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockPostRepository(ctrl)
+
+	ctx := asUser(1)
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	in := models.Post{
+		AuthorID: 1,
+		Body:     "read https://example.com",
+	}
+	repo.EXPECT().Create(ctx, in).Return(3, nil).Times(1)
+
+	previewer := &recordingPreviewer{}
+	service := New(repo, log, WithLinkPreviews(previewer))
+	id, err := service.Create(ctx, in)
+
+	// A failing previewer must not fail the post itself.
+	require.NoError(t, err)
+	require.Equal(t, 3, id)
+	require.Equal(t, 3, previewer.postID)
+	require.Equal(t, in.Body, previewer.body)
+}
+
+func TestCreateInvalidPoll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockPostRepository(ctrl)
+
+	ctx := asUser(1)
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	service := New(repo, log)
+	service.now = func() time.Time { return now }
+
+	cases := map[string]models.Poll{
+		"one option": {
+			ExpiresAt: now.Add(time.Hour),
+			Options:   []models.PollOption{{Text: "a"}},
+		},
+		"five options": {
+			ExpiresAt: now.Add(time.Hour),
+			Options:   []models.PollOption{{Text: "a"}, {Text: "b"}, {Text: "c"}, {Text: "d"}, {Text: "e"}},
+		},
+		"blank option": {
+			ExpiresAt: now.Add(time.Hour),
+			Options:   []models.PollOption{{Text: "a"}, {Text: "  "}},
+		},
+		"duplicate options": {
+			ExpiresAt: now.Add(time.Hour),
+			Options:   []models.PollOption{{Text: "a"}, {Text: "a "}},
+		},
+		"already expired": {
+			ExpiresAt: now.Add(-time.Hour),
+			Options:   []models.PollOption{{Text: "a"}, {Text: "b"}},
+		},
+	}
+	for name, poll := range cases {
+		poll := poll
+		_, err := service.Create(ctx, models.Post{AuthorID: 1, Body: "vote", Poll: &poll})
+		require.ErrorIs(t, err, ErrInvalidPoll, name)
+	}
+}
+
+func TestCreateWithPoll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockPostRepository(ctrl)
+
+	ctx := asUser(1)
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	in := models.Post{
+		AuthorID: 1,
+		Body:     "vote",
+		Poll: &models.Poll{
+			Multiple:  true,
+			ExpiresAt: now.Add(24 * time.Hour),
+			Options:   []models.PollOption{{Text: " tea "}, {Text: "coffee"}},
+		},
+	}
+	repo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, post models.Post) (int, error) {
+		require.Equal(t, "tea", post.Poll.Options[0].Text)
+		return 4, nil
+	}).Times(1)
+
+	service := New(repo, log)
+	service.now = func() time.Time { return now }
+
+	id, err := service.Create(ctx, in)
+	require.NoError(t, err)
+	require.Equal(t, 4, id)
+}
@@ -0,0 +1,174 @@
+package post
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	"github.com/AtIasShrugged/antisocial/internal/tracing"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+)
+
+const publishBatchSize = 50
+
+// GetDraft returns an unpublished post of authorID.
+func (p *PostService) GetDraft(ctx context.Context, id int, authorID int) (models.Post, error) {
+	const op = "PostService.GetDraft"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if err := auth.Authorize(ctx, auth.PostsReadDrafts, auth.Owner(authorID)); err != nil {
+		return models.Post{}, err
+	}
+
+	return p.repo.GetDraft(ctx, id, authorID)
+}
+
+// ListDrafts returns the drafts and scheduled posts of authorID.
+func (p *PostService) ListDrafts(ctx context.Context, authorID int) ([]models.Post, error) {
+	const op = "PostService.ListDrafts"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if err := auth.Authorize(ctx, auth.PostsReadDrafts, auth.Owner(authorID)); err != nil {
+		return nil, err
+	}
+
+	return p.repo.ListDrafts(ctx, authorID)
+}
+
+// UpdateDraft replaces the body of a draft or scheduled post.
+func (p *PostService) UpdateDraft(ctx context.Context, id int, authorID int, body string) error {
+	const op = "PostService.UpdateDraft"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if err := auth.Authorize(ctx, auth.PostsUpdate, auth.Owner(authorID)); err != nil {
+		return err
+	}
+
+	body, hold, err := p.screen(ctx, body)
+	if err != nil {
+		return err
+	}
+	spam := p.classify(ctx, models.Post{ID: id, AuthorID: authorID, Body: body})
+	return p.repo.UpdateDraft(ctx, id, authorID, body, spam, hold)
+}
+
+// Reschedule sets or moves the publish time of a draft or scheduled post.
+func (p *PostService) Reschedule(ctx context.Context, id int, authorID int, publishAt time.Time) error {
+	const op = "PostService.Reschedule"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if err := auth.Authorize(ctx, auth.PostsUpdate, auth.Owner(authorID)); err != nil {
+		return err
+	}
+
+	if !publishAt.After(p.now()) {
+		return fmt.Errorf("%w: publish time must be in the future", ErrInvalidSchedule)
+	}
+
+	draft, err := p.repo.GetDraft(ctx, id, authorID)
+	if err != nil {
+		return err
+	}
+	if err := checkPollOpen(draft.Poll, publishAt); err != nil {
+		return err
+	}
+
+	return p.repo.SetSchedule(ctx, id, authorID, models.PostScheduled, &publishAt)
+}
+
+// Cancel turns a scheduled post back into a draft. It fails with
+// ErrPostNotFound once the post has gone out.
+func (p *PostService) Cancel(ctx context.Context, id int, authorID int) error {
+	const op = "PostService.Cancel"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if err := auth.Authorize(ctx, auth.PostsUpdate, auth.Owner(authorID)); err != nil {
+		return err
+	}
+
+	return p.repo.SetSchedule(ctx, id, authorID, models.PostDraft, nil)
+}
+
+// Publish publishes a draft or scheduled post right away.
+func (p *PostService) Publish(ctx context.Context, id int, authorID int) error {
+	const op = "PostService.Publish"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	if err := auth.Authorize(ctx, auth.PostsUpdate, auth.Owner(authorID)); err != nil {
+		return err
+	}
+
+	draft, err := p.repo.GetDraft(ctx, id, authorID)
+	if err != nil {
+		return err
+	}
+	if err := checkPollOpen(draft.Poll, p.now()); err != nil {
+		return err
+	}
+
+	if err := p.repo.SetSchedule(ctx, id, authorID, models.PostPublished, nil); err != nil {
+		return err
+	}
+	if !held(draft) {
+		p.enqueuePreviews(ctx, id, draft.Body)
+	}
+
+	return nil
+}
+
+// PublishDue publishes scheduled posts whose time has come. It returns the
+// number of posts published.
+func (p *PostService) PublishDue(ctx context.Context) (int, error) {
+	const op = "PostService.PublishDue"
+	ctx, span := tracing.Start(ctx, op)
+	defer span.End()
+
+	posts, err := p.repo.PublishDue(ctx, p.now(), publishBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	metrics.PostsPublished.Add(float64(len(posts)))
+	for _, post := range posts {
+		if !held(post) {
+			p.enqueuePreviews(ctx, post.ID, post.Body)
+		}
+	}
+
+	return len(posts), nil
+}
+
+// RunScheduler calls PublishDue every interval until ctx is cancelled.
+func (p *PostService) RunScheduler(ctx context.Context, interval time.Duration) {
+	const op = "PostService.RunScheduler"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				n, err := p.PublishDue(ctx)
+				if err != nil {
+					p.log.Error(op+": "+err.Error(), sl.Op(op), sl.Err(err))
+					break
+				}
+				if n < publishBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
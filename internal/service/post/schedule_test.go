@@ -0,0 +1,167 @@
+package post
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	post_repo "github.com/AtIasShrugged/antisocial/internal/repository/post"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateScheduledDefersPreviews(t *testing.T) {
+	service, repo, previewer := newService(t)
+	ctx := asUser(1)
+
+	publishAt := testNow.Add(time.Hour)
+	in := models.Post{AuthorID: 1, Body: "later https://example.com", Status: models.PostScheduled, PublishAt: &publishAt}
+	repo.EXPECT().Create(ctx, in).Return(5, nil).Times(1)
+
+	id, err := service.Create(ctx, in)
+	require.NoError(t, err)
+	require.Equal(t, 5, id)
+	require.Zero(t, previewer.postID)
+}
+
+func TestCreateDraftDropsPublishTime(t *testing.T) {
+	service, repo, _ := newService(t)
+	ctx := asUser(1)
+
+	publishAt := testNow.Add(time.Hour)
+	repo.EXPECT().Create(ctx, models.Post{AuthorID: 1, Body: "wip", Status: models.PostDraft}).Return(6, nil).Times(1)
+
+	_, err := service.Create(ctx, models.Post{AuthorID: 1, Body: "wip", Status: models.PostDraft, PublishAt: &publishAt})
+	require.NoError(t, err)
+}
+
+func TestCreateInvalidSchedule(t *testing.T) {
+	service, _, _ := newService(t)
+	ctx := asUser(1)
+
+	past := testNow.Add(-time.Minute)
+	cases := map[string]models.Post{
+		"no publish time":   {AuthorID: 1, Body: "x", Status: models.PostScheduled},
+		"publish time past": {AuthorID: 1, Body: "x", Status: models.PostScheduled, PublishAt: &past},
+		"unknown status":    {AuthorID: 1, Body: "x", Status: "hidden"},
+	}
+	for name, in := range cases {
+		_, err := service.Create(ctx, in)
+		require.ErrorIs(t, err, ErrInvalidSchedule, name)
+	}
+}
+
+func TestCreateScheduledPollMeasuredFromPublication(t *testing.T) {
+	service, _, _ := newService(t)
+	ctx := asUser(1)
+
+	publishAt := testNow.Add(24 * time.Hour)
+	in := models.Post{
+		AuthorID:  1,
+		Body:      "vote tomorrow",
+		Status:    models.PostScheduled,
+		PublishAt: &publishAt,
+		Poll: &models.Poll{
+			// Long enough from now, but closed before the post goes out.
+			ExpiresAt: testNow.Add(time.Hour),
+			Options:   []models.PollOption{{Text: "a"}, {Text: "b"}},
+		},
+	}
+
+	_, err := service.Create(ctx, in)
+	require.ErrorIs(t, err, ErrInvalidPoll)
+}
+
+func TestReschedule(t *testing.T) {
+	service, repo, _ := newService(t)
+	ctx := asUser(1)
+
+	publishAt := testNow.Add(2 * time.Hour)
+	gomock.InOrder(
+		repo.EXPECT().GetDraft(ctx, 7, 1).Return(models.Post{ID: 7, AuthorID: 1, Status: models.PostScheduled}, nil),
+		repo.EXPECT().SetSchedule(ctx, 7, 1, models.PostScheduled, &publishAt).Return(nil),
+	)
+
+	require.NoError(t, service.Reschedule(ctx, 7, 1, publishAt))
+}
+
+func TestRescheduleRejections(t *testing.T) {
+	service, repo, _ := newService(t)
+	ctx := asUser(1)
+
+	err := service.Reschedule(ctx, 7, 1, testNow)
+	require.ErrorIs(t, err, ErrInvalidSchedule)
+
+	repo.EXPECT().GetDraft(ctx, 7, 1).Return(models.Post{}, post_repo.ErrPostNotFound).Times(1)
+	err = service.Reschedule(ctx, 7, 1, testNow.Add(time.Hour))
+	require.ErrorIs(t, err, post_repo.ErrPostNotFound)
+
+	withPoll := models.Post{ID: 7, AuthorID: 1, Poll: &models.Poll{ExpiresAt: testNow.Add(time.Hour)}}
+	repo.EXPECT().GetDraft(ctx, 7, 1).Return(withPoll, nil).Times(1)
+	err = service.Reschedule(ctx, 7, 1, testNow.Add(time.Hour))
+	require.ErrorIs(t, err, ErrInvalidPoll)
+}
+
+func TestCancel(t *testing.T) {
+	service, repo, _ := newService(t)
+	ctx := asUser(1)
+
+	repo.EXPECT().SetSchedule(ctx, 7, 1, models.PostDraft, nil).Return(post_repo.ErrPostNotFound).Times(1)
+
+	err := service.Cancel(ctx, 7, 1)
+	require.ErrorIs(t, err, post_repo.ErrPostNotFound)
+}
+
+func TestPublishEnqueuesPreviews(t *testing.T) {
+	service, repo, previewer := newService(t)
+	ctx := asUser(1)
+
+	gomock.InOrder(
+		repo.EXPECT().GetDraft(ctx, 7, 1).Return(models.Post{ID: 7, AuthorID: 1, Body: "now https://example.com"}, nil),
+		repo.EXPECT().SetSchedule(ctx, 7, 1, models.PostPublished, nil).Return(nil),
+	)
+
+	require.NoError(t, service.Publish(ctx, 7, 1))
+	require.Equal(t, 7, previewer.postID)
+}
+
+func TestPublishDue(t *testing.T) {
+	service, repo, previewer := newService(t)
+	ctx := asUser(1)
+
+	repo.EXPECT().PublishDue(ctx, testNow, publishBatchSize).Return([]models.Post{
+		{ID: 8, AuthorID: 1, Body: "first"},
+		{ID: 9, AuthorID: 2, Body: "second"},
+	}, nil).Times(1)
+
+	n, err := service.PublishDue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, 9, previewer.postID)
+	require.Equal(t, "second", previewer.body)
+}
+
+func TestPublishSkipsPreviewsOfHeldPosts(t *testing.T) {
+	service, repo, previewer := newService(t)
+	ctx := asUser(1)
+
+	for id, verdict := range map[int]models.SpamVerdict{7: models.SpamHold, 8: models.SpamLimit} {
+		held := models.Post{ID: id, AuthorID: 1, Body: "buy https://spam.test", Spam: &models.SpamClassification{Verdict: verdict}}
+		gomock.InOrder(
+			repo.EXPECT().GetDraft(ctx, id, 1).Return(held, nil),
+			repo.EXPECT().SetSchedule(ctx, id, 1, models.PostPublished, nil).Return(nil),
+		)
+		require.NoError(t, service.Publish(ctx, id, 1))
+	}
+	require.Zero(t, previewer.postID)
+
+	repo.EXPECT().PublishDue(ctx, testNow, publishBatchSize).Return([]models.Post{
+		{ID: 9, AuthorID: 1, Body: "fine https://example.com"},
+		{ID: 10, AuthorID: 2, Body: "buy https://spam.test", Spam: &models.SpamClassification{Verdict: models.SpamHold}},
+	}, nil).Times(1)
+
+	n, err := service.PublishDue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, 9, previewer.postID)
+}
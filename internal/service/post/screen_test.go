@@ -0,0 +1,77 @@
+package post
+
+import (
+	"testing"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/stretchr/testify/require"
+)
+
+type stubScreener struct {
+	s models.Screening
+}
+
+func (s stubScreener) Screen(string) models.Screening {
+	return s.s
+}
+
+func TestCreateRejectedByBlocklist(t *testing.T) {
+	service, _, _ := newService(t, WithScreener(stubScreener{s: models.Screening{
+		Severity: models.BlockReject,
+		Matches:  []models.BlocklistMatch{{EntryID: 2, Severity: models.BlockReject, Start: 0, End: 4}},
+	}}))
+
+	_, err := service.Create(asUser(1), models.Post{AuthorID: 1, Body: "slur"})
+	require.ErrorIs(t, err, ErrBlocked)
+}
+
+func TestCreateReplacedByBlocklist(t *testing.T) {
+	service, repo, previewer := newService(t, WithScreener(stubScreener{s: models.Screening{
+		Body:     "what the ****",
+		Severity: models.BlockReplace,
+		Matches:  []models.BlocklistMatch{{EntryID: 1, Severity: models.BlockReplace, Start: 9, End: 13}},
+	}}))
+
+	ctx := asUser(1)
+	repo.EXPECT().Create(ctx, models.Post{AuthorID: 1, Body: "what the ****"}).Return(3, nil)
+
+	_, err := service.Create(ctx, models.Post{AuthorID: 1, Body: "what the heck"})
+	require.NoError(t, err)
+	require.Equal(t, 3, previewer.postID)
+}
+
+func TestCreateHeldByBlocklist(t *testing.T) {
+	service, repo, previewer := newService(t, WithScreener(stubScreener{s: models.Screening{
+		Body:     "free money https://shop.example/promo",
+		Severity: models.BlockModerate,
+		Matches: []models.BlocklistMatch{
+			{EntryID: 3, Severity: models.BlockModerate, Start: 0, End: 10},
+			{EntryID: 7, Severity: models.BlockModerate, Start: 11, End: 37},
+			{EntryID: 3, Severity: models.BlockModerate, Start: 0, End: 10},
+		},
+	}}))
+
+	ctx := asUser(1)
+	in := models.Post{AuthorID: 1, Body: "free money https://shop.example/promo"}
+	want := in
+	want.Hold = &models.ModerationHold{Reason: models.ReasonOther, Comment: "Held by the blocklist: entries 3, 7"}
+	repo.EXPECT().Create(ctx, want).Return(4, nil)
+
+	_, err := service.Create(ctx, in)
+	require.NoError(t, err)
+	require.Zero(t, previewer.postID, "held posts get no previews until released")
+}
+
+func TestUpdateDraftScreened(t *testing.T) {
+	service, repo, _ := newService(t, WithScreener(stubScreener{s: models.Screening{
+		Body:     "free money",
+		Severity: models.BlockModerate,
+		Matches:  []models.BlocklistMatch{{EntryID: 3, Severity: models.BlockModerate, Start: 0, End: 10}},
+	}}))
+
+	ctx := asUser(1)
+	hold := &models.ModerationHold{Reason: models.ReasonOther, Comment: "Held by the blocklist: entries 3"}
+	repo.EXPECT().UpdateDraft(ctx, 5, 1, "free money", nil, hold).Return(nil)
+
+	require.NoError(t, service.UpdateDraft(ctx, 5, 1, "free money"))
+}
@@ -0,0 +1,79 @@
+package post
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	post_repo "github.com/AtIasShrugged/antisocial/internal/repository/post"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type stubClassifier struct {
+	c   models.SpamClassification
+	err error
+}
+
+func (s stubClassifier) Classify(context.Context, models.Post) (models.SpamClassification, error) {
+	return s.c, s.err
+}
+
+func TestCreateHeldBySpamFilter(t *testing.T) {
+	held := models.SpamClassification{Verdict: models.SpamHold, Score: 0.8, Reasons: []string{"duplicates"}}
+	service, repo, previewer := newService(t, WithClassifier(stubClassifier{c: held}))
+
+	ctx := asUser(1)
+	in := models.Post{AuthorID: 1, Body: "buy now https://example.com"}
+	want := in
+	want.Spam = &held
+	repo.EXPECT().Create(ctx, want).Return(4, nil)
+
+	id, err := service.Create(ctx, in)
+	require.NoError(t, err)
+	require.Equal(t, 4, id)
+	require.Zero(t, previewer.postID, "held posts get no previews until released")
+}
+
+func TestCreateAllowedBySpamFilter(t *testing.T) {
+	allowed := models.SpamClassification{Verdict: models.SpamAllow, Score: 0.1}
+	service, repo, previewer := newService(t, WithClassifier(stubClassifier{c: allowed}))
+
+	ctx := asUser(1)
+	in := models.Post{AuthorID: 1, Body: "read https://example.com"}
+	want := in
+	want.Spam = &allowed
+	repo.EXPECT().Create(ctx, want).Return(5, nil)
+
+	_, err := service.Create(ctx, in)
+	require.NoError(t, err)
+	require.Equal(t, 5, previewer.postID)
+}
+
+func TestCreateSpamFilterFailsOpen(t *testing.T) {
+	service, repo, _ := newService(t, WithClassifier(stubClassifier{err: errors.New("can't query spam signals")}))
+
+	ctx := asUser(1)
+	in := models.Post{AuthorID: 1, Body: "hello"}
+	repo.EXPECT().Create(ctx, in).Return(6, nil)
+
+	id, err := service.Create(ctx, in)
+	require.NoError(t, err)
+	require.Equal(t, 6, id)
+}
+
+func TestGetByIDHidesSpamFromOthers(t *testing.T) {
+	service, repo, _ := newService(t, WithClassifier(stubClassifier{}))
+
+	stored := models.Post{ID: 7, AuthorID: 1, Body: "buy now",
+		Spam: &models.SpamClassification{Verdict: models.SpamLimit, Score: 0.95}}
+	repo.EXPECT().GetByID(gomock.Any(), 7).Return(stored, nil).Times(2)
+
+	post, err := service.GetByID(asUser(1), 7)
+	require.NoError(t, err)
+	require.Equal(t, stored, post)
+
+	_, err = service.GetByID(asUser(2), 7)
+	require.ErrorIs(t, err, post_repo.ErrPostNotFound)
+}
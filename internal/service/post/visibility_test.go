@@ -0,0 +1,97 @@
+package post
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	post_repo "github.com/AtIasShrugged/antisocial/internal/repository/post"
+	repoMock "github.com/AtIasShrugged/antisocial/internal/repository/post/mocks"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// followGraph is who follows whom, keyed by follower.
+type followGraph map[int][]int
+
+func (f followGraph) Follows(_ context.Context, followerID, followeeID int) (bool, error) {
+	for _, id := range f[followerID] {
+		if id == followeeID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestGetByIDHonoursAuthorState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockPostRepository(ctrl)
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	service := New(repo, log, WithFollowGraph(followGraph{2: {1}}))
+
+	for _, tc := range []struct {
+		state   models.AccountState
+		viewer  context.Context
+		visible bool
+	}{
+		{"", context.Background(), true},
+		{models.AccountLimited, asUser(1), true},
+		{models.AccountLimited, asUser(2), true},
+		{models.AccountLimited, asUser(3), false},
+		{models.AccountLimited, context.Background(), false},
+		{models.AccountSuspended, asUser(2), false},
+		{models.AccountSuspended, asUser(1), true},
+		{models.AccountDeactivated, context.Background(), false},
+	} {
+		stored := models.Post{ID: 5, AuthorID: 1, Body: "hi", AuthorState: tc.state}
+		repo.EXPECT().GetByID(tc.viewer, 5).Return(stored, nil)
+
+		post, err := service.GetByID(tc.viewer, 5)
+		if tc.visible {
+			require.NoError(t, err, tc.state)
+			require.Equal(t, stored, post)
+		} else {
+			require.ErrorIs(t, err, post_repo.ErrPostNotFound, tc.state)
+		}
+	}
+}
+
+func TestGetByIDLimitedWithoutFollowGraph(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockPostRepository(ctrl)
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	service := New(repo, log)
+
+	repo.EXPECT().GetByID(gomock.Any(), 5).Return(models.Post{ID: 5, AuthorID: 1, AuthorState: models.AccountLimited}, nil)
+	_, err := service.GetByID(asUser(2), 5)
+	require.ErrorIs(t, err, post_repo.ErrPostNotFound)
+}
+
+func TestGetManyLeavesOutHiddenPosts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := repoMock.NewMockPostRepository(ctrl)
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	service := New(repo, log, WithFollowGraph(followGraph{}))
+
+	ctx := asUser(3)
+	repo.EXPECT().GetMany(ctx, []int{1, 2, 3, 4}).Return(map[int]models.Post{
+		1: {ID: 1, AuthorID: 1},
+		2: {ID: 2, AuthorID: 1, AuthorState: models.AccountSuspended},
+		3: {ID: 3, AuthorID: 2, Spam: &models.SpamClassification{Verdict: models.SpamHold}},
+		4: {ID: 4, AuthorID: 3, Spam: &models.SpamClassification{Verdict: models.SpamHold}},
+	}, nil)
+
+	posts, err := service.GetMany(ctx, []int{1, 2, 3, 4})
+	require.NoError(t, err)
+	require.Len(t, posts, 2)
+	require.Contains(t, posts, 1)
+	require.Contains(t, posts, 4, "authors see their own held posts")
+}
@@ -0,0 +1,9 @@
+package role
+
+import "errors"
+
+var (
+	ErrInvalidRole    = errors.New("invalid role")
+	ErrBootstrapAdmin = errors.New("role of a configured admin can't be changed")
+	ErrOwnRole        = errors.New("can't change your own role")
+)
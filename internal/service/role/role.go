@@ -0,0 +1,90 @@
+package role
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/AtIasShrugged/antisocial/internal/auth"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	role_repo "github.com/AtIasShrugged/antisocial/internal/repository/role"
+)
+
+type Auditor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Record(ctx context.Context, event models.AuditEvent) error
+}
+
+type RoleService struct {
+	repo role_repo.RoleRepository
+	// admins always hold models.RoleAdmin, so that someone can grant roles
+	// to everyone else.
+	admins  []int
+	auditor Auditor
+	log     *slog.Logger
+}
+
+func New(repo role_repo.RoleRepository, admins []int, auditor Auditor, log *slog.Logger) *RoleService {
+	return &RoleService{
+		repo:    repo,
+		admins:  admins,
+		auditor: auditor,
+		log:     log,
+	}
+}
+
+// Role returns the role of userID. It backs authentication and so checks no
+// permission.
+func (r *RoleService) Role(ctx context.Context, userID int) (models.Role, error) {
+	if slices.Contains(r.admins, userID) {
+		return models.RoleAdmin, nil
+	}
+	return r.repo.Get(ctx, userID)
+}
+
+func (r *RoleService) Get(ctx context.Context, userID int) (models.Role, error) {
+	if err := auth.Authorize(ctx, auth.RolesRead, nil); err != nil {
+		return "", err
+	}
+	return r.Role(ctx, userID)
+}
+
+// Set grants role to userID on behalf of the user of ctx.
+func (r *RoleService) Set(ctx context.Context, userID int, role models.Role) error {
+	if err := auth.Authorize(ctx, auth.RolesManage, nil); err != nil {
+		return err
+	}
+	if !slices.Contains(models.Roles, role) {
+		return fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+	if slices.Contains(r.admins, userID) {
+		return ErrBootstrapAdmin
+	}
+	granter, _ := auth.UserID(ctx)
+	if granter == userID {
+		return ErrOwnRole
+	}
+
+	previous, err := r.repo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return r.auditor.InTx(ctx, func(ctx context.Context) error {
+		if err := r.repo.Set(ctx, userID, role, granter); err != nil {
+			return err
+		}
+		return r.auditor.Record(ctx, models.AuditEvent{
+			Action:     models.AuditRoleChange,
+			TargetType: models.AuditTargetUser,
+			TargetID:   strconv.Itoa(userID),
+			Before:     roleState{Role: previous},
+			After:      roleState{Role: role},
+		})
+	})
+}
+
+type roleState struct {
+	Role models.Role `json:"role"`
+}
@@ -0,0 +1,5 @@
+package spam
+
+import "errors"
+
+var ErrInvalidConfig = errors.New("invalid spam filter config")
@@ -0,0 +1,166 @@
+package spam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"github.com/AtIasShrugged/antisocial/internal/metrics"
+	spam_repo "github.com/AtIasShrugged/antisocial/internal/repository/spam"
+)
+
+// Signals scoring below reasonThreshold don't show up among the reasons of
+// a classification.
+const reasonThreshold = 0.1
+
+// Caps on what each heuristic contributes. Links alone are common in
+// legitimate posts, so they can't get a post held on their own.
+const (
+	maxLinkScore       = 0.5
+	maxDuplicateScore  = 0.95
+	newAccountScore    = 0.25
+	maxVelocityScore   = 0.9
+	duplicatesForMax   = 4
+	linkDensityFloor   = 0.2
+	linkDensityCeiling = 0.8
+)
+
+// SpamService scores new posts and learns from moderator decisions. It
+// works from the database alone and never calls out to other services.
+type SpamService struct {
+	repo spam_repo.SpamRepository
+	cfg  config.SpamConfig
+	log  *slog.Logger
+	now  func() time.Time
+}
+
+func New(repo spam_repo.SpamRepository, cfg config.SpamConfig, log *slog.Logger) (*SpamService, error) {
+	if cfg.HoldThreshold <= 0 || cfg.HoldThreshold > cfg.LimitThreshold || cfg.LimitThreshold > 1 {
+		return nil, fmt.Errorf("%w: thresholds must satisfy 0 < hold <= limit <= 1", ErrInvalidConfig)
+	}
+	if cfg.VelocityLimit < 2 {
+		return nil, fmt.Errorf("%w: velocity limit must be at least 2", ErrInvalidConfig)
+	}
+	return &SpamService{
+		repo: repo,
+		cfg:  cfg,
+		log:  log,
+		now:  time.Now,
+	}, nil
+}
+
+type signal struct {
+	name  string
+	score float64
+}
+
+// Classify scores post, which isn't stored yet. Every signal yields a score
+// in [0, 1] and they combine like independent chances: the post is clean
+// only if no signal is right about it.
+func (s *SpamService) Classify(ctx context.Context, post models.Post) (models.SpamClassification, error) {
+	now := s.now()
+	facts, err := s.repo.Signals(ctx, post.AuthorID, post.Body, now.Add(-s.cfg.DuplicateWindow), now.Add(-s.cfg.VelocityWindow))
+	if err != nil {
+		return models.SpamClassification{}, err
+	}
+	model, err := s.repo.Model(ctx, Tokens(post.Body))
+	if err != nil {
+		return models.SpamClassification{}, err
+	}
+
+	signals := []signal{
+		{"links", linkScore(post.Body)},
+		{"duplicates", maxDuplicateScore * clamp(float64(facts.DuplicateAuthors)/duplicatesForMax)},
+		{"new_account", s.newAccountScore(facts.FirstPostAt, now)},
+		{"velocity", s.velocityScore(facts.RecentPosts)},
+	}
+	if p, ok := bayes(model, s.cfg.MinTrainingPosts); ok {
+		// Only confidence beyond a coin flip counts as a signal.
+		signals = append(signals, signal{"bayes", max(0, 2*p-1)})
+	}
+
+	clean := 1.0
+	c := models.SpamClassification{Verdict: models.SpamAllow}
+	for _, sig := range signals {
+		clean *= 1 - sig.score
+		if sig.score >= reasonThreshold {
+			c.Reasons = append(c.Reasons, sig.name)
+		}
+	}
+	c.Score = 1 - clean
+
+	switch {
+	case c.Score >= s.cfg.LimitThreshold:
+		c.Verdict = models.SpamLimit
+	case c.Score >= s.cfg.HoldThreshold:
+		c.Verdict = models.SpamHold
+	}
+	metrics.SpamVerdicts.WithLabelValues(string(c.Verdict)).Inc()
+
+	return c, nil
+}
+
+// Learn teaches the model that postID is spam or not. Posts that are gone,
+// such as reaped ephemeral posts, are skipped.
+func (s *SpamService) Learn(ctx context.Context, postID int, spam bool) error {
+	body, err := s.repo.PostBody(ctx, postID)
+	if err != nil {
+		if errors.Is(err, spam_repo.ErrPostNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.repo.Train(ctx, postID, spam, Tokens(body))
+}
+
+// linkScore grows with the share of words that are links.
+func linkScore(body string) float64 {
+	words := len(strings.Fields(body))
+	if words == 0 {
+		return 0
+	}
+	density := float64(len(linkPattern.FindAllString(body, -1))) / float64(words)
+	return maxLinkScore * clamp((density-linkDensityFloor)/(linkDensityCeiling-linkDensityFloor))
+}
+
+func (s *SpamService) newAccountScore(firstPostAt *time.Time, now time.Time) float64 {
+	if firstPostAt == nil || now.Sub(*firstPostAt) < s.cfg.NewAccountAge {
+		return newAccountScore
+	}
+	return 0
+}
+
+// velocityScore is zero up to half the velocity limit and climbs to its
+// maximum at the limit.
+func (s *SpamService) velocityScore(recent int) float64 {
+	half := float64(s.cfg.VelocityLimit) / 2
+	return maxVelocityScore * clamp((float64(recent)-half)/half)
+}
+
+// bayes returns the probability that a post with the tokens of model is
+// spam, or false while the model has learned from too few posts. Reported
+// posts skew the training set towards spam, so the prior is even rather
+// than the share of spam in it.
+func bayes(model models.SpamModel, minPosts int) (float64, bool) {
+	if model.SpamPosts < minPosts || model.HamPosts < minPosts {
+		return 0, false
+	}
+
+	logOdds := 0.0
+	for _, counts := range model.Tokens {
+		pSpam := (float64(counts.Spam) + 1) / (float64(model.SpamPosts) + 2)
+		pHam := (float64(counts.Ham) + 1) / (float64(model.HamPosts) + 2)
+		logOdds += math.Log(pSpam) - math.Log(pHam)
+	}
+	return 1 / (1 + math.Exp(-logOdds)), true
+}
+
+func clamp(x float64) float64 {
+	return min(1, max(0, x))
+}
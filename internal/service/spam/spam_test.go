@@ -0,0 +1,163 @@
+package spam
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	spam_repo "github.com/AtIasShrugged/antisocial/internal/repository/spam"
+	repoMock "github.com/AtIasShrugged/antisocial/internal/repository/spam/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var testConfig = config.SpamConfig{
+	Enabled:          true,
+	HoldThreshold:    0.7,
+	LimitThreshold:   0.9,
+	DuplicateWindow:  24 * time.Hour,
+	VelocityWindow:   time.Hour,
+	VelocityLimit:    20,
+	NewAccountAge:    24 * time.Hour,
+	MinTrainingPosts: 20,
+}
+
+func newService(t *testing.T) (*SpamService, *repoMock.MockSpamRepository) {
+	ctrl := gomock.NewController(t)
+	repo := repoMock.NewMockSpamRepository(ctrl)
+	log := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	service, err := New(repo, testConfig, log)
+	require.NoError(t, err)
+	service.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	return service, repo
+}
+
+func TestNewRejectsBadConfig(t *testing.T) {
+	log := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	for name, mutate := range map[string]func(*config.SpamConfig){
+		"zero hold":        func(c *config.SpamConfig) { c.HoldThreshold = 0 },
+		"hold above limit": func(c *config.SpamConfig) { c.HoldThreshold = 0.95 },
+		"limit above one":  func(c *config.SpamConfig) { c.LimitThreshold = 1.5 },
+		"velocity":         func(c *config.SpamConfig) { c.VelocityLimit = 1 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := testConfig
+			mutate(&cfg)
+			_, err := New(nil, cfg, log)
+			require.ErrorIs(t, err, ErrInvalidConfig)
+		})
+	}
+}
+
+func TestTokens(t *testing.T) {
+	tokens := Tokens("Buy CHEAP pills at https://Pills.example/buy?x=1 — cheap, cheap! a 💊 https://pills.example/more")
+	assert.Equal(t, []string{"host:pills.example", "buy", "cheap", "pills", "at"}, tokens)
+	assert.NotNil(t, Tokens(""))
+}
+
+func TestClassify(t *testing.T) {
+	established := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for name, tc := range map[string]struct {
+		body    string
+		signals models.SpamSignals
+		model   models.SpamModel
+		verdict models.SpamVerdict
+		reasons []string
+	}{
+		"clean": {
+			body:    "just had a lovely walk in the park",
+			signals: models.SpamSignals{RecentPosts: 2, FirstPostAt: &established},
+			verdict: models.SpamAllow,
+		},
+		"new account alone": {
+			body:    "hello everyone",
+			verdict: models.SpamAllow,
+			reasons: []string{"new_account"},
+		},
+		"copy pasted across accounts": {
+			body:    "great deals here",
+			signals: models.SpamSignals{DuplicateAuthors: 3, FirstPostAt: &established},
+			verdict: models.SpamHold,
+			reasons: []string{"duplicates"},
+		},
+		"flood of links from a new account": {
+			body:    "https://a.example https://b.example https://c.example",
+			signals: models.SpamSignals{DuplicateAuthors: 4, RecentPosts: 20},
+			verdict: models.SpamLimit,
+			reasons: []string{"links", "duplicates", "new_account", "velocity"},
+		},
+		"model too small to trust": {
+			body:    "cheap pills",
+			signals: models.SpamSignals{FirstPostAt: &established},
+			model: models.SpamModel{SpamPosts: 19, HamPosts: 100, Tokens: map[string]models.SpamTokenCounts{
+				"cheap": {Spam: 19}, "pills": {Spam: 19},
+			}},
+			verdict: models.SpamAllow,
+		},
+		"model knows the words": {
+			body:    "cheap pills",
+			signals: models.SpamSignals{FirstPostAt: &established},
+			model: models.SpamModel{SpamPosts: 50, HamPosts: 50, Tokens: map[string]models.SpamTokenCounts{
+				"cheap": {Spam: 40, Ham: 1}, "pills": {Spam: 45},
+			}},
+			verdict: models.SpamLimit,
+			reasons: []string{"bayes"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			service, repo := newService(t)
+			ctx := context.Background()
+			now := service.now()
+
+			repo.EXPECT().Signals(ctx, 7, tc.body, now.Add(-24*time.Hour), now.Add(-time.Hour)).Return(tc.signals, nil)
+			repo.EXPECT().Model(ctx, Tokens(tc.body)).Return(tc.model, nil)
+
+			c, err := service.Classify(ctx, models.Post{AuthorID: 7, Body: tc.body})
+			require.NoError(t, err)
+			assert.Equal(t, tc.verdict, c.Verdict, "score %.2f", c.Score)
+			assert.Equal(t, tc.reasons, c.Reasons)
+			assert.True(t, c.Score >= 0 && c.Score <= 1)
+		})
+	}
+}
+
+func TestClassifyRepoError(t *testing.T) {
+	service, repo := newService(t)
+	repoErr := errors.New("can't query spam signals")
+	repo.EXPECT().Signals(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(models.SpamSignals{}, repoErr)
+
+	_, err := service.Classify(context.Background(), models.Post{AuthorID: 1, Body: "x"})
+	require.ErrorIs(t, err, repoErr)
+}
+
+func TestBayes(t *testing.T) {
+	model := models.SpamModel{SpamPosts: 30, HamPosts: 30, Tokens: map[string]models.SpamTokenCounts{
+		"hello": {Spam: 1, Ham: 20},
+	}}
+	p, ok := bayes(model, 20)
+	require.True(t, ok)
+	assert.Less(t, p, 0.5)
+
+	p, ok = bayes(models.SpamModel{SpamPosts: 30, HamPosts: 30}, 20)
+	require.True(t, ok)
+	assert.Equal(t, 0.5, p, "unknown words say nothing")
+}
+
+func TestLearn(t *testing.T) {
+	service, repo := newService(t)
+	ctx := context.Background()
+
+	repo.EXPECT().PostBody(ctx, 3).Return("cheap pills", nil)
+	repo.EXPECT().Train(ctx, 3, true, []string{"cheap", "pills"}).Return(nil)
+	require.NoError(t, service.Learn(ctx, 3, true))
+
+	repo.EXPECT().PostBody(ctx, 4).Return("", spam_repo.ErrPostNotFound)
+	require.NoError(t, service.Learn(ctx, 4, false))
+}
@@ -0,0 +1,49 @@
+package spam
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Bounds on what the model learns from a single post.
+const (
+	maxTokens   = 200
+	minTokenLen = 2
+	maxTokenLen = 32
+)
+
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"'\x60]+`)
+
+// Tokens returns the distinct features of body the naive Bayes model works
+// on: lowercased words, and the host of every link as "host:<name>" so that
+// link farms are told apart by where they point rather than by their paths.
+func Tokens(body string) []string {
+	tokens := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(token string) {
+		if len(tokens) < maxTokens && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	for _, link := range linkPattern.FindAllString(body, -1) {
+		if u, err := url.Parse(link); err == nil && u.Hostname() != "" {
+			add("host:" + strings.ToLower(u.Hostname()))
+		}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(linkPattern.ReplaceAllString(body, " ")), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		if n := utf8.RuneCountInString(word); n >= minTokenLen && n <= maxTokenLen {
+			add(word)
+		}
+	}
+
+	return tokens
+}
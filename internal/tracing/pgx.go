@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracer creates a client span for every statement pgx runs. Set it as
+// the Tracer of a pgx.ConnConfig.
+//
+// With Log set it also logs every statement at debug level, under the op of
+// the repository method that ran it (see sl.WithOp), so that turning on
+// debug for a single repository shows its SQL.
+type QueryTracer struct {
+	Log *slog.Logger
+}
+
+type queryKey struct{}
+
+// query is what TraceQueryStart leaves for TraceQueryEnd to log.
+type query struct {
+	sql   string
+	start time.Time
+}
+
+var (
+	_ pgx.QueryTracer    = QueryTracer{}
+	_ pgx.CopyFromTracer = QueryTracer{}
+)
+
+func (t QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if t.Log != nil && sl.FromContext(ctx, t.Log).Enabled(ctx, slog.LevelDebug) {
+		ctx = context.WithValue(ctx, queryKey{}, query{sql: data.SQL, start: time.Now()})
+	}
+	operation := sqlOperation(data.SQL)
+	ctx, _ = otel.Tracer(instrumentationName).Start(ctx, "db "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperation(operation),
+			semconv.DBStatement(data.SQL),
+		),
+	)
+	return ctx
+}
+
+func (t QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	t.logQuery(ctx, data)
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		RecordError(span, data.Err)
+	} else {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+}
+
+func (QueryTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	ctx, _ = otel.Tracer(instrumentationName).Start(ctx, "db COPY",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperation("COPY"),
+			semconv.DBSQLTable(data.TableName.Sanitize()),
+		),
+	)
+	return ctx
+}
+
+func (QueryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		RecordError(span, data.Err)
+	} else {
+		span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+}
+
+func (t QueryTracer) logQuery(ctx context.Context, data pgx.TraceQueryEndData) {
+	q, ok := ctx.Value(queryKey{}).(query)
+	if !ok {
+		return
+	}
+
+	attrs := []slog.Attr{slog.String("statement", q.sql), slog.Duration("duration", time.Since(q.start))}
+	if op, ok := sl.OpFromContext(ctx); ok {
+		attrs = append(attrs, sl.Op(op))
+	}
+	if data.Err != nil {
+		attrs = append(attrs, sl.Err(data.Err))
+	} else {
+		attrs = append(attrs, slog.Int64("rows_affected", data.CommandTag.RowsAffected()))
+	}
+	sl.FromContext(ctx, t.Log).LogAttrs(ctx, slog.LevelDebug, "query done", attrs...)
+}
+
+// sqlOperation returns the leading keyword of a statement, e.g. "SELECT",
+// which keeps span names low-cardinality.
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(fields[0])
+}
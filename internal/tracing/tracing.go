@@ -0,0 +1,91 @@
+// Package tracing wires OpenTelemetry into the service: provider setup and
+// OTLP export, an Echo middleware for incoming requests, a pgx tracer for SQL
+// statements and a slog handler that stamps records with trace ids.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AtIasShrugged/antisocial/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans created by this service.
+const instrumentationName = "github.com/AtIasShrugged/antisocial"
+
+// Setup installs the global tracer provider and W3C trace context
+// propagation. Spans are exported over OTLP/HTTP when tracing is enabled and
+// dropped otherwise. The returned function flushes pending spans.
+func Setup(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("can't create otlp exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("can't build resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span named after op, the "Type.Method" constant every
+// layer already uses for its logs. While tracing is off altogether ctx is
+// returned as is, so callers pay nothing for it.
+func Start(ctx context.Context, op string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	spanCtx, span := otel.Tracer(instrumentationName).Start(ctx, op, opts...)
+	if !span.SpanContext().IsValid() {
+		return ctx, span
+	}
+	return spanCtx, span
+}
+
+// End ends span, marking it failed if *errp is set. It is meant to be
+// deferred with a pointer to a named error result:
+//
+//	ctx, span := tracing.Start(ctx, op)
+//	defer tracing.End(span, &err)
+func End(span trace.Span, errp *error) {
+	if errp != nil && *errp != nil {
+		RecordError(span, *errp)
+	}
+	span.End()
+}
+
+// RecordError marks span as failed because of err.
+func RecordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,209 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AtIasShrugged/antisocial/libs/logger"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// record installs a provider that keeps finished spans in memory.
+func record(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	return exporter
+}
+
+func spanByName(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	require.Failf(t, "span not found", "no span named %q in %v", name, spans)
+	return tracetest.SpanStub{}
+}
+
+func attr(s tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range s.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestMiddlewareContinuesIncomingTrace(t *testing.T) {
+	exporter := record(t)
+
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/things/:id", func(c echo.Context) error {
+		_, span := Start(c.Request().Context(), "ThingHandler.Get")
+		span.End()
+		return c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/things/42", nil)
+	req.Header.Set("traceparent", traceparent)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	server := spanByName(t, spans, "GET /things/:id")
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+	require.True(t, server.Parent.IsRemote())
+	require.Equal(t, int64(200), attr(server, "http.response.status_code").AsInt64())
+	require.Equal(t, "/things/:id", attr(server, "http.route").AsString())
+
+	child := spanByName(t, spans, "ThingHandler.Get")
+	require.Equal(t, server.SpanContext.SpanID(), child.Parent.SpanID())
+	require.Equal(t, server.SpanContext.TraceID(), child.SpanContext.TraceID())
+}
+
+func TestMiddlewareStatus(t *testing.T) {
+	exporter := record(t)
+
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/missing", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound)
+	})
+	e.GET("/broken", func(c echo.Context) error {
+		return errors.New("boom")
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/broken", nil))
+
+	spans := exporter.GetSpans()
+	missing := spanByName(t, spans, "GET /missing")
+	require.Equal(t, codes.Unset, missing.Status.Code)
+	require.Equal(t, int64(404), attr(missing, "http.response.status_code").AsInt64())
+
+	broken := spanByName(t, spans, "GET /broken")
+	require.Equal(t, codes.Error, broken.Status.Code)
+	require.Equal(t, int64(500), attr(broken, "http.response.status_code").AsInt64())
+	require.False(t, broken.Parent.IsValid())
+}
+
+func TestQueryTracer(t *testing.T) {
+	exporter := record(t)
+	tracer := QueryTracer{}
+
+	ctx, parent := Start(context.Background(), "PostRepository.GetByID")
+
+	qctx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "\n\tselect id FROM posts WHERE id = $1"})
+	tracer.TraceQueryEnd(qctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+
+	qctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "UPDATE posts SET body = $2"})
+	tracer.TraceQueryEnd(qctx, nil, pgx.TraceQueryEndData{Err: errors.New("deadlock detected")})
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	repo := spanByName(t, spans, "PostRepository.GetByID")
+	query := spanByName(t, spans, "db SELECT")
+	require.Equal(t, repo.SpanContext.SpanID(), query.Parent.SpanID())
+	require.Equal(t, "postgresql", attr(query, "db.system").AsString())
+	require.Equal(t, "\n\tselect id FROM posts WHERE id = $1", attr(query, "db.statement").AsString())
+	require.Equal(t, int64(1), attr(query, "db.rows_affected").AsInt64())
+
+	failed := spanByName(t, spans, "db UPDATE")
+	require.Equal(t, repo.SpanContext.SpanID(), failed.Parent.SpanID())
+	require.Equal(t, codes.Error, failed.Status.Code)
+	require.Equal(t, "deadlock detected", failed.Status.Description)
+}
+
+// A debug override for one repository shows the SQL of that repository
+// alone, through the same LevelHandler the application logs with.
+func TestQueryTracerLogsUnderRepositoryOp(t *testing.T) {
+	var buf bytes.Buffer
+	levels := logger.NewLevels(slog.LevelInfo)
+	log := slog.New(logger.NewLevelHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: levels}), levels))
+	tracer := QueryTracer{Log: log}
+
+	run := func(op string) {
+		ctx := sl.WithOp(context.Background(), op)
+		qctx := tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+		tracer.TraceQueryEnd(qctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+	}
+
+	run("PostRepository.GetByID")
+	require.Empty(t, buf.String())
+
+	levels.SetOverride("PostRepository", slog.LevelDebug, 0)
+	run("PollRepository.Vote")
+	require.Empty(t, buf.String())
+	run("PostRepository.GetByID")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "DEBUG", entry["level"])
+	require.Equal(t, "PostRepository.GetByID", entry[sl.OpKey])
+	require.Equal(t, "SELECT 1", entry["statement"])
+	require.Equal(t, float64(1), entry["rows_affected"])
+}
+
+func TestLogHandlerAddsTraceIDs(t *testing.T) {
+	record(t)
+
+	var buf bytes.Buffer
+	log := slog.New(NewLogHandler(slog.NewJSONHandler(&buf, nil))).With(slog.String("component", "test"))
+
+	ctx, span := Start(context.Background(), "Test.Op")
+	log.InfoContext(ctx, "inside")
+	span.End()
+	log.InfoContext(context.Background(), "outside")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var inside, outside map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &inside))
+	require.NoError(t, json.Unmarshal(lines[1], &outside))
+
+	require.Equal(t, span.SpanContext().TraceID().String(), inside["trace_id"])
+	require.Equal(t, span.SpanContext().SpanID().String(), inside["span_id"])
+	require.Equal(t, "test", inside["component"])
+	require.NotContains(t, outside, "trace_id")
+}
+
+func TestStartKeepsContextWhenTracingIsOff(t *testing.T) {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+
+	ctx := context.Background()
+	got, span := Start(ctx, "Test.Op")
+	span.End()
+
+	require.Equal(t, ctx, got)
+}
@@ -0,0 +1,152 @@
+package unfurl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+)
+
+var (
+	ErrTooManyRedirects = errors.New("too many redirects")
+	ErrBadStatus        = errors.New("unexpected response status")
+	ErrUnexpectedType   = errors.New("unexpected response content type")
+	ErrNoMetadata       = errors.New("page has no preview metadata")
+)
+
+type Config struct {
+	Timeout      time.Duration
+	MaxBodySize  int64
+	MaxRedirects int
+	UserAgent    string
+}
+
+// Fetcher downloads pages and extracts preview metadata from them.
+type Fetcher struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewFetcher returns a Fetcher that only connects to public addresses.
+func NewFetcher(cfg Config) *Fetcher {
+	return newFetcher(cfg, PublicOnly)
+}
+
+func newFetcher(cfg Config, policy AddrPolicy) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: cfg.Timeout,
+		Control: dialControl(policy),
+	}
+	transport := &http.Transport{
+		// No proxy: a proxy would make the dial-time address check useless.
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   cfg.Timeout,
+		ResponseHeaderTimeout: cfg.Timeout,
+		MaxIdleConns:          16,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &Fetcher{
+		cfg: cfg,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > cfg.MaxRedirects {
+					return ErrTooManyRedirects
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return fmt.Errorf("%w: redirect to %s", ErrForbiddenAddress, req.URL.Scheme)
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// Fetch retrieves rawURL and builds a preview from its OpenGraph, Twitter card
+// and oEmbed metadata, in that order of preference.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (models.LinkPreview, error) {
+	body, finalURL, err := f.get(ctx, rawURL, "text/html,application/xhtml+xml", "text/html", "application/xhtml+xml")
+	if err != nil {
+		return models.LinkPreview{}, err
+	}
+
+	meta := parseHTML(body, finalURL)
+
+	if meta.oEmbedURL != "" && (meta.title() == "" || meta.image() == "") {
+		if oembed, err := f.fetchOEmbed(ctx, meta.oEmbedURL); err == nil {
+			meta.oEmbed = oembed
+		}
+	}
+
+	preview := meta.preview(rawURL)
+	if preview.Title == "" && preview.Description == "" && preview.ImageURL == "" {
+		return models.LinkPreview{}, ErrNoMetadata
+	}
+	return preview, nil
+}
+
+func (f *Fetcher) fetchOEmbed(ctx context.Context, endpoint string) (map[string]any, error) {
+	body, _, err := f.get(ctx, endpoint, "application/json", "application/json", "text/javascript", "application/json+oembed")
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("bad oembed json: %w", err)
+	}
+	return data, nil
+}
+
+// get performs a size-capped GET and returns the body along with the URL the
+// redirect chain ended at.
+func (f *Fetcher) get(ctx context.Context, rawURL, accept string, allowedTypes ...string) ([]byte, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't build request: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+	if f.cfg.UserAgent != "" {
+		req.Header.Set("User-Agent", f.cfg.UserAgent)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%w: %s", ErrBadStatus, resp.Status)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	allowed := false
+	for _, t := range allowedTypes {
+		if mediaType == t {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnexpectedType, mediaType)
+	}
+
+	// Metadata lives in <head>, so a page cut off at the cap is still useful.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.cfg.MaxBodySize))
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't read body: %w", err)
+	}
+
+	return body, resp.Request.URL, nil
+}
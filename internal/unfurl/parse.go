@@ -0,0 +1,166 @@
+package unfurl
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AtIasShrugged/antisocial/internal/domain/models"
+	"golang.org/x/net/html"
+)
+
+const (
+	maxTitleLen       = 300
+	maxDescriptionLen = 1000
+)
+
+type pageMeta struct {
+	base      *url.URL
+	og        map[string]string
+	twitter   map[string]string
+	docTitle  string
+	describe  string
+	oEmbedURL string
+	oEmbed    map[string]any
+}
+
+// parseHTML collects metadata from <meta>, <title> and oEmbed discovery
+// <link> tags. It stops at <body> since nothing relevant lives there.
+func parseHTML(body []byte, base *url.URL) *pageMeta {
+	meta := &pageMeta{
+		base:    base,
+		og:      map[string]string{},
+		twitter: map[string]string{},
+	}
+
+	z := html.NewTokenizer(bytes.NewReader(body))
+	inTitle := false
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return meta
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "body":
+				return meta
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "meta":
+				meta.addMeta(attrs(tok))
+			case "link":
+				a := attrs(tok)
+				if strings.EqualFold(a["rel"], "alternate") && a["type"] == "application/json+oembed" && meta.oEmbedURL == "" {
+					meta.oEmbedURL = meta.resolve(a["href"])
+				}
+			}
+		case html.TextToken:
+			if inTitle && meta.docTitle == "" {
+				meta.docTitle = strings.TrimSpace(string(z.Text()))
+			}
+		case html.EndTagToken:
+			if tok := z.Token(); tok.Data == "title" {
+				inTitle = false
+			}
+		}
+	}
+}
+
+func attrs(tok html.Token) map[string]string {
+	m := make(map[string]string, len(tok.Attr))
+	for _, a := range tok.Attr {
+		m[strings.ToLower(a.Key)] = a.Val
+	}
+	return m
+}
+
+func (m *pageMeta) addMeta(a map[string]string) {
+	content := strings.TrimSpace(a["content"])
+	if content == "" {
+		return
+	}
+	key := strings.ToLower(a["property"])
+	if key == "" {
+		key = strings.ToLower(a["name"])
+	}
+
+	switch {
+	case strings.HasPrefix(key, "og:"):
+		if _, ok := m.og[key]; !ok {
+			m.og[key] = content
+		}
+	case strings.HasPrefix(key, "twitter:"):
+		if _, ok := m.twitter[key]; !ok {
+			m.twitter[key] = content
+		}
+	case key == "description" && m.describe == "":
+		m.describe = content
+	}
+}
+
+func (m *pageMeta) oEmbedString(key string) string {
+	if v, ok := m.oEmbed[key].(string); ok {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+func (m *pageMeta) title() string {
+	return firstNonEmpty(m.og["og:title"], m.twitter["twitter:title"], m.oEmbedString("title"), m.docTitle)
+}
+
+func (m *pageMeta) image() string {
+	return m.resolve(firstNonEmpty(
+		m.og["og:image"], m.og["og:image:url"], m.og["og:image:secure_url"],
+		m.twitter["twitter:image"], m.twitter["twitter:image:src"],
+		m.oEmbedString("thumbnail_url"),
+	))
+}
+
+func (m *pageMeta) preview(rawURL string) models.LinkPreview {
+	return models.LinkPreview{
+		URL:         rawURL,
+		Title:       clean(m.title(), maxTitleLen),
+		Description: clean(firstNonEmpty(m.og["og:description"], m.twitter["twitter:description"], m.describe), maxDescriptionLen),
+		ImageURL:    m.image(),
+		SiteName:    clean(firstNonEmpty(m.og["og:site_name"], m.oEmbedString("provider_name")), maxTitleLen),
+	}
+}
+
+// resolve makes ref absolute and discards anything that is not http(s).
+func (m *pageMeta) resolve(ref string) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if m.base != nil {
+		u = m.base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+	return u.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func clean(s string, limit int) string {
+	s = strings.Join(strings.Fields(strings.ToValidUTF8(s, "")), " ")
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
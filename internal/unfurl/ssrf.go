@@ -0,0 +1,73 @@
+package unfurl
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"syscall"
+)
+
+var ErrForbiddenAddress = errors.New("destination address is not allowed")
+
+// blockedPrefixes are ranges a server-side fetch must never reach: loopback,
+// private networks, link-local (cloud metadata endpoints live there), CGNAT,
+// multicast and the various reserved/documentation blocks.
+var blockedPrefixes = mustPrefixes(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"100::/64",
+	"2001:db8::/32",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+func mustPrefixes(cidrs ...string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefixes = append(prefixes, netip.MustParsePrefix(cidr))
+	}
+	return prefixes
+}
+
+// AddrPolicy decides whether a connection to addr may be opened.
+type AddrPolicy func(addr netip.AddrPort) error
+
+// PublicOnly rejects every address that is not globally routable unicast.
+func PublicOnly(addr netip.AddrPort) error {
+	ip := addr.Addr().Unmap()
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+		}
+	}
+	return nil
+}
+
+// dialControl enforces policy on the resolved address of every outgoing
+// connection. Checking here rather than on the URL host covers DNS names that
+// point inside, DNS rebinding, and every hop of a redirect chain.
+func dialControl(policy AddrPolicy) func(network, address string, _ syscall.RawConn) error {
+	return func(network, address string, _ syscall.RawConn) error {
+		addr, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+		}
+		return policy(addr)
+	}
+}
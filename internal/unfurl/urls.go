@@ -0,0 +1,67 @@
+package unfurl
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// MaxURLsPerPost caps how many links of a single post get unfurled.
+const MaxURLsPerPost = 3
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"'\x60]+`)
+
+// ExtractURLs returns the distinct http(s) URLs found in body, normalised and
+// in order of appearance, at most MaxURLsPerPost of them.
+func ExtractURLs(body string) []string {
+	var urls []string
+	seen := map[string]bool{}
+
+	for _, match := range urlPattern.FindAllString(body, -1) {
+		normalized, ok := Normalize(trimTrailing(match))
+		if !ok || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		urls = append(urls, normalized)
+		if len(urls) == MaxURLsPerPost {
+			break
+		}
+	}
+
+	return urls
+}
+
+// Normalize lowercases scheme and host and drops the fragment so that the
+// same page shares a single cache entry.
+func Normalize(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return "", false
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String(), true
+}
+
+// trimTrailing strips punctuation that usually ends the sentence rather than
+// the URL, keeping a closing parenthesis when the URL opened one.
+func trimTrailing(s string) string {
+	for len(s) > 0 {
+		last := s[len(s)-1]
+		switch {
+		case strings.IndexByte(".,;:!?'\"", last) >= 0:
+			s = s[:len(s)-1]
+		case last == ')' && strings.Count(s, "(") < strings.Count(s, ")"):
+			s = s[:len(s)-1]
+		default:
+			return s
+		}
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package slogmulti
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// Handler fans every record out to several handlers, each of which applies
+// its own level, so a terse console and a verbose file can be fed at once.
+type Handler struct {
+	handlers []slog.Handler
+}
+
+func Fanout(handlers ...slog.Handler) *Handler {
+	return &Handler{handlers: handlers}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, next := range h.handlers {
+		if next.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle passes r to every handler enabled for its level. A failing handler
+// doesn't keep the record from the others; all errors are returned joined.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, next := range h.handlers {
+		if !next.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := next.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, next := range h.handlers {
+		handlers[i] = next.WithAttrs(attrs)
+	}
+	return Fanout(handlers...)
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, next := range h.handlers {
+		handlers[i] = next.WithGroup(name)
+	}
+	return Fanout(handlers...)
+}
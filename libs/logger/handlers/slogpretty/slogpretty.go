@@ -1,93 +1,235 @@
 package slogpretty
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
-	stdLog "log"
 	"log/slog"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/fatih/color"
 )
 
+const timeFormat = "[15:04:05.000]"
+
 type PrettyHandlerOptions struct {
 	SlogOpts *slog.HandlerOptions
 }
 
+// PrettyHandler writes records for humans: a colored header line with the
+// time, level and message, followed by the attributes as indented JSON, with
+// groups rendered as nested objects.
 type PrettyHandler struct {
-	opts PrettyHandlerOptions
-	slog.Handler
-	l     *stdLog.Logger
-	attrs []slog.Attr
+	opts slog.HandlerOptions
+	// groups are the groups opened with WithGroup, outermost first.
+	groups []string
+	// attrs are the attributes added with WithAttrs, each with the groups
+	// that were open when it was added.
+	attrs []groupedAttr
+
+	mu  *sync.Mutex
+	out io.Writer
+}
+
+type groupedAttr struct {
+	groups []string
+	attr   slog.Attr
 }
 
 func (opts PrettyHandlerOptions) NewPrettyHandler(out io.Writer) *PrettyHandler {
-	return &PrettyHandler{
-		Handler: slog.NewJSONHandler(out, opts.SlogOpts),
-		l:       stdLog.New(out, "", 0),
+	h := &PrettyHandler{
+		mu:  &sync.Mutex{},
+		out: out,
+	}
+	if opts.SlogOpts != nil {
+		h.opts = *opts.SlogOpts
+	}
+	return h
+}
+
+func (h *PrettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
 	}
+	return level >= minLevel
 }
 
 func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
-	level := r.Level.String() + ":"
+	var header []string
+
+	if !r.Time.IsZero() {
+		if a, ok := h.replace(nil, slog.Time(slog.TimeKey, r.Time)); ok {
+			if a.Value.Kind() == slog.KindTime {
+				header = append(header, a.Value.Time().Format(timeFormat))
+			} else {
+				header = append(header, a.Value.String())
+			}
+		}
+	}
+
+	if a, ok := h.replace(nil, slog.Any(slog.LevelKey, r.Level)); ok {
+		header = append(header, colorLevel(r.Level, a.Value.String()+":"))
+	}
 
-	switch r.Level {
-	case slog.LevelDebug:
-		level = color.MagentaString(level)
-	case slog.LevelInfo:
-		level = color.BlueString(level)
-	case slog.LevelWarn:
-		level = color.YellowString(level)
-	case slog.LevelError:
-		level = color.RedString(level)
+	if a, ok := h.replace(nil, slog.String(slog.MessageKey, r.Message)); ok {
+		header = append(header, color.CyanString(a.Value.String()))
 	}
 
-	fields := make(map[string]any, r.NumAttrs())
+	fields := make(map[string]any)
 
+	if h.opts.AddSource && r.PC != 0 {
+		if a, ok := h.replace(nil, slog.Any(slog.SourceKey, source(r.PC))); ok {
+			if src, isSource := a.Value.Any().(*slog.Source); isSource {
+				fields[a.Key] = fmt.Sprintf("%s:%d", src.File, src.Line)
+			} else {
+				fields[a.Key] = value(a.Value)
+			}
+		}
+	}
+
+	for _, ga := range h.attrs {
+		h.add(fields, ga.groups, ga.attr)
+	}
 	r.Attrs(func(a slog.Attr) bool {
-		fields[a.Key] = a.Value.Any()
+		h.add(fields, h.groups, a)
 		return true
 	})
 
-	for _, a := range h.attrs {
-		fields[a.Key] = a.Value.Any()
+	var buf bytes.Buffer
+	for i, part := range header {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(part)
 	}
-
-	var b []byte
-	var err error
-
 	if len(fields) > 0 {
-		b, err = json.MarshalIndent(fields, "", "  ")
+		b, err := json.MarshalIndent(fields, "", "  ")
 		if err != nil {
 			return err
 		}
+		buf.WriteByte(' ')
+		buf.WriteString(color.WhiteString(string(b)))
 	}
+	buf.WriteByte('\n')
 
-	timeStr := r.Time.Format("[15:05:05.000]")
-	msg := color.CyanString(r.Message)
-
-	h.l.Println(
-		timeStr,
-		level,
-		msg,
-		color.WhiteString(string(b)),
-	)
-
-	return nil
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.out.Write(buf.Bytes())
+	return err
 }
 
 func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &PrettyHandler{
-		Handler: h.Handler,
-		l:       h.l,
-		attrs:   attrs,
+	if len(attrs) == 0 {
+		return h
+	}
+
+	h2 := h.clone()
+	for _, a := range attrs {
+		h2.attrs = append(h2.attrs, groupedAttr{groups: h.groups, attr: a})
 	}
+	return h2
 }
 
 func (h *PrettyHandler) WithGroup(name string) slog.Handler {
-	// TODO: implement
-	return &PrettyHandler{
-		Handler: h.Handler,
-		l:       h.l,
+	if name == "" {
+		return h
+	}
+
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
+	return h2
+}
+
+// clone copies h with capacity-clipped slices, so that appending to the copy
+// never writes into the backing array of h or its other derivatives.
+func (h *PrettyHandler) clone() *PrettyHandler {
+	h2 := *h
+	h2.groups = slices.Clip(h.groups)
+	h2.attrs = slices.Clip(h.attrs)
+	return &h2
+}
+
+// add resolves a and stores it in fields under groups. Group maps are created
+// only once a non-empty attribute lands in them, so empty groups vanish.
+func (h *PrettyHandler) add(fields map[string]any, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if a.Key != "" {
+			groups = append(slices.Clip(groups), a.Key)
+		}
+		for _, ga := range attrs {
+			h.add(fields, groups, ga)
+		}
+		return
+	}
+
+	a, ok := h.replace(groups, a)
+	if !ok {
+		return
+	}
+
+	m := fields
+	for _, g := range groups {
+		next, ok := m[g].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			m[g] = next
+		}
+		m = next
+	}
+	m[a.Key] = value(a.Value)
+}
+
+// replace applies ReplaceAttr and reports whether the attribute should be
+// kept.
+func (h *PrettyHandler) replace(groups []string, a slog.Attr) (slog.Attr, bool) {
+	if h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	return a, !a.Equal(slog.Attr{})
+}
+
+func value(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+	}
+	return v.Any()
+}
+
+func colorLevel(level slog.Level, s string) string {
+	switch {
+	case level < slog.LevelInfo:
+		return color.MagentaString(s)
+	case level < slog.LevelWarn:
+		return color.BlueString(s)
+	case level < slog.LevelError:
+		return color.YellowString(s)
+	default:
+		return color.RedString(s)
+	}
+}
+
+func source(pc uintptr) *slog.Source {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return &slog.Source{
+		Function: frame.Function,
+		File:     frame.File,
+		Line:     frame.Line,
 	}
 }
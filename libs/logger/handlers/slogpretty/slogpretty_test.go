@@ -0,0 +1,119 @@
+package slogpretty
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	color.NoColor = true
+}
+
+// parse turns one rendered record back into the map slogtest expects.
+func parse(t *testing.T, line string) map[string]any {
+	t.Helper()
+
+	m := make(map[string]any)
+	line = strings.TrimSuffix(line, "\n")
+
+	if strings.HasPrefix(line, "[") {
+		end := strings.Index(line, "] ")
+		require.NotEqual(t, -1, end, line)
+		m[slog.TimeKey] = line[:end+1]
+		line = line[end+2:]
+	}
+
+	level, rest, ok := strings.Cut(line, ": ")
+	require.True(t, ok, line)
+	m[slog.LevelKey] = level
+
+	msg, fields, _ := strings.Cut(rest, " {\n")
+	m[slog.MessageKey] = msg
+	if fields != "" {
+		require.NoError(t, json.Unmarshal([]byte("{\n"+fields), &m))
+	}
+	return m
+}
+
+func TestSlogtest(t *testing.T) {
+	var buf bytes.Buffer
+
+	slogtest.Run(t, func(*testing.T) slog.Handler {
+		buf.Reset()
+		return PrettyHandlerOptions{}.NewPrettyHandler(&buf)
+	}, func(t *testing.T) map[string]any {
+		return parse(t, buf.String())
+	})
+}
+
+func TestTimeFormat(t *testing.T) {
+	var buf bytes.Buffer
+	h := PrettyHandlerOptions{}.NewPrettyHandler(&buf)
+
+	r := slog.NewRecord(time.Date(2024, 1, 2, 13, 45, 7, 89e6, time.UTC), slog.LevelInfo, "hi", 0)
+	require.NoError(t, h.Handle(context.Background(), r))
+	require.Equal(t, "[13:45:07.089] INFO: hi\n", buf.String())
+}
+
+func TestLevelFiltering(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(PrettyHandlerOptions{
+		SlogOpts: &slog.HandlerOptions{Level: slog.LevelWarn},
+	}.NewPrettyHandler(&buf))
+
+	log.Info("dropped")
+	log.Warn("kept")
+	require.NotContains(t, buf.String(), "dropped")
+	require.Contains(t, buf.String(), "WARN: kept")
+}
+
+func TestWithAttrsAppends(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(PrettyHandlerOptions{
+		SlogOpts: &slog.HandlerOptions{Level: slog.LevelDebug},
+	}.NewPrettyHandler(&buf))
+
+	log.With("a", 1).With("b", 2).WithGroup("g").With("c", 3).Debug("msg", "d", 4)
+
+	m := parse(t, buf.String())
+	require.Equal(t, "DEBUG", m[slog.LevelKey])
+	require.Equal(t, float64(1), m["a"])
+	require.Equal(t, float64(2), m["b"])
+	require.Equal(t, map[string]any{"c": float64(3), "d": float64(4)}, m["g"])
+}
+
+func TestReplaceAttrAndSource(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(PrettyHandlerOptions{
+		SlogOpts: &slog.HandlerOptions{
+			AddSource: true,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey || a.Key == "password" {
+					return slog.Attr{}
+				}
+				if len(groups) == 1 && groups[0] == "user" && a.Key == "name" {
+					return slog.String("name", strings.ToUpper(a.Value.String()))
+				}
+				return a
+			},
+		},
+	}.NewPrettyHandler(&buf))
+
+	log.Info("login", slog.Group("user", "name", "ann", "password", "hunter2"))
+
+	out := buf.String()
+	require.True(t, strings.HasPrefix(out, "INFO: login {"), out)
+
+	m := parse(t, out)
+	require.Equal(t, map[string]any{"name": "ANN"}, m["user"])
+	require.Contains(t, m[slog.SourceKey], "slogpretty_test.go:")
+}
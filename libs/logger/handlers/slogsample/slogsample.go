@@ -0,0 +1,98 @@
+package slogsample
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	// Tick is the window counts are kept for.
+	Tick time.Duration
+	// First records with the same level and message are logged per tick...
+	First int
+	// ...and after that only every Thereafter-th. Zero drops the rest.
+	Thereafter int
+	// MaxLevel is the most severe level that is sampled. More severe records
+	// are always logged.
+	MaxLevel slog.Leveler
+}
+
+// Handler thins out repetitive records, such as per-request debug lines,
+// before passing them to the next handler. Records are considered the same
+// when their level and message match; attributes are ignored.
+type Handler struct {
+	next    slog.Handler
+	opts    Options
+	counter *counter
+}
+
+func NewHandler(next slog.Handler, opts Options) *Handler {
+	if opts.MaxLevel == nil {
+		opts.MaxLevel = slog.LevelDebug
+	}
+	return &Handler{
+		next:    next,
+		opts:    opts,
+		counter: &counter{now: time.Now, counts: make(map[key]int)},
+	}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level <= h.opts.MaxLevel.Level() && !h.keep(r) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{next: h.next.WithAttrs(attrs), opts: h.opts, counter: h.counter}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), opts: h.opts, counter: h.counter}
+}
+
+func (h *Handler) keep(r slog.Record) bool {
+	n := h.counter.inc(key{level: r.Level, msg: r.Message}, h.opts.Tick)
+	if n <= h.opts.First {
+		return true
+	}
+	return h.opts.Thereafter > 0 && (n-h.opts.First)%h.opts.Thereafter == 0
+}
+
+type key struct {
+	level slog.Level
+	msg   string
+}
+
+// counter is shared by a handler and everything derived from it, so that
+// loggers with different attributes still count towards the same records.
+type counter struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	window time.Time
+	counts map[key]int
+}
+
+// inc counts one more record for k in the current window and returns the
+// count. All counts start over when the window ends, which also keeps the map
+// from growing without bound.
+func (c *counter) inc(k key, tick time.Duration) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if now.Sub(c.window) >= tick {
+		c.window = now
+		clear(c.counts)
+	}
+	c.counts[k]++
+	return c.counts[k]
+}
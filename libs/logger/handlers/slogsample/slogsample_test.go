@@ -0,0 +1,58 @@
+package slogsample
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), Options{
+		Tick:       time.Second,
+		First:      2,
+		Thereafter: 3,
+	})
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	h.counter.now = func() time.Time { return now }
+	log := slog.New(h)
+
+	for i := 0; i < 8; i++ {
+		// Different attributes still count as the same record.
+		log.With("i", i).Debug("request handled")
+	}
+	log.Debug("something else")
+	for i := 0; i < 3; i++ {
+		log.Info("request handled")
+	}
+
+	out := buf.String()
+	// 1st, 2nd, then every 3rd after them: the 5th and the 8th.
+	require.Equal(t, 4, strings.Count(out, "level=DEBUG msg=\"request handled\""))
+	require.Contains(t, out, "i=4")
+	require.Contains(t, out, "i=7")
+	require.Contains(t, out, "something else")
+	require.Equal(t, 3, strings.Count(out, "level=INFO"))
+
+	now = now.Add(time.Second)
+	buf.Reset()
+	log.Debug("request handled")
+	require.Contains(t, buf.String(), "request handled")
+}
+
+func TestSamplingDropsRestWithoutThereafter(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), Options{
+		Tick:  time.Hour,
+		First: 1,
+	}))
+
+	for i := 0; i < 5; i++ {
+		log.Debug("noisy")
+	}
+	require.Equal(t, 1, strings.Count(buf.String(), "noisy"))
+}
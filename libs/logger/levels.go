@@ -0,0 +1,230 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+)
+
+// Levels holds the minimum level of the application's logs: a global level
+// and overrides for single components, such as "PostRepository" or
+// "PostRepository.Create". A component is matched against the "op" attribute
+// of a record, either exactly or by the part before the dot. Every change can
+// be given a TTL after which it reverts on its own, so that debug logging
+// turned on during an incident doesn't outlive it.
+//
+// Levels is a slog.Leveler reporting the most verbose level in effect, which
+// makes it suitable as the level of the handlers behind a LevelHandler.
+type Levels struct {
+	base slog.Level
+	now  func() time.Time
+
+	mu        sync.RWMutex
+	global    setting
+	overrides map[string]setting
+	// min caches the most verbose level in effect.
+	min slog.LevelVar
+	// generation tells timers apart from the changes that superseded them.
+	generation uint64
+}
+
+type setting struct {
+	level      slog.Level
+	expiresAt  time.Time
+	generation uint64
+}
+
+// LevelState describes a level and when it reverts.
+type LevelState struct {
+	Level     slog.Level `json:"level"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type LevelsSnapshot struct {
+	LevelState
+	Overrides map[string]LevelState `json:"overrides"`
+}
+
+func NewLevels(base slog.Level) *Levels {
+	l := &Levels{
+		base:      base,
+		now:       time.Now,
+		global:    setting{level: base},
+		overrides: make(map[string]setting),
+	}
+	l.min.Set(base)
+	return l
+}
+
+func (l *Levels) Level() slog.Level {
+	return l.min.Level()
+}
+
+// SetGlobal sets the global level. With a positive ttl the level returns to
+// the configured one after ttl.
+func (l *Levels) SetGlobal(level slog.Level, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.global = l.newSetting(level, ttl)
+	if ttl > 0 {
+		gen := l.global.generation
+		time.AfterFunc(ttl, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.global.generation == gen {
+				l.global = setting{level: l.base}
+				l.updateMin()
+			}
+		})
+	}
+	l.updateMin()
+}
+
+// SetOverride sets the level of one component. With a positive ttl the
+// override is removed after ttl.
+func (l *Levels) SetOverride(component string, level slog.Level, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := l.newSetting(level, ttl)
+	l.overrides[component] = s
+	if ttl > 0 {
+		time.AfterFunc(ttl, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if cur, ok := l.overrides[component]; ok && cur.generation == s.generation {
+				delete(l.overrides, component)
+				l.updateMin()
+			}
+		})
+	}
+	l.updateMin()
+}
+
+// RemoveOverride drops the override of component and reports whether there
+// was one.
+func (l *Levels) RemoveOverride(component string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.overrides[component]; !ok {
+		return false
+	}
+	delete(l.overrides, component)
+	l.updateMin()
+	return true
+}
+
+func (l *Levels) Snapshot() LevelsSnapshot {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	snapshot := LevelsSnapshot{
+		LevelState: l.global.state(),
+		Overrides:  make(map[string]LevelState, len(l.overrides)),
+	}
+	for component, s := range l.overrides {
+		snapshot.Overrides[component] = s.state()
+	}
+	return snapshot
+}
+
+// levelFor returns the level in effect for a record with the given op.
+func (l *Levels) levelFor(op string) slog.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if len(l.overrides) > 0 && op != "" {
+		if s, ok := l.overrides[op]; ok {
+			return s.level
+		}
+		if component, _, found := strings.Cut(op, "."); found {
+			if s, ok := l.overrides[component]; ok {
+				return s.level
+			}
+		}
+	}
+	return l.global.level
+}
+
+func (l *Levels) newSetting(level slog.Level, ttl time.Duration) setting {
+	l.generation++
+	s := setting{level: level, generation: l.generation}
+	if ttl > 0 {
+		s.expiresAt = l.now().Add(ttl)
+	}
+	return s
+}
+
+func (l *Levels) updateMin() {
+	lowest := l.global.level
+	for _, s := range l.overrides {
+		lowest = min(lowest, s.level)
+	}
+	l.min.Set(lowest)
+}
+
+func (s setting) state() LevelState {
+	state := LevelState{Level: s.level}
+	if !s.expiresAt.IsZero() {
+		expiresAt := s.expiresAt
+		state.ExpiresAt = &expiresAt
+	}
+	return state
+}
+
+// LevelHandler drops records below the level Levels has for their op. The
+// handler it wraps should use the Levels as its level, so that it lets
+// through everything some override may want.
+type LevelHandler struct {
+	next   slog.Handler
+	levels *Levels
+	// op is the op bound with WithAttrs, if any.
+	op string
+}
+
+func NewLevelHandler(next slog.Handler, levels *Levels) *LevelHandler {
+	return &LevelHandler{next: next, levels: levels}
+}
+
+func (h *LevelHandler) Levels() *Levels {
+	return h.levels
+}
+
+func (h *LevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.levels.Level() && h.next.Enabled(ctx, level)
+}
+
+func (h *LevelHandler) Handle(ctx context.Context, r slog.Record) error {
+	op := h.op
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == sl.OpKey {
+			op = a.Value.String()
+			return false
+		}
+		return true
+	})
+	if r.Level < h.levels.levelFor(op) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *LevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := &LevelHandler{next: h.next.WithAttrs(attrs), levels: h.levels, op: h.op}
+	for _, a := range attrs {
+		if a.Key == sl.OpKey {
+			h2.op = a.Value.String()
+		}
+	}
+	return h2
+}
+
+func (h *LevelHandler) WithGroup(name string) slog.Handler {
+	return &LevelHandler{next: h.next.WithGroup(name), levels: h.levels, op: h.op}
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/stretchr/testify/require"
+)
+
+func newLeveled(base slog.Level) (*slog.Logger, *Levels, *bytes.Buffer) {
+	var buf bytes.Buffer
+	levels := NewLevels(base)
+	h := NewLevelHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: levels}), levels)
+	return slog.New(h), levels, &buf
+}
+
+func TestLevelsOverrideByComponent(t *testing.T) {
+	log, levels, buf := newLeveled(slog.LevelInfo)
+
+	levels.SetOverride("PostRepository", slog.LevelDebug, 0)
+
+	log.Debug("repo detail", sl.Op("PostRepository.Create"))
+	log.With(sl.Op("PostRepository.GetByID")).Debug("bound op detail")
+	log.Debug("service detail", sl.Op("PostService.Create"))
+	log.Debug("no op")
+	log.Info("still logged", sl.Op("PostService.Create"))
+
+	out := buf.String()
+	require.Contains(t, out, "repo detail")
+	require.Contains(t, out, "bound op detail")
+	require.NotContains(t, out, "service detail")
+	require.NotContains(t, out, "no op")
+	require.Contains(t, out, "still logged")
+}
+
+func TestLevelsOverrideQuietsComponent(t *testing.T) {
+	log, levels, buf := newLeveled(slog.LevelDebug)
+
+	levels.SetOverride("PostRepository.Create", slog.LevelError, 0)
+
+	log.Warn("hidden", sl.Op("PostRepository.Create"))
+	log.Warn("shown", sl.Op("PostRepository.GetByID"))
+
+	require.NotContains(t, buf.String(), "hidden")
+	require.Contains(t, buf.String(), "shown")
+}
+
+func TestLevelsRevertAfterTTL(t *testing.T) {
+	log, levels, buf := newLeveled(slog.LevelInfo)
+
+	levels.SetGlobal(slog.LevelDebug, 20*time.Millisecond)
+	levels.SetOverride("PostRepository", slog.LevelDebug, 20*time.Millisecond)
+
+	snapshot := levels.Snapshot()
+	require.Equal(t, slog.LevelDebug, snapshot.Level)
+	require.NotNil(t, snapshot.ExpiresAt)
+	require.Contains(t, snapshot.Overrides, "PostRepository")
+
+	log.Debug("during")
+	require.Eventually(t, func() bool {
+		s := levels.Snapshot()
+		return s.Level == slog.LevelInfo && len(s.Overrides) == 0
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, slog.LevelInfo, levels.Level())
+
+	log.Debug("after", sl.Op("PostRepository.Create"))
+	require.Contains(t, buf.String(), "during")
+	require.NotContains(t, buf.String(), "after")
+}
+
+func TestLevelsNewerChangeOutlivesOldTTL(t *testing.T) {
+	levels := NewLevels(slog.LevelInfo)
+
+	levels.SetGlobal(slog.LevelDebug, 10*time.Millisecond)
+	levels.SetGlobal(slog.LevelWarn, time.Hour)
+
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, slog.LevelWarn, levels.Snapshot().Level)
+}
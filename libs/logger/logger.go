@@ -0,0 +1,150 @@
+// Package logger builds the application's slog handler from configuration.
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/libs/logger/handlers/slogmulti"
+	"github.com/AtIasShrugged/antisocial/libs/logger/handlers/slogpretty"
+	"github.com/AtIasShrugged/antisocial/libs/logger/handlers/slogsample"
+	"github.com/AtIasShrugged/antisocial/libs/logger/redact"
+	"github.com/AtIasShrugged/antisocial/libs/logger/rotate"
+)
+
+const (
+	EnvLocal = "local"
+	EnvDev   = "dev"
+	EnvProd  = "prod"
+)
+
+var (
+	ErrUnknownEnv   = errors.New("unknown env")
+	ErrUnknownLevel = errors.New("unknown log level")
+)
+
+type Config struct {
+	// Level overrides the env's default: debug for local and dev, info for
+	// prod.
+	Level    string         `yaml:"level"`
+	File     FileConfig     `yaml:"file"`
+	Sampling SamplingConfig `yaml:"sampling"`
+	Redact   RedactConfig   `yaml:"redact"`
+}
+
+// FileConfig adds a JSON log file next to the console output when Path is
+// set.
+type FileConfig struct {
+	Path       string        `yaml:"path"`
+	MaxSize    int64         `yaml:"max_size" env-default:"104857600"`
+	MaxAge     time.Duration `yaml:"max_age" env-default:"24h"`
+	MaxBackups int           `yaml:"max_backups" env-default:"7"`
+	Compress   bool          `yaml:"compress" env-default:"true"`
+}
+
+type SamplingConfig struct {
+	Enabled    bool          `yaml:"enabled" env-default:"false"`
+	Tick       time.Duration `yaml:"tick" env-default:"1s"`
+	First      int           `yaml:"first" env-default:"10"`
+	Thereafter int           `yaml:"thereafter" env-default:"100"`
+	// MaxLevel is the most severe level that is sampled.
+	MaxLevel string `yaml:"max_level" env-default:"debug"`
+}
+
+// RedactConfig adds to the keys and patterns redact masks by default.
+// Patterns are regular expressions whose matches are masked.
+type RedactConfig struct {
+	Keys     []string `yaml:"keys"`
+	Patterns []string `yaml:"patterns"`
+}
+
+// NewHandler builds the handler for env: pretty console output locally and
+// JSON elsewhere, fanned out to a rotating JSON file and sampled when cfg asks
+// for it. Sensitive data is redacted before any output sees a record. Its
+// levels can be changed at runtime through Levels. The returned function
+// closes the log file.
+func NewHandler(env string, cfg Config) (*LevelHandler, func() error, error) {
+	return newHandler(env, cfg, os.Stdout)
+}
+
+func newHandler(env string, cfg Config, console io.Writer) (*LevelHandler, func() error, error) {
+	var level slog.Level
+	switch env {
+	case EnvLocal, EnvDev:
+		level = slog.LevelDebug
+	case EnvProd:
+		level = slog.LevelInfo
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnknownEnv, env)
+	}
+	if cfg.Level != "" {
+		var err error
+		if level, err = parseLevel(cfg.Level); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	patterns, err := redact.CompilePatterns(cfg.Redact.Patterns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	levels := NewLevels(level)
+	opts := &slog.HandlerOptions{Level: levels}
+
+	var handler slog.Handler
+	if env == EnvLocal {
+		handler = slogpretty.PrettyHandlerOptions{SlogOpts: opts}.NewPrettyHandler(console)
+	} else {
+		handler = slog.NewJSONHandler(console, opts)
+	}
+
+	closeFn := func() error { return nil }
+	if cfg.File.Path != "" {
+		file, err := rotate.Open(cfg.File.Path, rotate.Options{
+			MaxSize:    cfg.File.MaxSize,
+			MaxAge:     cfg.File.MaxAge,
+			MaxBackups: cfg.File.MaxBackups,
+			Compress:   cfg.File.Compress,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		handler = slogmulti.Fanout(handler, slog.NewJSONHandler(file, opts))
+		closeFn = file.Close
+	}
+
+	handler = redact.NewHandler(handler, redact.Options{
+		Keys:     append(slices.Clone(redact.DefaultKeys), cfg.Redact.Keys...),
+		Patterns: append(redact.DefaultPatterns(), patterns...),
+	})
+
+	if cfg.Sampling.Enabled {
+		maxLevel, err := parseLevel(cfg.Sampling.MaxLevel)
+		if err != nil {
+			closeFn()
+			return nil, nil, err
+		}
+		handler = slogsample.NewHandler(handler, slogsample.Options{
+			Tick:       cfg.Sampling.Tick,
+			First:      cfg.Sampling.First,
+			Thereafter: cfg.Sampling.Thereafter,
+			MaxLevel:   maxLevel,
+		})
+	}
+
+	return NewLevelHandler(handler, levels), closeFn, nil
+}
+
+func parseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownLevel, s)
+	}
+	return level, nil
+}
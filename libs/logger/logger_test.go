@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AtIasShrugged/antisocial/libs/logger/redact"
+	"github.com/AtIasShrugged/antisocial/libs/logger/sl"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHandlerUnknownEnv(t *testing.T) {
+	_, _, err := NewHandler("staging", Config{})
+	require.ErrorIs(t, err, ErrUnknownEnv)
+}
+
+func TestNewHandlerUnknownLevel(t *testing.T) {
+	_, _, err := NewHandler(EnvProd, Config{Level: "loud"})
+	require.ErrorIs(t, err, ErrUnknownLevel)
+}
+
+func TestNewHandlerLevels(t *testing.T) {
+	cases := []struct {
+		env   string
+		level string
+		debug bool
+	}{
+		{env: EnvLocal, debug: true},
+		{env: EnvDev, debug: true},
+		{env: EnvProd, debug: false},
+		{env: EnvProd, level: "debug", debug: true},
+		{env: EnvDev, level: "warn", debug: false},
+	}
+	for _, tc := range cases {
+		h, closeLog, err := newHandler(tc.env, Config{Level: tc.level}, &bytes.Buffer{})
+		require.NoError(t, err)
+		require.Equal(t, tc.debug, h.Enabled(context.Background(), slog.LevelDebug), "%s %s", tc.env, tc.level)
+		require.NoError(t, closeLog())
+	}
+}
+
+func TestNewHandlerFanOutToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	var console bytes.Buffer
+
+	h, closeLog, err := newHandler(EnvLocal, Config{
+		File: FileConfig{Path: path, MaxSize: 1 << 20},
+	}, &console)
+	require.NoError(t, err)
+
+	slog.New(h).With("request_id", "abc").Info("hello", "n", 1)
+	require.NoError(t, closeLog())
+
+	require.Contains(t, console.String(), "INFO: hello")
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(raw, &entry))
+	require.Equal(t, "hello", entry["msg"])
+	require.Equal(t, "abc", entry["request_id"])
+	require.Equal(t, float64(1), entry["n"])
+}
+
+func TestNewHandlerRedactsEveryOutput(t *testing.T) {
+	for _, env := range []string{EnvLocal, EnvProd} {
+		path := filepath.Join(t.TempDir(), "app.log")
+		var console bytes.Buffer
+
+		h, closeLog, err := newHandler(env, Config{
+			Level:    "debug",
+			File:     FileConfig{Path: path, MaxSize: 1 << 20},
+			Sampling: SamplingConfig{Enabled: true, Tick: time.Second, First: 10, MaxLevel: "debug"},
+			Redact:   RedactConfig{Keys: []string{"session"}, Patterns: []string{`sk_live_[a-z0-9]+`}},
+		}, &console)
+		require.NoError(t, err)
+
+		log := slog.New(h).With("session", "s-123", "dsn", "postgres://root:hunter2@db/app")
+		log.WithGroup("req").Debug("charging sk_live_abc",
+			sl.Op("PostService.Create"),
+			sl.Err(errors.New("password=hunter2 rejected")),
+			slog.Any("pass", redact.NewSecret("hunter2")),
+		)
+		require.NoError(t, closeLog())
+
+		file, err := os.ReadFile(path)
+		require.NoError(t, err)
+		for name, out := range map[string]string{"console": console.String(), "file": string(file)} {
+			require.Contains(t, out, "charging", "%s %s", env, name)
+			for _, secret := range []string{"s-123", "hunter2", "sk_live_abc"} {
+				require.NotContains(t, out, secret, "%s %s", env, name)
+			}
+		}
+	}
+}
+
+func TestNewHandlerBadRedactPattern(t *testing.T) {
+	_, _, err := NewHandler(EnvProd, Config{Redact: RedactConfig{Patterns: []string{"("}}})
+	require.Error(t, err)
+}
@@ -0,0 +1,175 @@
+// Package redact keeps secrets out of logs: a slog handler that masks
+// sensitive attributes and secret-looking values, and a Secret type for
+// values that must never be logged at all.
+package redact
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces everything this package masks.
+const Redacted = "[REDACTED]"
+
+// DefaultKeys are attribute keys whose values are masked. A key also matches
+// as the last word of a longer key, so "password" covers "new_password".
+var DefaultKeys = []string{
+	"password", "passwd", "pass", "secret", "token", "api_key", "apikey",
+	"authorization", "cookie", "set_cookie", "private_key", "secret_key", "email",
+}
+
+// Pattern masks the parts of values matching Regexp. Replacement is as in
+// regexp.ReplaceAllString and defaults to Redacted.
+type Pattern struct {
+	Regexp      *regexp.Regexp
+	Replacement string
+}
+
+// DefaultPatterns returns patterns for common secrets found in free text,
+// such as error messages.
+func DefaultPatterns() []Pattern {
+	return []Pattern{
+		// Credentials in URLs and DSNs: keep the user, mask the password.
+		{Regexp: regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://[^:/?#\s@]*:)[^@/?#\s]+@`), Replacement: "${1}" + Redacted + "@"},
+		{Regexp: regexp.MustCompile(`(?i)\b(bearer|basic)\s+[a-z0-9\-._~+/]+=*`), Replacement: "${1} " + Redacted},
+		// JSON Web Tokens.
+		{Regexp: regexp.MustCompile(`\beyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]*`)},
+		// AWS access key IDs.
+		{Regexp: regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+		// key=value and key: value pairs in text, e.g. "password=hunter2".
+		{Regexp: regexp.MustCompile(`(?i)\b(password|passwd|pwd|secret|token|api[_-]?key)(\s*[=:]\s*)[^\s&,;"']+`), Replacement: "${1}${2}" + Redacted},
+		{Regexp: regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)},
+	}
+}
+
+type Options struct {
+	// Keys are attribute keys to mask, matched case-insensitively with "-"
+	// and "_" treated alike.
+	Keys     []string
+	Patterns []Pattern
+}
+
+// Handler masks sensitive data before records reach the next handler: the
+// values of attributes with sensitive keys, and secret-looking substrings of
+// messages, string values and errors, inside groups too.
+type Handler struct {
+	next slog.Handler
+	r    *redactor
+}
+
+func NewHandler(next slog.Handler, opts Options) *Handler {
+	r := &redactor{keys: make(map[string]struct{}, len(opts.Keys))}
+	for _, k := range opts.Keys {
+		r.keys[normalize(k)] = struct{}{}
+	}
+	for _, p := range opts.Patterns {
+		if p.Replacement == "" {
+			p.Replacement = Redacted
+		}
+		r.patterns = append(r.patterns, p)
+	}
+	return &Handler{next: next, r: r}
+}
+
+// CompilePatterns turns regular expressions from the config into patterns
+// that mask whole matches.
+func CompilePatterns(exprs []string) ([]Pattern, error) {
+	patterns := make([]Pattern, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("bad redaction pattern %q: %w", expr, err)
+		}
+		patterns = append(patterns, Pattern{Regexp: re})
+	}
+	return patterns, nil
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, h.r.text(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.r.attr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.r.attr(a)
+	}
+	return &Handler{next: h.next.WithAttrs(redacted), r: h.r}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), r: h.r}
+}
+
+type redactor struct {
+	keys     map[string]struct{}
+	patterns []Pattern
+}
+
+func (r *redactor) attr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		redacted := make([]slog.Attr, len(attrs))
+		for i, ga := range attrs {
+			redacted[i] = r.attr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	}
+
+	if r.sensitive(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(r.text(a.Value.String()))
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			a.Value = slog.StringValue(r.text(v.Error()))
+		case fmt.Stringer:
+			a.Value = slog.StringValue(r.text(v.String()))
+		case []byte:
+			a.Value = slog.StringValue(r.text(string(v)))
+		}
+	}
+	return a
+}
+
+func (r *redactor) sensitive(key string) bool {
+	key = normalize(key)
+	if _, ok := r.keys[key]; ok {
+		return true
+	}
+	for k := range r.keys {
+		if strings.HasSuffix(key, "_"+k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) text(s string) string {
+	for _, p := range r.patterns {
+		s = p.Regexp.ReplaceAllString(s, p.Replacement)
+	}
+	return s
+}
+
+func normalize(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "-", "_")
+}
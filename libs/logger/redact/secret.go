@@ -0,0 +1,68 @@
+package redact
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+// Secret holds a value, such as a password from the config, that must not
+// show up in logs or dumps. It logs, prints and marshals to JSON as Redacted;
+// the value is only available through Reveal.
+//
+// The value lives in a closure rather than a field, so even reflection-based
+// dumps like fmt's %+v of an enclosing struct can't reach it. Being a func
+// type also keeps cleanenv from mistaking it for a nested config section, so
+// env and env-default tags work on Secret fields.
+type Secret[T any] func() T
+
+func NewSecret[T any](v T) Secret[T] {
+	return func() T { return v }
+}
+
+// Reveal returns the value. Call it only where the value is used, never to
+// log it.
+func (s Secret[T]) Reveal() T {
+	if s == nil {
+		var zero T
+		return zero
+	}
+	return s()
+}
+
+func (s Secret[T]) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+func (s Secret[T]) String() string {
+	return Redacted
+}
+
+func (s Secret[T]) GoString() string {
+	return Redacted
+}
+
+func (s Secret[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Redacted)
+}
+
+// UnmarshalText lets config loaders fill a Secret from YAML or the
+// environment. T must be a string, a byte slice or a TextUnmarshaler.
+func (s *Secret[T]) UnmarshalText(text []byte) error {
+	var v T
+	switch p := any(&v).(type) {
+	case *string:
+		*p = string(text)
+	case *[]byte:
+		*p = append([]byte(nil), text...)
+	case encoding.TextUnmarshaler:
+		if err := p.UnmarshalText(text); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("can't unmarshal a secret of type %T", v)
+	}
+	*s = NewSecret(v)
+	return nil
+}
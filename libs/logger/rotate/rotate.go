@@ -0,0 +1,281 @@
+// Package rotate provides a log file writer that rotates by size and age,
+// compresses rotated files and keeps a bounded number of them.
+package rotate
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "20060102T150405.000"
+
+type Options struct {
+	// MaxSize is the size in bytes past which the file is rotated. Zero
+	// disables size-based rotation.
+	MaxSize int64
+	// MaxAge is how long a file is written to before it is rotated. Zero
+	// disables age-based rotation.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files kept. Zero keeps all.
+	MaxBackups int
+	// Compress gzips rotated files.
+	Compress bool
+}
+
+// Writer appends to a file and rotates it when it grows past MaxSize or gets
+// older than MaxAge. Rotated files are renamed to <name>-<time><ext> in the
+// same directory. It is safe for concurrent use.
+type Writer struct {
+	path string
+	opts Options
+	now  func() time.Time
+
+	mu     sync.Mutex
+	closed bool
+	// file is nil after a failed rotation couldn't reopen the log; the next
+	// write tries again.
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// mill serializes compression and pruning, which run in the background
+	// so that rotation doesn't stall logging.
+	mill sync.Mutex
+	wg   sync.WaitGroup
+}
+
+// Open opens path for appending, creating it and its directory if needed.
+func Open(path string, opts Options) (*Writer, error) {
+	return open(path, opts, time.Now)
+}
+
+func open(path string, opts Options, now func() time.Time) (*Writer, error) {
+	w := &Writer{
+		path: path,
+		opts: opts,
+		now:  now,
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("can't create log directory: %w", err)
+	}
+	if err := w.openExisting(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file == nil {
+		if err := w.openExisting(); err != nil {
+			return 0, err
+		}
+	}
+	if w.due(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the file and waits for pending compression to finish.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	var err error
+	w.closed = true
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	w.wg.Wait()
+	return err
+}
+
+// due reports whether writing n more bytes calls for a rotation first. An
+// empty file is never rotated, so a single oversized record still lands.
+func (w *Writer) due(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.opts.MaxSize > 0 && w.size+n > w.opts.MaxSize {
+		return true
+	}
+	return w.opts.MaxAge > 0 && w.now().Sub(w.openedAt) >= w.opts.MaxAge
+}
+
+func (w *Writer) openExisting() error {
+	info, err := os.Stat(w.path)
+	if os.IsNotExist(err) {
+		return w.openNew()
+	}
+	if err != nil {
+		return fmt.Errorf("can't stat log file: %w", err)
+	}
+
+	f, err := os.OpenFile(w.path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("can't open log file: %w", err)
+	}
+	w.file = f
+	w.size = info.Size()
+	// The creation time isn't portable; the last write is a lower bound.
+	w.openedAt = info.ModTime()
+	return nil
+}
+
+func (w *Writer) openNew() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("can't create log file: %w", err)
+	}
+	w.file = f
+	w.size = 0
+	w.openedAt = w.now()
+	return nil
+}
+
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("can't close log file: %w", err)
+	}
+	w.file = nil
+
+	backup := w.backupName(w.now())
+	if err := os.Rename(w.path, backup); err != nil {
+		// Keep logging to whatever is at path, or a new file if it's gone;
+		// the next write past the limits tries to rotate again.
+		return w.openExisting()
+	}
+	if err := w.openNew(); err != nil {
+		return err
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.mill.Lock()
+		defer w.mill.Unlock()
+
+		if w.opts.Compress {
+			// A failed compression leaves the plain backup in place, which
+			// is still a valid log; there is nowhere to report it but the
+			// log itself.
+			_ = compress(backup)
+		}
+		_ = w.prune()
+	}()
+	return nil
+}
+
+// backupName names the backup of a file rotated at t. Rotations within the
+// same millisecond get the next free millisecond instead of overwriting an
+// earlier backup, which keeps names in rotation order.
+func (w *Writer) backupName(t time.Time) string {
+	dir, base := filepath.Split(w.path)
+	ext := filepath.Ext(base)
+	for {
+		name := filepath.Join(dir, strings.TrimSuffix(base, ext)+"-"+t.Format(backupTimeFormat)+ext)
+		if !exists(name) && !exists(name+".gz") {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return !os.IsNotExist(err)
+}
+
+// backups lists rotated files, oldest first. The timestamp format sorts
+// lexically.
+func (w *Writer) backups() ([]string, error) {
+	dir, base := filepath.Split(w.path)
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)[len(prefix):]
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+		names = append(names, filepath.Join(dir, name))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (w *Writer) prune() error {
+	if w.opts.MaxBackups <= 0 {
+		return nil
+	}
+	names, err := w.backups()
+	if err != nil {
+		return err
+	}
+	for len(names) > w.opts.MaxBackups {
+		if err := os.Remove(names[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,176 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newClock() *clock {
+	return &clock{t: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func backupsOf(t *testing.T, w *Writer) []string {
+	t.Helper()
+	names, err := w.backups()
+	require.NoError(t, err)
+	for i, name := range names {
+		names[i] = filepath.Base(name)
+	}
+	return names
+}
+
+func TestRotatesBySize(t *testing.T) {
+	c := newClock()
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := open(path, Options{MaxSize: 10}, c.now)
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("0123456789"))
+	require.NoError(t, err)
+	c.advance(time.Second)
+	_, err = w.Write([]byte("abc"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	require.Equal(t, []string{"app-20240301T120001.000.log"}, backupsOf(t, w))
+
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "abc", string(current))
+}
+
+func TestRotatesByAge(t *testing.T) {
+	c := newClock()
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := open(path, Options{MaxAge: time.Hour}, c.now)
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("old\n"))
+	require.NoError(t, err)
+	c.advance(59 * time.Minute)
+	_, err = w.Write([]byte("still old\n"))
+	require.NoError(t, err)
+	c.advance(time.Minute)
+	_, err = w.Write([]byte("new\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	names := backupsOf(t, w)
+	require.Len(t, names, 1)
+	old, err := os.ReadFile(filepath.Join(filepath.Dir(path), names[0]))
+	require.NoError(t, err)
+	require.Equal(t, "old\nstill old\n", string(old))
+}
+
+func TestCompressesAndPrunes(t *testing.T) {
+	c := newClock()
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := open(path, Options{MaxSize: 4, MaxBackups: 2, Compress: true}, c.now)
+	require.NoError(t, err)
+
+	for _, line := range []string{"one\n", "two\n", "six\n", "ten\n"} {
+		_, err := w.Write([]byte(line))
+		require.NoError(t, err)
+		c.advance(time.Second)
+		// Let each background pass finish so backups are named predictably.
+		w.wg.Wait()
+	}
+	require.NoError(t, w.Close())
+
+	names := backupsOf(t, w)
+	require.Equal(t, []string{"app-20240301T120002.000.log.gz", "app-20240301T120003.000.log.gz"}, names)
+
+	f, err := os.Open(filepath.Join(filepath.Dir(path), names[1]))
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	content, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	require.Equal(t, "six\n", string(content))
+}
+
+func TestAppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	for _, line := range []string{"first\n", "second\n"} {
+		w, err := Open(path, Options{MaxSize: 1 << 20})
+		require.NoError(t, err)
+		_, err = w.Write([]byte(line))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+	}
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "first\nsecond\n", string(content))
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	w, err := Open(filepath.Join(t.TempDir(), "app.log"), Options{})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = w.Write([]byte("late"))
+	require.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestRotationsWithinAMillisecondKeepEveryBackup(t *testing.T) {
+	c := newClock()
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := open(path, Options{MaxSize: 4}, c.now)
+	require.NoError(t, err)
+
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n"} {
+		_, err = w.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	names := backupsOf(t, w)
+	require.Equal(t, []string{
+		"app-20240301T120000.000.log",
+		"app-20240301T120000.001.log",
+		"app-20240301T120000.002.log",
+	}, names)
+	for i, want := range []string{"one\n", "two\n", "three\n"} {
+		got, err := os.ReadFile(filepath.Join(filepath.Dir(path), names[i]))
+		require.NoError(t, err)
+		require.Equal(t, want, string(got))
+	}
+}
+
+// A log file removed from under the writer can't be renamed; logging goes on
+// in a new file instead of stopping for good.
+func TestKeepsWritingWhenRotationFails(t *testing.T) {
+	c := newClock()
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := open(path, Options{MaxSize: 4}, c.now)
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("one\n"))
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(path))
+
+	_, err = w.Write([]byte("two\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("three\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	require.Len(t, backupsOf(t, w), 1)
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "three\n", string(current))
+}
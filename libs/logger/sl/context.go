@@ -0,0 +1,56 @@
+package sl
+
+import (
+	"context"
+	"log/slog"
+)
+
+type (
+	ctxKey   struct{}
+	opCtxKey struct{}
+)
+
+// WithLogger returns a copy of ctx that carries log.
+func WithLogger(ctx context.Context, log *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, log)
+}
+
+// FromContext returns the logger carried by ctx, or fallback when ctx has
+// none, so code outside a request keeps logging through its own logger.
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if log, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return log
+	}
+	return fallback
+}
+
+// With returns a copy of ctx whose logger also carries args. Use it to add
+// attributes, such as the user ID, once they become known.
+func With(ctx context.Context, fallback *slog.Logger, args ...any) context.Context {
+	return WithLogger(ctx, FromContext(ctx, fallback).With(args...))
+}
+
+// OpKey is the key of the attribute naming the operation a record comes
+// from, such as "PostRepository.Create".
+const OpKey = "op"
+
+func Op(op string) slog.Attr {
+	return slog.String(OpKey, op)
+}
+
+func UserID(id int) slog.Attr {
+	return slog.Int("user_id", id)
+}
+
+// WithOp returns a copy of ctx recording that it runs on behalf of op, so
+// that code below it which doesn't know op, such as a database driver hook,
+// can still log records attributed to it.
+func WithOp(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, opCtxKey{}, op)
+}
+
+// OpFromContext returns the op ctx runs on behalf of, if any.
+func OpFromContext(ctx context.Context) (string, bool) {
+	op, ok := ctx.Value(opCtxKey{}).(string)
+	return op, ok
+}
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS posts (
+    id        SERIAL PRIMARY KEY,
+    author_id INTEGER NOT NULL,
+    body      TEXT    NOT NULL
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS attachments (
+    id           SERIAL PRIMARY KEY,
+    owner_id     INTEGER     NOT NULL,
+    blob_key     TEXT        NOT NULL UNIQUE,
+    content_type TEXT        NOT NULL,
+    size         BIGINT      NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS post_attachments (
+    post_id       INTEGER NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    attachment_id INTEGER NOT NULL REFERENCES attachments (id) ON DELETE RESTRICT,
+    position      SMALLINT NOT NULL,
+    alt_text      TEXT     NOT NULL DEFAULT '',
+    PRIMARY KEY (post_id, attachment_id),
+    UNIQUE (post_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS post_attachments_attachment_id_idx ON post_attachments (attachment_id);
+CREATE INDEX IF NOT EXISTS attachments_created_at_idx ON attachments (created_at);
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS attachment_variants;
+
+DROP INDEX IF EXISTS attachments_processing_idx;
+
+ALTER TABLE attachments
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS width,
+    DROP COLUMN IF EXISTS height,
+    DROP COLUMN IF EXISTS blurhash,
+    DROP COLUMN IF EXISTS processing_started_at;
@@ -0,0 +1,19 @@
+ALTER TABLE attachments
+    ADD COLUMN status                TEXT        NOT NULL DEFAULT 'ready',
+    ADD COLUMN width                 INTEGER,
+    ADD COLUMN height                INTEGER,
+    ADD COLUMN blurhash              TEXT,
+    ADD COLUMN processing_started_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS attachments_processing_idx ON attachments (id) WHERE status = 'processing';
+
+CREATE TABLE IF NOT EXISTS attachment_variants (
+    attachment_id INTEGER NOT NULL REFERENCES attachments (id) ON DELETE CASCADE,
+    name          TEXT    NOT NULL,
+    blob_key      TEXT    NOT NULL UNIQUE,
+    content_type  TEXT    NOT NULL,
+    width         INTEGER NOT NULL,
+    height        INTEGER NOT NULL,
+    size          BIGINT  NOT NULL,
+    PRIMARY KEY (attachment_id, name)
+);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS link_previews (
+    url         TEXT PRIMARY KEY,
+    status      TEXT        NOT NULL DEFAULT 'pending',
+    title       TEXT        NOT NULL DEFAULT '',
+    description TEXT        NOT NULL DEFAULT '',
+    image_url   TEXT        NOT NULL DEFAULT '',
+    site_name   TEXT        NOT NULL DEFAULT '',
+    fetched_at  TIMESTAMPTZ,
+    claimed_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS link_previews_pending_idx ON link_previews (url) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS post_link_previews (
+    post_id  INTEGER  NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    url      TEXT     NOT NULL REFERENCES link_previews (url) ON DELETE CASCADE,
+    position SMALLINT NOT NULL,
+    PRIMARY KEY (post_id, url)
+);
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS polls (
+    id          SERIAL PRIMARY KEY,
+    post_id     INTEGER     NOT NULL UNIQUE REFERENCES posts (id) ON DELETE CASCADE,
+    multiple    BOOLEAN     NOT NULL DEFAULT false,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    notified_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS polls_unnotified_idx ON polls (expires_at) WHERE notified_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS poll_options (
+    id       SERIAL PRIMARY KEY,
+    poll_id  INTEGER  NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    position SMALLINT NOT NULL,
+    text     TEXT     NOT NULL,
+    UNIQUE (poll_id, position)
+);
+
+-- One row per voter enforces a single ballot per user, even for
+-- multiple-choice polls where a ballot spans several options.
+CREATE TABLE IF NOT EXISTS poll_voters (
+    poll_id  INTEGER     NOT NULL REFERENCES polls (id) ON DELETE CASCADE,
+    user_id  INTEGER     NOT NULL,
+    voted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (poll_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS poll_votes (
+    poll_id   INTEGER NOT NULL,
+    user_id   INTEGER NOT NULL,
+    option_id INTEGER NOT NULL REFERENCES poll_options (id) ON DELETE CASCADE,
+    PRIMARY KEY (poll_id, user_id, option_id),
+    FOREIGN KEY (poll_id, user_id) REFERENCES poll_voters (poll_id, user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS poll_votes_option_id_idx ON poll_votes (option_id);
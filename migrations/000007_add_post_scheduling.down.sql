@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS posts_unpublished_author_idx;
+DROP INDEX IF EXISTS posts_due_idx;
+
+DELETE FROM posts WHERE status <> 'published';
+
+ALTER TABLE posts
+    DROP CONSTRAINT IF EXISTS posts_publish_at_check,
+    DROP CONSTRAINT IF EXISTS posts_status_check,
+    DROP COLUMN IF EXISTS published_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS publish_at,
+    DROP COLUMN IF EXISTS status;
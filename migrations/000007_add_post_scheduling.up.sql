@@ -0,0 +1,14 @@
+ALTER TABLE posts
+    ADD COLUMN status       TEXT        NOT NULL DEFAULT 'published',
+    ADD COLUMN publish_at   TIMESTAMPTZ,
+    ADD COLUMN created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN published_at TIMESTAMPTZ;
+
+UPDATE posts SET published_at = created_at;
+
+ALTER TABLE posts
+    ADD CONSTRAINT posts_status_check CHECK (status IN ('draft', 'scheduled', 'published')),
+    ADD CONSTRAINT posts_publish_at_check CHECK (status <> 'scheduled' OR publish_at IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS posts_due_idx ON posts (publish_at) WHERE status = 'scheduled';
+CREATE INDEX IF NOT EXISTS posts_unpublished_author_idx ON posts (author_id) WHERE status <> 'published';
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS expired_posts;
+DROP VIEW IF EXISTS visible_posts;
+DROP INDEX IF EXISTS posts_expires_at_idx;
+
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS ttl_seconds;
@@ -0,0 +1,17 @@
+ALTER TABLE posts
+    ADD COLUMN ttl_seconds INTEGER CHECK (ttl_seconds > 0),
+    ADD COLUMN expires_at  TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS posts_expires_at_idx ON posts (expires_at) WHERE expires_at IS NOT NULL;
+
+-- Posts that anyone may see right now. Listings and search read from here so
+-- that expired posts disappear the moment they expire, not when reaped.
+CREATE OR REPLACE VIEW visible_posts AS
+    SELECT * FROM posts
+    WHERE status = 'published' AND (expires_at IS NULL OR expires_at > now());
+
+-- Reaped posts leave a tombstone behind so they keep answering 410 Gone.
+CREATE TABLE IF NOT EXISTS expired_posts (
+    post_id    INTEGER     PRIMARY KEY,
+    expired_at TIMESTAMPTZ NOT NULL
+);
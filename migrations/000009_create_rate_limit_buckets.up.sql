@@ -0,0 +1,10 @@
+-- Token buckets shared by all instances. A bucket's tokens are refilled
+-- lazily from updated_at whenever it is taken from.
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key        TEXT             PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    allowed    BOOLEAN          NOT NULL,
+    updated_at TIMESTAMPTZ      NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS account_suspensions;
+DROP TABLE IF EXISTS moderation_actions;
+DROP TABLE IF EXISTS reports;
+
+-- Views can't drop columns in place, so restore the previous definition
+-- before the column goes.
+DROP VIEW IF EXISTS visible_posts;
+ALTER TABLE posts DROP COLUMN IF EXISTS removed_at;
+
+CREATE VIEW visible_posts AS
+    SELECT * FROM posts
+    WHERE status = 'published' AND (expires_at IS NULL OR expires_at > now());
@@ -0,0 +1,51 @@
+-- Removed posts keep their content as evidence for appeals but disappear
+-- from every public read.
+ALTER TABLE posts ADD COLUMN removed_at TIMESTAMPTZ;
+
+CREATE OR REPLACE VIEW visible_posts AS
+    SELECT * FROM posts
+    WHERE status = 'published' AND (expires_at IS NULL OR expires_at > now()) AND removed_at IS NULL;
+
+-- post_id carries no foreign key: ephemeral posts may be reaped while their
+-- reports are still being handled.
+CREATE TABLE IF NOT EXISTS reports (
+    id          SERIAL PRIMARY KEY,
+    reporter_id INTEGER     NOT NULL,
+    target      TEXT        NOT NULL CHECK (target IN ('post', 'account')),
+    post_id     INTEGER,
+    account_id  INTEGER     NOT NULL,
+    reason      TEXT        NOT NULL,
+    comment     TEXT        NOT NULL DEFAULT '',
+    status      TEXT        NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved')),
+    assignee_id INTEGER,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    resolved_at TIMESTAMPTZ,
+    CHECK ((target = 'post') = (post_id IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS reports_open_idx ON reports (id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS reports_account_id_idx ON reports (account_id);
+CREATE INDEX IF NOT EXISTS reports_post_id_idx ON reports (post_id) WHERE post_id IS NOT NULL;
+
+-- A reporter may have a single open report per post or account.
+CREATE UNIQUE INDEX IF NOT EXISTS reports_open_post_uniq
+    ON reports (reporter_id, post_id) WHERE status = 'open' AND target = 'post';
+CREATE UNIQUE INDEX IF NOT EXISTS reports_open_account_uniq
+    ON reports (reporter_id, account_id) WHERE status = 'open' AND target = 'account';
+
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    id           SERIAL PRIMARY KEY,
+    report_id    INTEGER     NOT NULL REFERENCES reports (id) ON DELETE CASCADE,
+    moderator_id INTEGER     NOT NULL,
+    action       TEXT        NOT NULL CHECK (action IN ('dismiss', 'remove_post', 'warn', 'suspend')),
+    rationale    TEXT        NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS moderation_actions_report_id_idx ON moderation_actions (report_id);
+
+CREATE TABLE IF NOT EXISTS account_suspensions (
+    user_id    INTEGER     PRIMARY KEY,
+    action_id  INTEGER     NOT NULL REFERENCES moderation_actions (id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
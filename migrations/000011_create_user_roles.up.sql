@@ -0,0 +1,7 @@
+-- Users without a row hold the default "user" role.
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id    INTEGER     PRIMARY KEY,
+    role       TEXT        NOT NULL CHECK (role IN ('moderator', 'admin')),
+    granted_by INTEGER     NOT NULL,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,34 @@
+-- Every row carries the hash of the one before it; see internal/audit. The
+-- triggers turn away changes through the application role, the chain catches
+-- whatever gets past them.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id          BIGSERIAL   PRIMARY KEY,
+    actor_id    INTEGER     NOT NULL,
+    action      TEXT        NOT NULL,
+    target_type TEXT        NOT NULL,
+    target_id   TEXT        NOT NULL,
+    ip          TEXT        NOT NULL DEFAULT '',
+    user_agent  TEXT        NOT NULL DEFAULT '',
+    -- JSON rather than JSONB keeps the text exactly as it was hashed.
+    before      JSON,
+    after       JSON,
+    created_at  TIMESTAMPTZ NOT NULL,
+    prev_hash   BYTEA       NOT NULL,
+    hash        BYTEA       NOT NULL UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_actor_id_idx ON audit_events (actor_id, id);
+CREATE INDEX IF NOT EXISTS audit_events_target_idx ON audit_events (target_type, target_id, id);
+CREATE INDEX IF NOT EXISTS audit_events_action_idx ON audit_events (action, id);
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON audit_events (created_at);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS spam_training;
+DROP TABLE IF EXISTS spam_tokens;
+
+DROP INDEX IF EXISTS posts_author_created_at_idx;
+DROP INDEX IF EXISTS posts_body_md5_idx;
+
+-- Views can't drop columns in place, so restore the previous definition
+-- before the columns go.
+DROP VIEW IF EXISTS visible_posts;
+ALTER TABLE posts DROP COLUMN IF EXISTS spam_score;
+ALTER TABLE posts DROP COLUMN IF EXISTS spam_verdict;
+
+CREATE VIEW visible_posts AS
+    SELECT * FROM posts
+    WHERE status = 'published' AND (expires_at IS NULL OR expires_at > now()) AND removed_at IS NULL;
@@ -0,0 +1,30 @@
+-- Posts the spam filter holds for review or shadow-limits stay visible to
+-- their author only.
+ALTER TABLE posts ADD COLUMN spam_verdict TEXT NOT NULL DEFAULT 'allow'
+    CHECK (spam_verdict IN ('allow', 'hold', 'limit'));
+ALTER TABLE posts ADD COLUMN spam_score REAL NOT NULL DEFAULT 0;
+
+CREATE OR REPLACE VIEW visible_posts AS
+    SELECT * FROM posts
+    WHERE status = 'published' AND (expires_at IS NULL OR expires_at > now()) AND removed_at IS NULL
+        AND spam_verdict = 'allow';
+
+-- Back the duplicate body and posting velocity heuristics.
+CREATE INDEX IF NOT EXISTS posts_body_md5_idx ON posts (md5(body), created_at);
+CREATE INDEX IF NOT EXISTS posts_author_created_at_idx ON posts (author_id, created_at);
+
+-- Per-token document counts of the naive Bayes model.
+CREATE TABLE IF NOT EXISTS spam_tokens (
+    token TEXT    PRIMARY KEY,
+    spam  INTEGER NOT NULL DEFAULT 0,
+    ham   INTEGER NOT NULL DEFAULT 0
+);
+
+-- Every post the model learned from, with the tokens it counted, so that a
+-- post is learned once and a changed decision can be taken back.
+CREATE TABLE IF NOT EXISTS spam_training (
+    post_id    INTEGER     PRIMARY KEY,
+    spam       BOOLEAN     NOT NULL,
+    tokens     TEXT[]      NOT NULL,
+    trained_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS appeals;
+
+CREATE OR REPLACE VIEW visible_posts AS
+    SELECT * FROM posts
+    WHERE status = 'published' AND (expires_at IS NULL OR expires_at > now()) AND removed_at IS NULL
+        AND spam_verdict = 'allow';
+
+DROP TABLE IF EXISTS follows;
+
+CREATE TABLE IF NOT EXISTS account_suspensions (
+    user_id    INTEGER     PRIMARY KEY,
+    action_id  INTEGER     NOT NULL REFERENCES moderation_actions (id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Only suspensions that came from a report fit the old table.
+INSERT INTO account_suspensions (user_id, action_id, created_at)
+    SELECT user_id, action_id, changed_at FROM account_states
+    WHERE state = 'suspended' AND action_id IS NOT NULL AND (expires_at IS NULL OR expires_at > now());
+
+DROP TABLE IF EXISTS account_states;
+ALTER TABLE moderation_actions DROP COLUMN IF EXISTS expires_at;
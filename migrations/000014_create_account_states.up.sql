@@ -0,0 +1,60 @@
+-- Accounts without a row, or whose row has expired, are active. Expired
+-- rows stay until the next change; reads filter them out.
+CREATE TABLE IF NOT EXISTS account_states (
+    user_id    INTEGER     PRIMARY KEY,
+    state      TEXT        NOT NULL CHECK (state IN ('limited', 'suspended', 'deactivated')),
+    reason     TEXT        NOT NULL,
+    -- NULL only for suspensions carried over from account_suspensions,
+    -- which had no expiry.
+    expires_at TIMESTAMPTZ,
+    changed_by INTEGER     NOT NULL,
+    action_id  INTEGER     REFERENCES moderation_actions (id),
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE moderation_actions ADD COLUMN expires_at TIMESTAMPTZ;
+
+INSERT INTO account_states (user_id, state, reason, changed_by, action_id, changed_at)
+    SELECT s.user_id, 'suspended', a.rationale, a.moderator_id, s.action_id, s.created_at
+    FROM account_suspensions s JOIN moderation_actions a ON a.id = s.action_id
+    ON CONFLICT (user_id) DO NOTHING;
+
+DROP TABLE IF EXISTS account_suspensions;
+
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id INTEGER     NOT NULL,
+    followee_id INTEGER     NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX IF NOT EXISTS follows_followee_id_idx ON follows (followee_id);
+
+-- Posts of suspended and deactivated accounts disappear from every public
+-- read. Limited accounts depend on who is looking, which a view can't know.
+CREATE OR REPLACE VIEW visible_posts AS
+    SELECT * FROM posts
+    WHERE status = 'published' AND (expires_at IS NULL OR expires_at > now()) AND removed_at IS NULL
+        AND spam_verdict = 'allow'
+        AND NOT EXISTS (
+            SELECT 1 FROM account_states s
+            WHERE s.user_id = posts.author_id AND s.state IN ('suspended', 'deactivated')
+                AND (s.expires_at IS NULL OR s.expires_at > now())
+        );
+
+CREATE TABLE IF NOT EXISTS appeals (
+    id          SERIAL PRIMARY KEY,
+    user_id     INTEGER     NOT NULL,
+    body        TEXT        NOT NULL,
+    status      TEXT        NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'granted', 'denied')),
+    reviewer_id INTEGER,
+    decision    TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    decided_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS appeals_open_idx ON appeals (id) WHERE status = 'open';
+
+-- A user may have a single appeal under review.
+CREATE UNIQUE INDEX IF NOT EXISTS appeals_open_user_uniq ON appeals (user_id) WHERE status = 'open';
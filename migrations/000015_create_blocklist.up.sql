@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS blocklist_entries (
+    id          SERIAL PRIMARY KEY,
+    kind        TEXT        NOT NULL CHECK (kind IN ('term', 'domain', 'url')),
+    pattern     TEXT        NOT NULL,
+    -- Only terms may be regular expressions.
+    regex       BOOLEAN     NOT NULL DEFAULT false CHECK (NOT regex OR kind = 'term'),
+    severity    TEXT        NOT NULL CHECK (severity IN ('reject', 'replace', 'moderate')),
+    replacement TEXT        NOT NULL DEFAULT '',
+    comment     TEXT        NOT NULL DEFAULT '',
+    created_by  INTEGER     NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (kind, pattern, regex)
+);
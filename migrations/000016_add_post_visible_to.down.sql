@@ -0,0 +1 @@
+DROP FUNCTION IF EXISTS post_visible_to(INTEGER, INTEGER);
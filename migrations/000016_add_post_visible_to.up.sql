@@ -0,0 +1,21 @@
+-- post_visible_to reports whether viewer_id, 0 when anonymous, may see
+-- post_id. Authors see their readable posts whatever the spam filter or
+-- moderators made of them; anyone else sees visible posts, and those of
+-- limited accounts only while they follow the author.
+CREATE OR REPLACE FUNCTION post_visible_to(post_id INTEGER, viewer_id INTEGER) RETURNS BOOLEAN
+    LANGUAGE sql STABLE AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM posts p
+        WHERE p.id = $1 AND p.author_id = $2
+            AND p.status = 'published' AND (p.expires_at IS NULL OR p.expires_at > now()) AND p.removed_at IS NULL
+    ) OR EXISTS (
+        SELECT 1 FROM visible_posts p
+        WHERE p.id = $1
+            AND NOT EXISTS (
+                SELECT 1 FROM account_states s
+                WHERE s.user_id = p.author_id AND s.state = 'limited'
+                    AND (s.expires_at IS NULL OR s.expires_at > now())
+                    AND NOT EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = $2 AND f.followee_id = p.author_id)
+            )
+    )
+$$;
@@ -0,0 +1,37 @@
+// Package migrations embeds the SQL migrations so the binary knows which
+// schema version it was built against.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// Latest returns the highest migration version, i.e. the schema version the
+// code expects the database to be at.
+func Latest() (uint, error) {
+	names, err := fs.Glob(FS, "*.up.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return 0, fmt.Errorf("malformed migration name %q", name)
+		}
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("malformed migration name %q: %w", name, err)
+		}
+		latest = max(latest, uint(v))
+	}
+	return latest, nil
+}